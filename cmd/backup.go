@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/freeverseio/laos-universal-node/internal/config"
+	ownershipSyncState "github.com/freeverseio/laos-universal-node/internal/platform/state/sync/ownership"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/tree/account"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/backup"
+)
+
+// runBackups backs up the database every interval until ctx is done. A failed backup is retried on the next one.
+func runBackups(ctx context.Context, interval time.Duration, backuper *backup.Backuper) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if _, err := backuper.Backup(false); err != nil {
+				slog.Error("error occurred while backing up the database", "err", err.Error())
+			}
+		}
+	}
+}
+
+// serveAdmin serves the admin endpoints on addr until ctx is done:
+//   - POST /backup backs up the database, incrementally unless ?full=true, and returns the backup written
+func serveAdmin(ctx context.Context, addr string, backuper *backup.Backuper) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/backup", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		entry, err := backuper.Backup(r.URL.Query().Get("full") == "true")
+		if err != nil {
+			slog.Error("error occurred while backing up the database", "err", err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(entry); err != nil {
+			slog.Error("error writing backup response", "err", err.Error())
+		}
+	})
+	// a full backup of a big database takes long, so responses have no write timeout
+	return serveHTTP(ctx, "admin", &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 20 * time.Second,
+	})
+}
+
+// serveHTTP serves server until ctx is done, then shuts it down gracefully
+func serveHTTP(ctx context.Context, name string, server *http.Server) error {
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.Error(name+" server shutdown", "err", err)
+		}
+	}()
+
+	slog.Info("starting "+name+" server", "listen_address", server.Addr)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return fmt.Errorf("%s server ListenAndServe: %w", name, err)
+	}
+	return nil
+}
+
+// backupState reads the state saved by a backup from the snapshot it backs up, which a restored database must have
+func backupState(tx storage.Tx) (backup.State, error) {
+	lastBlock, err := ownershipSyncState.NewService(tx).GetLastOwnershipBlock()
+	if err != nil {
+		return backup.State{}, err
+	}
+	tree, err := account.NewTree(tx)
+	if err != nil {
+		return backup.State{}, err
+	}
+	return backup.State{LastOwnershipBlock: lastBlock.Number, AccountHeadRoot: tree.Root()}, nil
+}
+
+// runRestore restores the database from the backups of a directory. The database is restored apart and only replaces
+// the missing database of the node once it has the state recorded in the manifest.
+func runRestore(args []string) error {
+	c, err := config.LoadRestore(args)
+	if err != nil {
+		return fmt.Errorf("error loading restore config: %w", err)
+	}
+	setLogger(c.Debug)
+
+	dbPath := c.DBPath()
+	if _, err := os.Stat(dbPath); err == nil {
+		return fmt.Errorf("a database already exists at %s, move it away to restore the backups", dbPath)
+	}
+	restorePath := dbPath + "-restoring"
+	if err := os.RemoveAll(restorePath); err != nil {
+		return fmt.Errorf("error removing a previous restore: %w", err)
+	}
+
+	entry, err := restore(c, restorePath)
+	if err != nil {
+		if errRemove := os.RemoveAll(restorePath); errRemove != nil {
+			slog.Error("error removing the restored database", "path", restorePath, "err", errRemove)
+		}
+		return err
+	}
+	if err := os.Rename(restorePath, dbPath); err != nil {
+		return fmt.Errorf("error moving the restored database to %s: %w", dbPath, err)
+	}
+	slog.Info("database restored", "path", dbPath, "lastOwnershipBlock", entry.LastOwnershipBlock, "accountHeadRoot", entry.AccountHeadRoot.Hex())
+	return nil
+}
+
+// restore loads the backups into a new database at path, and validates it once reopened
+func restore(c *config.RestoreConfig, path string) (backup.Entry, error) {
+	db, err := openDatabase(c.StorageEngine, path, true)
+	if err != nil {
+		return backup.Entry{}, err
+	}
+	entry, err := backup.Restore(c.BackupDir, db.backup)
+	if errClose := db.close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return backup.Entry{}, err
+	}
+
+	db, err = openDatabase(c.StorageEngine, path, true)
+	if err != nil {
+		return backup.Entry{}, err
+	}
+	tx := db.NewTransaction()
+	restored, err := backupState(tx)
+	tx.Discard()
+	if err == nil {
+		err = entry.Validate(restored)
+	}
+	if errClose := db.close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return backup.Entry{}, fmt.Errorf("error validating the restored database: %w", err)
+	}
+	return entry, nil
+}
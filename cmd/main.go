@@ -8,28 +8,37 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"path"
+	"strings"
 	"syscall"
 	"time"
 
-	"github.com/dgraph-io/badger/v4"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/freeverseio/laos-universal-node/cmd/server"
 	"github.com/freeverseio/laos-universal-node/internal/config"
+	shared "github.com/freeverseio/laos-universal-node/internal/core/processor"
 	blockMapperProcessor "github.com/freeverseio/laos-universal-node/internal/core/processor/blockmapper"
 	evoprocessor "github.com/freeverseio/laos-universal-node/internal/core/processor/evolution"
 	universalProcessor "github.com/freeverseio/laos-universal-node/internal/core/processor/universal"
+	backfillProcessor "github.com/freeverseio/laos-universal-node/internal/core/processor/universal/backfill"
 	contractDiscoverer "github.com/freeverseio/laos-universal-node/internal/core/processor/universal/discoverer"
 	"github.com/freeverseio/laos-universal-node/internal/core/processor/universal/discoverer/validator"
 	contractUpdater "github.com/freeverseio/laos-universal-node/internal/core/processor/universal/updater"
+	backfillWorker "github.com/freeverseio/laos-universal-node/internal/core/worker/backfill"
 	blockMapperWorker "github.com/freeverseio/laos-universal-node/internal/core/worker/blockmapper"
 	evoworker "github.com/freeverseio/laos-universal-node/internal/core/worker/evolution"
 	universalWorker "github.com/freeverseio/laos-universal-node/internal/core/worker/universal"
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/blockrange"
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/header"
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/substrate"
 	"github.com/freeverseio/laos-universal-node/internal/platform/scan"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/schema"
 	v1 "github.com/freeverseio/laos-universal-node/internal/platform/state/v1"
-	badgerStorage "github.com/freeverseio/laos-universal-node/internal/platform/storage/badger"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/backup"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/migration"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/replication"
 )
 
 var version = "undefined"
@@ -38,12 +47,48 @@ const (
 	klaosNovaChainID = 27181
 )
 
+// commands run offline on the database instead of starting the node
+var commands = map[string]func(args []string) error{
+	"migrate": runMigrate,
+	"restore": runRestore,
+	"rewind":  runRewind,
+	"verify":  runVerify,
+}
+
 func main() {
-	if err := run(); err != nil {
+	if err := runCommand(os.Args[1:]); err != nil {
 		slog.Error("error occurred", "err", err)
 	}
 }
 
+// entrypointFlags are the flags the docker entrypoint passes before the arguments of the container, e.g. the storage path
+var entrypointFlags = map[string]bool{
+	"storage_path": true,
+}
+
+// runCommand runs the command of the first argument that is neither an entrypoint flag nor its value, passing it the rest of
+// the arguments, and starts the node if that argument is not a command
+func runCommand(args []string) error {
+	i := 0
+	for i < len(args) {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "-") || !entrypointFlags[name] {
+			break
+		}
+		i++
+		if !hasValue && i < len(args) {
+			// the value follows the flag
+			i++
+		}
+	}
+	if i < len(args) {
+		if command, ok := commands[args[i]]; ok {
+			return command(append(append([]string{}, args[:i]...), args[i+1:]...))
+		}
+	}
+	return run()
+}
+
 func run() error {
 	c, err := config.Load()
 	if err != nil {
@@ -75,17 +120,21 @@ func run() error {
 	if err != nil {
 		return err
 	}
-	dbPath := path.Join(c.Path, fmt.Sprintf("%s-%s", ownershipChainID.String(), evoChainID.String()))
+	dbPath := config.DBPath(c.Path, c.StorageEngine, ownershipChainID, evoChainID)
+	c.SetOwnershipFinality(shared.ResolveFinality(ctx, ownershipChainClient, c.OwnershipFinality))
 
 	c.LogFields()
 
-	// "WithMemTableSize" increases MemTableSize to 1GB (1<<30 is 1GB). This increases the transaction size to about 153MB (15% of MemTableSize)
-	db, err := badger.Open(badger.DefaultOptions(dbPath).WithLoggingLevel(badger.ERROR).WithMemTableSize(1 << 30))
+	if c.RpcOnly {
+		return runReplica(ctx, c, dbPath)
+	}
+
+	db, err := openDatabase(c.StorageEngine, dbPath, false)
 	if err != nil {
-		return fmt.Errorf("error initializing storage: %w", err)
+		return err
 	}
 	defer func() {
-		err = db.Close()
+		err = db.close()
 		if err != nil {
 			slog.Error("error closing db", "err", err)
 		}
@@ -94,76 +143,90 @@ func run() error {
 	// Disclaimer
 	slog.Info("******************************************************************************")
 	slog.Info("This is a beta version of the Laos Universal Node. It is not intended for production use. Use at your own risk.")
-	slog.Info("You are now running the Universal Node Docker Image. Please be aware that this version currently does not handle blockchain reorganizations (reorgs). As a precaution, we strongly encourage operating with a heightened safety margin in your ownership chain management.")
+	slog.Info("You are now running the Universal Node Docker Image. Reorganizations (reorgs) deeper than the retained block history stop the node. Unless following the finalized head, we strongly encourage operating with a heightened safety margin in your ownership chain management.")
 	slog.Info("******************************************************************************")
 
-	storageService := badgerStorage.NewService(db)
+	var storageService storage.Service = db.Service
+	migrator, err := schema.NewMigrator(storageService)
+	if err != nil {
+		return err
+	}
+	if _, err := migrator.Run(migration.Options{BatchSize: c.MigrationBatchSize}); err != nil {
+		return fmt.Errorf("error migrating database: %w", err)
+	}
+	var replicationLog *replication.Log
+	if c.ReplicationAddr != "" {
+		// the writes are committed through the log that streams them to the rpc_only nodes
+		replicationLog, err = replication.NewLog(storageService, int(c.ReplicationLogSize)<<20)
+		if err != nil {
+			return err
+		}
+		storageService = replicationLog
+	}
 	stateService := v1.NewStateService(storageService)
 
 	group, ctx := errgroup.WithContext(ctx)
 
-	// Badger DB garbage collection
-	group.Go(func() error {
-		numIterations := 3
-		ticker := time.NewTicker(10 * time.Minute)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				return nil
-			case <-ticker.C:
-				// garbage collection cleans up at most 1 file per iteration
-				// https://dgraph.io/docs/badger/get-started/#garbage-collection
-				for i := 0; i < numIterations; i++ {
-					err := db.RunValueLogGC(0.5)
-					if err != nil {
-						if err != badger.ErrNoRewrite {
-							slog.Error("error occurred while running badger GC", "err", err.Error())
-						}
-						break
-					}
-				}
-			}
+	if replicationLog != nil {
+		group.Go(func() error {
+			return serveReplication(ctx, c.ReplicationAddr, replicationLog, stateService)
+		})
+	}
+
+	// Backups, scheduled or requested through the admin endpoints
+	if c.BackupDir != "" {
+		backuper := backup.New(c.BackupDir, db.backup, backupState)
+		if c.BackupInterval > 0 {
+			group.Go(func() error {
+				return runBackups(ctx, c.BackupInterval, backuper)
+			})
 		}
-	})
+		if c.AdminAddr != "" {
+			group.Go(func() error {
+				return serveAdmin(ctx, c.AdminAddr, backuper)
+			})
+		}
+	}
 
-	// Ownership delete old block tags
-	group.Go(func() error {
-		ticker := time.NewTicker(10 * time.Minute)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				return nil
-			case <-ticker.C:
-				tx, err := stateService.NewTransaction()
-				if err != nil {
-					slog.Error("error occurred while creating new transaction", "err", err.Error())
-					return err
-				}
-				err = tx.DeleteOldStoredBlockNumbers()
-				if err != nil {
-					slog.Error("error occurred while cleaning stored block numbers", "err", err.Error())
-				}
-				err = tx.Commit()
-				if err != nil {
-					slog.Error("error occurred while committing clean stored block numbers", "err", err.Error())
+	// garbage collection of the engines that need it, like Badger
+	if db.gc != nil {
+		group.Go(func() error {
+			ticker := time.NewTicker(10 * time.Minute)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+					db.gc()
 				}
 			}
-		}
-	})
+		})
+	}
 
 	// Ownership chain scanner
 	group.Go(func() error {
-		s := scan.NewScanner(ownershipChainClient, c.Contracts...)
+		// the block range adapts to the logs queries, and headers are shared by the scanner, the updater and the processor
+		client := header.NewCache(blockrange.NewClient(ownershipChainClient, uint64(c.BlocksRange), c.MaxBlocksRange), ownershipChainClient.Client())
+		s := scan.NewScanner(client, c.Contracts...)
 		discoveryValidator := validator.New(c.GlobalConsensus, c.Parachain)
-		discoverer := contractDiscoverer.New(ownershipChainClient, c.Contracts, s, discoveryValidator)
-		updater := contractUpdater.New(ownershipChainClient, s)
-		processor := universalProcessor.NewProcessor(ownershipChainClient, stateService, s, c, discoverer, updater)
+		discoverer := contractDiscoverer.New(client, c.Contracts, s, discoveryValidator)
+		updater := contractUpdater.New(client, s)
+		processor := universalProcessor.NewProcessor(client, stateService, s, c, discoverer, updater)
 		uWorker := universalWorker.New(c, processor)
 		return uWorker.Run(ctx)
 	})
 
+	// Backfills of the contracts followed after their deployment
+	group.Go(func() error {
+		// the replayed blocks are old, so their headers are kept out of the cache of the ownership chain scanner
+		client := blockrange.NewClient(ownershipChainClient, uint64(c.BlocksRange), c.MaxBlocksRange)
+		updater := contractUpdater.New(client, scan.NewScanner(client, c.Contracts...))
+		processor := backfillProcessor.NewProcessor(client, stateService, updater, uint64(c.BlocksRange))
+		worker := backfillWorker.New(c.WaitingTime, processor)
+		return worker.Run(ctx)
+	})
+
 	// Evolution chain scanner
 	group.Go(func() error {
 		if evoChainID.Cmp(big.NewInt(klaosNovaChainID)) == 0 {
@@ -174,12 +237,21 @@ func run() error {
 			slog.Info("***********************************************************************************************")
 		}
 
-		laosHTTPClient := evoprocessor.NewLaosHTTP(&http.Client{}, c.EvoRpc)
-		scanner := scan.NewScanner(evoChainClient)
-		processor := evoprocessor.NewProcessor(evoChainClient,
+		substrateClient := substrate.NewClient(&http.Client{}, c.EvoRpc)
+		finality := evoprocessor.NewFinalityTracker(substrateClient)
+		go func() {
+			// without a subscription the finalized head is requested whenever the scan reaches it
+			if err := finality.Subscribe(ctx); err != nil {
+				slog.Info("finalized heads subscription not available, requesting the finalized head when needed", "err", err.Error())
+			}
+		}()
+		client := header.NewCache(blockrange.NewClient(evoChainClient, uint64(c.EvoBlocksRange), c.EvoMaxBlocksRange), evoChainClient.Client())
+		scanner := scan.NewScanner(client)
+		processor := evoprocessor.NewProcessor(client,
+			substrateClient,
 			stateService,
 			scanner,
-			laosHTTPClient,
+			finality,
 			c)
 
 		evoWorker := evoworker.New(c, processor)
@@ -196,7 +268,7 @@ func run() error {
 
 	// Universal node RPC server
 	group.Go(func() error {
-		rpcServer, err := server.New()
+		rpcServer, err := server.New(server.WithOwnershipFinality(c.OwnershipFinality))
 		if err != nil {
 			return fmt.Errorf("failed to create RPC server: %w", err)
 		}
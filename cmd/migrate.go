@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/freeverseio/laos-universal-node/internal/config"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/schema"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/migration"
+)
+
+// runMigrate rewrites the database in place to the schema version of this node, as the node does at startup, or only
+// reports the migrations to run in dry-run mode. It works offline on the database and must not be run while the node
+// is running. An interrupted migration is resumed by running it again.
+func runMigrate(args []string) error {
+	c, err := config.LoadMigrate(args)
+	if err != nil {
+		return fmt.Errorf("error loading migrate config: %w", err)
+	}
+	setLogger(c.Debug)
+
+	db, err := openExistingDatabase(c.OfflineConfig)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = db.close()
+		if err != nil {
+			slog.Error("error closing db", "err", err)
+		}
+	}()
+
+	migrator, err := schema.NewMigrator(db.Service)
+	if err != nil {
+		return err
+	}
+	report, err := migrator.Run(migration.Options{BatchSize: c.BatchSize, DryRun: c.DryRun})
+	if err != nil {
+		return fmt.Errorf("error migrating database: %w", err)
+	}
+	if len(report.Migrations) == 0 {
+		slog.Info("database schema is up to date", "version", report.ToVersion)
+		return nil
+	}
+	for _, m := range report.Migrations {
+		slog.Info("migration", "version", m.Version, "description", m.Description, "scanned", m.Scanned, "rewritten", m.Rewritten)
+	}
+	if c.DryRun {
+		slog.Info("dry run done, the database was not modified", "version", report.FromVersion, "latestVersion", report.ToVersion)
+		return nil
+	}
+	slog.Info("database schema migrated", "fromVersion", report.FromVersion, "toVersion", report.ToVersion)
+	return nil
+}
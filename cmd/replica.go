@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/freeverseio/laos-universal-node/cmd/server"
+	"github.com/freeverseio/laos-universal-node/internal/config"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/schema"
+	v1 "github.com/freeverseio/laos-universal-node/internal/platform/state/v1"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/replication"
+)
+
+// replicaBatchSize is the amount of entries of the writer snapshot written per transaction by the replicas
+const replicaBatchSize = 1000
+
+// runReplica serves the RPC API from a replica of the state of the node at c.ReplicateFrom instead of running the workers.
+// The replica loads a new snapshot on every start, and stops when it misses write-sets the writer no longer retains,
+// so that it is restarted.
+func runReplica(ctx context.Context, c *config.Config, dbPath string) error {
+	replicaPath := dbPath + "-replica"
+	if err := os.RemoveAll(replicaPath); err != nil {
+		return fmt.Errorf("error removing the previous replica: %w", err)
+	}
+	db, err := openDatabase(c.StorageEngine, replicaPath, false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = db.close()
+		if err != nil {
+			slog.Error("error closing db", "err", err)
+		}
+	}()
+
+	stateService := v1.NewStateService(db.Service)
+	replica := replication.NewReplica(c.ReplicateFrom, db.Service, replicaBatchSize, lastOwnershipBlock(stateService))
+	if err := replica.LoadSnapshot(ctx); err != nil {
+		return fmt.Errorf("error loading the snapshot of the writer: %w", err)
+	}
+	migrator, err := schema.NewMigrator(db.Service)
+	if err != nil {
+		return err
+	}
+	if err := migrator.Check(); err != nil {
+		return fmt.Errorf("the writer runs a different release: %w", err)
+	}
+
+	group, ctx := errgroup.WithContext(ctx)
+
+	// garbage collection of the engines that need it, like Badger
+	if db.gc != nil {
+		group.Go(func() error {
+			ticker := time.NewTicker(10 * time.Minute)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+					db.gc()
+				}
+			}
+		})
+	}
+
+	// Replication of the writer
+	group.Go(func() error {
+		err := replica.Run(ctx)
+		if errors.Is(err, replication.ErrResyncRequired) {
+			slog.Error("the replica fell behind the writer and stops to load a new snapshot on restart", "err", err)
+		}
+		return err
+	})
+
+	// Universal node RPC server
+	group.Go(func() error {
+		rpcServer, err := server.New(server.WithOwnershipFinality(c.OwnershipFinality))
+		if err != nil {
+			return fmt.Errorf("failed to create RPC server: %w", err)
+		}
+		addr := fmt.Sprintf("0.0.0.0:%v", c.Port)
+		slog.Info("starting RPC server", "listen_address", addr, "replicating", c.ReplicateFrom)
+		return rpcServer.ListenAndServe(ctx, c.Rpc, c.EvoRpc, addr, stateService)
+	})
+
+	return group.Wait()
+}
+
+// serveReplication serves the write-sets of log to the replicas on addr until ctx is done
+func serveReplication(ctx context.Context, addr string, log *replication.Log, stateService state.Service) error {
+	slog.Info("serving replication", "epoch", log.Epoch())
+	// snapshots are streamed for as long as they take, so responses have no write timeout
+	return serveHTTP(ctx, "replication", &http.Server{
+		Addr:              addr,
+		Handler:           replication.NewHandler(log, lastOwnershipBlock(stateService)),
+		ReadHeaderTimeout: 20 * time.Second,
+	})
+}
+
+// lastOwnershipBlock returns a function that reads the last ownership block processed in the state
+func lastOwnershipBlock(stateService state.Service) func() (uint64, error) {
+	return func() (uint64, error) {
+		tx, err := stateService.NewTransaction()
+		if err != nil {
+			return 0, err
+		}
+		defer tx.Discard()
+		block, err := tx.GetLastOwnershipBlock()
+		if err != nil {
+			return 0, err
+		}
+		return block.Number, nil
+	}
+}
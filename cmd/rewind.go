@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/freeverseio/laos-universal-node/internal/config"
+	"github.com/freeverseio/laos-universal-node/internal/core/rewind"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/schema"
+	v1 "github.com/freeverseio/laos-universal-node/internal/platform/state/v1"
+)
+
+// runRewind rolls the ownership state back to an arbitrary block. It works offline on the database
+// and must not be run while the node is running.
+func runRewind(args []string) error {
+	c, err := config.LoadRewind(args)
+	if err != nil {
+		return fmt.Errorf("error loading rewind config: %w", err)
+	}
+	setLogger(c.Debug)
+
+	db, err := openExistingDatabase(c.OfflineConfig)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = db.close()
+		if err != nil {
+			slog.Error("error closing db", "err", err)
+		}
+	}()
+
+	storageService := db.Service
+	migrator, err := schema.NewMigrator(storageService)
+	if err != nil {
+		return err
+	}
+	if err := migrator.Check(); err != nil {
+		return err
+	}
+	stateService := v1.NewStateService(storageService)
+	tx, err := stateService.NewTransaction()
+	if err != nil {
+		return fmt.Errorf("error creating a new transaction: %w", err)
+	}
+	defer tx.Discard()
+
+	summary, err := rewind.Rewind(tx, c.ToBlock)
+	if err != nil {
+		return fmt.Errorf("error rewinding state: %w", err)
+	}
+	fmt.Print(summary.String())
+
+	if !c.Yes && !confirm("Proceed with the rewind? [y/N]: ") {
+		slog.Info("rewind aborted, no changes were made")
+		return nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing rewind: %w", err)
+	}
+	slog.Info("state rewound successfully", "toBlock", c.ToBlock)
+	return nil
+}
+
+func confirm(prompt string) bool {
+	fmt.Print(prompt)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
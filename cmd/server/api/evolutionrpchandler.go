@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/freeverseio/laos-universal-node/internal/core/evomints"
+	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+)
+
+const (
+	defaultEvoMintsLimit = 20
+	maxEvoMintsLimit     = 100
+)
+
+// EvoEvent is an evochain event that minted a token
+type EvoEvent struct {
+	TokenId      *hexutil.Big   `json:"tokenId"`
+	EvoBlock     hexutil.Uint64 `json:"evoBlock"`
+	EvoTimestamp hexutil.Uint64 `json:"evoTimestamp"`
+	TxIndex      hexutil.Uint64 `json:"txIndex"`
+	Slot         *hexutil.Big   `json:"slot"`
+	InitialOwner common.Address `json:"initialOwner"`
+	TokenURI     string         `json:"tokenURI"`
+}
+
+func newEvoEvent(event *model.MintedWithExternalURI) EvoEvent {
+	return EvoEvent{
+		TokenId:      (*hexutil.Big)(event.TokenId),
+		EvoBlock:     hexutil.Uint64(event.BlockNumber),
+		EvoTimestamp: hexutil.Uint64(event.Timestamp),
+		TxIndex:      hexutil.Uint64(event.TxIndex),
+		Slot:         (*hexutil.Big)(event.Slot),
+		InitialOwner: event.To,
+		TokenURI:     event.TokenURI,
+	}
+}
+
+// TokenProvenance is the evochain event that minted a token of an ownership contract
+type TokenProvenance struct {
+	Contract   common.Address `json:"contract"`
+	Collection common.Address `json:"collection"`
+	EvoEvent
+}
+
+// EvoMints is a page of the evo mints of a collection and their status in its ownership contracts. The last ownership
+// block processed tells how far the pending mints are from being applied, which happens once an ownership block
+// as recent as their evo block is processed.
+type EvoMints struct {
+	Collection             common.Address  `json:"collection"`
+	LastOwnershipBlock     hexutil.Uint64  `json:"lastOwnershipBlock"`
+	LastOwnershipTimestamp hexutil.Uint64  `json:"lastOwnershipTimestamp"`
+	Mints                  []EvoMint       `json:"mints"`
+	Next                   *hexutil.Uint64 `json:"next,omitempty"`
+}
+
+type EvoMint struct {
+	EvoEvent
+	Contracts []EvoMintStatus `json:"contracts"`
+}
+
+type EvoMintStatus struct {
+	Contract       common.Address  `json:"contract"`
+	Status         string          `json:"status"`
+	OwnershipBlock *hexutil.Uint64 `json:"ownershipBlock,omitempty"`
+}
+
+// tokenProvenance handles universal_tokenProvenance, whose params are an ownership contract and a token id.
+// It returns the provenance of the token, or null if it has not been minted on the evochain.
+func (h *GlobalRPCHandler) tokenProvenance(req JSONRPCRequest) RPCResponse {
+	var contract common.Address
+	var tokenId hexutil.Big
+	if len(req.Params) != 2 || json.Unmarshal(req.Params[0], &contract) != nil || json.Unmarshal(req.Params[1], &tokenId) != nil {
+		return getErrorResponse(fmt.Errorf("error parsing params or missing params"), req.ID)
+	}
+
+	tx, err := h.stateService.NewTransaction()
+	if err != nil {
+		return getErrorResponse(fmt.Errorf("error creating transaction: %w", err), req.ID)
+	}
+	defer tx.Discard()
+
+	exists, err := tx.HasERC721UniversalContract(contract.String())
+	if err != nil {
+		return getErrorResponse(fmt.Errorf("error checking contract list: %w", err), req.ID)
+	}
+	if !exists {
+		return getErrorResponse(fmt.Errorf("contract %s is not an ERC721 universal contract", contract.String()), req.ID)
+	}
+	collection, err := tx.GetCollectionAddress(contract.String())
+	if err != nil {
+		return getErrorResponse(fmt.Errorf("error getting collection address: %w", err), req.ID)
+	}
+	event, err := tx.GetTokenMintedWithExternalURIEvent(collection.String(), tokenId.ToInt())
+	if err != nil {
+		return getErrorResponse(fmt.Errorf("error getting the minted event of the token: %w", err), req.ID)
+	}
+	if event == nil {
+		return getJSONResponse(nil, req.ID, nil)
+	}
+	return getJSONResponse(TokenProvenance{Contract: contract, Collection: collection, EvoEvent: newEvoEvent(event)}, req.ID, nil)
+}
+
+// evoMints handles universal_evoMints, whose params are an ownership contract or a collection, and optionally the evo block
+// to list the mints after, which is the next block of the previous page, and the amount of mints to list.
+func (h *GlobalRPCHandler) evoMints(req JSONRPCRequest) RPCResponse {
+	var address common.Address
+	var after hexutil.Uint64
+	limit := hexutil.Uint64(defaultEvoMintsLimit)
+	if len(req.Params) == 0 || len(req.Params) > 3 || json.Unmarshal(req.Params[0], &address) != nil {
+		return getErrorResponse(fmt.Errorf("error parsing params or missing params"), req.ID)
+	}
+	if len(req.Params) > 1 && json.Unmarshal(req.Params[1], &after) != nil {
+		return getErrorResponse(fmt.Errorf("error parsing the evo block to list the mints after"), req.ID)
+	}
+	if len(req.Params) > 2 && (json.Unmarshal(req.Params[2], &limit) != nil || limit == 0 || limit > maxEvoMintsLimit) {
+		return getErrorResponse(fmt.Errorf("invalid limit, it must be between 1 and %d", maxEvoMintsLimit), req.ID)
+	}
+
+	tx, err := h.stateService.NewTransaction()
+	if err != nil {
+		return getErrorResponse(fmt.Errorf("error creating transaction: %w", err), req.ID)
+	}
+	// the transaction checks out the roots of the state and must not be committed
+	defer tx.Discard()
+
+	collection, contracts, err := evomints.Resolve(tx, address)
+	if err != nil {
+		return getErrorResponse(err, req.ID)
+	}
+	lastBlock, err := tx.GetLastOwnershipBlock()
+	if err != nil {
+		return getErrorResponse(fmt.Errorf("error getting the last ownership block: %w", err), req.ID)
+	}
+	page, err := evomints.List(tx, collection, contracts, uint64(after), int(limit))
+	if err != nil {
+		return getErrorResponse(fmt.Errorf("error listing the evo mints: %w", err), req.ID)
+	}
+
+	result := EvoMints{
+		Collection:             collection,
+		LastOwnershipBlock:     hexutil.Uint64(lastBlock.Number),
+		LastOwnershipTimestamp: hexutil.Uint64(lastBlock.Timestamp),
+		Mints:                  make([]EvoMint, 0, len(page.Mints)),
+	}
+	for i := range page.Mints {
+		mint := EvoMint{EvoEvent: newEvoEvent(&page.Mints[i].MintedWithExternalURI), Contracts: make([]EvoMintStatus, 0, len(page.Mints[i].Contracts))}
+		for _, status := range page.Mints[i].Contracts {
+			mintStatus := EvoMintStatus{Contract: status.Contract, Status: string(status.Status)}
+			if status.Status == evomints.StatusApplied {
+				ownershipBlock := hexutil.Uint64(status.OwnershipBlock)
+				mintStatus.OwnershipBlock = &ownershipBlock
+			}
+			mint.Contracts = append(mint.Contracts, mintStatus)
+		}
+		result.Mints = append(result.Mints, mint)
+	}
+	if page.Next != 0 {
+		next := hexutil.Uint64(page.Next)
+		result.Next = &next
+	}
+	return getJSONResponse(result, req.ID, nil)
+}
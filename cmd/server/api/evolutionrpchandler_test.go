@@ -0,0 +1,232 @@
+package api_test
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/mock/gomock"
+
+	"github.com/freeverseio/laos-universal-node/cmd/server/api"
+	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+	stateMock "github.com/freeverseio/laos-universal-node/internal/platform/state/mock"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/tree/account"
+)
+
+func TestTokenProvenance(t *testing.T) {
+	t.Parallel()
+	contract := common.HexToAddress("0x26CB70039FE1bd36b4659858d4c4D0cBcafd743A")
+	collection := common.HexToAddress("0x0000000000000000000000000000000000000501")
+	event := &model.MintedWithExternalURI{
+		Slot:        big.NewInt(2),
+		To:          common.HexToAddress("0x3"),
+		TokenURI:    "ipfs://2",
+		TokenId:     big.NewInt(0x102),
+		BlockNumber: 100,
+		Timestamp:   1000,
+		TxIndex:     1,
+	}
+	tests := []struct {
+		name             string
+		params           string
+		isContract       bool
+		event            *model.MintedWithExternalURI
+		expectedTxCalls  int
+		expectedGetEvent bool
+		expectedBody     string
+	}{
+		{
+			name:             "returns the provenance of a minted token",
+			params:           `["0x26CB70039FE1bd36b4659858d4c4D0cBcafd743A","0x102"]`,
+			isContract:       true,
+			event:            event,
+			expectedTxCalls:  1,
+			expectedGetEvent: true,
+			expectedBody: `{"jsonrpc":"2.0","id":1,"result":{"contract":"0x26cb70039fe1bd36b4659858d4c4d0cbcafd743a",` +
+				`"collection":"0x0000000000000000000000000000000000000501","tokenId":"0x102","evoBlock":"0x64","evoTimestamp":"0x3e8",` +
+				`"txIndex":"0x1","slot":"0x2","initialOwner":"0x0000000000000000000000000000000000000003","tokenURI":"ipfs://2"}}`,
+		},
+		{
+			name:             "returns null for a token that has not been minted",
+			params:           `["0x26CB70039FE1bd36b4659858d4c4D0cBcafd743A","0x102"]`,
+			isContract:       true,
+			expectedTxCalls:  1,
+			expectedGetEvent: true,
+			expectedBody:     `{"jsonrpc":"2.0","id":1,"result":null}`,
+		},
+		{
+			name:            "fails for a contract that is not universal",
+			params:          `["0x26CB70039FE1bd36b4659858d4c4D0cBcafd743A","0x102"]`,
+			expectedTxCalls: 1,
+			expectedBody:    `{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"execution reverted"}}`,
+		},
+		{
+			name:         "fails for a token id that is not a hex quantity",
+			params:       `["0x26CB70039FE1bd36b4659858d4c4D0cBcafd743A","258"]`,
+			expectedBody: `{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"execution reverted"}}`,
+		},
+		{
+			name:         "fails without a token id",
+			params:       `["0x26CB70039FE1bd36b4659858d4c4D0cBcafd743A"]`,
+			expectedBody: `{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"execution reverted"}}`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			stateService := stateMock.NewMockService(ctrl)
+			tx := stateMock.NewMockTx(ctrl)
+			stateService.EXPECT().NewTransaction().Return(tx, nil).Times(tt.expectedTxCalls)
+			tx.EXPECT().Discard().Times(tt.expectedTxCalls)
+			tx.EXPECT().HasERC721UniversalContract(contract.String()).Return(tt.isContract, nil).Times(tt.expectedTxCalls)
+			if tt.isContract {
+				tx.EXPECT().GetCollectionAddress(contract.String()).Return(collection, nil)
+			}
+			if tt.expectedGetEvent {
+				tx.EXPECT().GetTokenMintedWithExternalURIEvent(collection.String(), big.NewInt(0x102)).Return(tt.event, nil)
+			}
+
+			request := httptest.NewRequest(http.MethodPost, "/",
+				strings.NewReader(`{"jsonrpc":"2.0","method":"universal_tokenProvenance","params":`+tt.params+`,"id":1}`))
+			request.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			handler := api.NewGlobalRPCHandler("https://example.com/", "https://example.com/")
+			handler.SetStateService(stateService)
+			http.HandlerFunc(handler.PostRPCRequestHandler).ServeHTTP(recorder, request)
+
+			body := strings.TrimSpace(recorder.Body.String())
+			if body != tt.expectedBody {
+				t.Fatalf("got body %s, expected %s", body, tt.expectedBody)
+			}
+		})
+	}
+}
+
+func TestEvoMints(t *testing.T) {
+	t.Parallel()
+	contract := common.HexToAddress("0x26CB70039FE1bd36b4659858d4c4D0cBcafd743A")
+	collection := common.HexToAddress("0x0000000000000000000000000000000000000501")
+	events := map[uint64]model.MintedWithExternalURI{
+		5: {Slot: big.NewInt(1), To: common.HexToAddress("0x3"), TokenURI: "ipfs://1", TokenId: big.NewInt(0x101), BlockNumber: 5, Timestamp: 50},
+		7: {Slot: big.NewInt(2), To: common.HexToAddress("0x3"), TokenURI: "ipfs://2", TokenId: big.NewInt(0x102), BlockNumber: 7, Timestamp: 70},
+	}
+	invalidRequest := `{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"execution reverted"}}`
+	tests := []struct {
+		name         string
+		params       string
+		isContract   bool
+		expectedBody string
+	}{
+		{
+			name:       "lists the mints of a contract with their status",
+			params:     `["0x26CB70039FE1bd36b4659858d4c4D0cBcafd743A"]`,
+			isContract: true,
+			expectedBody: `{"jsonrpc":"2.0","id":1,"result":{"collection":"0x0000000000000000000000000000000000000501",` +
+				`"lastOwnershipBlock":"0xc","lastOwnershipTimestamp":"0x78","mints":[` +
+				`{"tokenId":"0x101","evoBlock":"0x5","evoTimestamp":"0x32","txIndex":"0x0","slot":"0x1",` +
+				`"initialOwner":"0x0000000000000000000000000000000000000003","tokenURI":"ipfs://1",` +
+				`"contracts":[{"contract":"0x26cb70039fe1bd36b4659858d4c4d0cbcafd743a","status":"applied","ownershipBlock":"0xb"}]},` +
+				`{"tokenId":"0x102","evoBlock":"0x7","evoTimestamp":"0x46","txIndex":"0x0","slot":"0x2",` +
+				`"initialOwner":"0x0000000000000000000000000000000000000003","tokenURI":"ipfs://2",` +
+				`"contracts":[{"contract":"0x26cb70039fe1bd36b4659858d4c4d0cbcafd743a","status":"pending"}]}]}}`,
+		},
+		{
+			name:       "returns the next evo block when the limit is reached",
+			params:     `["0x26CB70039FE1bd36b4659858d4c4D0cBcafd743A","0x0","0x1"]`,
+			isContract: true,
+			expectedBody: `{"jsonrpc":"2.0","id":1,"result":{"collection":"0x0000000000000000000000000000000000000501",` +
+				`"lastOwnershipBlock":"0xc","lastOwnershipTimestamp":"0x78","mints":[` +
+				`{"tokenId":"0x101","evoBlock":"0x5","evoTimestamp":"0x32","txIndex":"0x0","slot":"0x1",` +
+				`"initialOwner":"0x0000000000000000000000000000000000000003","tokenURI":"ipfs://1",` +
+				`"contracts":[{"contract":"0x26cb70039fe1bd36b4659858d4c4d0cbcafd743a","status":"applied","ownershipBlock":"0xb"}]}],` +
+				`"next":"0x5"}}`,
+		},
+		{
+			name:       "lists the mints after the next evo block of the previous page",
+			params:     `["0x26CB70039FE1bd36b4659858d4c4D0cBcafd743A","0x5","0x1"]`,
+			isContract: true,
+			expectedBody: `{"jsonrpc":"2.0","id":1,"result":{"collection":"0x0000000000000000000000000000000000000501",` +
+				`"lastOwnershipBlock":"0xc","lastOwnershipTimestamp":"0x78","mints":[` +
+				`{"tokenId":"0x102","evoBlock":"0x7","evoTimestamp":"0x46","txIndex":"0x0","slot":"0x2",` +
+				`"initialOwner":"0x0000000000000000000000000000000000000003","tokenURI":"ipfs://2",` +
+				`"contracts":[{"contract":"0x26cb70039fe1bd36b4659858d4c4d0cbcafd743a","status":"pending"}]}]}}`,
+		},
+		{
+			name:         "fails for an unknown address",
+			params:       `["0x26CB70039FE1bd36b4659858d4c4D0cBcafd743A"]`,
+			expectedBody: invalidRequest,
+		},
+		{
+			name:         "fails for a limit of 0",
+			params:       `["0x26CB70039FE1bd36b4659858d4c4D0cBcafd743A","0x0","0x0"]`,
+			expectedBody: invalidRequest,
+		},
+		{
+			name:         "fails for a limit over the maximum",
+			params:       `["0x26CB70039FE1bd36b4659858d4c4D0cBcafd743A","0x0","0x65"]`,
+			expectedBody: invalidRequest,
+		},
+		{
+			name:         "fails without params",
+			params:       `[]`,
+			expectedBody: invalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			stateService := stateMock.NewMockService(ctrl)
+			tx := stateMock.NewMockTx(ctrl)
+			stateService.EXPECT().NewTransaction().Return(tx, nil).AnyTimes()
+			tx.EXPECT().Discard().AnyTimes()
+			tx.EXPECT().HasERC721UniversalContract(contract.String()).Return(tt.isContract, nil).AnyTimes()
+			tx.EXPECT().GetAllERC721UniversalContracts().Return(nil).AnyTimes()
+			tx.EXPECT().GetCollectionAddress(contract.String()).Return(collection, nil).AnyTimes()
+			tx.EXPECT().GetLastOwnershipBlock().Return(model.Block{Number: 12, Timestamp: 120}, nil).AnyTimes()
+			tx.EXPECT().GetFirstOwnershipBlock().Return(model.Block{Number: 10, Timestamp: 100}, nil).AnyTimes()
+			tx.EXPECT().GetLastTaggedBlock().Return(int64(12), nil).AnyTimes()
+			// the contract applied the mint of evo block 5 at ownership block 11
+			checkedOut := int64(12)
+			tx.EXPECT().Checkout(gomock.Any()).DoAndReturn(func(block int64) error {
+				checkedOut = block
+				return nil
+			}).AnyTimes()
+			tx.EXPECT().AccountData(contract).DoAndReturn(func(common.Address) (*account.AccountData, error) {
+				if checkedOut < 11 {
+					return &account.AccountData{}, nil
+				}
+				return &account.AccountData{LastProcessedEvoBlock: 5}, nil
+			}).AnyTimes()
+			nextEvoEventBlocks := map[uint64]uint64{0: 5, 5: 7}
+			tx.EXPECT().GetNextEvoEventBlock(strings.ToLower(contract.String()), gomock.Any()).Return(uint64(0), nil).AnyTimes()
+			tx.EXPECT().GetNextEvoEventBlock(strings.ToLower(collection.String()), gomock.Any()).DoAndReturn(
+				func(_ string, block uint64) (uint64, error) { return nextEvoEventBlocks[block], nil }).AnyTimes()
+			tx.EXPECT().GetMintedWithExternalURIEvents(collection.String(), gomock.Any()).DoAndReturn(
+				func(_ string, block uint64) ([]model.MintedWithExternalURI, error) {
+					return []model.MintedWithExternalURI{events[block]}, nil
+				}).AnyTimes()
+
+			request := httptest.NewRequest(http.MethodPost, "/",
+				strings.NewReader(`{"jsonrpc":"2.0","method":"universal_evoMints","params":`+tt.params+`,"id":1}`))
+			request.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			handler := api.NewGlobalRPCHandler("https://example.com/", "https://example.com/")
+			handler.SetStateService(stateService)
+			http.HandlerFunc(handler.PostRPCRequestHandler).ServeHTTP(recorder, request)
+
+			body := strings.TrimSpace(recorder.Body.String())
+			if body != tt.expectedBody {
+				t.Fatalf("got body %s, expected %s", body, tt.expectedBody)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/freeverseio/laos-universal-node/internal/config"
 	"github.com/freeverseio/laos-universal-node/internal/platform/state"
 )
 
@@ -43,6 +44,7 @@ type GlobalRPCHandler struct {
 	stateService               state.Service
 	universalMintingRPCHandler RPCUniversalHandler
 	rpcProxyHandler            ProxyHandler
+	ownershipFinality          string
 }
 
 func (h *GlobalRPCHandler) GetUniversalMintingRPCHandler() RPCUniversalHandler {
@@ -151,6 +153,13 @@ func WithUniversalMintingRPCHandler(handler RPCUniversalHandler) HandlerOption {
 	}
 }
 
+// WithOwnershipFinality sets the ownership finality mode returned by the universal_ownershipFinality method
+func WithOwnershipFinality(finality string) HandlerOption {
+	return func(h *GlobalRPCHandler) {
+		h.ownershipFinality = finality
+	}
+}
+
 func WithRPCProxyHandler(handler ProxyHandler) HandlerOption {
 	return func(h *GlobalRPCHandler) {
 		h.rpcProxyHandler = handler
@@ -175,6 +184,7 @@ func NewGlobalRPCHandler(rpcUrl, evoRpcUrl string, opts ...HandlerOption) *Globa
 			httpClient:            httpClient,
 			proxyRPCMethodManager: NewProxyRPCMethodManager(),
 		},
+		ownershipFinality: config.OwnershipFinalityMargin,
 	}
 
 	for _, opt := range opts {
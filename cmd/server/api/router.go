@@ -1,6 +1,8 @@
 package api
 
 import (
+	"expvar"
+	"fmt"
 	"net/http"
 
 	"github.com/freeverseio/laos-universal-node/internal/platform/state"
@@ -33,5 +35,37 @@ func Routes(h RPCHandler, r Router, stateService state.Service) Router {
 	})).Methods("OPTIONS")
 
 	router.Handle("/", PostRpcRequestMiddleware(h, stateService)).Methods("POST")
+
+	// node metrics, such as the finality lag of the evolution chain
+	router.Handle("/debug/vars", http.HandlerFunc(metricsHandler)).Methods("GET")
 	return router
 }
+
+// metricVars are the expvar variables published by the node. The default ones are not, as cmdline holds the RPC URLs
+// of the chains, which might contain API keys.
+var metricVars = []string{
+	"evo_finality_lag_blocks",
+	"ownership_block_hashes_pruned",
+	"ownership_block_hash_prune_errors",
+	"replication_lag_blocks",
+	"replication_sequence",
+}
+
+// metricsHandler writes the metric variables registered by the running node in the JSON format of expvar.Handler
+func metricsHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprint(w, "{")
+	first := true
+	for _, name := range metricVars {
+		v := expvar.Get(name)
+		if v == nil {
+			continue
+		}
+		if !first {
+			fmt.Fprint(w, ",")
+		}
+		first = false
+		fmt.Fprintf(w, "\n%q: %s", name, v.String())
+	}
+	fmt.Fprint(w, "\n}\n")
+}
@@ -1,6 +1,8 @@
 package api_test
 
 import (
+	"encoding/json"
+	"expvar"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -27,6 +29,7 @@ func TestCORS(t *testing.T) {
 		{"SupportPost", "POST", "/", http.StatusOK, "*", "POST, OPTIONS", 1},
 		{"SupportOPTIONS", "OPTIONS", "/", http.StatusOK, "*", "POST, OPTIONS", 0},
 		{"SupportGet", "GET", "/", http.StatusMethodNotAllowed, "", "", 0},
+		{"SupportMetrics", "GET", "/debug/vars", http.StatusOK, "*", "POST, OPTIONS", 0},
 	}
 
 	for _, tc := range tests {
@@ -74,3 +77,31 @@ func TestCORS(t *testing.T) {
 		})
 	}
 }
+
+func TestMetricsOnlyPublishesNodeMetrics(t *testing.T) {
+	t.Parallel()
+	mockCtrl := gomock.NewController(t)
+	router := mux.NewRouter()
+	api.Routes(mock.NewMockRPCHandler(mockCtrl), router, stateMock.NewMockService(mockCtrl))
+	lag, ok := expvar.Get("evo_finality_lag_blocks").(*expvar.Int)
+	if !ok {
+		lag = expvar.NewInt("evo_finality_lag_blocks")
+	}
+	lag.Set(3)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/debug/vars", http.NoBody))
+	if res.Code != http.StatusOK {
+		t.Fatalf("unexpected status: got %v, expected %v", res.Code, http.StatusOK)
+	}
+	var metrics map[string]json.RawMessage
+	if err := json.Unmarshal(res.Body.Bytes(), &metrics); err != nil {
+		t.Fatalf("could not decode metrics %s: %v", res.Body.String(), err)
+	}
+	if _, ok := metrics["cmdline"]; ok {
+		t.Errorf("cmdline is published in the metrics %s", res.Body.String())
+	}
+	if lag := string(metrics["evo_finality_lag_blocks"]); lag != "3" {
+		t.Errorf("unexpected evo_finality_lag_blocks: got %v, expected 3", lag)
+	}
+}
@@ -106,6 +106,13 @@ func (h *GlobalRPCHandler) getRPCResponse(r *http.Request, req JSONRPCRequest) R
 		return h.handleEthCallMethod(r, req)
 	case "eth_blockNumber":
 		return h.HandleUniversalMinting(r, req)
+	case "universal_ownershipFinality":
+		// tells the clients which ownership chain head the state follows, i.e. whether it can be reorged
+		return getResponse(h.ownershipFinality, req.ID, nil)
+	case "universal_tokenProvenance":
+		return h.tokenProvenance(req)
+	case "universal_evoMints":
+		return h.evoMints(req)
 	default:
 		return h.HandleProxyRPC(r, req)
 	}
@@ -195,6 +202,23 @@ func getResponse(result string, id *json.RawMessage, err error) RPCResponse {
 	}
 }
 
+// getJSONResponse returns a response whose result is result marshalled as JSON, null if it is nil
+func getJSONResponse(result any, id *json.RawMessage, err error) RPCResponse {
+	if err != nil {
+		return getErrorResponse(err, id)
+	}
+	marshalledResult, err := json.Marshal(result)
+	if err != nil {
+		return getErrorResponse(fmt.Errorf("error marshalling result: %w", err), id)
+	}
+	r := json.RawMessage(marshalledResult)
+	return RPCResponse{
+		Jsonrpc: "2.0",
+		ID:      id,
+		Result:  &r,
+	}
+}
+
 func getErrorResponse(err error, id *json.RawMessage) RPCResponse {
 	slog.Error("Failed to send response", "err", err)
 
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/freeverseio/laos-universal-node/cmd/server/api"
@@ -271,6 +272,43 @@ func TestPostRPCRequestHandler(t *testing.T) {
 	}
 }
 
+func TestOwnershipFinality(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		opts         []api.HandlerOption
+		expectedBody string
+	}{
+		{
+			name:         "returns the margin mode by default",
+			expectedBody: `{"jsonrpc":"2.0","id":1,"result":"margin"}`,
+		},
+		{
+			name:         "returns the configured mode",
+			opts:         []api.HandlerOption{api.WithOwnershipFinality("finalized")},
+			expectedBody: `{"jsonrpc":"2.0","id":1,"result":"finalized"}`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			request := httptest.NewRequest(http.MethodPost, "/",
+				strings.NewReader(`{"jsonrpc":"2.0","method":"universal_ownershipFinality","params":[],"id":1}`))
+			request.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			handler := api.NewGlobalRPCHandler("https://example.com/", "https://example.com/", tc.opts...)
+			http.HandlerFunc(handler.PostRPCRequestHandler).ServeHTTP(recorder, request)
+
+			body := strings.TrimSpace(recorder.Body.String())
+			if body != tc.expectedBody {
+				t.Fatalf("got body %s, expected %s", body, tc.expectedBody)
+			}
+		})
+	}
+}
+
 func getJsonRawMessagePointer(idStr string) *json.RawMessage {
 	rawMsg := json.RawMessage(idStr)
 	return &rawMsg
@@ -46,7 +46,8 @@ func (h *HTTPServer) SetHandler(handler http.Handler) {
 }
 
 type Server struct {
-	httpServer HTTPServerController
+	httpServer        HTTPServerController
+	ownershipFinality string
 }
 
 type ServerOption func(*Server) error
@@ -59,6 +60,14 @@ func WithHTTPServer(httpServer HTTPServerController) ServerOption {
 	}
 }
 
+// WithOwnershipFinality sets the ownership finality mode reported to the RPC clients.
+func WithOwnershipFinality(finality string) ServerOption {
+	return func(s *Server) error {
+		s.ownershipFinality = finality
+		return nil
+	}
+}
+
 func New(opts ...ServerOption) (*Server, error) {
 	server := &Server{
 		httpServer: &HTTPServer{
@@ -84,7 +93,11 @@ func New(opts ...ServerOption) (*Server, error) {
 func (s Server) ListenAndServe(ctx context.Context, rpcUrl, evoRpcUrl, addr string, stateService state.Service) error {
 	s.httpServer.SetAddr(addr)
 
-	handler := api.NewGlobalRPCHandler(rpcUrl, evoRpcUrl)
+	var handlerOpts []api.HandlerOption
+	if s.ownershipFinality != "" {
+		handlerOpts = append(handlerOpts, api.WithOwnershipFinality(s.ownershipFinality))
+	}
+	handler := api.NewGlobalRPCHandler(rpcUrl, evoRpcUrl, handlerOpts...)
 	router := mux.NewRouter()
 	s.httpServer.SetHandler(api.Routes(handler, router, stateService))
 	slog.Info("server listening", "address", addr)
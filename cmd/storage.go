@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"github.com/freeverseio/laos-universal-node/internal/config"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/backup"
+	badgerStorage "github.com/freeverseio/laos-universal-node/internal/platform/storage/badger"
+	pebbleStorage "github.com/freeverseio/laos-universal-node/internal/platform/storage/pebble"
+)
+
+// database is the storage service of a storage engine along with the functions to maintain and close it
+type database struct {
+	storage.Service
+	// gc reclaims the disk space of the engine, it is nil for the engines that reclaim it while compacting
+	gc func()
+	// backup backs up the database, it is nil for the engines without incremental backups
+	backup backup.Database
+	close  func() error
+}
+
+// openDatabase opens the database of the storage engine at dbPath, creating it if it does not exist.
+// The offline commands rewrite the whole state at once and need bigger transactions than the node.
+func openDatabase(engine, dbPath string, offline bool) (*database, error) {
+	switch engine {
+	case config.StorageEngineBadger:
+		// transactions are limited to about 15% of the default 64MB MemTableSize: the processors split the block ranges that do not fit
+		opts := badger.DefaultOptions(dbPath).WithLoggingLevel(badger.ERROR)
+		if offline {
+			opts = opts.WithMemTableSize(1 << 30)
+		}
+		db, err := badger.Open(opts)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing storage: %w", err)
+		}
+		service := badgerStorage.NewService(db)
+		return &database{
+			Service: service,
+			gc:      func() { runBadgerGC(db) },
+			backup:  service,
+			close:   db.Close,
+		}, nil
+	case config.StorageEnginePebble:
+		db, err := pebbleStorage.Open(dbPath)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing storage: %w", err)
+		}
+		return &database{
+			Service: pebbleStorage.NewService(db),
+			close:   db.Close,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown storage engine: %s", engine)
+}
+
+// openExistingDatabase opens the database of an offline command, failing if it does not exist instead of creating an empty one
+func openExistingDatabase(c config.OfflineConfig) (*database, error) {
+	dbPath := c.DBPath()
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("error opening database at %s: %w", dbPath, err)
+	}
+	return openDatabase(c.StorageEngine, dbPath, true)
+}
+
+// runBadgerGC cleans up the value log of db
+func runBadgerGC(db *badger.DB) {
+	// garbage collection cleans up at most 1 file per iteration
+	// https://dgraph.io/docs/badger/get-started/#garbage-collection
+	numIterations := 3
+	for i := 0; i < numIterations; i++ {
+		err := db.RunValueLogGC(0.5)
+		if err != nil {
+			if err != badger.ErrNoRewrite {
+				slog.Error("error occurred while running badger GC", "err", err.Error())
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/freeverseio/laos-universal-node/internal/config"
+	contractUpdater "github.com/freeverseio/laos-universal-node/internal/core/processor/universal/updater"
+	"github.com/freeverseio/laos-universal-node/internal/core/verify"
+	"github.com/freeverseio/laos-universal-node/internal/platform/scan"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/schema"
+	v1 "github.com/freeverseio/laos-universal-node/internal/platform/state/v1"
+)
+
+// runVerify checks the stored state, either by recomputing the roots from the raw events or by checking
+// the internal invariants of the trees. It must not be run while the node is running.
+func runVerify(args []string) error {
+	c, err := config.LoadVerify(args)
+	if err != nil {
+		return fmt.Errorf("error loading verify config: %w", err)
+	}
+	setLogger(c.Debug)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill, syscall.SIGTERM)
+	defer stop()
+
+	db, err := openExistingDatabase(c.OfflineConfig)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = db.close()
+		if err != nil {
+			slog.Error("error closing db", "err", err)
+		}
+	}()
+	storageService := db.Service
+	migrator, err := schema.NewMigrator(storageService)
+	if err != nil {
+		return err
+	}
+	if err := migrator.Check(); err != nil {
+		return err
+	}
+	stateService := v1.NewStateService(storageService)
+
+	var divergence *verify.Divergence
+	switch c.Mode {
+	case config.VerifyModeReplay:
+		ownershipChainClient, errDial := ethclient.Dial(c.Rpc)
+		if errDial != nil {
+			return fmt.Errorf("error instantiating eth client: %w", errDial)
+		}
+		updater := contractUpdater.New(ownershipChainClient, scan.NewScanner(ownershipChainClient))
+		divergence, err = verify.NewReplayer(updater, uint64(c.BlocksRange)).Replay(ctx, stateService, c.FromBlock, c.ToBlock)
+	case config.VerifyModeInvariants:
+		tx, errTx := stateService.NewTransaction()
+		if errTx != nil {
+			return fmt.Errorf("error creating a new transaction: %w", errTx)
+		}
+		defer tx.Discard()
+		divergence, err = verify.CheckInvariants(tx, c.FromBlock, c.ToBlock)
+	}
+	if err != nil {
+		return fmt.Errorf("error verifying state: %w", err)
+	}
+
+	if divergence != nil {
+		slog.Error("state verification failed", "mode", c.Mode, "block", divergence.Block, "contract", divergence.Contract, "reason", divergence.Reason)
+		return errors.New(divergence.String())
+	}
+	slog.Info("state verified successfully", "mode", c.Mode)
+	return nil
+}
@@ -23,6 +23,26 @@ const (
 	caladanGlobalConsensus   string = "0:0x22c48a576c33970622a2b4686a8aa5e4b58350247d69fb5d8015f12a8c8e1e4c"
 )
 
+// Ownership finality modes, defining which head of the ownership chain the universal node follows
+const (
+	OwnershipFinalityLatest    = "latest"
+	OwnershipFinalitySafe      = "safe"
+	OwnershipFinalityFinalized = "finalized"
+	OwnershipFinalityMargin    = "margin"
+)
+
+// Storage engines the database can be stored with
+const (
+	StorageEngineBadger = "badger"
+	StorageEnginePebble = "pebble"
+)
+
+// defaultReorgWindowRanges is the depth in block ranges below the latest block of the reorgs the default reorg window covers
+const defaultReorgWindowRanges = 250
+
+// defaultMigrationBatchSize is the amount of entries rewritten per transaction by the schema migrations
+const defaultMigrationBatchSize = 1000
+
 type Config struct {
 	WaitingTime           time.Duration
 	WaitingRPCRequestTime time.Duration
@@ -38,8 +58,26 @@ type Config struct {
 	BlocksRange           uint
 	EvoBlocksMargin       uint
 	EvoBlocksRange        uint
+	MaxBlocksRange        uint64
+	EvoMaxBlocksRange     uint64
+	ReorgWindow           uint64
+	ReorgWindowDuration   time.Duration
+	CheckpointInterval    uint64
+	OwnershipFinality     string
+	CatchUpDepth          uint
+	MigrationBatchSize    int
+	StorageEngine         string
+	RpcOnly               bool
+	ReplicateFrom         string
+	ReplicationAddr       string
+	ReplicationLogSize    uint
+	BackupDir             string
+	BackupInterval        time.Duration
+	AdminAddr             string
 	Port                  uint
 	Debug                 bool
+	// defaultReorgWindow is set when neither reorg_window nor reorg_window_duration are, so that the window follows the finality
+	defaultReorgWindow bool
 }
 
 func Load() (*Config, error) {
@@ -47,7 +85,9 @@ func Load() (*Config, error) {
 
 	blocksRange := flag.Uint("blocks_range", 10, "Amount of blocks the scanner processes")
 	blocksMargin := flag.Uint("blocks_margin", 0, "Number of blocks to assume finality")
-	evoBlocksRange := flag.Uint("evo_blocks_range", 1, "Amount of blocks the scanner processes on the evolution chain")
+	evoBlocksRange := flag.Uint("evo_blocks_range", 10, "Amount of blocks the scanner processes on the evolution chain")
+	maxBlocksRange := flag.Uint64("max_blocks_range", 1000, "Maximum amount of blocks the scanner processes, the range grows up to it while few logs are found")
+	evoMaxBlocksRange := flag.Uint64("evo_max_blocks_range", 1000, "Maximum amount of blocks the scanner processes on the evolution chain, the range grows up to it while few logs are found")
 	evoBlocksMargin := flag.Uint("evo_blocks_margin", 0, "Number of blocks to assume finality on the evolution chain")
 	contracts := flag.String("contracts", "", "Comma-separated list of the web3 addresses of the smart contracts to scan")
 	debug := flag.Bool("debug", false, "Set logs to debug level")
@@ -57,13 +97,56 @@ func Load() (*Config, error) {
 	startingBlock := flag.Uint64("starting_block", 0, "Initial block where the scanning process should start from")
 	evoStartingBlock := flag.Uint64("evo_starting_block", 0, "Initial block where the scanning process should start from on the evolution chain")
 	waitingTime := flag.Duration("wait", 5*time.Second, "Waiting time between scans when scanning reaches the last block")
-	waitingRPCRequestTime := flag.Duration("wait_rpc", 5*time.Second, "Waiting time between block finality requests to the LAOS parachain")
+	waitingRPCRequestTime := flag.Duration("wait_rpc", 5*time.Second, "Waiting time between block finality requests to the LAOS parachain once the evolution chain scan reaches the finalized block")
 	storagePath := flag.String("storage_path", defaultStoragePath, "Path to the storage folder")
+	reorgWindow := flag.Uint64("reorg_window", 0, "Number of blocks whose hashes are fully retained to detect reorgs on the ownership chain (by default, the blocks that a reorg 250 times blocks_range deep can reach given blocks_margin and ownership_finality, unless reorg_window_duration is set)")
+	reorgWindowDuration := flag.Duration("reorg_window_duration", 0, "Age of the blocks whose hashes are fully retained to detect reorgs on the ownership chain, e.g. 24h (disabled by default)")
+	checkpointInterval := flag.Uint64("checkpoint_interval", 1000, "Number of blocks between the sparse block hash checkpoints retained beyond reorg_window, 0 disables them")
+	ownershipFinality := flag.String("ownership_finality", OwnershipFinalityMargin,
+		"Head of the ownership chain to follow: latest, safe, finalized or margin (latest minus blocks_margin)")
+	catchUpDepth := flag.Uint("catchup_depth", 4, "Number of upcoming ownership block ranges fetched in parallel while catching up with the chain, 0 disables it")
+	migrationBatchSize := flag.Int("migration_batch_size", defaultMigrationBatchSize, "Amount of entries rewritten per transaction by the schema migrations run at startup")
+	storageEngine := flag.String("storage_engine", StorageEngineBadger, "Storage engine of the database: badger or pebble, which needs less memory")
+	rpcOnly := flag.Bool("rpc_only", false, "Serve the RPC API from a replica of the state of the node at replicate_from instead of running the workers")
+	replicateFrom := flag.String("replicate_from", "", "URL of the replication endpoints of the node replicated by an rpc_only node")
+	replicationAddr := flag.String("replication_addr", "", "Address to serve the replication endpoints to the rpc_only nodes on, e.g. 0.0.0.0:5002 (disabled by default)")
+	replicationLogSize := flag.Uint("replication_log_size", 64, "Size in MB of the latest committed writes retained for the rpc_only nodes, which load a new snapshot when they fall further behind")
+	backupDir := flag.String("backup_dir", "", "Directory the database is backed up to, incrementally on top of its previous backups")
+	backupInterval := flag.Duration("backup_interval", 0, "Interval between the scheduled backups to backup_dir, 0 disables them")
+	adminAddr := flag.String("admin_addr", "", "Address to serve the admin endpoints on, e.g. 127.0.0.1:5003 (disabled by default)")
 
 	flag.Parse()
 
-	if *evoBlocksRange > 1 {
-		return nil, fmt.Errorf("evo_blocks_range can not be bigger than 1")
+	switch *ownershipFinality {
+	case OwnershipFinalityLatest, OwnershipFinalitySafe, OwnershipFinalityFinalized, OwnershipFinalityMargin:
+	default:
+		return nil, fmt.Errorf("unknown ownership_finality: %s", *ownershipFinality)
+	}
+	if *reorgWindowDuration < 0 {
+		return nil, fmt.Errorf("reorg_window_duration cannot be negative")
+	}
+	if *migrationBatchSize <= 0 {
+		return nil, fmt.Errorf("migration_batch_size must be positive")
+	}
+	if err := validateStorageEngine(*storageEngine); err != nil {
+		return nil, err
+	}
+	if *rpcOnly && *replicateFrom == "" {
+		return nil, fmt.Errorf("replicate_from is required with rpc_only")
+	}
+	if *rpcOnly && *replicationAddr != "" {
+		return nil, fmt.Errorf("replication_addr cannot be set with rpc_only")
+	}
+	if (*backupInterval != 0 || *adminAddr != "") && *backupDir == "" {
+		return nil, fmt.Errorf("backup_dir is required with backup_interval and admin_addr")
+	}
+	if *backupDir != "" {
+		if *rpcOnly {
+			return nil, fmt.Errorf("backup_dir cannot be set with rpc_only")
+		}
+		if err := validateBackupStorageEngine(*storageEngine); err != nil {
+			return nil, err
+		}
 	}
 
 	c := &Config{
@@ -71,6 +154,8 @@ func Load() (*Config, error) {
 		BlocksRange:           *blocksRange,
 		EvoBlocksMargin:       *evoBlocksMargin,
 		EvoBlocksRange:        *evoBlocksRange,
+		MaxBlocksRange:        *maxBlocksRange,
+		EvoMaxBlocksRange:     *evoMaxBlocksRange,
 		Debug:                 *debug,
 		Rpc:                   *rpc,
 		EvoRpc:                *evoRpc,
@@ -80,7 +165,23 @@ func Load() (*Config, error) {
 		WaitingRPCRequestTime: *waitingRPCRequestTime,
 		Port:                  *port,
 		Path:                  *storagePath,
+		ReorgWindow:           *reorgWindow,
+		ReorgWindowDuration:   *reorgWindowDuration,
+		CheckpointInterval:    *checkpointInterval,
+		OwnershipFinality:     *ownershipFinality,
+		CatchUpDepth:          *catchUpDepth,
+		MigrationBatchSize:    *migrationBatchSize,
+		StorageEngine:         *storageEngine,
+		RpcOnly:               *rpcOnly,
+		ReplicateFrom:         strings.TrimSuffix(*replicateFrom, "/"),
+		ReplicationAddr:       *replicationAddr,
+		ReplicationLogSize:    *replicationLogSize,
+		BackupDir:             *backupDir,
+		BackupInterval:        *backupInterval,
+		AdminAddr:             *adminAddr,
 	}
+	c.defaultReorgWindow = c.ReorgWindow == 0 && c.ReorgWindowDuration == 0
+	c.SetOwnershipFinality(c.OwnershipFinality)
 
 	if *contracts != "" {
 		c.Contracts = strings.Split(*contracts, ",")
@@ -89,11 +190,258 @@ func Load() (*Config, error) {
 	return c, nil
 }
 
+// SetOwnershipFinality sets the head of the ownership chain the node follows, once it is known whether the chain supports it.
+// Unless reorg_window or reorg_window_duration are set, the reorg window is derived from it: it retains the hashes of the
+// processed blocks that a reorg up to 250 block ranges deep below the latest block can reach, which excludes the blocks_margin
+// the margin mode already trails the latest block by. The safe and finalized heads are not expected to be reorganized, so only
+// the last block range is retained for them.
+func (c *Config) SetOwnershipFinality(finality string) {
+	c.OwnershipFinality = finality
+	if !c.defaultReorgWindow {
+		return
+	}
+	blocksRange := uint64(c.BlocksRange)
+	depth := defaultReorgWindowRanges * blocksRange
+	switch finality {
+	case OwnershipFinalitySafe, OwnershipFinalityFinalized:
+		c.ReorgWindow = blocksRange
+	case OwnershipFinalityMargin:
+		c.ReorgWindow = max(depth-min(depth, uint64(c.BlocksMargin)), blocksRange)
+	default:
+		c.ReorgWindow = depth
+	}
+}
+
+// DBPath returns the path of the database of the chains in storagePath. The databases of the engines other than Badger,
+// the original one, are kept apart so that switching engines starts a new database instead of opening the files of another one.
+func DBPath(storagePath, storageEngine string, ownershipChainID, evoChainID fmt.Stringer) string {
+	name := fmt.Sprintf("%s-%s", ownershipChainID, evoChainID)
+	if storageEngine != StorageEngineBadger {
+		name += "-" + storageEngine
+	}
+	return path.Join(storagePath, name)
+}
+
+func validateStorageEngine(storageEngine string) error {
+	switch storageEngine {
+	case StorageEngineBadger, StorageEnginePebble:
+		return nil
+	}
+	return fmt.Errorf("unknown storage_engine: %s", storageEngine)
+}
+
+// validateBackupStorageEngine checks that the database of the storage engine can be backed up, which requires
+// the incremental backups of Badger
+func validateBackupStorageEngine(storageEngine string) error {
+	if storageEngine != StorageEngineBadger {
+		return fmt.Errorf("backups require the %s storage_engine", StorageEngineBadger)
+	}
+	return nil
+}
+
+// OfflineConfig holds the arguments shared by the commands that operate offline on the database
+type OfflineConfig struct {
+	Path             string
+	StorageEngine    string
+	OwnershipChainID uint64
+	EvoChainID       uint64
+	Debug            bool
+}
+
+// DBPath returns the path of the database the offline commands operate on
+func (c *OfflineConfig) DBPath() string {
+	return DBPath(c.Path, c.StorageEngine, new(big.Int).SetUint64(c.OwnershipChainID), new(big.Int).SetUint64(c.EvoChainID))
+}
+
+// offlineFlags defines the flags shared by the offline commands in fs. The returned function must be called after parsing.
+func offlineFlags(fs *flag.FlagSet) func() (OfflineConfig, error) {
+	ownershipChainID := fs.Uint64("chain_id", 0, "Chain ID of the ownership chain, used to locate the database")
+	evoChainID := fs.Uint64("evo_chain_id", 0, "Chain ID of the evolution chain, used to locate the database")
+	storagePath := fs.String("storage_path", getDefaultStoragePath(), "Path to the storage folder")
+	storageEngine := fs.String("storage_engine", StorageEngineBadger, "Storage engine of the database: badger or pebble")
+	debug := fs.Bool("debug", false, "Set logs to debug level")
+
+	return func() (OfflineConfig, error) {
+		if *ownershipChainID == 0 || *evoChainID == 0 {
+			return OfflineConfig{}, fmt.Errorf("chain_id and evo_chain_id are required")
+		}
+		if err := validateStorageEngine(*storageEngine); err != nil {
+			return OfflineConfig{}, err
+		}
+		return OfflineConfig{
+			Path:             *storagePath,
+			StorageEngine:    *storageEngine,
+			OwnershipChainID: *ownershipChainID,
+			EvoChainID:       *evoChainID,
+			Debug:            *debug,
+		}, nil
+	}
+}
+
+// RewindConfig holds the arguments of the offline rewind command
+type RewindConfig struct {
+	OfflineConfig
+	ToBlock uint64
+	Yes     bool
+}
+
+// LoadRewind parses the arguments of the rewind command (without the command name itself)
+func LoadRewind(args []string) (*RewindConfig, error) {
+	fs := flag.NewFlagSet("rewind", flag.ContinueOnError)
+	toBlock := fs.Int64("to-block", -1, "Ownership block the state is rolled back to")
+	yes := fs.Bool("yes", false, "Skip the confirmation prompt")
+	offline := offlineFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *toBlock < 0 {
+		return nil, fmt.Errorf("to-block is required")
+	}
+	offlineConfig, err := offline()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RewindConfig{
+		OfflineConfig: offlineConfig,
+		ToBlock:       uint64(*toBlock),
+		Yes:           *yes,
+	}, nil
+}
+
+// MigrateConfig holds the arguments of the offline migrate command
+type MigrateConfig struct {
+	OfflineConfig
+	BatchSize int
+	DryRun    bool
+}
+
+// LoadMigrate parses the arguments of the migrate command (without the command name itself)
+func LoadMigrate(args []string) (*MigrateConfig, error) {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	batchSize := fs.Int("batch_size", defaultMigrationBatchSize, "Amount of entries rewritten per transaction")
+	dryRun := fs.Bool("dry_run", false, "Report the migrations to run and the entries they rewrite without writing anything")
+	offline := offlineFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *batchSize <= 0 {
+		return nil, fmt.Errorf("batch_size must be positive")
+	}
+	offlineConfig, err := offline()
+	if err != nil {
+		return nil, err
+	}
+
+	return &MigrateConfig{
+		OfflineConfig: offlineConfig,
+		BatchSize:     *batchSize,
+		DryRun:        *dryRun,
+	}, nil
+}
+
+// RestoreConfig holds the arguments of the offline restore command
+type RestoreConfig struct {
+	OfflineConfig
+	BackupDir string
+}
+
+// LoadRestore parses the arguments of the restore command (without the command name itself)
+func LoadRestore(args []string) (*RestoreConfig, error) {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	backupDir := fs.String("backup_dir", "", "Directory of the backups to restore")
+	offline := offlineFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *backupDir == "" {
+		return nil, fmt.Errorf("backup_dir is required")
+	}
+	offlineConfig, err := offline()
+	if err != nil {
+		return nil, err
+	}
+	if err := validateBackupStorageEngine(offlineConfig.StorageEngine); err != nil {
+		return nil, err
+	}
+
+	return &RestoreConfig{
+		OfflineConfig: offlineConfig,
+		BackupDir:     *backupDir,
+	}, nil
+}
+
+const (
+	VerifyModeReplay     = "replay"
+	VerifyModeInvariants = "invariants"
+)
+
+// VerifyConfig holds the arguments of the offline verify command
+type VerifyConfig struct {
+	OfflineConfig
+	Mode        string
+	Rpc         string
+	FromBlock   uint64
+	ToBlock     uint64
+	BlocksRange uint
+}
+
+// LoadVerify parses the arguments of the verify command (without the command name itself)
+func LoadVerify(args []string) (*VerifyConfig, error) {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	mode := fs.String("mode", VerifyModeInvariants, "Verification mode: replay (recompute roots from raw events) or invariants (check internal tree invariants)")
+	rpc := fs.String("rpc", "", "URL of the RPC node of the ownership chain, required by the replay mode")
+	fromBlock := fs.Uint64("from_block", 0, "First ownership block to verify (defaults to the last processed block in invariants mode and to the first processed block in replay mode)")
+	toBlock := fs.Uint64("to_block", 0, "Last ownership block to verify (defaults to the last processed block)")
+	blocksRange := fs.Uint("blocks_range", 10, "Amount of blocks whose Transfer logs are fetched per request in replay mode")
+	offline := offlineFlags(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	switch *mode {
+	case VerifyModeReplay:
+		if *rpc == "" {
+			return nil, fmt.Errorf("rpc is required in %s mode", VerifyModeReplay)
+		}
+	case VerifyModeInvariants:
+	default:
+		return nil, fmt.Errorf("unknown verify mode: %s", *mode)
+	}
+	if *toBlock != 0 && *fromBlock > *toBlock {
+		return nil, fmt.Errorf("from_block %d is bigger than to_block %d", *fromBlock, *toBlock)
+	}
+	offlineConfig, err := offline()
+	if err != nil {
+		return nil, err
+	}
+
+	return &VerifyConfig{
+		OfflineConfig: offlineConfig,
+		Mode:          *mode,
+		Rpc:           *rpc,
+		FromBlock:     *fromBlock,
+		ToBlock:       *toBlock,
+		BlocksRange:   *blocksRange,
+	}, nil
+}
+
 func (c *Config) LogFields() {
 	slog.Debug("config loaded", slog.Group("config", "rpc", c.Rpc, "evo_rpc", c.EvoRpc, "contracts", c.Contracts, "starting_block", c.StartingBlock,
 		"evo_starting_block", c.EvoStartingBlock, "blocks_margin", c.BlocksMargin, "evo_blocks_margin", c.EvoBlocksMargin, "blocks_range", c.BlocksRange,
-		"evo_blocks_range", c.EvoBlocksRange, "evo_global_consensus", c.GlobalConsensus, "evo_parachain", c.Parachain, "debug", c.Debug,
-		"wait", c.WaitingTime, "wait_rpc", c.WaitingRPCRequestTime, "port", c.Port, "storage_path", c.Path))
+		"evo_blocks_range", c.EvoBlocksRange, "max_blocks_range", c.MaxBlocksRange, "evo_max_blocks_range", c.EvoMaxBlocksRange, "evo_global_consensus", c.GlobalConsensus, "evo_parachain", c.Parachain, "debug", c.Debug,
+		"wait", c.WaitingTime, "wait_rpc", c.WaitingRPCRequestTime, "port", c.Port, "storage_path", c.Path, "storage_engine", c.StorageEngine,
+		"rpc_only", c.RpcOnly, "replicate_from", c.ReplicateFrom, "replication_addr", c.ReplicationAddr, "replication_log_size", c.ReplicationLogSize,
+		"backup_dir", c.BackupDir, "backup_interval", c.BackupInterval, "admin_addr", c.AdminAddr,
+		"reorg_window", c.ReorgWindow, "reorg_window_duration", c.ReorgWindowDuration, "checkpoint_interval", c.CheckpointInterval, "catchup_depth", c.CatchUpDepth,
+		"ownership_finality", c.OwnershipFinality, "migration_batch_size", c.MigrationBatchSize))
 }
 
 func getDefaultStoragePath() string {
@@ -6,6 +6,7 @@ import (
 	"math/big"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/freeverseio/laos-universal-node/internal/config"
 )
@@ -84,19 +85,332 @@ func TestLoadConfig(t *testing.T) {
 			t.Errorf("got evo blocks range %d, expected 1", c.EvoBlocksRange) // Fixed assertion to match expected behavior
 		}
 	})
-	t.Run("fails when evo blocks range is greater than 1", func(t *testing.T) {
+	t.Run("loads evo blocks range bigger than 1", func(t *testing.T) {
 		resetFlagSet() // Reset the flag set before defining new flags
-		args := []string{"cmd", "--evo_blocks_range=2"}
+		args := []string{"cmd", "--evo_blocks_range=100", "--evo_max_blocks_range=500"}
 		os.Args = args
+		c, err := config.Load()
+		if err != nil {
+			t.Fatalf("got error %s while no error was expected", err.Error())
+		}
+		if c.EvoBlocksRange != 100 {
+			t.Errorf("got evo blocks range %d, expected 100", c.EvoBlocksRange)
+		}
+		if c.EvoMaxBlocksRange != 500 {
+			t.Errorf("got evo max blocks range %d, expected 500", c.EvoMaxBlocksRange)
+		}
+	})
+	t.Run("retains the block hashes of 250 block ranges by default", func(t *testing.T) {
+		resetFlagSet() // Reset the flag set before defining new flags
+		os.Args = []string{"cmd", "--blocks_range=20"}
+		c, err := config.Load()
+		if err != nil {
+			t.Fatalf("got error %s while no error was expected", err.Error())
+		}
+		if c.ReorgWindow != 5000 || c.ReorgWindowDuration != 0 {
+			t.Errorf("got reorg window of %d blocks and %s, expected 5000 blocks", c.ReorgWindow, c.ReorgWindowDuration)
+		}
+	})
+	t.Run("derives the default reorg window from the blocks margin and the ownership finality", func(t *testing.T) {
+		tests := []struct {
+			name                string
+			args                []string
+			finality            string
+			expectedReorgWindow uint64
+		}{
+			{
+				name:                "excludes the blocks margin",
+				args:                []string{"--blocks_range=20", "--blocks_margin=1000"},
+				finality:            config.OwnershipFinalityMargin,
+				expectedReorgWindow: 4000,
+			},
+			{
+				name:                "retains a block range when the blocks margin is deeper",
+				args:                []string{"--blocks_range=20", "--blocks_margin=6000"},
+				finality:            config.OwnershipFinalityMargin,
+				expectedReorgWindow: 20,
+			},
+			{
+				name:                "ignores the blocks margin following the latest block",
+				args:                []string{"--blocks_range=20", "--blocks_margin=1000"},
+				finality:            config.OwnershipFinalityLatest,
+				expectedReorgWindow: 5000,
+			},
+			{
+				name:                "retains a block range following the finalized block",
+				args:                []string{"--blocks_range=20"},
+				finality:            config.OwnershipFinalityFinalized,
+				expectedReorgWindow: 20,
+			},
+			{
+				name:                "follows the finality the chain supports",
+				args:                []string{"--blocks_range=20", "--ownership_finality=safe"},
+				finality:            config.OwnershipFinalityMargin,
+				expectedReorgWindow: 5000,
+			},
+			{
+				name:                "keeps the reorg window that is set",
+				args:                []string{"--blocks_range=20", "--reorg_window=300"},
+				finality:            config.OwnershipFinalityFinalized,
+				expectedReorgWindow: 300,
+			},
+		}
+		for _, tt := range tests {
+			resetFlagSet() // Reset the flag set before defining new flags
+			os.Args = append([]string{"cmd"}, tt.args...)
+			c, err := config.Load()
+			if err != nil {
+				t.Fatalf("%s: got error %s while no error was expected", tt.name, err.Error())
+			}
+			c.SetOwnershipFinality(tt.finality)
+			if c.OwnershipFinality != tt.finality || c.ReorgWindow != tt.expectedReorgWindow {
+				t.Errorf("%s: got reorg window of %d blocks following %s, expected %d blocks following %s",
+					tt.name, c.ReorgWindow, c.OwnershipFinality, tt.expectedReorgWindow, tt.finality)
+			}
+		}
+	})
+	t.Run("retains the block hashes by age", func(t *testing.T) {
+		resetFlagSet() // Reset the flag set before defining new flags
+		os.Args = []string{"cmd", "--reorg_window_duration=24h"}
+		c, err := config.Load()
+		if err != nil {
+			t.Fatalf("got error %s while no error was expected", err.Error())
+		}
+		if c.ReorgWindow != 0 || c.ReorgWindowDuration != 24*time.Hour {
+			t.Errorf("got reorg window of %d blocks and %s, expected 24h0m0s only", c.ReorgWindow, c.ReorgWindowDuration)
+		}
+	})
+	t.Run("loads the ownership finality mode", func(t *testing.T) {
+		resetFlagSet() // Reset the flag set before defining new flags
+		os.Args = []string{"cmd", "--ownership_finality=finalized"}
+		c, err := config.Load()
+		if err != nil {
+			t.Fatalf("got error %s while no error was expected", err.Error())
+		}
+		if c.OwnershipFinality != config.OwnershipFinalityFinalized {
+			t.Errorf("got ownership finality %s, expected %s", c.OwnershipFinality, config.OwnershipFinalityFinalized)
+		}
+	})
+	t.Run("fails when the ownership finality mode is unknown", func(t *testing.T) {
+		resetFlagSet() // Reset the flag set before defining new flags
+		os.Args = []string{"cmd", "--ownership_finality=pending"}
 		_, err := config.Load()
-		if err == nil {
-			t.Fatalf("got no error while an error was expected")
+		expectedErr := "unknown ownership_finality: pending"
+		if err == nil || err.Error() != expectedErr {
+			t.Fatalf(`got error "%v", expected "%s"`, err, expectedErr)
+		}
+	})
+	t.Run("loads an rpc only replica", func(t *testing.T) {
+		resetFlagSet() // Reset the flag set before defining new flags
+		os.Args = []string{"cmd", "--rpc_only", "--replicate_from=http://writer:5002/"}
+		c, err := config.Load()
+		if err != nil {
+			t.Fatalf("got error %s while no error was expected", err.Error())
+		}
+		if !c.RpcOnly || c.ReplicateFrom != "http://writer:5002" {
+			t.Errorf("got rpc only %t replicating from %s, expected true replicating from http://writer:5002", c.RpcOnly, c.ReplicateFrom)
+		}
+	})
+	t.Run("fails when an rpc only replica has no writer", func(t *testing.T) {
+		resetFlagSet() // Reset the flag set before defining new flags
+		os.Args = []string{"cmd", "--rpc_only"}
+		_, err := config.Load()
+		expectedErr := "replicate_from is required with rpc_only"
+		if err == nil || err.Error() != expectedErr {
+			t.Fatalf(`got error "%v", expected "%s"`, err, expectedErr)
+		}
+	})
+	t.Run("loads scheduled backups", func(t *testing.T) {
+		resetFlagSet() // Reset the flag set before defining new flags
+		os.Args = []string{"cmd", "--backup_dir=/backups", "--backup_interval=1h", "--admin_addr=127.0.0.1:5003"}
+		c, err := config.Load()
+		if err != nil {
+			t.Fatalf("got error %s while no error was expected", err.Error())
+		}
+		if c.BackupDir != "/backups" || c.BackupInterval != time.Hour || c.AdminAddr != "127.0.0.1:5003" {
+			t.Errorf("got backups to %s every %s and admin address %s, expected /backups every 1h0m0s and 127.0.0.1:5003",
+				c.BackupDir, c.BackupInterval, c.AdminAddr)
+		}
+	})
+	t.Run("fails when scheduled backups have no directory", func(t *testing.T) {
+		resetFlagSet() // Reset the flag set before defining new flags
+		os.Args = []string{"cmd", "--backup_interval=1h"}
+		_, err := config.Load()
+		expectedErr := "backup_dir is required with backup_interval and admin_addr"
+		if err == nil || err.Error() != expectedErr {
+			t.Fatalf(`got error "%v", expected "%s"`, err, expectedErr)
+		}
+	})
+	t.Run("fails when backing up pebble", func(t *testing.T) {
+		resetFlagSet() // Reset the flag set before defining new flags
+		os.Args = []string{"cmd", "--backup_dir=/backups", "--storage_engine=pebble"}
+		_, err := config.Load()
+		expectedErr := "backups require the badger storage_engine"
+		if err == nil || err.Error() != expectedErr {
+			t.Fatalf(`got error "%v", expected "%s"`, err, expectedErr)
+		}
+	})
+}
+
+func TestLoadRewind(t *testing.T) {
+	t.Parallel()
+	t.Run("loads rewind config", func(t *testing.T) {
+		t.Parallel()
+		c, err := config.LoadRewind([]string{"--to-block=100", "--chain_id=1", "--evo_chain_id=667", "--storage_path=/tmp/unode", "--yes"})
+		if err != nil {
+			t.Fatalf("got error %s while no error was expected", err.Error())
+		}
+		if c.ToBlock != 100 {
+			t.Errorf("got to block %d, expected 100", c.ToBlock)
+		}
+		if !c.Yes {
+			t.Errorf("got yes false, expected true")
 		}
-		expectedErr := "evo_blocks_range can not be bigger than 1"
-		if err.Error() != expectedErr {
-			t.Fatalf(`got error "%s", expected "%s"`, err.Error(), expectedErr)
+		if c.DBPath() != "/tmp/unode/1-667" {
+			t.Errorf("got db path %s, expected /tmp/unode/1-667", c.DBPath())
 		}
 	})
+	t.Run("locates the database of the storage engine", func(t *testing.T) {
+		t.Parallel()
+		c, err := config.LoadRewind([]string{"--to-block=100", "--chain_id=1", "--evo_chain_id=667", "--storage_path=/tmp/unode", "--storage_engine=pebble"})
+		if err != nil {
+			t.Fatalf("got error %s while no error was expected", err.Error())
+		}
+		if c.DBPath() != "/tmp/unode/1-667-pebble" {
+			t.Errorf("got db path %s, expected /tmp/unode/1-667-pebble", c.DBPath())
+		}
+	})
+	t.Run("fails with unknown storage engine", func(t *testing.T) {
+		t.Parallel()
+		_, err := config.LoadRewind([]string{"--to-block=100", "--chain_id=1", "--evo_chain_id=667", "--storage_engine=leveldb"})
+		if err == nil || err.Error() != "unknown storage_engine: leveldb" {
+			t.Fatalf(`got error "%v", expected "unknown storage_engine: leveldb"`, err)
+		}
+	})
+	t.Run("fails when to-block is missing", func(t *testing.T) {
+		t.Parallel()
+		_, err := config.LoadRewind([]string{"--chain_id=1", "--evo_chain_id=667"})
+		if err == nil || err.Error() != "to-block is required" {
+			t.Fatalf(`got error "%v", expected "to-block is required"`, err)
+		}
+	})
+	t.Run("fails when chain ids are missing", func(t *testing.T) {
+		t.Parallel()
+		_, err := config.LoadRewind([]string{"--to-block=100"})
+		if err == nil || err.Error() != "chain_id and evo_chain_id are required" {
+			t.Fatalf(`got error "%v", expected "chain_id and evo_chain_id are required"`, err)
+		}
+	})
+}
+
+func TestLoadMigrate(t *testing.T) {
+	t.Parallel()
+	t.Run("loads migrate config", func(t *testing.T) {
+		t.Parallel()
+		c, err := config.LoadMigrate([]string{"--chain_id=1", "--evo_chain_id=667", "--storage_path=/tmp/unode", "--batch_size=50", "--dry_run"})
+		if err != nil {
+			t.Fatalf("got error %s while no error was expected", err.Error())
+		}
+		if c.BatchSize != 50 {
+			t.Errorf("got batch size %d, expected 50", c.BatchSize)
+		}
+		if !c.DryRun {
+			t.Error("got dry run false, expected true")
+		}
+		if c.DBPath() != "/tmp/unode/1-667" {
+			t.Errorf("got db path %s, expected /tmp/unode/1-667", c.DBPath())
+		}
+	})
+	t.Run("fails when the batch size is not positive", func(t *testing.T) {
+		t.Parallel()
+		_, err := config.LoadMigrate([]string{"--chain_id=1", "--evo_chain_id=667", "--batch_size=0"})
+		if err == nil || err.Error() != "batch_size must be positive" {
+			t.Fatalf(`got error "%v", expected "batch_size must be positive"`, err)
+		}
+	})
+}
+
+func TestLoadRestore(t *testing.T) {
+	t.Parallel()
+	t.Run("loads restore config", func(t *testing.T) {
+		t.Parallel()
+		c, err := config.LoadRestore([]string{"--backup_dir=/backups", "--chain_id=1", "--evo_chain_id=667", "--storage_path=/tmp/unode"})
+		if err != nil {
+			t.Fatalf("got error %s while no error was expected", err.Error())
+		}
+		if c.BackupDir != "/backups" {
+			t.Errorf("got backup dir %s, expected /backups", c.BackupDir)
+		}
+		if c.DBPath() != "/tmp/unode/1-667" {
+			t.Errorf("got db path %s, expected /tmp/unode/1-667", c.DBPath())
+		}
+	})
+	t.Run("fails when the backup dir is missing", func(t *testing.T) {
+		t.Parallel()
+		_, err := config.LoadRestore([]string{"--chain_id=1", "--evo_chain_id=667"})
+		if err == nil || err.Error() != "backup_dir is required" {
+			t.Fatalf(`got error "%v", expected "backup_dir is required"`, err)
+		}
+	})
+	t.Run("fails with pebble", func(t *testing.T) {
+		t.Parallel()
+		_, err := config.LoadRestore([]string{"--backup_dir=/backups", "--chain_id=1", "--evo_chain_id=667", "--storage_engine=pebble"})
+		if err == nil || err.Error() != "backups require the badger storage_engine" {
+			t.Fatalf(`got error "%v", expected "backups require the badger storage_engine"`, err)
+		}
+	})
+}
+
+func TestLoadVerify(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		args        []string
+		expectedErr string
+	}{
+		{
+			name: "loads invariants mode by default",
+			args: []string{"--chain_id=1", "--evo_chain_id=667"},
+		},
+		{
+			name: "loads replay mode",
+			args: []string{"--mode=replay", "--rpc=http://localhost:8545", "--chain_id=1", "--evo_chain_id=667", "--from_block=10", "--to_block=20"},
+		},
+		{
+			name:        "fails when replay mode has no rpc",
+			args:        []string{"--mode=replay", "--chain_id=1", "--evo_chain_id=667"},
+			expectedErr: "rpc is required in replay mode",
+		},
+		{
+			name:        "fails with unknown mode",
+			args:        []string{"--mode=other", "--chain_id=1", "--evo_chain_id=667"},
+			expectedErr: "unknown verify mode: other",
+		},
+		{
+			name:        "fails when from block is bigger than to block",
+			args:        []string{"--from_block=20", "--to_block=10", "--chain_id=1", "--evo_chain_id=667"},
+			expectedErr: "from_block 20 is bigger than to_block 10",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c, err := config.LoadVerify(tt.args)
+			if tt.expectedErr != "" {
+				if err == nil || err.Error() != tt.expectedErr {
+					t.Fatalf(`got error "%v", expected "%s"`, err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got error %s while no error was expected", err.Error())
+			}
+			if c.DBPath() != c.Path+"/1-667" {
+				t.Errorf("got db path %s, expected %s", c.DBPath(), c.Path+"/1-667")
+			}
+		})
+	}
 }
 
 func resetFlagSet() {
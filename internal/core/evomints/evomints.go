@@ -0,0 +1,208 @@
+// Package evomints lists the evo mints of a collection along with their status in the ownership contracts of the collection,
+// so that users know whether a token they minted on the evochain is already served by its contracts.
+package evomints
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state"
+)
+
+var ErrUnknownAddress = errors.New("address is neither an ERC721 universal contract nor a collection with evo mints")
+
+type Status string
+
+const (
+	// StatusPending is the status of the mints that a contract has not applied yet, because the ownership blocks
+	// processed so far are not mapped to their evo block
+	StatusPending Status = "pending"
+	// StatusApplied is the status of the mints that a contract applied while processing an ownership block
+	StatusApplied Status = "applied"
+)
+
+// ContractStatus is the status of a mint in an ownership contract
+type ContractStatus struct {
+	Contract common.Address
+	Status   Status
+	// OwnershipBlock is the ownership block whose processing applied the mint, 0 while it is pending
+	OwnershipBlock uint64
+}
+
+// Mint is an evo mint of a collection and its status in the ownership contracts of the collection
+type Mint struct {
+	model.MintedWithExternalURI
+	Contracts []ContractStatus
+}
+
+// Page is a page of the evo mints of a collection
+type Page struct {
+	Mints []Mint
+	// Next is the evo block after which the next page is listed, 0 if there are no more mints
+	Next uint64
+}
+
+// Resolve returns the collection of address and the ownership contracts the mints are listed for. If address is
+// an ownership contract, it is the only contract. Otherwise address is a collection, whose contracts are all returned.
+func Resolve(tx state.Tx, address common.Address) (common.Address, []common.Address, error) {
+	isContract, err := tx.HasERC721UniversalContract(address.String())
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("error checking contract %s: %w", address.String(), err)
+	}
+	if isContract {
+		collection, err := tx.GetCollectionAddress(address.String())
+		if err != nil {
+			return common.Address{}, nil, fmt.Errorf("error retrieving the collection of contract %s: %w", address.String(), err)
+		}
+		return collection, []common.Address{address}, nil
+	}
+
+	var contracts []common.Address
+	for _, contract := range tx.GetAllERC721UniversalContracts() {
+		collection, err := tx.GetCollectionAddress(contract)
+		if err != nil {
+			return common.Address{}, nil, fmt.Errorf("error retrieving the collection of contract %s: %w", contract, err)
+		}
+		if collection == address {
+			contracts = append(contracts, common.HexToAddress(contract))
+		}
+	}
+	if len(contracts) > 0 {
+		return address, contracts, nil
+	}
+	// the mints of a collection are indexed even if the node does not follow any of its contracts
+	first, err := tx.GetNextEvoEventBlock(strings.ToLower(address.String()), 0)
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("error retrieving the first evo mint block of collection %s: %w", address.String(), err)
+	}
+	if first == 0 {
+		return common.Address{}, nil, fmt.Errorf("%w: %s", ErrUnknownAddress, address.String())
+	}
+	return address, nil, nil
+}
+
+// List returns the mints of collection and their status in contracts, starting after the evo block after, which is
+// either 0 or the Next block of the previous page. The mints of an evo block are listed together, so a page has
+// at least limit mints unless it is the last one.
+//
+// A contract applies the mints of an evo block once it processes an ownership block mapped to it. The ownership block
+// is searched in the roots tagged for every processed block, in which the last processed evo block of a contract only grows,
+// starting at the block found for the previous evo block. Every evo block costs a few checkouts per contract, so limit should
+// stay small. List checks out the roots in tx, which must be discarded.
+func List(tx state.Tx, collection common.Address, contracts []common.Address, after uint64, limit int) (*Page, error) {
+	// the roots are checked out after reading the last processed evo block of every contract at the head
+	lastProcessed := make([]uint64, len(contracts))
+	for i, contract := range contracts {
+		data, err := tx.AccountData(contract)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving account data for contract %s: %w", contract.String(), err)
+		}
+		lastProcessed[i] = data.LastProcessedEvoBlock
+	}
+	firstBlock, err := tx.GetFirstOwnershipBlock()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving the first ownership block: %w", err)
+	}
+	lastTaggedBlock, err := tx.GetLastTaggedBlock()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving the last tagged block: %w", err)
+	}
+	// the evo blocks are listed in order, so the search for every contract starts at the block found for the previous one
+	searchFrom := make([]uint64, len(contracts))
+	for i := range searchFrom {
+		searchFrom[i] = firstBlock.Number
+	}
+
+	page := &Page{}
+	evoBlock := after
+	for {
+		next, err := tx.GetNextEvoEventBlock(strings.ToLower(collection.String()), evoBlock)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving the next evo mint block of collection %s after block %d: %w", collection.String(), evoBlock, err)
+		}
+		if next == 0 || next == evoBlock {
+			return page, nil
+		}
+		if len(page.Mints) >= limit {
+			page.Next = evoBlock
+			return page, nil
+		}
+		evoBlock = next
+
+		events, err := tx.GetMintedWithExternalURIEvents(collection.String(), evoBlock)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving the evo mints of collection %s at block %d: %w", collection.String(), evoBlock, err)
+		}
+		statuses := make([]ContractStatus, len(contracts))
+		for i, contract := range contracts {
+			statuses[i] = ContractStatus{Contract: contract, Status: StatusPending}
+			if lastProcessed[i] < evoBlock {
+				continue
+			}
+			block, err := appliedAt(tx, contract, evoBlock, searchFrom[i], uint64(lastTaggedBlock))
+			if err != nil {
+				return nil, err
+			}
+			statuses[i] = ContractStatus{Contract: contract, Status: StatusApplied, OwnershipBlock: block}
+			searchFrom[i] = block
+		}
+		for _, event := range events {
+			page.Mints = append(page.Mints, Mint{MintedWithExternalURI: event, Contracts: statuses})
+		}
+	}
+}
+
+// appliedAt returns the first ownership block in [from, to] whose tagged root has evoBlock processed by contract,
+// which the root tagged for to has. The blocks after from are probed at growing distances before the search is narrowed down,
+// so that a mint applied shortly after from, like the mints of consecutive evo blocks, only takes a few checkouts.
+func appliedAt(tx state.Tx, contract common.Address, evoBlock, from, to uint64) (uint64, error) {
+	processed := func(block uint64) (bool, error) {
+		if err := tx.Checkout(int64(block)); err != nil {
+			return false, fmt.Errorf("error checking out state at block %d: %w", block, err)
+		}
+		data, err := tx.AccountData(contract)
+		if err != nil {
+			return false, fmt.Errorf("error retrieving account data for contract %s at block %d: %w", contract.String(), block, err)
+		}
+		return data.LastProcessedEvoBlock >= evoBlock, nil
+	}
+
+	// the block is in [low, high]
+	low, high := from, to
+	for step := uint64(1); from+step-1 < high; step *= 2 {
+		block := from + step - 1
+		ok, err := processed(block)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			high = block
+			break
+		}
+		low = block + 1
+	}
+	if low >= high {
+		return high, nil
+	}
+	var searchErr error
+	i := sort.Search(int(high-low), func(i int) bool {
+		if searchErr != nil {
+			return true
+		}
+		ok, err := processed(low + uint64(i))
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return ok
+	})
+	if searchErr != nil {
+		return 0, searchErr
+	}
+	return low + uint64(i), nil
+}
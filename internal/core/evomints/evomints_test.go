@@ -0,0 +1,219 @@
+package evomints_test
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/freeverseio/laos-universal-node/internal/core/evomints"
+	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state"
+	v1 "github.com/freeverseio/laos-universal-node/internal/platform/state/v1"
+	badgerStorage "github.com/freeverseio/laos-universal-node/internal/platform/storage/badger"
+)
+
+var (
+	contract   = common.HexToAddress("0x500")
+	collection = common.HexToAddress("0x501")
+	// unfollowed is a collection with evo mints but no contract followed by the node
+	unfollowed = common.HexToAddress("0x502")
+)
+
+func TestList(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		after         uint64
+		limit         int
+		expectedMints string
+		expectedNext  uint64
+	}{
+		{
+			name:          "lists all the mints with their status",
+			limit:         10,
+			expectedMints: "[1@5:applied@12 2@5:applied@12 3@7:applied@15 4@9:pending]",
+		},
+		{
+			name:          "lists whole evo blocks until the limit",
+			limit:         1,
+			expectedMints: "[1@5:applied@12 2@5:applied@12]",
+			expectedNext:  5,
+		},
+		{
+			name:          "lists the mints after the next evo block of the previous page",
+			after:         5,
+			limit:         2,
+			expectedMints: "[3@7:applied@15 4@9:pending]",
+		},
+		{
+			name:          "lists nothing after the last mint",
+			after:         9,
+			limit:         10,
+			expectedMints: "[]",
+		},
+	}
+	db := createBadger(t)
+	populateState(t, db)
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tx := createTransaction(t, db)
+			defer tx.Discard()
+
+			page, err := evomints.List(tx, collection, []common.Address{contract}, tt.after, tt.limit)
+			if err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			mints := make([]string, 0, len(page.Mints))
+			for _, mint := range page.Mints {
+				if len(mint.Contracts) != 1 || mint.Contracts[0].Contract != contract {
+					t.Fatalf("got contracts %v, expected the status of contract %s", mint.Contracts, contract.String())
+				}
+				status := mint.Contracts[0]
+				s := fmt.Sprintf("%s@%d:%s", mint.TokenId, mint.BlockNumber, status.Status)
+				if status.Status == evomints.StatusApplied {
+					s += fmt.Sprintf("@%d", status.OwnershipBlock)
+				}
+				mints = append(mints, s)
+			}
+			if fmt.Sprint(mints) != tt.expectedMints {
+				t.Fatalf("got mints %v, expected %s", mints, tt.expectedMints)
+			}
+			if page.Next != tt.expectedNext {
+				t.Fatalf("got next %d, expected %d", page.Next, tt.expectedNext)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name               string
+		address            common.Address
+		expectedCollection common.Address
+		expectedContracts  []common.Address
+		expectedErr        error
+	}{
+		{
+			name:               "resolves a contract to its collection",
+			address:            contract,
+			expectedCollection: collection,
+			expectedContracts:  []common.Address{contract},
+		},
+		{
+			name:               "resolves a collection to its contracts",
+			address:            collection,
+			expectedCollection: collection,
+			expectedContracts:  []common.Address{contract},
+		},
+		{
+			name:               "resolves a collection without contracts",
+			address:            unfollowed,
+			expectedCollection: unfollowed,
+		},
+		{
+			name:        "fails for an unknown address",
+			address:     common.HexToAddress("0x503"),
+			expectedErr: evomints.ErrUnknownAddress,
+		},
+	}
+	db := createBadger(t)
+	populateState(t, db)
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tx := createTransaction(t, db)
+			defer tx.Discard()
+
+			gotCollection, gotContracts, err := evomints.Resolve(tx, tt.address)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf(`got error "%v", expected "%v"`, err, tt.expectedErr)
+			}
+			if gotCollection != tt.expectedCollection {
+				t.Fatalf("got collection %s, expected %s", gotCollection.String(), tt.expectedCollection.String())
+			}
+			if fmt.Sprint(gotContracts) != fmt.Sprint(tt.expectedContracts) {
+				t.Fatalf("got contracts %v, expected %v", gotContracts, tt.expectedContracts)
+			}
+		})
+	}
+}
+
+// populateState processes the ownership blocks 10 to 20. The contract applies the mints of evo block 5 at block 12
+// and the one of evo block 7 at block 15, while the one of evo block 9 is pending.
+func populateState(t *testing.T, db *badger.DB) {
+	t.Helper()
+	tx := createTransaction(t, db)
+	defer tx.Discard()
+	check := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+	}
+
+	check(tx.StoreERC721UniversalContracts([]model.ERC721UniversalContract{{Address: contract, CollectionAddress: collection}}))
+	check(tx.SetFirstOwnershipBlock(model.Block{Number: 10, Timestamp: 100, Hash: common.HexToHash("0x10")}))
+	mints := map[uint64][]int64{5: {1, 2}, 7: {3}, 9: {4}}
+	for _, evoBlock := range []uint64{5, 7, 9} {
+		for i, tokenId := range mints[evoBlock] {
+			check(tx.StoreMintedWithExternalURIEvent(collection.String(), &model.MintedWithExternalURI{
+				Slot:        big.NewInt(tokenId),
+				To:          common.HexToAddress("0x1"),
+				TokenURI:    "tokenURI",
+				TokenId:     big.NewInt(tokenId),
+				BlockNumber: evoBlock,
+				TxIndex:     uint64(i),
+			}))
+		}
+		check(tx.SetNextEvoEventBlock(collection.String(), evoBlock))
+	}
+	check(tx.SetNextEvoEventBlock(unfollowed.String(), 8))
+
+	applied := map[int64]uint64{12: 5, 15: 7}
+	for block := int64(10); block <= 20; block++ {
+		if evoBlock, ok := applied[block]; ok {
+			check(tx.LoadContractTrees(contract))
+			for _, tokenId := range mints[evoBlock] {
+				check(tx.Mint(contract, &model.MintedWithExternalURI{Slot: big.NewInt(tokenId), To: common.HexToAddress("0x1"), TokenId: big.NewInt(tokenId)}))
+			}
+			check(tx.UpdateContractState(contract, evoBlock))
+		}
+		check(tx.TagRoot(block))
+	}
+	check(tx.SetLastOwnershipBlock(model.Block{Number: 20, Timestamp: 200, Hash: common.HexToHash("0x20")}))
+	check(tx.Commit())
+}
+
+func createBadger(t *testing.T) *badger.DB {
+	t.Helper()
+	db, err := badger.Open(
+		badger.DefaultOptions("").
+			WithInMemory(true).
+			WithLoggingLevel(badger.ERROR))
+	if err != nil {
+		t.Fatalf("error initializing storage: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("error closing db: %v", err)
+		}
+	})
+	return db
+}
+
+func createTransaction(t *testing.T, db *badger.DB) state.Tx {
+	t.Helper()
+	tx, err := v1.NewStateService(badgerStorage.NewService(db)).NewTransaction()
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	return tx
+}
@@ -53,16 +53,16 @@ func (mr *MockProcessorMockRecorder) IsMappingSyncedWithProcessing() *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsMappingSyncedWithProcessing", reflect.TypeOf((*MockProcessor)(nil).IsMappingSyncedWithProcessing))
 }
 
-// MapNextBlock mocks base method.
-func (m *MockProcessor) MapNextBlock(ctx context.Context) error {
+// MapNextBlocks mocks base method.
+func (m *MockProcessor) MapNextBlocks(ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MapNextBlock", ctx)
+	ret := m.ctrl.Call(m, "MapNextBlocks", ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// MapNextBlock indicates an expected call of MapNextBlock.
-func (mr *MockProcessorMockRecorder) MapNextBlock(ctx any) *gomock.Call {
+// MapNextBlocks indicates an expected call of MapNextBlocks.
+func (mr *MockProcessorMockRecorder) MapNextBlocks(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MapNextBlock", reflect.TypeOf((*MockProcessor)(nil).MapNextBlock), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MapNextBlocks", reflect.TypeOf((*MockProcessor)(nil).MapNextBlocks), ctx)
 }
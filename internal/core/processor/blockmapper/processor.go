@@ -6,13 +6,16 @@ import (
 	"log/slog"
 	"math/big"
 
+	"github.com/ethereum/go-ethereum/common"
+
 	"github.com/freeverseio/laos-universal-node/internal/core/block/search"
 	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain"
+	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	"github.com/freeverseio/laos-universal-node/internal/platform/state"
 )
 
 type Processor interface {
-	MapNextBlock(ctx context.Context) error
+	MapNextBlocks(ctx context.Context) error
 	IsMappingSyncedWithProcessing() (bool, error)
 }
 
@@ -68,9 +71,18 @@ func (p *processor) IsMappingSyncedWithProcessing() (bool, error) {
 	return false, nil
 }
 
-// MapNextBlock retrieves the last mapped ownership block number from storage, advances to the next one,
-// looks for the corresponding evo block in time and stores the ownership-evo block pair
-func (p *processor) MapNextBlock(ctx context.Context) error {
+// maxMappedEvoBlocks bounds the evo blocks, and thus the ownership blocks produced meanwhile, mapped in a single transaction
+const maxMappedEvoBlocks = 1000
+
+type blockMapping struct {
+	ownershipBlock uint64
+	evoBlock       uint64
+}
+
+// MapNextBlocks retrieves the last mapped ownership block number from storage and maps the next ownership blocks
+// to the evo blocks produced right before them in a single transaction. Blocks are mapped by walking the timestamps
+// indexed by the workers; when they are not indexed yet, only the next block is mapped, searching it on chain.
+func (p *processor) MapNextBlocks(ctx context.Context) error {
 	tx, err := p.stateService.NewTransaction()
 	if err != nil {
 		err = fmt.Errorf("error occurred creating transaction: %w", err)
@@ -96,33 +108,124 @@ func (p *processor) MapNextBlock(ctx context.Context) error {
 			lastMappedOwnershipBlock, err)
 	}
 
-	// given the ownership block timestamp, find the corresponding evo block number
+	evoBlocks, err := getIndexedEvoBlocks(tx, evoBlockStartingPoint)
+	if err != nil {
+		return err
+	}
+	var ownershipBlocks []model.Block
+	if len(evoBlocks) > 0 {
+		ownershipBlocks, err = tx.GetOwnershipBlocksByTimestamp(evoBlocks[0].Timestamp, evoBlocks[len(evoBlocks)-1].Timestamp)
+		if err != nil {
+			return fmt.Errorf("error occurred retrieving the indexed ownership blocks from storage: %w", err)
+		}
+	}
+	mappings := mergeBlocks(ownershipBlocks, evoBlocks, toMapOwnershipBlock)
+	if len(mappings) == 0 {
+		mapping, err := p.mapBlock(ctx, toMapOwnershipBlock, evoBlockStartingPoint, evoBlocks)
+		if err != nil {
+			return err
+		}
+		mappings = append(mappings, mapping)
+	}
+
+	// set ownership block -> evo block mappings
+	slog.Debug("setting ownership block to evo block mappings",
+		"fromOwnershipBlock", mappings[0].ownershipBlock, "toOwnershipBlock", mappings[len(mappings)-1].ownershipBlock)
+	for _, mapping := range mappings {
+		err = tx.SetOwnershipEvoBlockMapping(mapping.ownershipBlock, mapping.evoBlock)
+		if err != nil {
+			return fmt.Errorf("error setting ownership block number %d (key) to evo block number %d (value) in storage: %w",
+				mapping.ownershipBlock, mapping.evoBlock, err)
+		}
+	}
+	lastMappedOwnershipBlock = mappings[len(mappings)-1].ownershipBlock
+	err = tx.SetLastMappedOwnershipBlockNumber(lastMappedOwnershipBlock)
+	if err != nil {
+		return fmt.Errorf("error setting the last mapped ownership block number %d in storage: %w", lastMappedOwnershipBlock, err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// mapBlock maps a single ownership block whose timestamp is not indexed. Its timestamp is requested to the ownership chain
+// and, unless the indexed evo blocks cover it, the evo block is searched on the evolution chain.
+func (p *processor) mapBlock(ctx context.Context, toMapOwnershipBlock, evoBlockStartingPoint uint64, evoBlocks []model.Block) (blockMapping, error) {
 	toMapOwnershipHeader, err := p.ownershipClient.HeaderByNumber(ctx, big.NewInt(int64(toMapOwnershipBlock)))
 	if err != nil {
-		return fmt.Errorf("error occurred retrieving block number %d from ownership chain: %w", toMapOwnershipBlock, err)
+		return blockMapping{}, fmt.Errorf("error occurred retrieving block number %d from ownership chain: %w", toMapOwnershipBlock, err)
 	}
+	ownershipBlock := model.Block{Number: toMapOwnershipBlock, Timestamp: toMapOwnershipHeader.Time}
+	if mappings := mergeBlocks([]model.Block{ownershipBlock}, evoBlocks, toMapOwnershipBlock); len(mappings) > 0 {
+		return mappings[0], nil
+	}
+
 	toMapEvoBlock, err := p.blockSearch.GetEvolutionBlockByTimestamp(ctx, toMapOwnershipHeader.Time, evoBlockStartingPoint)
 	if err != nil {
-		return fmt.Errorf("error occurred searching for evolution block number by target timestamp %d (ownership block number %d): %w",
+		return blockMapping{}, fmt.Errorf("error occurred searching for evolution block number by target timestamp %d (ownership block number %d): %w",
 			toMapOwnershipHeader.Time, toMapOwnershipBlock, err)
 	}
+	return blockMapping{ownershipBlock: toMapOwnershipBlock, evoBlock: toMapEvoBlock}, nil
+}
 
-	// set ownership block -> evo block mapping
-	slog.Debug("setting ownership block to evo block mapping", "toMapOwnershipBlock", toMapOwnershipBlock, "toMapEvoBlock", toMapEvoBlock)
-	err = tx.SetOwnershipEvoBlockMapping(toMapOwnershipBlock, toMapEvoBlock)
+// getIndexedEvoBlocks returns up to maxMappedEvoBlocks indexed evo blocks starting at startingPoint, sorted by timestamp.
+// It returns none if startingPoint was processed before evo blocks were indexed.
+func getIndexedEvoBlocks(tx state.Tx, startingPoint uint64) ([]model.Block, error) {
+	firstBlock, err := tx.GetEvoBlock(startingPoint)
 	if err != nil {
-		return fmt.Errorf("error setting ownership block number %d (key) to evo block number %d (value) in storage: %w",
-			toMapOwnershipBlock, toMapEvoBlock, err)
+		return nil, fmt.Errorf("error occurred retrieving evolution block %d from storage: %w", startingPoint, err)
 	}
-	err = tx.SetLastMappedOwnershipBlockNumber(toMapOwnershipBlock)
+	if (firstBlock.Hash == common.Hash{}) {
+		return nil, nil
+	}
+
+	lastEvoBlock, err := tx.GetLastEvoBlock()
 	if err != nil {
-		return fmt.Errorf("error setting the last mapped ownership block number %d in storage: %w", toMapOwnershipBlock, err)
+		return nil, fmt.Errorf("error occurred retrieving the last evolution block from storage: %w", err)
 	}
-	err = tx.Commit()
+	lastBlock := lastEvoBlock
+	if lastEvoBlock.Number > startingPoint+maxMappedEvoBlocks {
+		lastBlock, err = tx.GetEvoBlock(startingPoint + maxMappedEvoBlocks)
+		if err != nil {
+			return nil, fmt.Errorf("error occurred retrieving evolution block %d from storage: %w", startingPoint+maxMappedEvoBlocks, err)
+		}
+	}
+
+	evoBlocks, err := tx.GetEvoBlocksByTimestamp(firstBlock.Timestamp, lastBlock.Timestamp)
 	if err != nil {
-		return fmt.Errorf("error committing transaction: %w", err)
+		return nil, fmt.Errorf("error occurred retrieving the indexed evolution blocks from storage: %w", err)
 	}
-	return nil
+	return evoBlocks, nil
+}
+
+// mergeBlocks walks the ownership and evo blocks, both sorted by timestamp, mapping every ownership block from
+// firstOwnershipBlock on to the last evo block produced before it, as search.GetEvolutionBlockByTimestamp does.
+// It stops at the first ownership block that is not indexed or that is not followed by an evo block, as newer evo blocks
+// might still be produced before it.
+func mergeBlocks(ownershipBlocks, evoBlocks []model.Block, firstOwnershipBlock uint64) []blockMapping {
+	var mappings []blockMapping
+	nextOwnershipBlock := firstOwnershipBlock
+	evoIdx := 0
+	for _, ownershipBlock := range ownershipBlocks {
+		// blocks produced at the same time as already mapped ones
+		if ownershipBlock.Number < nextOwnershipBlock {
+			continue
+		}
+		if ownershipBlock.Number != nextOwnershipBlock {
+			break
+		}
+		for evoIdx+1 < len(evoBlocks) && evoBlocks[evoIdx+1].Timestamp < ownershipBlock.Timestamp {
+			evoIdx++
+		}
+		if evoIdx+1 >= len(evoBlocks) || evoBlocks[evoIdx].Timestamp >= ownershipBlock.Timestamp {
+			break
+		}
+		mappings = append(mappings, blockMapping{ownershipBlock: ownershipBlock.Number, evoBlock: evoBlocks[evoIdx].Number})
+		nextOwnershipBlock++
+	}
+	return mappings
 }
 
 func (p *processor) getNextOwnershipBlockToBeMapped(ctx context.Context, lastMappedOwnershipBlock uint64, tx state.Tx) (uint64, error) {
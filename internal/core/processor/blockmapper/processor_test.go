@@ -6,12 +6,16 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	searchMock "github.com/freeverseio/laos-universal-node/internal/core/block/search/mock"
 	"github.com/freeverseio/laos-universal-node/internal/core/processor/blockmapper"
 	clientMock "github.com/freeverseio/laos-universal-node/internal/platform/blockchain/mock"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	stateMock "github.com/freeverseio/laos-universal-node/internal/platform/state/mock"
+	v1 "github.com/freeverseio/laos-universal-node/internal/platform/state/v1"
+	badgerStorage "github.com/freeverseio/laos-universal-node/internal/platform/storage/badger"
 	"go.uber.org/mock/gomock"
 )
 
@@ -160,18 +164,133 @@ func TestMapNextBlock(t *testing.T) {
 	mockObjects.tx.EXPECT().GetLastMappedOwnershipBlockNumber().Return(lastMappedOwnershipBlock, nil)
 	mockObjects.tx.EXPECT().GetMappedEvoBlockNumber(uint64(99)).Return(mappedEvoBlock, nil)
 	mockObjects.ownClient.EXPECT().HeaderByNumber(context.Background(), big.NewInt(int64(nextOwnershipBlock))).Return(&nextOwnershipBlockHeader, nil)
+	// the mapped evo block was processed before evo blocks were indexed
+	mockObjects.tx.EXPECT().GetEvoBlock(mappedEvoBlock).Return(model.Block{}, nil)
 	mockObjects.search.EXPECT().GetEvolutionBlockByTimestamp(context.Background(), nextOwnershipBlockHeader.Time, mappedEvoBlock).Return(toMapEvoBlock, nil)
 	mockObjects.tx.EXPECT().SetOwnershipEvoBlockMapping(nextOwnershipBlock, toMapEvoBlock).Return(nil)
 	mockObjects.tx.EXPECT().SetLastMappedOwnershipBlockNumber(nextOwnershipBlock).Return(nil)
 	mockObjects.tx.EXPECT().Commit().Return(nil)
 
 	processor := blockmapper.New(mockObjects.ownClient, mockObjects.evoClient, mockObjects.state, blockmapper.WithBlockSearch(mockObjects.search))
-	err := processor.MapNextBlock(context.Background())
+	err := processor.MapNextBlocks(context.Background())
 	if err != nil {
 		t.Errorf("got error '%v' while no error was expected", err)
 	}
 }
 
+func TestMapNextBlocksWithIndexedTimestamps(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		lastMappedBlock  uint64
+		mappedEvoBlock   uint64
+		ownershipBlocks  []model.Block
+		evoBlocks        []model.Block
+		expectedMappings map[uint64]uint64
+		expectedRPCBlock bool
+	}{
+		{
+			name:            "maps every indexed ownership block followed by an evo block",
+			lastMappedBlock: 99,
+			mappedEvoBlock:  9,
+			ownershipBlocks: []model.Block{
+				{Number: 99, Timestamp: 104}, {Number: 100, Timestamp: 106}, {Number: 101, Timestamp: 108},
+				{Number: 102, Timestamp: 112}, {Number: 103, Timestamp: 114}, {Number: 104, Timestamp: 118},
+				{Number: 105, Timestamp: 120},
+			},
+			evoBlocks: []model.Block{
+				{Number: 9, Timestamp: 100}, {Number: 10, Timestamp: 106}, {Number: 11, Timestamp: 112}, {Number: 12, Timestamp: 118},
+			},
+			// block 105 is produced after the last evo block, so a newer evo block is needed to map it
+			expectedMappings: map[uint64]uint64{100: 9, 101: 10, 102: 10, 103: 11, 104: 11},
+		},
+		{
+			name:             "maps a single block through the chain when the ownership blocks are not indexed",
+			lastMappedBlock:  99,
+			mappedEvoBlock:   9,
+			evoBlocks:        []model.Block{{Number: 9, Timestamp: 100}, {Number: 10, Timestamp: 106}, {Number: 11, Timestamp: 112}},
+			expectedMappings: map[uint64]uint64{100: 10},
+			expectedRPCBlock: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl, mockObjects := getMocks(t)
+			defer ctrl.Finish()
+
+			db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLoggingLevel(badger.ERROR))
+			if err != nil {
+				t.Fatalf("error initializing storage: %v", err)
+			}
+			defer db.Close()
+			stateService := v1.NewStateService(badgerStorage.NewService(db))
+			tx, err := stateService.NewTransaction()
+			if err != nil {
+				t.Fatalf("got error '%v' while no error was expected", err)
+			}
+			for _, block := range tt.ownershipBlocks {
+				if err := tx.SetOwnershipBlockTimestamp(block); err != nil {
+					t.Fatalf("got error '%v' while no error was expected", err)
+				}
+			}
+			for _, block := range tt.evoBlocks {
+				block.Hash = common.BigToHash(new(big.Int).SetUint64(block.Number))
+				if err := tx.SetEvoBlock(block); err != nil {
+					t.Fatalf("got error '%v' while no error was expected", err)
+				}
+				if err := tx.SetLastEvoBlock(block); err != nil {
+					t.Fatalf("got error '%v' while no error was expected", err)
+				}
+			}
+			if err := tx.SetLastMappedOwnershipBlockNumber(tt.lastMappedBlock); err != nil {
+				t.Fatalf("got error '%v' while no error was expected", err)
+			}
+			if err := tx.SetOwnershipEvoBlockMapping(tt.lastMappedBlock, tt.mappedEvoBlock); err != nil {
+				t.Fatalf("got error '%v' while no error was expected", err)
+			}
+			if err := tx.Commit(); err != nil {
+				t.Fatalf("got error '%v' while no error was expected", err)
+			}
+			if tt.expectedRPCBlock {
+				mockObjects.ownClient.EXPECT().HeaderByNumber(context.Background(), big.NewInt(int64(tt.lastMappedBlock+1))).
+					Return(&types.Header{Number: big.NewInt(int64(tt.lastMappedBlock + 1)), Time: 108}, nil)
+			}
+
+			processor := blockmapper.New(mockObjects.ownClient, mockObjects.evoClient, stateService, blockmapper.WithBlockSearch(mockObjects.search))
+			if err := processor.MapNextBlocks(context.Background()); err != nil {
+				t.Fatalf("got error '%v' while no error was expected", err)
+			}
+
+			tx, err = stateService.NewTransaction()
+			if err != nil {
+				t.Fatalf("got error '%v' while no error was expected", err)
+			}
+			defer tx.Discard()
+			lastMappedBlock := tt.lastMappedBlock
+			for ownershipBlock, expectedEvoBlock := range tt.expectedMappings {
+				evoBlock, err := tx.GetMappedEvoBlockNumber(ownershipBlock)
+				if err != nil {
+					t.Fatalf("got error '%v' while no error was expected", err)
+				}
+				if evoBlock != expectedEvoBlock {
+					t.Fatalf("got ownership block %d mapped to evo block %d, expected %d", ownershipBlock, evoBlock, expectedEvoBlock)
+				}
+				lastMappedBlock = max(lastMappedBlock, ownershipBlock)
+			}
+			storedLastMappedBlock, err := tx.GetLastMappedOwnershipBlockNumber()
+			if err != nil {
+				t.Fatalf("got error '%v' while no error was expected", err)
+			}
+			if storedLastMappedBlock != lastMappedBlock {
+				t.Fatalf("got last mapped block %d, expected %d", storedLastMappedBlock, lastMappedBlock)
+			}
+		})
+	}
+}
+
 func TestMapNextBlockError(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -417,13 +536,14 @@ func TestMapNextBlockError(t *testing.T) {
 			tt.getFirstOwnershipBlockFunc(mockObjects.tx)
 			tt.getMappedEvoBlockFunc(mockObjects.tx)
 			tt.headerByNumberFunc(mockObjects.ownClient)
+			mockObjects.tx.EXPECT().GetEvoBlock(gomock.Any()).Return(model.Block{}, nil).AnyTimes()
 			tt.getEvolutionBlockByTimestampFunc(mockObjects.search)
 			tt.setOwnershipEvoBlockMappingFunc(mockObjects.tx)
 			tt.setLastMappedOwnBlockFunc(mockObjects.tx)
 			tt.commitFunc(mockObjects.tx)
 
 			processor := blockmapper.New(mockObjects.ownClient, mockObjects.evoClient, mockObjects.state, blockmapper.WithBlockSearch(mockObjects.search))
-			err := processor.MapNextBlock(context.Background())
+			err := processor.MapNextBlocks(context.Background())
 			if err == nil || err.Error() != tt.expectedErr.Error() {
 				t.Fatalf("got error '%v', want '%v'", err, tt.expectedErr)
 			}
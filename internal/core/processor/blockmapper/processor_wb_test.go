@@ -132,3 +132,43 @@ func getMocks(t *testing.T) (ctrl *gomock.Controller, objects *mocks) {
 		search: searchMock.NewMockSearch(ctrl),
 	}
 }
+
+func TestMergeBlocks(t *testing.T) {
+	t.Parallel()
+	evoBlocks := []model.Block{{Number: 9, Timestamp: 100}, {Number: 10, Timestamp: 106}, {Number: 11, Timestamp: 112}}
+	tests := []struct {
+		name             string
+		ownershipBlocks  []model.Block
+		expectedMappings []blockMapping
+	}{
+		{
+			name:             "stops at the first ownership block that is not indexed",
+			ownershipBlocks:  []model.Block{{Number: 100, Timestamp: 102}, {Number: 102, Timestamp: 104}},
+			expectedMappings: []blockMapping{{ownershipBlock: 100, evoBlock: 9}},
+		},
+		{
+			name:             "skips the ownership blocks already mapped",
+			ownershipBlocks:  []model.Block{{Number: 99, Timestamp: 101}, {Number: 100, Timestamp: 107}},
+			expectedMappings: []blockMapping{{ownershipBlock: 100, evoBlock: 10}},
+		},
+		{
+			name:            "does not map ownership blocks produced before the first evo block",
+			ownershipBlocks: []model.Block{{Number: 100, Timestamp: 100}},
+		},
+		{
+			name:            "does not map ownership blocks produced after the last evo block",
+			ownershipBlocks: []model.Block{{Number: 100, Timestamp: 113}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			mappings := mergeBlocks(tt.ownershipBlocks, evoBlocks, 100)
+			if fmt.Sprint(mappings) != fmt.Sprint(tt.expectedMappings) {
+				t.Errorf("got mappings %v, expected %v", mappings, tt.expectedMappings)
+			}
+		})
+	}
+}
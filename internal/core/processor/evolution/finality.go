@@ -0,0 +1,179 @@
+package evolution
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/substrate"
+)
+
+const (
+	initialResubscribeDelay = time.Second
+	maxResubscribeDelay     = time.Minute
+)
+
+// finalityLag is the number of blocks between the newest known block of the evolution chain and its finalized block
+var finalityLag = expvar.NewInt("evo_finality_lag_blocks")
+
+// FinalityTracker returns the finalized block of the evolution chain
+type FinalityTracker interface {
+	// FinalizedBlock returns the highest known finalized block. It is only requested to the chain
+	// when targetBlock is ahead of the known one.
+	FinalizedBlock(ctx context.Context, targetBlock uint64) (uint64, error)
+}
+
+type finalityTracker struct {
+	client substrate.Client
+
+	mu        sync.Mutex
+	finalized uint64
+	head      uint64
+}
+
+// NewFinalityTracker returns a FinalityTracker that requests the finalized head through client
+func NewFinalityTracker(client substrate.Client) *finalityTracker {
+	return &finalityTracker{client: client}
+}
+
+func (f *finalityTracker) FinalizedBlock(ctx context.Context, targetBlock uint64) (uint64, error) {
+	if finalized := f.finalizedBlock(); finalized >= targetBlock {
+		return finalized, nil
+	}
+
+	blockHash, err := f.client.FinalizedHead(ctx)
+	if err != nil {
+		return 0, err
+	}
+	header, err := f.client.Header(ctx, blockHash)
+	if err != nil {
+		return 0, err
+	}
+	f.observeFinalized(header.Number)
+	return f.finalizedBlock(), nil
+}
+
+// Lag returns the number of blocks between the newest known block and the finalized block.
+// The newest block is only known from the new heads subscription, so the lag is 0 without it.
+func (f *finalityTracker) Lag() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.head < f.finalized {
+		return 0
+	}
+	return f.head - f.finalized
+}
+
+// Subscribe follows the finalized and new heads of the evolution chain through the WebSocket endpoint of its RPC URL,
+// so that the finalized block is known without requesting it.
+// It returns an error if the endpoint does not support WebSocket subscriptions. Otherwise, it resubscribes
+// whenever a subscription is lost and returns once ctx is canceled.
+func (f *finalityTracker) Subscribe(ctx context.Context) error {
+	subs, err := f.subscribe(ctx)
+	if err != nil {
+		return fmt.Errorf("error subscribing to finalized heads: %w", err)
+	}
+	slog.Info("subscribed to the finalized heads of the evolution chain")
+
+	for {
+		if err = f.readHeads(ctx, subs); ctx.Err() != nil {
+			return nil
+		}
+		slog.Warn("finalized heads subscription lost, resubscribing", "err", err.Error())
+		if subs, err = f.resubscribe(ctx); err != nil {
+			return nil
+		}
+	}
+}
+
+// resubscribe subscribes again with an increasing delay until it succeeds or ctx is canceled
+func (f *finalityTracker) resubscribe(ctx context.Context) (*headSubscriptions, error) {
+	delay := initialResubscribeDelay
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		subs, err := f.subscribe(ctx)
+		if err == nil {
+			return subs, nil
+		}
+		slog.Warn("error resubscribing to finalized heads", "delay", delay, "err", err.Error())
+		delay = min(delay*2, maxResubscribeDelay)
+	}
+}
+
+type headSubscriptions struct {
+	finalized, new           *substrate.Subscription
+	finalizedHeads, newHeads chan *substrate.Header
+}
+
+// subscribe subscribes to the finalized and new heads
+func (f *finalityTracker) subscribe(ctx context.Context) (*headSubscriptions, error) {
+	subs := &headSubscriptions{
+		finalizedHeads: make(chan *substrate.Header),
+		newHeads:       make(chan *substrate.Header),
+	}
+	var err error
+	if subs.finalized, err = f.client.SubscribeFinalizedHeads(ctx, subs.finalizedHeads); err != nil {
+		return nil, err
+	}
+	if subs.new, err = f.client.SubscribeNewHeads(ctx, subs.newHeads); err != nil {
+		subs.finalized.Unsubscribe()
+		return nil, err
+	}
+	return subs, nil
+}
+
+// readHeads updates the known heads with the headers received until one of the subscriptions ends or ctx is canceled
+func (f *finalityTracker) readHeads(ctx context.Context, subs *headSubscriptions) error {
+	defer subs.finalized.Unsubscribe()
+	defer subs.new.Unsubscribe()
+
+	for {
+		var err error
+		select {
+		case header := <-subs.finalizedHeads:
+			f.observeFinalized(header.Number)
+			continue
+		case header := <-subs.newHeads:
+			f.observeHead(header.Number)
+			continue
+		case err = <-subs.finalized.Err():
+		case err = <-subs.new.Err():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err == nil {
+			err = errors.New("subscription closed")
+		}
+		return err
+	}
+}
+
+func (f *finalityTracker) finalizedBlock() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.finalized
+}
+
+// observeFinalized and observeHead only move the known blocks forward, as notifications and responses might arrive out of order
+func (f *finalityTracker) observeFinalized(blockNumber uint64) {
+	f.mu.Lock()
+	f.finalized = max(f.finalized, blockNumber)
+	f.head = max(f.head, f.finalized)
+	f.mu.Unlock()
+	finalityLag.Set(int64(f.Lag()))
+}
+
+func (f *finalityTracker) observeHead(blockNumber uint64) {
+	f.mu.Lock()
+	f.head = max(f.head, blockNumber)
+	f.mu.Unlock()
+	finalityLag.Set(int64(f.Lag()))
+}
@@ -0,0 +1,233 @@
+package evolution_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/freeverseio/laos-universal-node/internal/core/processor/evolution"
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/substrate"
+)
+
+// substrateStub is a Substrate JSON-RPC server answering chain_getFinalizedHead and chain_getHeader over HTTP
+// and pushing the heads sent to its channels to the chain_subscribeFinalizedHeads and chain_subscribeNewHeads subscribers
+type substrateStub struct {
+	mu             sync.Mutex
+	finalized      uint64
+	httpCalls      int
+	finalizedHeads chan uint64
+	newHeads       chan uint64
+	noWebSocket    bool
+}
+
+func newSubstrateStub(finalized uint64) *substrateStub {
+	return &substrateStub{
+		finalized:      finalized,
+		finalizedHeads: make(chan uint64),
+		newHeads:       make(chan uint64),
+	}
+}
+
+func (s *substrateStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		if s.noWebSocket {
+			http.Error(w, "websocket not supported", http.StatusNotFound)
+			return
+		}
+		s.serveSubscriptions(w, r)
+		return
+	}
+
+	var request struct {
+		ID     int    `json:"id"`
+		Method string `json:"method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.httpCalls++
+	finalized := s.finalized
+	s.mu.Unlock()
+
+	var response string
+	switch request.Method {
+	case "chain_getFinalizedHead":
+		response = fmt.Sprintf(`{"jsonrpc":"2.0","result":"0x%064x","id":%d}`, finalized, request.ID)
+	case "chain_getHeader":
+		response = fmt.Sprintf(`{"jsonrpc":"2.0","result":{"number":"0x%x","digest":{"logs":[]}},"id":%d}`, finalized, request.ID)
+	default:
+		http.Error(w, "unknown method", http.StatusBadRequest)
+		return
+	}
+	if _, err := w.Write([]byte(response)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *substrateStub) serveSubscriptions(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var request struct {
+		ID     int    `json:"id"`
+		Method string `json:"method"`
+	}
+	if err := conn.ReadJSON(&request); err != nil {
+		return
+	}
+	subscription := fmt.Sprintf("sub%d", request.ID)
+	if err := conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "result": subscription, "id": request.ID}); err != nil {
+		return
+	}
+
+	method, heads := "chain_finalizedHead", s.finalizedHeads
+	if request.Method == "chain_subscribeNewHeads" {
+		method, heads = "chain_newHead", s.newHeads
+	}
+	for {
+		var number uint64
+		select {
+		case number = <-heads:
+		case <-r.Context().Done():
+			return
+		}
+		notification := map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  method,
+			"params": map[string]interface{}{
+				"subscription": subscription,
+				"result":       map[string]interface{}{"number": fmt.Sprintf("0x%x", number), "digest": map[string]interface{}{"logs": []string{}}},
+			},
+		}
+		if err := conn.WriteJSON(notification); err != nil {
+			return
+		}
+	}
+}
+
+func (s *substrateStub) setFinalized(finalized uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finalized = finalized
+}
+
+func (s *substrateStub) getHTTPCalls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.httpCalls
+}
+
+func TestFinalizedBlockIsOnlyRequestedWhenPassed(t *testing.T) {
+	t.Parallel()
+	stub := newSubstrateStub(100)
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	tracker := evolution.NewFinalityTracker(substrate.NewClient(&http.Client{}, server.URL))
+
+	steps := []struct {
+		targetBlock       uint64
+		chainFinalized    uint64
+		expectedFinalized uint64
+		expectedHTTPCalls int
+	}{
+		{targetBlock: 50, chainFinalized: 100, expectedFinalized: 100, expectedHTTPCalls: 2},
+		// the known finalized block is ahead of the target, nothing is requested
+		{targetBlock: 90, chainFinalized: 120, expectedFinalized: 100, expectedHTTPCalls: 2},
+		{targetBlock: 110, chainFinalized: 120, expectedFinalized: 120, expectedHTTPCalls: 4},
+		// the target is not finalized yet
+		{targetBlock: 130, chainFinalized: 120, expectedFinalized: 120, expectedHTTPCalls: 6},
+	}
+	for _, step := range steps {
+		stub.setFinalized(step.chainFinalized)
+		finalized, err := tracker.FinalizedBlock(context.Background(), step.targetBlock)
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if finalized != step.expectedFinalized {
+			t.Fatalf("got finalized block %d for target %d, expected %d", finalized, step.targetBlock, step.expectedFinalized)
+		}
+		if stub.getHTTPCalls() != step.expectedHTTPCalls {
+			t.Fatalf("got %d requests for target %d, expected %d", stub.getHTTPCalls(), step.targetBlock, step.expectedHTTPCalls)
+		}
+	}
+	// the targets are not heads of the chain
+	if tracker.Lag() != 0 {
+		t.Fatalf("got finality lag %d, expected 0", tracker.Lag())
+	}
+}
+
+func TestFinalizedHeadsSubscription(t *testing.T) {
+	t.Parallel()
+	stub := newSubstrateStub(0)
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	tracker := evolution.NewFinalityTracker(substrate.NewClient(&http.Client{}, server.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	subscribed := make(chan error, 1)
+	go func() {
+		subscribed <- tracker.Subscribe(ctx)
+	}()
+
+	stub.newHeads <- 210
+	stub.finalizedHeads <- 200
+	waitFor(t, func() bool { return tracker.Lag() == 10 })
+
+	finalized, err := tracker.FinalizedBlock(context.Background(), 150)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if finalized != 200 {
+		t.Fatalf("got finalized block %d, expected 200", finalized)
+	}
+	if stub.getHTTPCalls() != 0 {
+		t.Fatalf("got %d requests, expected the finalized block to be known from the subscription", stub.getHTTPCalls())
+	}
+
+	cancel()
+	select {
+	case err := <-subscribed:
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("subscription did not stop after the context was canceled")
+	}
+}
+
+func TestFinalizedHeadsSubscriptionNotSupported(t *testing.T) {
+	t.Parallel()
+	stub := newSubstrateStub(0)
+	stub.noWebSocket = true
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	tracker := evolution.NewFinalityTracker(substrate.NewClient(&http.Client{}, server.URL))
+
+	if err := tracker.Subscribe(context.Background()); err == nil {
+		t.Fatalf("got no error while an error was expected")
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met before the deadline")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -2,18 +2,21 @@ package evolution
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"math/big"
-	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 
 	"github.com/freeverseio/laos-universal-node/internal/config"
 	shared "github.com/freeverseio/laos-universal-node/internal/core/processor"
 	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain"
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/substrate"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	"github.com/freeverseio/laos-universal-node/internal/platform/scan"
 	"github.com/freeverseio/laos-universal-node/internal/platform/state"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
 )
 
 type ReorgError struct {
@@ -34,32 +37,36 @@ type Processor interface {
 }
 
 type processor struct {
-	client       blockchain.EthClient
-	stateService state.Service
-	scanner      scan.Scanner
-	laosHTTP     LaosRPCRequests
-	waitingTime  time.Duration
+	client          blockchain.EthClient
+	substrateClient substrate.Client
+	stateService    state.Service
+	scanner         scan.Scanner
+	finality        FinalityTracker
 	shared.BlockHelper
 }
 
+// NewProcessor returns a processor that scans the evolution chain through client and records the Substrate blocks
+// fetched through substrateClient, whose headers carry the hashes of the EVM blocks
 func NewProcessor(client blockchain.EthClient,
+	substrateClient substrate.Client,
 	stateService state.Service,
 	scanner scan.Scanner,
-	laosHTTP LaosRPCRequests,
+	finality FinalityTracker,
 	c *config.Config,
 ) *processor {
 	return &processor{
-		client:       client,
-		stateService: stateService,
-		scanner:      scanner,
-		laosHTTP:     laosHTTP,
-		waitingTime:  c.WaitingRPCRequestTime,
+		client:          client,
+		substrateClient: substrateClient,
+		stateService:    stateService,
+		scanner:         scanner,
+		finality:        finality,
 		BlockHelper: shared.NewBlockHelper(
 			client,
 			stateService,
 			uint64(c.EvoBlocksRange),
 			uint64(c.EvoBlocksMargin),
 			c.EvoStartingBlock,
+			config.OwnershipFinalityMargin,
 		),
 	}
 }
@@ -68,7 +75,30 @@ func (p *processor) GetInitStartingBlock(ctx context.Context) (uint64, error) {
 	return p.GetEvoInitStartingBlock(ctx)
 }
 
+// GetLastBlock returns the last block of the range starting at startingBlock, which is never ahead of the finalized head
+// of the evolution chain. The finalized head is only requested when the range passes the known one, and if it is behind
+// startingBlock the returned block is lower than startingBlock.
+func (p *processor) GetLastBlock(ctx context.Context, startingBlock uint64) (uint64, error) {
+	lastBlock, err := p.BlockHelper.GetLastBlock(ctx, startingBlock)
+	if err != nil {
+		return 0, err
+	}
+
+	finalizedBlock, err := p.finality.FinalizedBlock(ctx, lastBlock)
+	if err != nil {
+		slog.Error("error occurred while checking latest finalized block", "err", err.Error())
+		return 0, err
+	}
+	if lastBlock > finalizedBlock {
+		slog.Debug("last block not finalized, scanning up to the finalized block", "lastBlock", lastBlock, "finalizedBlock", finalizedBlock)
+		return finalizedBlock, nil
+	}
+	return lastBlock, nil
+}
+
 func (p *processor) VerifyChainConsistency(ctx context.Context, startingBlock uint64) error {
+	// headers cached in the previous iteration might have been reorged
+	blockchain.ResetHeaders(p.client)
 	tx, err := p.stateService.NewTransaction()
 	if err != nil {
 		slog.Debug("error occurred while creating new transaction", "err", err.Error())
@@ -90,21 +120,60 @@ func (p *processor) VerifyChainConsistency(ctx context.Context, startingBlock ui
 	// Verify whether the hash of the last block from the previous iteration remains unchanged;
 	// if it differs, it indicates a reorganization has taken place.
 	previousLastBlock := startingBlock - 1
+	previousLastBlockDB, err := tx.GetEvoBlock(previousLastBlock)
+	if err != nil {
+		slog.Error("error occurred retrieving evo block from storage", "blockNumber", previousLastBlock, "err", err.Error())
+		return err
+	}
+	// When the previous block is stored, the parent hash of the next range is checked against it
+	// while the range is processed, so there is no need to request it.
+	if previousLastBlockDB.Hash == lastBlockDB.Hash {
+		return nil
+	}
 	slog.Debug("verifying chain consistency on block number", "previousLastBlock", previousLastBlock)
-	previousLastBlockData, err := p.client.BlockByNumber(ctx, big.NewInt(int64(previousLastBlock)))
+	previousLastBlockHash, err := p.substrateClient.BlockHash(ctx, previousLastBlock)
 	if err != nil {
 		slog.Error("error occurred while retrieving new start range block", "err", err.Error())
 		return err
 	}
+	if previousLastBlockHash == lastBlockDB.Hash {
+		return nil
+	}
 
-	if previousLastBlockData.Hash().Cmp(lastBlockDB.Hash) != 0 {
-		return ReorgError{Block: previousLastBlock, ChainHash: previousLastBlockData.Hash(), StorageHash: lastBlockDB.Hash}
+	// databases written before Substrate hashes were recorded store the hash of the EVM block
+	previousLastBlockData, err := p.client.BlockByNumber(ctx, big.NewInt(int64(previousLastBlock)))
+	if err != nil {
+		slog.Error("error occurred while retrieving new start range block", "err", err.Error())
+		return err
+	}
+	if previousLastBlockData.Hash() != lastBlockDB.Hash {
+		return ReorgError{Block: previousLastBlock, ChainHash: previousLastBlockHash, StorageHash: lastBlockDB.Hash}
 	}
 
 	return nil
 }
 
+// ProcessEvoBlockRange stores the minted events and the blocks of the range in a single transaction. If the range does not fit
+// in a transaction, it is split in halves that are processed and committed one after the other, so that the last evo block
+// always points to the last committed half. A crash or an error in between leaves the first half committed, and the range is resumed after it.
 func (p *processor) ProcessEvoBlockRange(ctx context.Context, startingBlock, lastBlock uint64) error {
+	err := p.processEvoBlockRangeInTx(ctx, startingBlock, lastBlock)
+	if !errors.Is(err, storage.ErrTxnTooBig) {
+		return err
+	}
+	if startingBlock == lastBlock {
+		return fmt.Errorf("evo block %d does not fit in a single transaction: %w", startingBlock, err)
+	}
+	middleBlock := startingBlock + (lastBlock-startingBlock)/2
+	slog.Warn("evo block range does not fit in a single transaction, splitting it",
+		"startingBlock", startingBlock, "middleBlock", middleBlock, "lastBlock", lastBlock)
+	if err := p.ProcessEvoBlockRange(ctx, startingBlock, middleBlock); err != nil {
+		return err
+	}
+	return p.ProcessEvoBlockRange(ctx, middleBlock+1, lastBlock)
+}
+
+func (p *processor) processEvoBlockRangeInTx(ctx context.Context, startingBlock, lastBlock uint64) error {
 	tx, err := p.stateService.NewTransaction()
 	if err != nil {
 		slog.Debug("error occurred while creating new transaction", "err", err.Error())
@@ -112,21 +181,6 @@ func (p *processor) ProcessEvoBlockRange(ctx context.Context, startingBlock, las
 	}
 	defer tx.Discard()
 
-	for {
-		var ok bool
-		ok, errFinalize := p.hasBlockFinalize(big.NewInt(int64(lastBlock)))
-		if errFinalize != nil {
-			slog.Error("error occurred while checking latest finalized block", "err", errFinalize.Error())
-			return errFinalize
-		}
-		if ok {
-			break
-		}
-		slog.Debug("block not finalized, waiting for finality", "block", lastBlock)
-
-		p.waitBeforeNextRequest(ctx)
-	}
-
 	events, err := p.scanner.ScanEvents(ctx, big.NewInt(int64(startingBlock)), big.NewInt(int64(lastBlock)), nil)
 	if err != nil {
 		slog.Error("error occurred while scanning LaosEvolution events", "err", err.Error())
@@ -139,12 +193,17 @@ func (p *processor) ProcessEvoBlockRange(ctx context.Context, startingBlock, las
 		return err
 	}
 
-	err = p.updateFirstBlockData(ctx, tx, startingBlock)
+	blocks, err := p.storeEvoBlocks(ctx, tx, startingBlock, lastBlock)
+	if err != nil {
+		return err
+	}
+
+	err = updateFirstBlockData(tx, blocks[0])
 	if err != nil {
 		return err
 	}
 
-	err = p.updateLastBlockData(ctx, tx, lastBlock)
+	err = updateLastBlockData(tx, blocks[len(blocks)-1])
 	if err != nil {
 		return err
 	}
@@ -157,7 +216,70 @@ func (p *processor) ProcessEvoBlockRange(ctx context.Context, startingBlock, las
 	return nil
 }
 
-func (p *processor) updateFirstBlockData(ctx context.Context, tx state.Tx, firstBlock uint64) error {
+// storeEvoBlocks stores the number, Substrate hash and timestamp of every block in the range, so that neither the universal processor
+// nor the block mapper have to request them again. Before storing them, it verifies that the Substrate headers of the range are chained
+// to each other and to the last stored block, which is checked locally instead of requesting the previous block again,
+// and that the EVM blocks the events were scanned from are the ones recorded in the Substrate headers.
+func (p *processor) storeEvoBlocks(ctx context.Context, tx state.Tx, startingBlock, lastBlock uint64) ([]model.Block, error) {
+	headers, err := blockchain.HeadersByNumber(ctx, p.client, startingBlock, lastBlock)
+	if err != nil {
+		slog.Error("error occurred while fetching LaosEvolution block headers",
+			"startingBlock", startingBlock, "lastBlock", lastBlock, "err", err.Error())
+		return nil, err
+	}
+	substrateHeaders, err := p.substrateClient.HeadersByNumber(ctx, startingBlock, lastBlock)
+	if err != nil {
+		slog.Error("error occurred while fetching LaosEvolution Substrate headers",
+			"startingBlock", startingBlock, "lastBlock", lastBlock, "err", err.Error())
+		return nil, err
+	}
+
+	var parentHash common.Hash
+	if startingBlock > 0 {
+		parent, err := tx.GetEvoBlock(startingBlock - 1)
+		if err != nil {
+			slog.Error("error occurred retrieving evo block from storage", "blockNumber", startingBlock-1, "err", err.Error())
+			return nil, err
+		}
+		parentHash = parent.Hash
+	}
+
+	blocks := make([]model.Block, 0, lastBlock-startingBlock+1)
+	for blockNumber := startingBlock; blockNumber <= lastBlock; blockNumber++ {
+		header, ok := headers[blockNumber]
+		if !ok || header == nil {
+			return nil, fmt.Errorf("header of LaosEvolution block %d not found", blockNumber)
+		}
+		substrateHeader, ok := substrateHeaders[blockNumber]
+		if !ok || substrateHeader == nil {
+			return nil, fmt.Errorf("substrate header of LaosEvolution block %d not found", blockNumber)
+		}
+		if ethereumHash, ok := substrateHeader.EthereumBlockHash(); ok && ethereumHash != header.Hash() {
+			return nil, fmt.Errorf("hash %s of LaosEvolution EVM block %d does not match the hash %s recorded in its Substrate header",
+				header.Hash(), blockNumber, ethereumHash)
+		}
+		// the parent of the first block of the range is unknown if it was processed before evo blocks were stored,
+		// and it is the hash of the EVM block if it was stored before Substrate hashes were recorded
+		legacyParent := blockNumber == startingBlock && header.ParentHash == parentHash
+		if (parentHash != common.Hash{}) && substrateHeader.ParentHash != parentHash && !legacyParent {
+			return nil, ReorgError{Block: blockNumber - 1, ChainHash: substrateHeader.ParentHash, StorageHash: parentHash}
+		}
+		block := model.Block{
+			Number:    blockNumber,
+			Timestamp: header.Time,
+			Hash:      substrateHeader.Hash(),
+		}
+		if err := tx.SetEvoBlock(block); err != nil {
+			slog.Error("error occurred setting evo block to storage", "blockNumber", blockNumber, "err", err.Error())
+			return nil, err
+		}
+		blocks = append(blocks, block)
+		parentHash = block.Hash
+	}
+	return blocks, nil
+}
+
+func updateFirstBlockData(tx state.Tx, firstBlock model.Block) error {
 	defaultBlock := model.Block{}
 	firstBlockStorage, err := tx.GetFirstEvoBlock()
 	if err != nil {
@@ -165,54 +287,35 @@ func (p *processor) updateFirstBlockData(ctx context.Context, tx state.Tx, first
 		return err
 	}
 	if firstBlockStorage == defaultBlock {
-		firstBlockData, err := p.client.BlockByNumber(ctx, big.NewInt(int64(firstBlock)))
-		if err != nil {
-			slog.Error("error occurred while fetching LaosEvolution block", "firstBlock", firstBlock, "err", err.Error())
-			return err
-		}
 		slog.Debug("setting evo first block data for block number",
-			"blockNumber", firstBlockData.Number(), "blockHash", firstBlockData.Hash(), "timestamp", firstBlockData.Header().Time)
-		block := model.Block{
-			Number:    firstBlock,
-			Timestamp: firstBlockData.Header().Time,
-			Hash:      firstBlockData.Hash(),
-		}
-		err = tx.SetFirstEvoBlock(block)
+			"blockNumber", firstBlock.Number, "blockHash", firstBlock.Hash, "timestamp", firstBlock.Timestamp)
+		err = tx.SetFirstEvoBlock(firstBlock)
 		if err != nil {
-			slog.Error("error occurred setting first evo block to storage", "firstBlock", firstBlock, "err", err.Error())
+			slog.Error("error occurred setting first evo block to storage", "firstBlock", firstBlock.Number, "err", err.Error())
 			return err
 		}
 	}
 	return nil
 }
 
-func (p *processor) updateLastBlockData(ctx context.Context, tx state.Tx, lastBlock uint64) error {
-	lastBlockData, err := p.client.BlockByNumber(ctx, big.NewInt(int64(lastBlock)))
-	if err != nil {
-		slog.Error("error occurred while fetching LaosEvolution end range block", "lastBlock", lastBlock, "err", err.Error())
-		return err
-	}
-
+func updateLastBlockData(tx state.Tx, lastBlock model.Block) error {
 	slog.Debug("setting evo end range block data for block number",
-		"blockNumber", lastBlockData.Number(), "blockHash", lastBlockData.Hash(), "timestamp", lastBlockData.Header().Time)
-
-	block := model.Block{
-		Number:    lastBlock,
-		Timestamp: lastBlockData.Header().Time,
-		Hash:      lastBlockData.Hash(),
-	}
+		"blockNumber", lastBlock.Number, "blockHash", lastBlock.Hash, "timestamp", lastBlock.Timestamp)
 
-	err = tx.SetLastEvoBlock(block)
+	err := tx.SetLastEvoBlock(lastBlock)
 	if err != nil {
 		slog.Error("error occurred while setting lastEvoBlock to storage",
-			"lastBlock", lastBlock, "err", err.Error())
+			"lastBlock", lastBlock.Number, "err", err.Error())
 		return err
 	}
 
 	return nil
 }
 
+// storeMintedWithExternalURIEventsByContract stores the minted events of a block range, which are sorted by block,
+// and chains every block with events of a contract to the previous one so that the universal processor can walk them in order
 func storeMintedWithExternalURIEventsByContract(tx state.Tx, events []scan.Event) error {
+	lastChainedBlocks := make(map[string]uint64)
 	for _, event := range events {
 		e, ok := event.(scan.EventMintedWithExternalURI)
 		if ok {
@@ -230,39 +333,16 @@ func storeMintedWithExternalURIEventsByContract(tx state.Tx, events []scan.Event
 				return err
 			}
 
+			// a block with several events of the same contract is chained once, otherwise it would point to itself
+			if lastChainedBlock, ok := lastChainedBlocks[e.Contract.String()]; ok && lastChainedBlock == e.BlockNumber {
+				continue
+			}
 			if err := tx.SetNextEvoEventBlock(e.Contract.String(), e.BlockNumber); err != nil {
 				return err
 			}
+			lastChainedBlocks[e.Contract.String()] = e.BlockNumber
 		}
 	}
 
 	return nil
 }
-
-func (p *processor) hasBlockFinalize(blockNumber *big.Int) (bool, error) {
-	blockHash, err := p.laosHTTP.LatestFinalizedBlockHash()
-	if err != nil {
-		return false, err
-	}
-
-	finalizedBlockNumber, err := p.laosHTTP.BlockNumber(blockHash)
-	if err != nil {
-		return false, err
-	}
-
-	// if blockNumber > finalizedBlockNumber
-	if blockNumber.Cmp(finalizedBlockNumber) == 1 {
-		return false, nil
-	}
-
-	return true, nil
-}
-
-func (p *processor) waitBeforeNextRequest(ctx context.Context) {
-	timer := time.NewTimer(p.waitingTime)
-	select {
-	case <-ctx.Done():
-		timer.Stop()
-	case <-timer.C:
-	}
-}
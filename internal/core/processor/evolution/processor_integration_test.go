@@ -7,7 +7,6 @@ import (
 
 	"github.com/dgraph-io/badger/v4"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/freeverseio/laos-universal-node/internal/config"
 	"github.com/freeverseio/laos-universal-node/internal/core/processor/evolution"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
@@ -20,47 +19,24 @@ import (
 func TestProcessEvoBlockRangeWithBadger(t *testing.T) {
 	t.Run("obtained one event, event processed and last block updated successfully with badger", func(t *testing.T) {
 		ctx := context.TODO()
-		_, _, client, scanner, laosRpc := createMocks(t)
+		_, _, client, scanner, substrateClient := createMocks(t)
 
 		db := createBadger(t)
 		badgerService := badgerStorage.NewService(db)
 		stateService := v1.NewStateService(badgerService)
 
-		lastBlockData := model.Block{
-			Number:    120,
-			Hash:      common.HexToHash("0x7ea18f6be7115ddbb51aa052f2780a1501847f4b3a444f1a6066982b7dbab6fc"),
-			Timestamp: 150,
-		}
-		startingBlock := uint64(100)
-		startingBlockData := model.Block{
-			Number:    100,
-			Hash:      common.HexToHash("0xb72b31eb84c4bbbbd62aff06a3c8c88991ac7c118c47aa6fba3609ed1baa8fd3"),
-			Timestamp: 110,
-		}
+		startingBlock, lastBlock := uint64(100), uint64(120)
+		headers := createChainedHeaders(startingBlock, lastBlock, common.Hash{})
+		lastBlockData := headers.blockOf(lastBlock)
 		contract := common.HexToAddress("0x555")
 		event, _ := createEventMintedWithExternalURI(lastBlockData.Number, contract)
 
-		laosRpc.EXPECT().LatestFinalizedBlockHash().Return(latestFinalizedBlockHash, nil).Times(1)
-		laosRpc.EXPECT().BlockNumber(latestFinalizedBlockHash).Return(big.NewInt(125), nil).Times(1)
-
 		scanner.EXPECT().
 			ScanEvents(ctx, big.NewInt(int64(startingBlock)), big.NewInt(int64(lastBlockData.Number)), nil).
 			Return([]scan.Event{event}, nil)
+		expectHeaders(client, substrateClient, headers)
 
-		client.EXPECT().
-			BlockByNumber(ctx, big.NewInt(int64(lastBlockData.Number))).
-			Return(types.NewBlockWithHeader(&types.Header{
-				Time:   lastBlockData.Timestamp,
-				Number: big.NewInt(int64(lastBlockData.Number)),
-			}), nil)
-		client.EXPECT().
-			BlockByNumber(ctx, big.NewInt(int64(startingBlockData.Number))).
-			Return(types.NewBlockWithHeader(&types.Header{
-				Time:   startingBlockData.Timestamp,
-				Number: big.NewInt(int64(startingBlockData.Number)),
-			}), nil)
-
-		p := evolution.NewProcessor(client, stateService, scanner, laosRpc, &config.Config{})
+		p := evolution.NewProcessor(client, substrateClient, stateService, scanner, evolution.NewFinalityTracker(substrateClient), &config.Config{})
 		err := p.ProcessEvoBlockRange(ctx, startingBlock, lastBlockData.Number)
 		assertError(t, nil, err)
 
@@ -75,13 +51,18 @@ func TestProcessEvoBlockRangeWithBadger(t *testing.T) {
 		if events[0].BlockNumber != lastBlockData.Number {
 			t.Fatalf("expected block number %d, got %d", lastBlockData.Number, events[0].BlockNumber)
 		}
+		storedLastBlock, err := tx.GetLastEvoBlock()
+		assertError(t, nil, err)
+		if storedLastBlock != lastBlockData {
+			t.Fatalf("expected last evo block %v, got %v", lastBlockData, storedLastBlock)
+		}
 	})
 }
 
 func TestProcessEvoBlockRangeWithBadger100Events(t *testing.T) {
 	t.Run("obtained 100 events, each event processed and last block updated successfully with badger", func(t *testing.T) {
 		ctx := context.TODO()
-		_, _, client, scanner, laosRpc := createMocks(t)
+		_, _, client, scanner, substrateClient := createMocks(t)
 
 		db := createBadger(t)
 		badgerService := badgerStorage.NewService(db)
@@ -102,21 +83,13 @@ func TestProcessEvoBlockRangeWithBadger100Events(t *testing.T) {
 			events[i] = event
 		}
 
-		laosRpc.EXPECT().LatestFinalizedBlockHash().Return(latestFinalizedBlockHash, nil).AnyTimes()
-		laosRpc.EXPECT().BlockNumber(latestFinalizedBlockHash).Return(big.NewInt(125), nil).AnyTimes()
-
 		scanner.EXPECT().
 			ScanEvents(ctx, big.NewInt(int64(startingBlock)), big.NewInt(int64(lastBlockData.Number)), nil).
 			Return(events, nil).AnyTimes()
 
-		client.EXPECT().
-			BlockByNumber(ctx, gomock.Any()).
-			Return(types.NewBlockWithHeader(&types.Header{
-				Time:   lastBlockData.Timestamp,
-				Number: big.NewInt(int64(lastBlockData.Number)),
-			}), nil).AnyTimes()
+		expectHeaders(client, substrateClient, createChainedHeaders(startingBlock, lastBlockData.Number, common.Hash{}))
 
-		p := evolution.NewProcessor(client, stateService, scanner, laosRpc, &config.Config{})
+		p := evolution.NewProcessor(client, substrateClient, stateService, scanner, evolution.NewFinalityTracker(substrateClient), &config.Config{})
 
 		err := p.ProcessEvoBlockRange(ctx, startingBlock, lastBlockData.Number)
 		assertError(t, nil, err)
@@ -131,6 +104,83 @@ func TestProcessEvoBlockRangeWithBadger100Events(t *testing.T) {
 	})
 }
 
+func TestProcessEvoBlockRangeWithBadgerChainsBlocksWithEvents(t *testing.T) {
+	ctx := context.TODO()
+	_, _, client, scanner, substrateClient := createMocks(t)
+	stateService := v1.NewStateService(badgerStorage.NewService(createBadger(t)))
+
+	startingBlock, lastBlock := uint64(100), uint64(200)
+	contract := common.HexToAddress("0x555")
+	var events []scan.Event
+	for i, blockNumber := range []uint64{105, 105, 110, 150, 150, 150, 199} {
+		event, _ := createEventMintedWithExternalURIWithIndex(blockNumber, contract, uint64(i))
+		events = append(events, event)
+	}
+
+	scanner.EXPECT().
+		ScanEvents(ctx, big.NewInt(int64(startingBlock)), big.NewInt(int64(lastBlock)), nil).
+		Return(events, nil)
+	headers := createChainedHeaders(startingBlock, lastBlock, common.Hash{})
+	expectHeaders(client, substrateClient, headers)
+
+	p := evolution.NewProcessor(client, substrateClient, stateService, scanner, evolution.NewFinalityTracker(substrateClient), &config.Config{})
+	err := p.ProcessEvoBlockRange(ctx, startingBlock, lastBlock)
+	assertError(t, nil, err)
+
+	tx, err := stateService.NewTransaction()
+	assertError(t, nil, err)
+	defer tx.Discard()
+	// every block with events points to the next one, and the last one to none
+	expectedNextBlocks := map[uint64]uint64{0: 105, 105: 110, 110: 150, 150: 199, 199: 0}
+	for blockNumber, expectedNextBlock := range expectedNextBlocks {
+		nextBlock, err := tx.GetNextEvoEventBlock(contract.Hex(), blockNumber)
+		assertError(t, nil, err)
+		if nextBlock != expectedNextBlock {
+			t.Fatalf("got next evo event block %d after block %d, expected %d", nextBlock, blockNumber, expectedNextBlock)
+		}
+	}
+	events150, err := tx.GetMintedWithExternalURIEvents(contract.Hex(), 150)
+	assertError(t, nil, err)
+	if len(events150) != 3 {
+		t.Fatalf("got %d events in block 150, expected 3", len(events150))
+	}
+	for blockNumber := startingBlock; blockNumber <= lastBlock; blockNumber++ {
+		block, err := tx.GetEvoBlock(blockNumber)
+		assertError(t, nil, err)
+		if block != headers.blockOf(blockNumber) {
+			t.Fatalf("got evo block %v, expected %v", block, headers.blockOf(blockNumber))
+		}
+	}
+}
+
+func TestProcessEvoBlockRangeWithBadgerVerifiesHashChain(t *testing.T) {
+	ctx := context.TODO()
+	_, _, client, scanner, substrateClient := createMocks(t)
+	stateService := v1.NewStateService(badgerStorage.NewService(createBadger(t)))
+
+	headers := createChainedHeaders(100, 110, common.Hash{})
+	// block 111 is not a child of the stored block 110
+	reorged := createChainedHeaders(111, 120, common.HexToHash("0x110"))
+	for blockNumber := uint64(111); blockNumber <= 120; blockNumber++ {
+		headers.evm[blockNumber] = reorged.evm[blockNumber]
+		headers.substrate[blockNumber] = reorged.substrate[blockNumber]
+	}
+	expectHeaders(client, substrateClient, headers)
+	scanner.EXPECT().ScanEvents(ctx, gomock.Any(), gomock.Any(), nil).Return(nil, nil).AnyTimes()
+
+	p := evolution.NewProcessor(client, substrateClient, stateService, scanner, evolution.NewFinalityTracker(substrateClient), &config.Config{})
+	err := p.ProcessEvoBlockRange(ctx, 100, 110)
+	assertError(t, nil, err)
+	err = p.VerifyChainConsistency(ctx, 111)
+	assertError(t, nil, err)
+
+	err = p.ProcessEvoBlockRange(ctx, 111, 120)
+	expectedErr := evolution.ReorgError{Block: 110, ChainHash: common.HexToHash("0x110"), StorageHash: headers.substrate[110].Hash()}
+	if err != expectedErr {
+		t.Fatalf(`got error "%v", expected %v`, err, expectedErr)
+	}
+}
+
 func createBadger(t *testing.T) *badger.DB {
 	t.Helper()
 	db, err := badger.Open(
@@ -3,24 +3,28 @@ package evolution_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"go.uber.org/mock/gomock"
 
 	"github.com/freeverseio/laos-universal-node/internal/config"
 	"github.com/freeverseio/laos-universal-node/internal/core/processor/evolution"
-	mockRPCRequests "github.com/freeverseio/laos-universal-node/internal/core/processor/evolution/mock"
 	mockClient "github.com/freeverseio/laos-universal-node/internal/platform/blockchain/mock"
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/substrate"
+	mockSubstrate "github.com/freeverseio/laos-universal-node/internal/platform/blockchain/substrate/mock"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	"github.com/freeverseio/laos-universal-node/internal/platform/scan"
 	mockScan "github.com/freeverseio/laos-universal-node/internal/platform/scan/mock"
 	mockTx "github.com/freeverseio/laos-universal-node/internal/platform/state/mock"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
 )
 
-const latestFinalizedBlockHash = "0x95207a95aaf6c516017758f2fd4b7e173fb5a3fb56d3b0cdc0044cd0a9553f38"
+var latestFinalizedBlockHash = common.HexToHash("0x95207a95aaf6c516017758f2fd4b7e173fb5a3fb56d3b0cdc0044cd0a9553f38")
 
 func TestGetInitStartingBlock(t *testing.T) {
 	t.Parallel()
@@ -89,7 +93,7 @@ func TestGetInitStartingBlock(t *testing.T) {
 			t.Parallel()
 
 			ctx := context.TODO()
-			stateService, tx, client, _, laosRpc := createMocks(t)
+			stateService, tx, client, _, substrateClient := createMocks(t)
 
 			stateService.EXPECT().NewTransaction().Return(tx, nil)
 			tx.EXPECT().GetLastEvoBlock().Return(tt.startingBlockData, tt.startingBlockError)
@@ -98,7 +102,7 @@ func TestGetInitStartingBlock(t *testing.T) {
 				client.EXPECT().BlockNumber(ctx).Return(tt.lastBlockNumberFromClient, tt.lastBlockNumberFromClientError)
 			}
 
-			p := evolution.NewProcessor(client, stateService, nil, laosRpc, &config.Config{EvoStartingBlock: tt.userProvidedBlock})
+			p := evolution.NewProcessor(client, substrateClient, stateService, nil, evolution.NewFinalityTracker(substrateClient), &config.Config{EvoStartingBlock: tt.userProvidedBlock})
 			result, err := p.GetInitStartingBlock(ctx)
 			assertError(t, tt.expectedError, err)
 			if result != tt.expectedResult {
@@ -114,6 +118,9 @@ func TestGetLastBlock(t *testing.T) {
 		name               string
 		startingBlock      uint64
 		l1LatestBlock      uint64
+		l1LatestBlockError error
+		finalizedBlock     uint64
+		finalizedError     error
 		configBlocksRange  uint64
 		configBlocksMargin uint64
 		expectedResult     uint64
@@ -123,6 +130,7 @@ func TestGetLastBlock(t *testing.T) {
 			name:               "Starting block within range",
 			startingBlock:      100,
 			l1LatestBlock:      200,
+			finalizedBlock:     200,
 			configBlocksRange:  10,
 			configBlocksMargin: 5,
 			expectedResult:     110,
@@ -132,20 +140,52 @@ func TestGetLastBlock(t *testing.T) {
 			name:               "Starting block exceeds range",
 			startingBlock:      195,
 			l1LatestBlock:      200,
+			finalizedBlock:     200,
 			configBlocksRange:  10,
 			configBlocksMargin: 5,
 			expectedResult:     195,
 			expectedError:      nil,
 		},
+		{
+			name:               "Range is limited to the finalized block",
+			startingBlock:      100,
+			l1LatestBlock:      200,
+			finalizedBlock:     104,
+			configBlocksRange:  10,
+			configBlocksMargin: 5,
+			expectedResult:     104,
+			expectedError:      nil,
+		},
+		{
+			name:               "Finalized block behind starting block",
+			startingBlock:      100,
+			l1LatestBlock:      200,
+			finalizedBlock:     98,
+			configBlocksRange:  10,
+			configBlocksMargin: 5,
+			expectedResult:     98,
+			expectedError:      nil,
+		},
 		{
 			name:               "Error getting latest block",
 			startingBlock:      100,
 			l1LatestBlock:      0,
+			l1LatestBlockError: errors.New("error getting latest block"),
 			configBlocksRange:  10,
 			configBlocksMargin: 5,
 			expectedResult:     0,
 			expectedError:      errors.New("error getting latest block"),
 		},
+		{
+			name:               "Error when request to parachain fails",
+			startingBlock:      100,
+			l1LatestBlock:      200,
+			finalizedError:     errors.New("error fetching finalized header"),
+			configBlocksRange:  10,
+			configBlocksMargin: 5,
+			expectedResult:     0,
+			expectedError:      errors.New("error fetching finalized header"),
+		},
 	}
 
 	for _, tt := range testCases {
@@ -154,11 +194,16 @@ func TestGetLastBlock(t *testing.T) {
 			t.Parallel()
 
 			ctx := context.TODO()
-			_, _, client, _, laosRpc := createMocks(t)
+			_, _, client, _, substrateClient := createMocks(t)
 
-			client.EXPECT().BlockNumber(ctx).Return(tt.l1LatestBlock, tt.expectedError)
+			client.EXPECT().BlockNumber(ctx).Return(tt.l1LatestBlock, tt.l1LatestBlockError)
+			if tt.l1LatestBlockError == nil {
+				substrateClient.EXPECT().FinalizedHead(gomock.Any()).Return(latestFinalizedBlockHash, nil).Times(1)
+				substrateClient.EXPECT().Header(gomock.Any(), latestFinalizedBlockHash).
+					Return(&substrate.Header{Number: tt.finalizedBlock}, tt.finalizedError).Times(1)
+			}
 
-			p := evolution.NewProcessor(client, nil, nil, laosRpc, &config.Config{EvoBlocksMargin: uint(tt.configBlocksMargin), EvoBlocksRange: uint(tt.configBlocksRange)})
+			p := evolution.NewProcessor(client, substrateClient, nil, nil, evolution.NewFinalityTracker(substrateClient), &config.Config{EvoBlocksMargin: uint(tt.configBlocksMargin), EvoBlocksRange: uint(tt.configBlocksRange)})
 			result, err := p.GetLastBlock(ctx, tt.startingBlock)
 			assertError(t, tt.expectedError, err)
 			if result != tt.expectedResult {
@@ -170,11 +215,15 @@ func TestGetLastBlock(t *testing.T) {
 
 func TestVerifyChainConsistency(t *testing.T) {
 	t.Parallel()
+	evmBlock := types.NewBlockWithHeader(&types.Header{ParentHash: common.HexToHash("0x123")})
 	tests := []struct {
 		name                   string
 		startingBlock          uint64
 		lastBlockDB            model.Block
 		lastBlockDBError       error
+		previousBlockDB        model.Block
+		previousBlockHash      common.Hash
+		previousBlockHashError error
 		previousBlockData      *types.Block
 		previousBlockDataError error
 		expectedError          error
@@ -192,36 +241,58 @@ func TestVerifyChainConsistency(t *testing.T) {
 			lastBlockDBError: errors.New("error from storage"),
 			expectedError:    errors.New("error from storage"),
 		},
-
 		{
 			name:              "Previous block hash matches",
 			startingBlock:     100,
-			lastBlockDB:       model.Block{Hash: common.HexToHash("0x558af54aec2a3b01640511cfc1d2b5772373b7b73ff621225031de3cae9a2c3e")},
+			lastBlockDB:       model.Block{Hash: common.HexToHash("0x456")},
 			lastBlockDBError:  nil,
-			previousBlockData: types.NewBlockWithHeader(&types.Header{ParentHash: common.HexToHash("0x123")}),
+			previousBlockHash: common.HexToHash("0x456"),
 			expectedError:     nil,
 		},
-
 		{
-			name:                   "error when trying to obtain previous block from chain",
+			name:             "Previous block stored locally",
+			startingBlock:    100,
+			lastBlockDB:      model.Block{Number: 99, Hash: common.HexToHash("0x123")},
+			lastBlockDBError: nil,
+			previousBlockDB:  model.Block{Number: 99, Hash: common.HexToHash("0x123")},
+			expectedError:    nil,
+		},
+		{
+			name:                   "error when trying to obtain previous block hash from chain",
 			startingBlock:          100,
-			lastBlockDB:            model.Block{Hash: common.HexToHash("0x558af54aec2a3b01640511cfc1d2b5772373b7b73ff621225031de3cae9a2c3e")},
+			lastBlockDB:            model.Block{Hash: common.HexToHash("0x456")},
 			lastBlockDBError:       nil,
-			previousBlockData:      nil,
-			previousBlockDataError: errors.New("error retrieving previous block from chain"),
-			expectedError:          errors.New("error retrieving previous block from chain"),
+			previousBlockHashError: errors.New("error retrieving previous block hash from chain"),
+			expectedError:          errors.New("error retrieving previous block hash from chain"),
+		},
+		{
+			name:              "Previous EVM block hash stored before Substrate hashes were recorded matches",
+			startingBlock:     100,
+			lastBlockDB:       model.Block{Hash: evmBlock.Hash()},
+			lastBlockDBError:  nil,
+			previousBlockHash: common.HexToHash("0x456"),
+			previousBlockData: evmBlock,
+			expectedError:     nil,
 		},
-
 		{
-			name:                   "Previous block hash does not match",
+			name:                   "error when trying to obtain previous block from chain",
 			startingBlock:          100,
 			lastBlockDB:            model.Block{Hash: common.HexToHash("0x123")},
 			lastBlockDBError:       nil,
-			previousBlockData:      types.NewBlockWithHeader(&types.Header{ParentHash: common.HexToHash("0x123")}),
-			previousBlockDataError: nil,
+			previousBlockHash:      common.HexToHash("0x456"),
+			previousBlockDataError: errors.New("error retrieving previous block from chain"),
+			expectedError:          errors.New("error retrieving previous block from chain"),
+		},
+		{
+			name:              "Previous block hash does not match",
+			startingBlock:     100,
+			lastBlockDB:       model.Block{Hash: common.HexToHash("0x123")},
+			lastBlockDBError:  nil,
+			previousBlockHash: common.HexToHash("0x456"),
+			previousBlockData: evmBlock,
 			expectedError: evolution.ReorgError{
 				Block:       99,
-				ChainHash:   common.HexToHash("0x558af54aec2a3b01640511cfc1d2b5772373b7b73ff621225031de3cae9a2c3e"),
+				ChainHash:   common.HexToHash("0x456"),
 				StorageHash: common.HexToHash("0x123"),
 			},
 		},
@@ -232,18 +303,24 @@ func TestVerifyChainConsistency(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 			ctx := context.TODO()
-			stateService, tx, client, _, laosRpc := createMocks(t)
+			stateService, tx, client, _, substrateClient := createMocks(t)
 
 			stateService.EXPECT().NewTransaction().Return(tx, nil)
 			tx.EXPECT().GetLastEvoBlock().Return(tt.lastBlockDB, tt.lastBlockDBError)
 			tx.EXPECT().Discard()
 
 			if tt.lastBlockDBError == nil && tt.lastBlockDB.Hash != (common.Hash{}) {
+				tx.EXPECT().GetEvoBlock(tt.startingBlock-1).Return(tt.previousBlockDB, nil)
+			}
+			if tt.lastBlockDBError == nil && tt.lastBlockDB.Hash != (common.Hash{}) && tt.previousBlockDB.Hash != tt.lastBlockDB.Hash {
+				substrateClient.EXPECT().BlockHash(ctx, tt.startingBlock-1).Return(tt.previousBlockHash, tt.previousBlockHashError)
+			}
+			if tt.previousBlockHashError == nil && tt.previousBlockHash != (common.Hash{}) && tt.previousBlockHash != tt.lastBlockDB.Hash {
 				client.EXPECT().BlockByNumber(ctx, big.NewInt(int64(tt.startingBlock-1))).
 					Return(tt.previousBlockData, tt.previousBlockDataError)
 			}
 
-			p := evolution.NewProcessor(client, stateService, nil, laosRpc, &config.Config{})
+			p := evolution.NewProcessor(client, substrateClient, stateService, nil, evolution.NewFinalityTracker(substrateClient), &config.Config{})
 			err := p.VerifyChainConsistency(ctx, tt.startingBlock)
 			assertError(t, tt.expectedError, err)
 		})
@@ -256,7 +333,7 @@ func TestProcessEvoBlockRange(t *testing.T) {
 	t.Run("error when scanning for events", func(t *testing.T) {
 		t.Parallel()
 		ctx := context.TODO()
-		stateService, tx, client, scanner, laosRpc := createMocks(t)
+		stateService, tx, client, scanner, substrateClient := createMocks(t)
 
 		stateService.EXPECT().NewTransaction().Return(tx, nil)
 		tx.EXPECT().Discard()
@@ -264,14 +341,11 @@ func TestProcessEvoBlockRange(t *testing.T) {
 		lastBlockData := model.Block{Number: 120, Hash: common.HexToHash("0x123"), Timestamp: 150}
 		startingBlock := uint64(100)
 
-		laosRpc.EXPECT().LatestFinalizedBlockHash().Return(latestFinalizedBlockHash, nil).Times(1)
-		laosRpc.EXPECT().BlockNumber(latestFinalizedBlockHash).Return(big.NewInt(125), nil).Times(1)
-
 		scanner.EXPECT().
 			ScanEvents(ctx, big.NewInt(int64(startingBlock)), big.NewInt(int64(lastBlockData.Number)), nil).
 			Return(make([]scan.Event, 0), errors.New("error scanning events"))
 
-		p := evolution.NewProcessor(client, stateService, scanner, laosRpc, &config.Config{})
+		p := evolution.NewProcessor(client, substrateClient, stateService, scanner, evolution.NewFinalityTracker(substrateClient), &config.Config{})
 		err := p.ProcessEvoBlockRange(ctx, startingBlock, lastBlockData.Number)
 		assertError(t, errors.New("error scanning events"), err)
 	})
@@ -279,7 +353,7 @@ func TestProcessEvoBlockRange(t *testing.T) {
 	t.Run("obtained one event, error on storing events in db", func(t *testing.T) {
 		t.Parallel()
 		ctx := context.TODO()
-		stateService, tx, client, scanner, laosRpc := createMocks(t)
+		stateService, tx, client, scanner, substrateClient := createMocks(t)
 
 		stateService.EXPECT().NewTransaction().Return(tx, nil)
 		tx.EXPECT().Discard()
@@ -289,9 +363,6 @@ func TestProcessEvoBlockRange(t *testing.T) {
 		contract := common.HexToAddress("0x555")
 		event, adjustedEvent := createEventMintedWithExternalURI(lastBlockData.Number, contract)
 
-		laosRpc.EXPECT().LatestFinalizedBlockHash().Return(latestFinalizedBlockHash, nil).Times(1)
-		laosRpc.EXPECT().BlockNumber(latestFinalizedBlockHash).Return(big.NewInt(125), nil).Times(1)
-
 		scanner.EXPECT().
 			ScanEvents(ctx, big.NewInt(int64(startingBlock)), big.NewInt(int64(lastBlockData.Number)), nil).
 			Return([]scan.Event{event}, nil)
@@ -300,185 +371,304 @@ func TestProcessEvoBlockRange(t *testing.T) {
 			StoreMintedWithExternalURIEvent(contract.String(), &adjustedEvent).
 			Return(errors.New("error storing events to db"))
 
-		p := evolution.NewProcessor(client, stateService, scanner, laosRpc, &config.Config{})
+		p := evolution.NewProcessor(client, substrateClient, stateService, scanner, evolution.NewFinalityTracker(substrateClient), &config.Config{})
 		err := p.ProcessEvoBlockRange(ctx, startingBlock, lastBlockData.Number)
 		assertError(t, errors.New("error storing events to db"), err)
 	})
 
-	t.Run("obtained one event, error when getting last block info", func(t *testing.T) {
+	t.Run("obtained one event, error when getting block headers", func(t *testing.T) {
 		t.Parallel()
 		ctx := context.TODO()
-		stateService, tx, client, scanner, laosRpc := createMocks(t)
+		stateService, tx, client, scanner, substrateClient := createMocks(t)
 
 		stateService.EXPECT().NewTransaction().Return(tx, nil)
 		tx.EXPECT().Discard()
 
-		lastBlockData := model.Block{Number: 120, Hash: common.HexToHash("0x123"), Timestamp: 150}
-		startingBlock := uint64(100)
-		startingBlockData := model.Block{
-			Number:    100,
-			Hash:      common.HexToHash("0xb72b31eb84c4bbbbd62aff06a3c8c88991ac7c118c47aa6fba3609ed1baa8fd3"),
-			Timestamp: 110,
-		}
+		startingBlock, lastBlock := uint64(100), uint64(120)
 		contract := common.HexToAddress("0x555")
-		event, adjustedEvent := createEventMintedWithExternalURI(lastBlockData.Number, contract)
-
-		laosRpc.EXPECT().LatestFinalizedBlockHash().Return(latestFinalizedBlockHash, nil).Times(1)
-		laosRpc.EXPECT().BlockNumber(latestFinalizedBlockHash).Return(big.NewInt(125), nil).Times(1)
+		event, adjustedEvent := createEventMintedWithExternalURI(lastBlock, contract)
 
 		scanner.EXPECT().
-			ScanEvents(ctx, big.NewInt(int64(startingBlock)), big.NewInt(int64(lastBlockData.Number)), nil).
+			ScanEvents(ctx, big.NewInt(int64(startingBlock)), big.NewInt(int64(lastBlock)), nil).
 			Return([]scan.Event{event}, nil)
-
 		tx.EXPECT().
 			StoreMintedWithExternalURIEvent(contract.String(), &adjustedEvent).
 			Return(nil)
-		tx.EXPECT().
-			GetFirstEvoBlock().Return(startingBlockData, nil)
-
-		tx.EXPECT().SetNextEvoEventBlock(contract.String(), lastBlockData.Number)
+		tx.EXPECT().SetNextEvoEventBlock(contract.String(), lastBlock)
 
 		client.EXPECT().
-			BlockByNumber(ctx, big.NewInt(int64(lastBlockData.Number))).
-			Return(nil, errors.New("error getting last block info"))
+			HeaderByNumber(ctx, gomock.Any()).
+			Return(nil, errors.New("error getting block headers")).
+			AnyTimes()
 
-		p := evolution.NewProcessor(client, stateService, scanner, laosRpc, &config.Config{})
-		err := p.ProcessEvoBlockRange(ctx, startingBlock, lastBlockData.Number)
-		assertError(t, errors.New("error getting last block info"), err)
+		p := evolution.NewProcessor(client, substrateClient, stateService, scanner, evolution.NewFinalityTracker(substrateClient), &config.Config{})
+		err := p.ProcessEvoBlockRange(ctx, startingBlock, lastBlock)
+		assertError(t, errors.New("error getting block headers"), err)
 	})
 
-	t.Run("obtained one event, error when storing last block info", func(t *testing.T) {
+	t.Run("range not chained to the last stored block", func(t *testing.T) {
 		t.Parallel()
 		ctx := context.TODO()
-		stateService, tx, client, scanner, laosRpc := createMocks(t)
+		stateService, tx, client, scanner, substrateClient := createMocks(t)
 
 		stateService.EXPECT().NewTransaction().Return(tx, nil)
 		tx.EXPECT().Discard()
 
-		lastBlockData := model.Block{
-			Number:    120,
-			Hash:      common.HexToHash("0x7ea18f6be7115ddbb51aa052f2780a1501847f4b3a444f1a6066982b7dbab6fc"),
-			Timestamp: 150,
-		}
-		startingBlock := uint64(100)
-		startingBlockData := model.Block{
-			Number:    100,
-			Hash:      common.HexToHash("0xb72b31eb84c4bbbbd62aff06a3c8c88991ac7c118c47aa6fba3609ed1baa8fd3"),
-			Timestamp: 110,
+		startingBlock, lastBlock := uint64(100), uint64(120)
+		headers := createChainedHeaders(startingBlock, lastBlock, common.HexToHash("0x98"))
+		expectHeaders(client, substrateClient, headers)
+
+		scanner.EXPECT().
+			ScanEvents(ctx, big.NewInt(int64(startingBlock)), big.NewInt(int64(lastBlock)), nil).
+			Return(nil, nil)
+		tx.EXPECT().GetEvoBlock(startingBlock-1).Return(model.Block{Number: 99, Hash: common.HexToHash("0x99")}, nil)
+
+		p := evolution.NewProcessor(client, substrateClient, stateService, scanner, evolution.NewFinalityTracker(substrateClient), &config.Config{})
+		err := p.ProcessEvoBlockRange(ctx, startingBlock, lastBlock)
+		assertError(t, evolution.ReorgError{Block: 99, ChainHash: common.HexToHash("0x98"), StorageHash: common.HexToHash("0x99")}, err)
+	})
+
+	t.Run("EVM block not recorded in its Substrate header", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.TODO()
+		stateService, tx, client, scanner, substrateClient := createMocks(t)
+
+		stateService.EXPECT().NewTransaction().Return(tx, nil)
+		tx.EXPECT().Discard()
+
+		startingBlock, lastBlock := uint64(100), uint64(120)
+		headers := createChainedHeaders(startingBlock, lastBlock, common.HexToHash("0x99"))
+		headers.substrate[110].Digest = []hexutil.Bytes{frontierDigestItem(common.HexToHash("0x110"))}
+		expectHeaders(client, substrateClient, headers)
+
+		scanner.EXPECT().
+			ScanEvents(ctx, big.NewInt(int64(startingBlock)), big.NewInt(int64(lastBlock)), nil).
+			Return(nil, nil)
+		tx.EXPECT().GetEvoBlock(startingBlock-1).Return(model.Block{Number: 99, Hash: common.HexToHash("0x99")}, nil)
+		tx.EXPECT().SetEvoBlock(gomock.Any()).Return(nil).Times(10)
+
+		p := evolution.NewProcessor(client, substrateClient, stateService, scanner, evolution.NewFinalityTracker(substrateClient), &config.Config{})
+		err := p.ProcessEvoBlockRange(ctx, startingBlock, lastBlock)
+		expectedErr := fmt.Errorf("hash %s of LaosEvolution EVM block 110 does not match the hash %s recorded in its Substrate header",
+			headers.evm[110].Hash(), common.HexToHash("0x110"))
+		assertError(t, expectedErr, err)
+	})
+
+	t.Run("range chained to the EVM hash stored before Substrate hashes were recorded", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.TODO()
+		stateService, tx, client, scanner, substrateClient := createMocks(t)
+
+		stateService.EXPECT().NewTransaction().Return(tx, nil)
+		tx.EXPECT().Discard()
+
+		startingBlock, lastBlock := uint64(100), uint64(120)
+		headers := createChainedHeaders(startingBlock, lastBlock, common.HexToHash("0x99"))
+		headers.substrate[startingBlock].ParentHash = common.HexToHash("0x98")
+		for blockNumber := startingBlock + 1; blockNumber <= lastBlock; blockNumber++ {
+			headers.substrate[blockNumber].ParentHash = headers.substrate[blockNumber-1].Hash()
 		}
-		contract := common.HexToAddress("0x555")
-		event, adjustedEvent := createEventMintedWithExternalURI(lastBlockData.Number, contract)
+		expectHeaders(client, substrateClient, headers)
+
+		scanner.EXPECT().
+			ScanEvents(ctx, big.NewInt(int64(startingBlock)), big.NewInt(int64(lastBlock)), nil).
+			Return(nil, nil)
+		tx.EXPECT().GetEvoBlock(startingBlock-1).Return(model.Block{Number: 99, Hash: common.HexToHash("0x99")}, nil)
+		tx.EXPECT().SetEvoBlock(gomock.Any()).Return(nil).Times(int(lastBlock - startingBlock + 1))
+		tx.EXPECT().GetFirstEvoBlock().Return(model.Block{Number: 1, Hash: common.HexToHash("0x1"), Timestamp: 1}, nil)
+		tx.EXPECT().SetLastEvoBlock(headers.blockOf(lastBlock)).Return(nil)
+		tx.EXPECT().Commit().Return(nil)
+
+		p := evolution.NewProcessor(client, substrateClient, stateService, scanner, evolution.NewFinalityTracker(substrateClient), &config.Config{})
+		err := p.ProcessEvoBlockRange(ctx, startingBlock, lastBlock)
+		assertError(t, nil, err)
+	})
 
-		laosRpc.EXPECT().LatestFinalizedBlockHash().Return(latestFinalizedBlockHash, nil).Times(1)
-		laosRpc.EXPECT().BlockNumber(latestFinalizedBlockHash).Return(big.NewInt(125), nil).Times(1)
+	t.Run("obtained one event, error when storing last block info", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.TODO()
+		stateService, tx, client, scanner, substrateClient := createMocks(t)
+
+		stateService.EXPECT().NewTransaction().Return(tx, nil)
+		tx.EXPECT().Discard()
+
+		startingBlock, lastBlock := uint64(100), uint64(120)
+		headers := createChainedHeaders(startingBlock, lastBlock, common.HexToHash("0x99"))
+		expectHeaders(client, substrateClient, headers)
+		contract := common.HexToAddress("0x555")
+		event, adjustedEvent := createEventMintedWithExternalURI(lastBlock, contract)
 
 		scanner.EXPECT().
-			ScanEvents(ctx, big.NewInt(int64(startingBlock)), big.NewInt(int64(lastBlockData.Number)), nil).
+			ScanEvents(ctx, big.NewInt(int64(startingBlock)), big.NewInt(int64(lastBlock)), nil).
 			Return([]scan.Event{event}, nil)
-
 		tx.EXPECT().
 			StoreMintedWithExternalURIEvent(contract.String(), &adjustedEvent).
 			Return(nil)
-		tx.EXPECT().
-			GetFirstEvoBlock().Return(startingBlockData, nil)
-
-		client.EXPECT().
-			BlockByNumber(ctx, big.NewInt(int64(lastBlockData.Number))).
-			Return(types.NewBlockWithHeader(&types.Header{
-				Time:   lastBlockData.Timestamp,
-				Number: big.NewInt(int64(lastBlockData.Number)),
-			}), nil)
-
-		tx.EXPECT().SetNextEvoEventBlock(contract.String(), lastBlockData.Number)
-		tx.EXPECT().SetLastEvoBlock(lastBlockData).Return(errors.New("error storing last block info"))
-
-		p := evolution.NewProcessor(client, stateService, scanner, laosRpc, &config.Config{})
-		err := p.ProcessEvoBlockRange(ctx, startingBlock, lastBlockData.Number)
+		tx.EXPECT().SetNextEvoEventBlock(contract.String(), lastBlock)
+		tx.EXPECT().GetEvoBlock(startingBlock-1).Return(model.Block{Number: 99, Hash: common.HexToHash("0x99")}, nil)
+		tx.EXPECT().SetEvoBlock(gomock.Any()).Return(nil).Times(int(lastBlock - startingBlock + 1))
+		tx.EXPECT().GetFirstEvoBlock().Return(model.Block{Number: 1, Hash: common.HexToHash("0x1"), Timestamp: 1}, nil)
+		tx.EXPECT().SetLastEvoBlock(headers.blockOf(lastBlock)).Return(errors.New("error storing last block info"))
+
+		p := evolution.NewProcessor(client, substrateClient, stateService, scanner, evolution.NewFinalityTracker(substrateClient), &config.Config{})
+		err := p.ProcessEvoBlockRange(ctx, startingBlock, lastBlock)
 		assertError(t, errors.New("error storing last block info"), err)
 	})
 
-	t.Run("obtained one event, event processed and last block updated successfully", func(t *testing.T) {
+	t.Run("obtained one event, event processed and blocks stored successfully", func(t *testing.T) {
 		t.Parallel()
 		ctx := context.TODO()
-		stateService, tx, client, scanner, laosRpc := createMocks(t)
+		stateService, tx, client, scanner, substrateClient := createMocks(t)
 
 		stateService.EXPECT().NewTransaction().Return(tx, nil)
 		tx.EXPECT().Discard()
 
-		lastBlockData := model.Block{
-			Number:    120,
-			Hash:      common.HexToHash("0x7ea18f6be7115ddbb51aa052f2780a1501847f4b3a444f1a6066982b7dbab6fc"),
-			Timestamp: 150,
-		}
-		startingBlock := uint64(100)
-		startingBlockData := model.Block{
-			Number:    100,
-			Hash:      common.HexToHash("0xb72b31eb84c4bbbbd62aff06a3c8c88991ac7c118c47aa6fba3609ed1baa8fd3"),
-			Timestamp: 110,
-		}
+		startingBlock, lastBlock := uint64(100), uint64(120)
+		headers := createChainedHeaders(startingBlock, lastBlock, common.HexToHash("0x99"))
+		expectHeaders(client, substrateClient, headers)
 		contract := common.HexToAddress("0x555")
-		event, adjustedEvent := createEventMintedWithExternalURI(lastBlockData.Number, contract)
-
-		laosRpc.EXPECT().LatestFinalizedBlockHash().Return(latestFinalizedBlockHash, nil).Times(1)
-		laosRpc.EXPECT().BlockNumber(latestFinalizedBlockHash).Return(big.NewInt(125), nil).Times(1)
+		event, adjustedEvent := createEventMintedWithExternalURI(lastBlock, contract)
 
 		scanner.EXPECT().
-			ScanEvents(ctx, big.NewInt(int64(startingBlock)), big.NewInt(int64(lastBlockData.Number)), nil).
+			ScanEvents(ctx, big.NewInt(int64(startingBlock)), big.NewInt(int64(lastBlock)), nil).
 			Return([]scan.Event{event}, nil)
-
 		tx.EXPECT().
 			StoreMintedWithExternalURIEvent(contract.String(), &adjustedEvent).
 			Return(nil)
-		tx.EXPECT().
-			GetFirstEvoBlock().Return(model.Block{}, nil)
-
-		client.EXPECT().
-			BlockByNumber(ctx, big.NewInt(int64(lastBlockData.Number))).
-			Return(types.NewBlockWithHeader(&types.Header{
-				Time:   lastBlockData.Timestamp,
-				Number: big.NewInt(int64(lastBlockData.Number)),
-			}), nil)
-		client.EXPECT().
-			BlockByNumber(ctx, big.NewInt(int64(startingBlockData.Number))).
-			Return(types.NewBlockWithHeader(&types.Header{
-				Time:   startingBlockData.Timestamp,
-				Number: big.NewInt(int64(startingBlockData.Number)),
-			}), nil)
-
-		tx.EXPECT().SetNextEvoEventBlock(contract.String(), lastBlockData.Number)
-		tx.EXPECT().SetLastEvoBlock(lastBlockData).Return(nil)
-		tx.EXPECT().SetFirstEvoBlock(startingBlockData).Return(nil)
+		tx.EXPECT().SetNextEvoEventBlock(contract.String(), lastBlock)
+		// the parent of the range was processed before evo blocks were stored
+		tx.EXPECT().GetEvoBlock(startingBlock-1).Return(model.Block{}, nil)
+		for blockNumber := startingBlock; blockNumber <= lastBlock; blockNumber++ {
+			tx.EXPECT().SetEvoBlock(headers.blockOf(blockNumber)).Return(nil)
+		}
+		tx.EXPECT().GetFirstEvoBlock().Return(model.Block{}, nil)
+		tx.EXPECT().SetFirstEvoBlock(headers.blockOf(startingBlock)).Return(nil)
+		tx.EXPECT().SetLastEvoBlock(headers.blockOf(lastBlock)).Return(nil)
 		tx.EXPECT().Commit().Return(nil)
 
-		p := evolution.NewProcessor(client, stateService, scanner, laosRpc, &config.Config{})
-		err := p.ProcessEvoBlockRange(ctx, startingBlock, lastBlockData.Number)
+		p := evolution.NewProcessor(client, substrateClient, stateService, scanner, evolution.NewFinalityTracker(substrateClient), &config.Config{})
+		err := p.ProcessEvoBlockRange(ctx, startingBlock, lastBlock)
 		assertError(t, nil, err)
 	})
+}
 
-	t.Run("error when request to parachain fails", func(t *testing.T) {
+func TestProcessEvoBlockRangeTooBig(t *testing.T) {
+	t.Parallel()
+	t.Run("splits the range and commits every half", func(t *testing.T) {
 		t.Parallel()
 		ctx := context.TODO()
-		stateService, tx, client, scanner, laosRpc := createMocks(t)
+		stateService, tx, client, scanner, substrateClient := createMocks(t)
+		headers := createChainedHeaders(100, 101, common.HexToHash("0x99"))
+		expectHeaders(client, substrateClient, headers)
+		stateService.EXPECT().NewTransaction().Return(tx, nil).Times(3)
+		tx.EXPECT().Discard().Times(3)
+		for _, r := range [][2]int64{{100, 101}, {100, 100}, {101, 101}} {
+			scanner.EXPECT().ScanEvents(ctx, big.NewInt(r[0]), big.NewInt(r[1]), nil).Return(nil, nil)
+		}
+		tx.EXPECT().GetEvoBlock(uint64(99)).Return(model.Block{Number: 99, Hash: common.HexToHash("0x99")}, nil).Times(2)
+		tx.EXPECT().SetEvoBlock(headers.blockOf(100)).Return(nil).Times(2)
+		tx.EXPECT().SetEvoBlock(headers.blockOf(101)).Return(fmt.Errorf("error setting evo block: %w", storage.ErrTxnTooBig))
+		// first half
+		tx.EXPECT().GetFirstEvoBlock().Return(model.Block{}, nil)
+		tx.EXPECT().SetFirstEvoBlock(headers.blockOf(100)).Return(nil)
+		tx.EXPECT().SetLastEvoBlock(headers.blockOf(100)).Return(nil)
+		// second half, chained to the first one
+		tx.EXPECT().GetEvoBlock(uint64(100)).Return(headers.blockOf(100), nil)
+		tx.EXPECT().SetEvoBlock(headers.blockOf(101)).Return(nil)
+		tx.EXPECT().GetFirstEvoBlock().Return(headers.blockOf(100), nil)
+		tx.EXPECT().SetLastEvoBlock(headers.blockOf(101)).Return(nil)
+		tx.EXPECT().Commit().Return(nil).Times(2)
+
+		p := evolution.NewProcessor(client, substrateClient, stateService, scanner, evolution.NewFinalityTracker(substrateClient), &config.Config{})
+		err := p.ProcessEvoBlockRange(ctx, 100, 101)
+		assertError(t, nil, err)
+	})
 
+	t.Run("fails when a single block does not fit", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.TODO()
+		stateService, tx, client, scanner, substrateClient := createMocks(t)
+		headers := createChainedHeaders(100, 100, common.HexToHash("0x99"))
+		expectHeaders(client, substrateClient, headers)
 		stateService.EXPECT().NewTransaction().Return(tx, nil)
 		tx.EXPECT().Discard()
+		scanner.EXPECT().ScanEvents(ctx, big.NewInt(100), big.NewInt(100), nil).Return(nil, nil)
+		tx.EXPECT().GetEvoBlock(uint64(99)).Return(model.Block{Number: 99, Hash: common.HexToHash("0x99")}, nil)
+		tx.EXPECT().SetEvoBlock(headers.blockOf(100)).Return(storage.ErrTxnTooBig)
+
+		p := evolution.NewProcessor(client, substrateClient, stateService, scanner, evolution.NewFinalityTracker(substrateClient), &config.Config{})
+		err := p.ProcessEvoBlockRange(ctx, 100, 100)
+		if !errors.Is(err, storage.ErrTxnTooBig) {
+			t.Fatalf(`got error "%v" when storage.ErrTxnTooBig was expected`, err)
+		}
+	})
+}
 
-		lastBlockData := model.Block{Number: 120, Hash: common.HexToHash("0x123"), Timestamp: 150}
-		startingBlock := uint64(100)
+// chainHeaders holds the EVM and Substrate headers of the same evolution blocks
+type chainHeaders struct {
+	evm       map[uint64]*types.Header
+	substrate map[uint64]*substrate.Header
+}
 
-		laosRpc.EXPECT().LatestFinalizedBlockHash().Return(latestFinalizedBlockHash, nil).Times(1)
-		laosRpc.EXPECT().BlockNumber(latestFinalizedBlockHash).Return(nil, errors.New("error converting latest finalized block number")).Times(1)
+// createChainedHeaders returns the headers from fromBlock to toBlock, the first one being a child of parentHash
+// in both chains. Every Substrate header records the hash of its EVM block in its digest.
+func createChainedHeaders(fromBlock, toBlock uint64, parentHash common.Hash) chainHeaders {
+	headers := chainHeaders{evm: make(map[uint64]*types.Header), substrate: make(map[uint64]*substrate.Header)}
+	evmParentHash, substrateParentHash := parentHash, parentHash
+	for blockNumber := fromBlock; blockNumber <= toBlock; blockNumber++ {
+		header := &types.Header{
+			ParentHash: evmParentHash,
+			Number:     new(big.Int).SetUint64(blockNumber),
+			Time:       blockNumber + 10,
+		}
+		substrateHeader := &substrate.Header{
+			ParentHash: substrateParentHash,
+			Number:     blockNumber,
+			Digest:     []hexutil.Bytes{frontierDigestItem(header.Hash())},
+		}
+		headers.evm[blockNumber] = header
+		headers.substrate[blockNumber] = substrateHeader
+		evmParentHash, substrateParentHash = header.Hash(), substrateHeader.Hash()
+	}
+	return headers
+}
 
-		p := evolution.NewProcessor(client, stateService, scanner, laosRpc, &config.Config{})
-		err := p.ProcessEvoBlockRange(ctx, startingBlock, lastBlockData.Number)
-		assertError(t, errors.New("error converting latest finalized block number"), err)
-	})
+// frontierDigestItem returns the consensus digest item recording the hash of the EVM block
+func frontierDigestItem(hash common.Hash) hexutil.Bytes {
+	postLog := append([]byte{3}, hash.Bytes()...)
+	item := append([]byte{4}, "fron"...)
+	item = append(item, byte(len(postLog)<<2))
+	return append(item, postLog...)
+}
+
+func expectHeaders(client *mockClient.MockEthClient, substrateClient *mockSubstrate.MockClient, headers chainHeaders) {
+	client.EXPECT().
+		HeaderByNumber(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, number *big.Int) (*types.Header, error) {
+			return headers.evm[number.Uint64()], nil
+		}).
+		AnyTimes()
+	substrateClient.EXPECT().
+		HeadersByNumber(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, fromBlock, toBlock uint64) (map[uint64]*substrate.Header, error) {
+			substrateHeaders := make(map[uint64]*substrate.Header)
+			for blockNumber := fromBlock; blockNumber <= toBlock; blockNumber++ {
+				substrateHeaders[blockNumber] = headers.substrate[blockNumber]
+			}
+			return substrateHeaders, nil
+		}).
+		AnyTimes()
+}
+
+// blockOf returns the block stored for blockNumber, which has the Substrate hash and the EVM timestamp
+func (h chainHeaders) blockOf(blockNumber uint64) model.Block {
+	return model.Block{Number: blockNumber, Hash: h.substrate[blockNumber].Hash(), Timestamp: h.evm[blockNumber].Time}
 }
 
-func createMocks(t *testing.T) (*mockTx.MockService, *mockTx.MockTx, *mockClient.MockEthClient, *mockScan.MockScanner, *mockRPCRequests.MockLaosRPCRequests) {
+func createMocks(t *testing.T) (*mockTx.MockService, *mockTx.MockTx, *mockClient.MockEthClient, *mockScan.MockScanner, *mockSubstrate.MockClient) {
 	ctrl := gomock.NewController(t)
 
-	return mockTx.NewMockService(ctrl), mockTx.NewMockTx(ctrl), mockClient.NewMockEthClient(ctrl), mockScan.NewMockScanner(ctrl), mockRPCRequests.NewMockLaosRPCRequests(ctrl)
+	return mockTx.NewMockService(ctrl), mockTx.NewMockTx(ctrl), mockClient.NewMockEthClient(ctrl), mockScan.NewMockScanner(ctrl), mockSubstrate.NewMockClient(ctrl)
 }
 
 func assertError(t *testing.T, expectedError, err error) {
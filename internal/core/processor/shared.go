@@ -4,7 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"math/big"
 
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/freeverseio/laos-universal-node/internal/config"
 	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	"github.com/freeverseio/laos-universal-node/internal/platform/state"
@@ -22,26 +26,74 @@ type blockHelper struct {
 	blocksRange   uint64
 	blocksMargin  uint64
 	startingBlock uint64
+	finality      string
 }
 
-func NewBlockHelper(client blockchain.EthClient, stateService state.Service, blocksRange, blocksMargin, startingBlock uint64) BlockHelper {
+// NewBlockHelper returns a BlockHelper following the chain head defined by finality (one of the config.OwnershipFinality* modes).
+// blocksMargin is only applied in config.OwnershipFinalityMargin mode.
+func NewBlockHelper(client blockchain.EthClient, stateService state.Service, blocksRange, blocksMargin, startingBlock uint64, finality string) BlockHelper {
 	return &blockHelper{
 		client:        client,
 		stateService:  stateService,
 		blocksRange:   blocksRange,
 		blocksMargin:  blocksMargin,
 		startingBlock: startingBlock,
+		finality:      finality,
 	}
 }
 
 func (h *blockHelper) GetLastBlock(ctx context.Context, startingBlock uint64) (uint64, error) {
-	l1LatestBlock, err := h.client.BlockNumber(ctx)
-	if err != nil {
-		slog.Error("error retrieving the latest block", "err", err.Error())
-		return 0, err
+	blocksRange := h.blocksRange
+	// the range is adapted to the provider responses when the client supports it
+	if ranger, ok := blockchain.As[blockchain.BlockRanger](h.client); ok {
+		blocksRange = ranger.BlockRange()
 	}
 
-	return min(startingBlock+h.blocksRange, l1LatestBlock-h.blocksMargin), nil
+	switch h.finality {
+	case config.OwnershipFinalitySafe, config.OwnershipFinalityFinalized:
+		header, err := h.client.HeaderByNumber(ctx, finalityTagNumber(h.finality))
+		if err != nil {
+			slog.Error("error retrieving the head block", "finality", h.finality, "err", err.Error())
+			return 0, err
+		}
+		return min(startingBlock+blocksRange, header.Number.Uint64()), nil
+	case config.OwnershipFinalityLatest:
+		l1LatestBlock, err := h.client.BlockNumber(ctx)
+		if err != nil {
+			slog.Error("error retrieving the latest block", "err", err.Error())
+			return 0, err
+		}
+		return min(startingBlock+blocksRange, l1LatestBlock), nil
+	default:
+		l1LatestBlock, err := h.client.BlockNumber(ctx)
+		if err != nil {
+			slog.Error("error retrieving the latest block", "err", err.Error())
+			return 0, err
+		}
+		return min(startingBlock+blocksRange, l1LatestBlock-h.blocksMargin), nil
+	}
+}
+
+// ResolveFinality returns the finality mode the node can actually follow on the chain of client.
+// The safe and finalized modes fall back to config.OwnershipFinalityMargin on chains that do not support the block tags.
+func ResolveFinality(ctx context.Context, client blockchain.EthClient, finality string) string {
+	if finality != config.OwnershipFinalitySafe && finality != config.OwnershipFinalityFinalized {
+		return finality
+	}
+	header, err := client.HeaderByNumber(ctx, finalityTagNumber(finality))
+	if err != nil || header == nil || header.Number == nil {
+		slog.Warn("the chain does not support the block tag, falling back to the blocks margin",
+			"finality", finality, "err", err)
+		return config.OwnershipFinalityMargin
+	}
+	return finality
+}
+
+func finalityTagNumber(finality string) *big.Int {
+	if finality == config.OwnershipFinalitySafe {
+		return big.NewInt(int64(rpc.SafeBlockNumber))
+	}
+	return big.NewInt(int64(rpc.FinalizedBlockNumber))
 }
 
 func (h *blockHelper) GetOwnershipInitStartingBlock(ctx context.Context) (uint64, error) {
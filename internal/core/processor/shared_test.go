@@ -3,9 +3,15 @@ package shared_test
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/freeverseio/laos-universal-node/internal/config"
 	shared "github.com/freeverseio/laos-universal-node/internal/core/processor"
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/blockrange"
 	blockchainMock "github.com/freeverseio/laos-universal-node/internal/platform/blockchain/mock"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	stateMock "github.com/freeverseio/laos-universal-node/internal/platform/state/mock"
@@ -34,7 +40,7 @@ func TestGetLastBlock(t *testing.T) {
 
 				mockClient, mockStateService := getMocks(ctrl)
 
-				blockHelper := shared.NewBlockHelper(mockClient, mockStateService, 10, 0, 1)
+				blockHelper := shared.NewBlockHelper(mockClient, mockStateService, 10, 0, 1, config.OwnershipFinalityMargin)
 				mockClient.EXPECT().BlockNumber(context.Background()).Return(tt.blockNumber, nil)
 
 				got, err := blockHelper.GetLastBlock(context.Background(), 1)
@@ -54,7 +60,7 @@ func TestGetLastBlock(t *testing.T) {
 
 		mockClient, mockStateService := getMocks(ctrl)
 
-		blockHelper := shared.NewBlockHelper(mockClient, mockStateService, 10, 0, 1)
+		blockHelper := shared.NewBlockHelper(mockClient, mockStateService, 10, 0, 1, config.OwnershipFinalityMargin)
 		expectedErr := fmt.Errorf("an error occurred")
 		mockClient.EXPECT().BlockNumber(context.Background()).Return(uint64(0), expectedErr)
 
@@ -66,6 +72,117 @@ func TestGetLastBlock(t *testing.T) {
 			t.Errorf("got error message '%s', expected '%s'", err.Error(), expectedErr.Error())
 		}
 	})
+	t.Run("uses the range adapted by the client", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockClient, mockStateService := getMocks(ctrl)
+		client := blockrange.NewClient(mockClient, 50, 100)
+
+		blockHelper := shared.NewBlockHelper(client, mockStateService, 10, 0, 1, config.OwnershipFinalityMargin)
+		mockClient.EXPECT().BlockNumber(context.Background()).Return(uint64(1000), nil)
+
+		got, err := blockHelper.GetLastBlock(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("got error '%v' while no error was expected", err)
+		}
+		if got != 51 {
+			t.Fatalf("got %d, expected 51", got)
+		}
+	})
+	t.Run("follows the finality modes", func(t *testing.T) {
+		t.Parallel()
+		tests := []struct {
+			name     string
+			finality string
+			tag      *big.Int
+			head     uint64
+			expected uint64
+		}{
+			{"latest ignores the blocks margin", config.OwnershipFinalityLatest, nil, 8, 8},
+			{"margin subtracts the blocks margin", config.OwnershipFinalityMargin, nil, 8, 5},
+			{"finalized follows the finalized head", config.OwnershipFinalityFinalized, big.NewInt(int64(rpc.FinalizedBlockNumber)), 8, 8},
+			{"safe follows the safe head", config.OwnershipFinalitySafe, big.NewInt(int64(rpc.SafeBlockNumber)), 20, 11},
+		}
+
+		for _, tt := range tests {
+			tt := tt
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				mockClient, mockStateService := getMocks(ctrl)
+
+				blockHelper := shared.NewBlockHelper(mockClient, mockStateService, 10, 3, 1, tt.finality)
+				if tt.tag != nil {
+					mockClient.EXPECT().HeaderByNumber(context.Background(), tt.tag).Return(&types.Header{Number: big.NewInt(int64(tt.head))}, nil)
+				} else {
+					mockClient.EXPECT().BlockNumber(context.Background()).Return(tt.head, nil)
+				}
+
+				got, err := blockHelper.GetLastBlock(context.Background(), 1)
+				if err != nil {
+					t.Fatalf("got error '%v' while no error was expected", err)
+				}
+				if got != tt.expected {
+					t.Fatalf("got %d, expected %d", got, tt.expected)
+				}
+			})
+		}
+	})
+}
+
+func TestResolveFinality(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		finality  string
+		tag       *big.Int
+		header    *types.Header
+		headerErr error
+		expected  string
+	}{
+		{
+			name:     "latest does not need the chain support",
+			finality: config.OwnershipFinalityLatest,
+			expected: config.OwnershipFinalityLatest,
+		},
+		{
+			name:     "finalized is kept when the chain supports the tag",
+			finality: config.OwnershipFinalityFinalized,
+			tag:      big.NewInt(int64(rpc.FinalizedBlockNumber)),
+			header:   &types.Header{Number: big.NewInt(100)},
+			expected: config.OwnershipFinalityFinalized,
+		},
+		{
+			name:      "safe falls back to margin when the chain does not support the tag",
+			finality:  config.OwnershipFinalitySafe,
+			tag:       big.NewInt(int64(rpc.SafeBlockNumber)),
+			headerErr: fmt.Errorf("unknown block"),
+			expected:  config.OwnershipFinalityMargin,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockClient, _ := getMocks(ctrl)
+			if tt.tag != nil {
+				mockClient.EXPECT().HeaderByNumber(context.Background(), tt.tag).Return(tt.header, tt.headerErr)
+			}
+
+			got := shared.ResolveFinality(context.Background(), mockClient, tt.finality)
+			if got != tt.expected {
+				t.Fatalf("got finality %s, expected %s", got, tt.expected)
+			}
+		})
+	}
 }
 
 func TestGetInitStartingBlock(t *testing.T) {
@@ -183,7 +300,7 @@ func TestGetInitStartingBlock(t *testing.T) {
 				tt.getLastBlockFunc(tx)
 				mockClient.EXPECT().BlockNumber(context.Background()).Return(tt.chainLatestBlock, nil).Times(tt.blockNumberTimes)
 
-				helper := shared.NewBlockHelper(mockClient, mockStateService, 100, 10, tt.userStartingBlock)
+				helper := shared.NewBlockHelper(mockClient, mockStateService, 100, 10, tt.userStartingBlock, config.OwnershipFinalityMargin)
 				actualStartingBlock, err := tt.targetFunc(helper, context.Background())
 				if err != nil {
 					t.Fatalf("got error '%v' while no error was expected", err)
@@ -229,7 +346,7 @@ func TestGetInitStartingBlock(t *testing.T) {
 
 					mockStateService.EXPECT().NewTransaction().Return(nil, errMsg)
 
-					helper := shared.NewBlockHelper(mockClient, mockStateService, 100, 10, 0)
+					helper := shared.NewBlockHelper(mockClient, mockStateService, 100, 10, 0, config.OwnershipFinalityMargin)
 					_, err := tt.targetFunc(helper, context.Background())
 					if err == nil {
 						t.Fatalf("got no error when '%v' was expected", expectedErr)
@@ -282,7 +399,7 @@ func TestGetInitStartingBlock(t *testing.T) {
 					tx.EXPECT().Discard()
 					tt.getLastBlockFunc(tx)
 
-					helper := shared.NewBlockHelper(mockClient, mockStateService, 100, 10, 0)
+					helper := shared.NewBlockHelper(mockClient, mockStateService, 100, 10, 0, config.OwnershipFinalityMargin)
 					_, err := tt.targetFunc(helper, context.Background())
 					if err == nil {
 						t.Fatalf("got no error when '%v' was expected", expectedErr)
@@ -336,7 +453,7 @@ func TestGetInitStartingBlock(t *testing.T) {
 					tt.getLastBlockFunc(tx)
 					mockClient.EXPECT().BlockNumber(context.Background()).Return(uint64(0), errMsg)
 
-					helper := shared.NewBlockHelper(mockClient, mockStateService, 100, 10, 0)
+					helper := shared.NewBlockHelper(mockClient, mockStateService, 100, 10, 0, config.OwnershipFinalityMargin)
 					_, err := tt.targetFunc(helper, context.Background())
 					if err == nil {
 						t.Fatalf("got no error when '%v' was expected", expectedErr)
@@ -0,0 +1,228 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	contractUpdater "github.com/freeverseio/laos-universal-node/internal/core/processor/universal/updater"
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain"
+	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/tree/account"
+)
+
+// Processor replays the history of the contracts that were followed after their deployment, while the universal
+// processor keeps applying new blocks to the other contracts. Each contract is replayed from its deployment block
+// into its own trees and joins the live contracts once it reaches the last processed ownership block.
+type Processor interface {
+	// ProcessBackfills replays the next block range of every backfill behind the last processed ownership block.
+	// It returns whether any backfill was replayed.
+	ProcessBackfills(ctx context.Context) (bool, error)
+}
+
+type processor struct {
+	client       blockchain.EthClient
+	stateService state.Service
+	updater      contractUpdater.Updater
+	blocksRange  uint64
+}
+
+func NewProcessor(client blockchain.EthClient,
+	stateService state.Service,
+	updater contractUpdater.Updater,
+	blocksRange uint64,
+) *processor {
+	return &processor{
+		client:       client,
+		stateService: stateService,
+		updater:      updater,
+		blocksRange:  blocksRange,
+	}
+}
+
+func (p *processor) ProcessBackfills(ctx context.Context) (bool, error) {
+	tx, err := p.stateService.NewTransaction()
+	if err != nil {
+		return false, err
+	}
+	backfills, err := tx.GetContractBackfills()
+	if err != nil {
+		tx.Discard()
+		return false, err
+	}
+	lastBlock, err := tx.GetLastOwnershipBlock()
+	tx.Discard()
+	if err != nil {
+		return false, err
+	}
+
+	replayed := false
+	for i := range backfills {
+		if backfills[i].NextBlock > lastBlock.Number {
+			// waiting for the universal processor to join it
+			continue
+		}
+		toBlock := min(backfills[i].NextBlock+p.blocksRange-1, lastBlock.Number)
+		if err := p.replay(ctx, backfills[i].Contract.Address, toBlock); err != nil {
+			return false, fmt.Errorf("error backfilling contract %s: %w", backfills[i].Contract.Address.String(), err)
+		}
+		replayed = true
+	}
+	return replayed, nil
+}
+
+// replay applies the transfer events and the evo mints of the contract up to toBlock to its trees,
+// and patches the root tags of the replayed blocks with the contract state at each of them.
+// The last ownership block is not read in this transaction, as it would conflict with every range applied meanwhile.
+// Instead, the root tags are read: they only change when the universal processor recovers from a reorg.
+func (p *processor) replay(ctx context.Context, contract common.Address, toBlock uint64) error {
+	tx, err := p.stateService.NewTransaction()
+	if err != nil {
+		return err
+	}
+	defer tx.Discard()
+
+	contractString := strings.ToLower(contract.String())
+	backfill, err := tx.GetContractBackfill(contractString)
+	if err != nil {
+		return err
+	}
+	if backfill == nil || backfill.NextBlock > toBlock {
+		// the backfill was joined or rewound meanwhile
+		return nil
+	}
+	// blocks before the first processed one were never tagged
+	firstBlock, err := tx.GetFirstOwnershipBlock()
+	if err != nil {
+		return err
+	}
+
+	fromBlock := backfill.NextBlock
+	slog.Debug("backfilling contract", "contract", contractString, "fromBlock", fromBlock, "toBlock", toBlock)
+	transferEvents, err := p.updater.GetModelTransferEvents(ctx, fromBlock, toBlock, []string{contractString})
+	if err != nil {
+		return err
+	}
+	blockTimestamps, err := contractUpdater.GetBlockTimestampsParallel(ctx, p.client, fromBlock, toBlock)
+	if err != nil {
+		return err
+	}
+
+	data := AccountData(backfill)
+	if err := tx.LoadContractTreesAt(contract, data); err != nil {
+		return fmt.Errorf("error occurred while loading merkle trees for contract %s: %w", contractString, err)
+	}
+	for block := fromBlock; block <= toBlock; block++ {
+		evoBlock, evoEvents, err := contractUpdater.GetEvoEventsFrom(tx,
+			contractString, backfill.Contract.CollectionAddress, data.LastProcessedEvoBlock, blockTimestamps[block])
+		if err != nil {
+			return err
+		}
+		if len(evoEvents) > 0 || len(transferEvents[block][contractString]) > 0 {
+			if err := contractUpdater.ApplyEvents(tx, contractString, evoEvents, transferEvents[block][contractString]); err != nil {
+				return err
+			}
+			if data, err = tx.ContractAccountData(contract, evoBlock); err != nil {
+				return err
+			}
+		}
+		// as for the contracts followed from their deployment, there is no account data until the first event
+		if block < firstBlock.Number || *data == (account.AccountData{}) {
+			continue
+		}
+		if err := tx.PatchRootTag(int64(block), contract, data); err != nil {
+			return fmt.Errorf("error occurred while patching the root tag of block %d: %w", block, err)
+		}
+	}
+
+	setAccountData(backfill, data)
+	backfill.NextBlock = toBlock + 1
+	if err := tx.StoreContractBackfill(*backfill); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	slog.Info("contract backfilled", "contract", contractString, "lastBlock", toBlock, "totalSupply", data.TotalSupply)
+	return nil
+}
+
+// Join adds the contracts whose backfill has reached startingBlock to the live contracts, with the state they were replayed to.
+// It must be called in the transaction applying the block range from startingBlock, so that the contracts are updated from there on.
+func Join(tx state.Tx, startingBlock uint64) error {
+	backfills, err := tx.GetContractBackfills()
+	if err != nil {
+		return err
+	}
+	for i := range backfills {
+		backfill := &backfills[i]
+		if backfill.NextBlock != startingBlock {
+			continue
+		}
+		if err := tx.StoreERC721UniversalContracts([]model.ERC721UniversalContract{backfill.Contract}); err != nil {
+			return err
+		}
+		if data := AccountData(backfill); *data != (account.AccountData{}) {
+			if err := tx.LoadContractTreesAt(backfill.Contract.Address, data); err != nil {
+				return err
+			}
+			if err := tx.UpdateContractState(backfill.Contract.Address, data.LastProcessedEvoBlock); err != nil {
+				return err
+			}
+		}
+		if err := tx.DeleteContractBackfill(backfill.Contract.Address.String()); err != nil {
+			return err
+		}
+		slog.Info("backfilled contract joined the live contracts", "contract", backfill.Contract.Address.String(), "startingBlock", startingBlock)
+	}
+	return nil
+}
+
+// Rewind resets the backfills replayed after blockNumber to the state of their contract at blockNumber.
+// It must be called once the state is checked out at blockNumber, whose root tag holds the state of the backfilled contracts.
+func Rewind(tx state.Tx, blockNumber uint64) error {
+	backfills, err := tx.GetContractBackfills()
+	if err != nil {
+		return err
+	}
+	for i := range backfills {
+		backfill := &backfills[i]
+		if backfill.NextBlock <= blockNumber+1 {
+			continue
+		}
+		data, err := tx.AccountData(backfill.Contract.Address)
+		if err != nil {
+			return fmt.Errorf("error retrieving account data for contract %s: %w", backfill.Contract.Address.String(), err)
+		}
+		setAccountData(backfill, data)
+		backfill.NextBlock = max(blockNumber+1, backfill.Contract.BlockNumber)
+		if err := tx.StoreContractBackfill(*backfill); err != nil {
+			return err
+		}
+		slog.Debug("backfill rewound", "contract", backfill.Contract.Address.String(), "nextBlock", backfill.NextBlock)
+	}
+	return nil
+}
+
+// AccountData returns the roots the contract trees of the backfill were replayed to
+func AccountData(backfill *model.ContractBackfill) *account.AccountData {
+	return &account.AccountData{
+		EnumeratedRoot:        backfill.EnumeratedRoot,
+		EnumeratedTotalRoot:   backfill.EnumeratedTotalRoot,
+		OwnershipRoot:         backfill.OwnershipRoot,
+		TotalSupply:           backfill.TotalSupply,
+		LastProcessedEvoBlock: backfill.LastProcessedEvoBlock,
+	}
+}
+
+func setAccountData(backfill *model.ContractBackfill, data *account.AccountData) {
+	backfill.EnumeratedRoot = data.EnumeratedRoot
+	backfill.EnumeratedTotalRoot = data.EnumeratedTotalRoot
+	backfill.OwnershipRoot = data.OwnershipRoot
+	backfill.TotalSupply = data.TotalSupply
+	backfill.LastProcessedEvoBlock = data.LastProcessedEvoBlock
+}
@@ -0,0 +1,254 @@
+package backfill_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/mock/gomock"
+
+	"github.com/freeverseio/laos-universal-node/internal/core/processor/universal/backfill"
+	mockUpdater "github.com/freeverseio/laos-universal-node/internal/core/processor/universal/updater/mock"
+	mockClient "github.com/freeverseio/laos-universal-node/internal/platform/blockchain/mock"
+	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state"
+	v1 "github.com/freeverseio/laos-universal-node/internal/platform/state/v1"
+	badgerStorage "github.com/freeverseio/laos-universal-node/internal/platform/storage/badger"
+)
+
+var (
+	contract   = common.HexToAddress("0x500")
+	collection = common.HexToAddress("0x501")
+	alice      = common.HexToAddress("0xa")
+	bob        = common.HexToAddress("0xb")
+	// the slot owner of the token is alice
+	tokenId = alice.Big()
+)
+
+// seedState processes the ownership blocks from 10 to lastBlock without the contract, which is deployed at block 5.
+// Its token is minted at evo block 100 and transferred from alice to bob at block 11.
+func seedState(t *testing.T, stateService state.Service, lastBlock uint64) {
+	t.Helper()
+	tx, err := stateService.NewTransaction()
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	defer tx.Discard()
+
+	mustSucceed(t, tx.SetFirstOwnershipBlock(model.Block{Number: 10}))
+	mustSucceed(t, tx.SetLastOwnershipBlock(model.Block{Number: lastBlock, Hash: common.HexToHash("0x1")}))
+	for block := int64(10); block <= 12; block++ {
+		mustSucceed(t, tx.TagRoot(block))
+	}
+	mustSucceed(t, tx.StoreMintedWithExternalURIEvent(collection.String(), &model.MintedWithExternalURI{
+		Slot:        big.NewInt(1),
+		To:          alice,
+		TokenURI:    "tokenURI",
+		TokenId:     tokenId,
+		BlockNumber: 100,
+		Timestamp:   850,
+	}))
+	mustSucceed(t, tx.SetEvoBlock(model.Block{Number: 100, Timestamp: 850}))
+	mustSucceed(t, tx.SetNextEvoEventBlock(collection.String(), 100))
+	mustSucceed(t, tx.StoreContractBackfill(model.ContractBackfill{
+		Contract:  model.ERC721UniversalContract{Address: contract, CollectionAddress: collection, BlockNumber: 5},
+		NextBlock: 5,
+	}))
+	mustSucceed(t, tx.Commit())
+}
+
+func TestProcessBackfills(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	contractString := strings.ToLower(contract.String())
+
+	t.Run("replays the contract from its deployment and patches the root tags", func(t *testing.T) {
+		t.Parallel()
+		stateService := createStateService(t)
+		seedState(t, stateService, 12)
+		client, updater := createMocks(t)
+		updater.EXPECT().GetModelTransferEvents(ctx, uint64(5), uint64(12), []string{contractString}).
+			Return(map[uint64]map[string][]model.ERC721Transfer{
+				11: {contractString: {{From: alice, To: bob, TokenId: tokenId, BlockNumber: 11, Contract: contract}}},
+			}, nil)
+
+		p := backfill.NewProcessor(client, stateService, updater, 100)
+		replayed, err := p.ProcessBackfills(ctx)
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if !replayed {
+			t.Fatalf("got no backfill replayed when one was expected")
+		}
+
+		tx := newTransaction(t, stateService)
+		defer tx.Discard()
+		pending, err := tx.GetContractBackfill(contractString)
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if pending.NextBlock != 13 || pending.TotalSupply != 1 || pending.LastProcessedEvoBlock != 100 {
+			t.Fatalf("got backfill %+v, expected it replayed up to block 12 with the minted token", pending)
+		}
+		for block, expectedOwner := range map[int64]common.Address{10: alice, 11: bob, 12: bob} {
+			assertOwnerAt(t, stateService, block, expectedOwner)
+		}
+
+		// caught up with the last ownership block
+		replayed, err = p.ProcessBackfills(ctx)
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if replayed {
+			t.Fatalf("got a backfill replayed when none was expected")
+		}
+	})
+
+	t.Run("fails when a replayed block is not tagged", func(t *testing.T) {
+		t.Parallel()
+		stateService := createStateService(t)
+		seedState(t, stateService, 13)
+		client, updater := createMocks(t)
+		updater.EXPECT().GetModelTransferEvents(ctx, uint64(5), uint64(13), []string{contractString}).
+			Return(map[uint64]map[string][]model.ERC721Transfer{}, nil)
+
+		p := backfill.NewProcessor(client, stateService, updater, 100)
+		_, err := p.ProcessBackfills(ctx)
+		if err == nil || !strings.Contains(err.Error(), "no tag found for this block number 13") {
+			t.Fatalf(`got error "%v" when the missing tag error was expected`, err)
+		}
+	})
+}
+
+func TestJoin(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	contractString := strings.ToLower(contract.String())
+	stateService := createStateService(t)
+	seedState(t, stateService, 12)
+	client, updater := createMocks(t)
+	updater.EXPECT().GetModelTransferEvents(ctx, uint64(5), uint64(12), []string{contractString}).
+		Return(map[uint64]map[string][]model.ERC721Transfer{}, nil)
+	if _, err := backfill.NewProcessor(client, stateService, updater, 100).ProcessBackfills(ctx); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+
+	tx := newTransaction(t, stateService)
+	defer tx.Discard()
+	// the range starting at block 12 was already applied
+	mustSucceed(t, backfill.Join(tx, 12))
+	hasContract, err := tx.HasERC721UniversalContract(contractString)
+	if err != nil || hasContract {
+		t.Fatalf(`got contract joined "%v" with error "%v" before it caught up`, hasContract, err)
+	}
+
+	mustSucceed(t, backfill.Join(tx, 13))
+	hasContract, err = tx.HasERC721UniversalContract(contractString)
+	if err != nil || !hasContract {
+		t.Fatalf(`got contract joined "%v" with error "%v" when it was expected to join`, hasContract, err)
+	}
+	pending, err := tx.GetContractBackfill(contractString)
+	if err != nil || pending != nil {
+		t.Fatalf(`got backfill %+v with error "%v" after joining`, pending, err)
+	}
+	accountData, err := tx.AccountData(contract)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if accountData.TotalSupply != 1 || accountData.LastProcessedEvoBlock != 100 {
+		t.Fatalf("got account data %+v, expected the replayed state of the contract", accountData)
+	}
+}
+
+func TestRewind(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	contractString := strings.ToLower(contract.String())
+	stateService := createStateService(t)
+	seedState(t, stateService, 12)
+	client, updater := createMocks(t)
+	updater.EXPECT().GetModelTransferEvents(ctx, uint64(5), uint64(12), []string{contractString}).
+		Return(map[uint64]map[string][]model.ERC721Transfer{
+			11: {contractString: {{From: alice, To: bob, TokenId: tokenId, BlockNumber: 11, Contract: contract}}},
+		}, nil)
+	if _, err := backfill.NewProcessor(client, stateService, updater, 100).ProcessBackfills(ctx); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+
+	tx := newTransaction(t, stateService)
+	defer tx.Discard()
+	mustSucceed(t, tx.Checkout(10))
+	expected, err := tx.AccountData(contract)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	mustSucceed(t, backfill.Rewind(tx, 10))
+	pending, err := tx.GetContractBackfill(contractString)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if pending.NextBlock != 11 || *backfill.AccountData(pending) != *expected {
+		t.Fatalf("got backfill %+v, expected it rewound to the state at block 10 %+v", pending, expected)
+	}
+}
+
+func assertOwnerAt(t *testing.T, stateService state.Service, block int64, expectedOwner common.Address) {
+	t.Helper()
+	tx := newTransaction(t, stateService)
+	defer tx.Discard()
+	mustSucceed(t, tx.Checkout(block))
+	mustSucceed(t, tx.LoadContractTrees(contract))
+	owner, err := tx.OwnerOf(contract, tokenId)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if owner != expectedOwner {
+		t.Fatalf("got owner %s at block %d, expected %s", owner, block, expectedOwner)
+	}
+}
+
+func createMocks(t *testing.T) (*mockClient.MockEthClient, *mockUpdater.MockUpdater) {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	client := mockClient.NewMockEthClient(ctrl)
+	// block n is mined at n*100
+	client.EXPECT().HeaderByNumber(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, number *big.Int) (*types.Header, error) {
+			return &types.Header{Number: number, Time: number.Uint64() * 100}, nil
+		}).AnyTimes()
+	return client, mockUpdater.NewMockUpdater(ctrl)
+}
+
+func createStateService(t *testing.T) state.Service {
+	t.Helper()
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLoggingLevel(badger.ERROR))
+	if err != nil {
+		t.Fatalf("error initializing storage: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf(`got error "%v" closing the storage`, err)
+		}
+	})
+	return v1.NewStateService(badgerStorage.NewService(db))
+}
+
+func newTransaction(t *testing.T, stateService state.Service) state.Tx {
+	t.Helper()
+	tx, err := stateService.NewTransaction()
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	return tx
+}
+
+func mustSucceed(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+}
@@ -2,21 +2,37 @@ package discoverer
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"math/big"
+	"sync"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	uValidator "github.com/freeverseio/laos-universal-node/internal/core/processor/universal/discoverer/validator"
 	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain"
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/contract"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	"github.com/freeverseio/laos-universal-node/internal/platform/scan"
 	"github.com/freeverseio/laos-universal-node/internal/platform/state"
 )
 
+var (
+	// ErrContractNotDeployed is returned by DiscoverContract when there is no code at the address
+	ErrContractNotDeployed = errors.New("contract not deployed")
+	// ErrNotUniversalContract is returned by DiscoverContract when the contract has no base URI pointing to a collection of the evochain
+	ErrNotUniversalContract = errors.New("not an ERC721 universal contract of the evochain")
+	// ErrDeploymentBlockNotFound is returned by DiscoverContract when the code of the contract cannot be read at past blocks,
+	// which requires an archive node
+	ErrDeploymentBlockNotFound = errors.New("deployment block not found")
+)
+
 type Discoverer interface {
 	ShouldDiscover(tx state.Tx, startingBlock, lastBlock uint64) (bool, error)
 	GetContracts(tx state.Tx) ([]string, error)
 	DiscoverContracts(ctx context.Context, tx state.Tx, startingBlock, lastBlock uint64) (map[common.Address]uint64, error)
+	DiscoverContract(ctx context.Context, address common.Address, lastBlock uint64) (model.ERC721UniversalContract, error)
 }
 
 type discoverer struct {
@@ -24,6 +40,9 @@ type discoverer struct {
 	contracts []string
 	scanner   scan.Scanner
 	validator uValidator.Validator
+	// rejected are the listed contracts that are not universal contracts of the evochain, which are not discovered again
+	rejected   map[string]struct{}
+	rejectedMu sync.Mutex
 }
 
 func New(
@@ -37,6 +56,7 @@ func New(
 		contracts: contracts,
 		scanner:   scanner,
 		validator: validator,
+		rejected:  make(map[string]struct{}),
 	}
 }
 
@@ -45,17 +65,46 @@ func (d *discoverer) ShouldDiscover(tx state.Tx, startingBlock, lastBlock uint64
 		return true, nil
 	}
 	for i := 0; i < len(d.contracts); i++ {
-		hasContract, err := tx.HasERC721UniversalContract(d.contracts[i])
+		if d.isRejected(d.contracts[i]) {
+			continue
+		}
+		followed, err := isFollowed(tx, d.contracts[i])
 		if err != nil {
 			return false, err
 		}
-		if !hasContract {
+		if !followed {
 			return true, nil
 		}
 	}
 	return false, nil
 }
 
+func (d *discoverer) isRejected(contract string) bool {
+	d.rejectedMu.Lock()
+	defer d.rejectedMu.Unlock()
+	_, ok := d.rejected[contract]
+	return ok
+}
+
+func (d *discoverer) reject(contract string) {
+	d.rejectedMu.Lock()
+	defer d.rejectedMu.Unlock()
+	d.rejected[contract] = struct{}{}
+}
+
+// isFollowed returns whether the contract is discovered, either live or being backfilled
+func isFollowed(tx state.Tx, contract string) (bool, error) {
+	hasContract, err := tx.HasERC721UniversalContract(contract)
+	if err != nil || hasContract {
+		return hasContract, err
+	}
+	backfill, err := tx.GetContractBackfill(contract)
+	if err != nil {
+		return false, err
+	}
+	return backfill != nil, nil
+}
+
 func (d *discoverer) DiscoverContracts(
 	ctx context.Context,
 	tx state.Tx,
@@ -86,9 +135,110 @@ func (d *discoverer) DiscoverContracts(
 		// We are passing list of newly discovered contracts to the updater also
 	}
 
+	// listed contracts deployed before the scanned blocks, or by factories emitting other events, are never found by their events
+	for _, listedContract := range d.contracts {
+		if d.isRejected(listedContract) {
+			continue
+		}
+		followed, err := isFollowed(tx, listedContract)
+		if err != nil {
+			return nil, err
+		}
+		if followed {
+			continue
+		}
+		contract, err := d.DiscoverContract(ctx, common.HexToAddress(listedContract), lastBlock)
+		if errors.Is(err, ErrContractNotDeployed) {
+			slog.Debug("listed contract not deployed yet", "contract", listedContract, "block", lastBlock)
+			continue
+		}
+		if errors.Is(err, ErrNotUniversalContract) {
+			slog.Warn("listed contract discarded", "contract", listedContract, "err", err.Error())
+			d.reject(listedContract)
+			continue
+		}
+		if errors.Is(err, ErrDeploymentBlockNotFound) {
+			// the search is retried with the next range, in case the error is transient
+			slog.Error("listed contract skipped, discovering it on demand requires an archive node", "contract", listedContract, "err", err.Error())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if contract.BlockNumber < startingBlock {
+			// the blocks since its deployment are already processed, its history is replayed by a backfill
+			if err = tx.StoreContractBackfill(model.ContractBackfill{Contract: contract, NextBlock: contract.BlockNumber}); err != nil {
+				return nil, err
+			}
+			slog.Info("backfilling listed contract deployed before the processed blocks", "contract", listedContract, "deployment_block", contract.BlockNumber)
+			continue
+		}
+		if err = tx.StoreERC721UniversalContracts([]model.ERC721UniversalContract{contract}); err != nil {
+			slog.Error("error occurred while storing universal contract(s)", "err", err.Error())
+			return nil, err
+		}
+		newContracts[contract.Address] = contract.BlockNumber
+	}
+
 	return newContracts, nil
 }
 
+// DiscoverContract returns the ERC721 universal contract at address without its NewERC721Universal event.
+// Its base URI is read at lastBlock and validated, and its deployment block is searched on chain,
+// so that its state can be built from the block where it was deployed. The contract is not stored.
+func (d *discoverer) DiscoverContract(
+	ctx context.Context,
+	address common.Address,
+	lastBlock uint64,
+) (model.ERC721UniversalContract, error) {
+	code, err := d.client.CodeAt(ctx, address, new(big.Int).SetUint64(lastBlock))
+	if err != nil {
+		return model.ERC721UniversalContract{}, fmt.Errorf("error retrieving the code of contract %s: %w", address, err)
+	}
+	if len(code) == 0 {
+		return model.ERC721UniversalContract{}, ErrContractNotDeployed
+	}
+
+	caller, err := contract.NewErc721universalCaller(address, d.client)
+	if err != nil {
+		return model.ERC721UniversalContract{}, fmt.Errorf("error instantiating contract %s: %w", address, err)
+	}
+	// a contract without baseURI() reverts, which is not told apart from other errors of the call
+	baseURI, err := caller.BaseURI(&bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(lastBlock)})
+	if err != nil {
+		return model.ERC721UniversalContract{}, fmt.Errorf("%w: error calling baseURI(): %w", ErrNotUniversalContract, err)
+	}
+	universalContract, err := d.validator.Validate(scan.EventNewERC721Universal{NewContractAddress: address, BaseURI: baseURI})
+	if err != nil {
+		return model.ERC721UniversalContract{}, fmt.Errorf("%w: %w", ErrNotUniversalContract, err)
+	}
+
+	universalContract.BlockNumber, err = d.searchDeploymentBlock(ctx, address, lastBlock)
+	if err != nil {
+		return model.ERC721UniversalContract{}, err
+	}
+	slog.Info("universal contract discovered", "contract", address, "deployment_block", universalContract.BlockNumber, "base_uri", baseURI)
+	return universalContract, nil
+}
+
+// searchDeploymentBlock returns the first block with code at address, which must have code at lastBlock
+func (d *discoverer) searchDeploymentBlock(ctx context.Context, address common.Address, lastBlock uint64) (uint64, error) {
+	low, high := uint64(0), lastBlock
+	for low < high {
+		mid := low + (high-low)/2
+		code, err := d.client.CodeAt(ctx, address, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return 0, fmt.Errorf("%w: error reading the code of contract %s at block %d: %w", ErrDeploymentBlockNotFound, address, mid, err)
+		}
+		if len(code) > 0 {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+	return low, nil
+}
+
 func (d *discoverer) GetContracts(tx state.Tx) ([]string, error) {
 	if len(d.contracts) > 0 {
 		return tx.GetExistingERC721UniversalContracts(d.contracts)
@@ -11,6 +11,7 @@ import (
 
 	cDiscoverer "github.com/freeverseio/laos-universal-node/internal/core/processor/universal/discoverer"
 	mockValidator "github.com/freeverseio/laos-universal-node/internal/core/processor/universal/discoverer/validator/mock"
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/contract"
 	mockClient "github.com/freeverseio/laos-universal-node/internal/platform/blockchain/mock"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	"github.com/freeverseio/laos-universal-node/internal/platform/scan"
@@ -24,6 +25,7 @@ func TestShouldDiscover(t *testing.T) {
 	tests := []struct {
 		name             string
 		contracts        []string
+		backfilling      bool
 		expectedDiscover bool
 		expectedError    error
 	}{
@@ -46,6 +48,13 @@ func TestShouldDiscover(t *testing.T) {
 			expectedDiscover: false,
 			expectedError:    nil,
 		},
+		{
+			name:             "contract being backfilled should not be discovered",
+			contracts:        []string{"contract1"},
+			backfilling:      true,
+			expectedDiscover: false,
+			expectedError:    nil,
+		},
 		{
 			name:             "error happened on execution",
 			contracts:        []string{"contract1"},
@@ -62,7 +71,15 @@ func TestShouldDiscover(t *testing.T) {
 
 			discoverer := cDiscoverer.New(client, tt.contracts, nil, nil)
 			if len(tt.contracts) == 1 {
-				tx.EXPECT().HasERC721UniversalContract(tt.contracts[0]).Return(!tt.expectedDiscover, tt.expectedError)
+				stored := !tt.expectedDiscover && !tt.backfilling
+				tx.EXPECT().HasERC721UniversalContract(tt.contracts[0]).Return(stored, tt.expectedError)
+				if !stored && tt.expectedError == nil {
+					var backfill *model.ContractBackfill
+					if tt.backfilling {
+						backfill = &model.ContractBackfill{NextBlock: 5}
+					}
+					tx.EXPECT().GetContractBackfill(tt.contracts[0]).Return(backfill, nil)
+				}
 			}
 
 			shouldDiscover, err := discoverer.ShouldDiscover(tx, 10, 20)
@@ -265,6 +282,210 @@ func TestDiscoverContractsSuccess(t *testing.T) {
 	})
 }
 
+func TestDiscoverListedContracts(t *testing.T) {
+	t.Parallel()
+	const deploymentBlock = 150
+	baseURI := "https://uloc.io/GlobalConsensus(3)/Parachain(9999)/AccountKey20(0x0000000000000000000000000000000000000001)/"
+	listed := common.HexToAddress("0xC3dd09D5387FA0Ab798e0ADC152d15b8d1a299DF")
+	expectedContract := model.ERC721UniversalContract{
+		Address:           listed,
+		CollectionAddress: common.HexToAddress("0x0000000000000000000000000000000000000001"),
+		BlockNumber:       deploymentBlock,
+	}
+
+	tests := []struct {
+		name              string
+		stored            bool
+		backfilling       bool
+		startingBlock     uint64
+		lastBlock         uint64
+		validationErr     error
+		expectedBackfill  bool
+		expectedContracts map[common.Address]uint64
+	}{
+		{
+			name:              "listed contract is discovered on demand from its deployment block",
+			startingBlock:     100,
+			lastBlock:         200,
+			expectedContracts: map[common.Address]uint64{listed: deploymentBlock},
+		},
+		{
+			name:              "listed contract deployed before the range is backfilled",
+			startingBlock:     160,
+			lastBlock:         200,
+			expectedBackfill:  true,
+			expectedContracts: map[common.Address]uint64{},
+		},
+		{
+			name:              "listed contract already discovered",
+			stored:            true,
+			startingBlock:     100,
+			lastBlock:         200,
+			expectedContracts: map[common.Address]uint64{},
+		},
+		{
+			name:              "listed contract being backfilled",
+			backfilling:       true,
+			startingBlock:     160,
+			lastBlock:         200,
+			expectedContracts: map[common.Address]uint64{},
+		},
+		{
+			name:              "listed contract not deployed yet",
+			startingBlock:     100,
+			lastBlock:         120,
+			expectedContracts: map[common.Address]uint64{},
+		},
+		{
+			name:              "listed contract pointing to another evochain",
+			startingBlock:     100,
+			lastBlock:         200,
+			validationErr:     fmt.Errorf("universal contract's base URI points to a collection in a different evochain, contract discarded"),
+			expectedContracts: map[common.Address]uint64{},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.TODO()
+			tx, client, scanner, validator := createMocks(t)
+
+			scanner.EXPECT().ScanNewUniversalEvents(ctx, new(big.Int).SetUint64(tt.startingBlock), new(big.Int).SetUint64(tt.lastBlock)).Return(nil, nil)
+			tx.EXPECT().HasERC721UniversalContract(listed.String()).Return(tt.stored, nil)
+			if !tt.stored {
+				var backfill *model.ContractBackfill
+				if tt.backfilling {
+					backfill = &model.ContractBackfill{Contract: expectedContract, NextBlock: deploymentBlock}
+				}
+				tx.EXPECT().GetContractBackfill(listed.String()).Return(backfill, nil)
+			}
+			discovering := !tt.stored && !tt.backfilling
+			if discovering {
+				expectDeployedContract(t, client, listed, deploymentBlock, baseURI)
+			}
+			if discovering && tt.lastBlock >= deploymentBlock {
+				event := scan.EventNewERC721Universal{NewContractAddress: listed, BaseURI: baseURI}
+				validator.EXPECT().Validate(event).Return(model.ERC721UniversalContract{Address: listed, CollectionAddress: expectedContract.CollectionAddress}, tt.validationErr)
+			}
+			if tt.validationErr == nil && len(tt.expectedContracts) > 0 {
+				tx.EXPECT().StoreERC721UniversalContracts([]model.ERC721UniversalContract{expectedContract}).Return(nil)
+			}
+			if tt.expectedBackfill {
+				tx.EXPECT().StoreContractBackfill(model.ContractBackfill{Contract: expectedContract, NextBlock: deploymentBlock}).Return(nil)
+			}
+
+			d := cDiscoverer.New(client, []string{listed.String()}, scanner, validator)
+			contracts, err := d.DiscoverContracts(ctx, tx, tt.startingBlock, tt.lastBlock)
+			assertError(t, nil, err)
+			if len(contracts) != len(tt.expectedContracts) {
+				t.Fatalf("got contracts %v, expected %v", contracts, tt.expectedContracts)
+			}
+			for address, block := range tt.expectedContracts {
+				if contracts[address] != block {
+					t.Fatalf("got block %d for contract %s, expected %d", contracts[address], address, block)
+				}
+			}
+		})
+	}
+}
+
+func TestDiscoverListedContractRejectedOnce(t *testing.T) {
+	t.Parallel()
+	ctx := context.TODO()
+	baseURI := "https://uloc.io/GlobalConsensus(3)/Parachain(1)/AccountKey20(0x0000000000000000000000000000000000000001)/"
+	listed := common.HexToAddress("0xC3dd09D5387FA0Ab798e0ADC152d15b8d1a299DF")
+	tx, client, scanner, validator := createMocks(t)
+
+	scanner.EXPECT().ScanNewUniversalEvents(ctx, big.NewInt(100), big.NewInt(200)).Return(nil, nil)
+	tx.EXPECT().HasERC721UniversalContract(listed.String()).Return(false, nil).Times(2)
+	tx.EXPECT().GetContractBackfill(listed.String()).Return(nil, nil).Times(2)
+	expectDeployedContract(t, client, listed, 150, baseURI)
+	validator.EXPECT().Validate(scan.EventNewERC721Universal{NewContractAddress: listed, BaseURI: baseURI}).
+		Return(model.ERC721UniversalContract{}, fmt.Errorf("universal contract's base URI points to a collection in a different evochain, contract discarded")).
+		Times(1)
+
+	d := cDiscoverer.New(client, []string{listed.String()}, scanner, validator)
+	shouldDiscover, err := d.ShouldDiscover(tx, 100, 200)
+	assertError(t, nil, err)
+	if !shouldDiscover {
+		t.Fatalf("got should discover false, expected true before the listed contract is rejected")
+	}
+	contracts, err := d.DiscoverContracts(ctx, tx, 100, 200)
+	assertError(t, nil, err)
+	if len(contracts) != 0 {
+		t.Fatalf("got contracts %v, expected none", contracts)
+	}
+
+	// the rejected contract is neither read from the state nor discovered again
+	shouldDiscover, err = d.ShouldDiscover(tx, 201, 300)
+	assertError(t, nil, err)
+	if shouldDiscover {
+		t.Fatalf("got should discover true, expected false once the listed contract is rejected")
+	}
+}
+
+func TestDiscoverListedContractWithoutArchiveNode(t *testing.T) {
+	t.Parallel()
+	ctx := context.TODO()
+	baseURI := "https://uloc.io/GlobalConsensus(3)/Parachain(9999)/AccountKey20(0x0000000000000000000000000000000000000001)/"
+	listed := common.HexToAddress("0xC3dd09D5387FA0Ab798e0ADC152d15b8d1a299DF")
+	tx, client, scanner, validator := createMocks(t)
+
+	scanner.EXPECT().ScanNewUniversalEvents(ctx, big.NewInt(100), big.NewInt(200)).Return(nil, nil)
+	tx.EXPECT().HasERC721UniversalContract(listed.String()).Return(false, nil)
+	tx.EXPECT().GetContractBackfill(listed.String()).Return(nil, nil)
+	client.EXPECT().CodeAt(gomock.Any(), listed, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ common.Address, blockNumber *big.Int) ([]byte, error) {
+			if blockNumber.Uint64() < 200 {
+				return nil, fmt.Errorf("missing trie node")
+			}
+			return []byte{0x60, 0x80}, nil
+		}).
+		AnyTimes()
+	contractAbi, err := contract.Erc721universalMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("error instantiating ABI: %v", err)
+	}
+	output, err := contractAbi.Methods["baseURI"].Outputs.Pack(baseURI)
+	if err != nil {
+		t.Fatalf("error packing baseURI: %v", err)
+	}
+	client.EXPECT().CallContract(gomock.Any(), gomock.Any(), gomock.Any()).Return(output, nil)
+	validator.EXPECT().Validate(scan.EventNewERC721Universal{NewContractAddress: listed, BaseURI: baseURI}).
+		Return(model.ERC721UniversalContract{Address: listed}, nil)
+
+	d := cDiscoverer.New(client, []string{listed.String()}, scanner, validator)
+	contracts, err := d.DiscoverContracts(ctx, tx, 100, 200)
+	assertError(t, nil, err)
+	if len(contracts) != 0 {
+		t.Fatalf("got contracts %v, expected none", contracts)
+	}
+}
+
+// expectDeployedContract mocks a contract with code from deploymentBlock on, whose baseURI() returns baseURI
+func expectDeployedContract(t *testing.T, client *mockClient.MockEthClient, address common.Address, deploymentBlock uint64, baseURI string) {
+	t.Helper()
+	client.EXPECT().CodeAt(gomock.Any(), address, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ common.Address, blockNumber *big.Int) ([]byte, error) {
+			if blockNumber.Uint64() < deploymentBlock {
+				return nil, nil
+			}
+			return []byte{0x60, 0x80}, nil
+		}).
+		AnyTimes()
+
+	contractAbi, err := contract.Erc721universalMetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("error instantiating ABI: %v", err)
+	}
+	output, err := contractAbi.Methods["baseURI"].Outputs.Pack(baseURI)
+	if err != nil {
+		t.Fatalf("error packing baseURI: %v", err)
+	}
+	client.EXPECT().CallContract(gomock.Any(), gomock.Any(), gomock.Any()).Return(output, nil).AnyTimes()
+}
+
 func createMocks(t *testing.T) (*mockTx.MockTx, *mockClient.MockEthClient, *mockScan.MockScanner, *mockValidator.MockValidator) {
 	ctrl := gomock.NewController(t)
 	return mockTx.NewMockTx(ctrl), mockClient.NewMockEthClient(ctrl), mockScan.NewMockScanner(ctrl), mockValidator.NewMockValidator(ctrl)
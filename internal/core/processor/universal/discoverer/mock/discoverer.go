@@ -13,6 +13,7 @@ import (
 	reflect "reflect"
 
 	common "github.com/ethereum/go-ethereum/common"
+	model "github.com/freeverseio/laos-universal-node/internal/platform/model"
 	state "github.com/freeverseio/laos-universal-node/internal/platform/state"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -40,6 +41,21 @@ func (m *MockDiscoverer) EXPECT() *MockDiscovererMockRecorder {
 	return m.recorder
 }
 
+// DiscoverContract mocks base method.
+func (m *MockDiscoverer) DiscoverContract(ctx context.Context, address common.Address, lastBlock uint64) (model.ERC721UniversalContract, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiscoverContract", ctx, address, lastBlock)
+	ret0, _ := ret[0].(model.ERC721UniversalContract)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiscoverContract indicates an expected call of DiscoverContract.
+func (mr *MockDiscovererMockRecorder) DiscoverContract(ctx, address, lastBlock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscoverContract", reflect.TypeOf((*MockDiscoverer)(nil).DiscoverContract), ctx, address, lastBlock)
+}
+
 // DiscoverContracts mocks base method.
 func (m *MockDiscoverer) DiscoverContracts(ctx context.Context, tx state.Tx, startingBlock, lastBlock uint64) (map[common.Address]uint64, error) {
 	m.ctrl.T.Helper()
@@ -6,6 +6,7 @@ import (
 
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	"github.com/freeverseio/laos-universal-node/internal/platform/scan"
+	"github.com/freeverseio/laos-universal-node/internal/platform/uloc"
 )
 
 type Validator interface {
@@ -25,26 +26,14 @@ func New(globalConsensus string, parachain uint64) Validator {
 }
 
 func (v *validator) Validate(event scan.EventNewERC721Universal) (model.ERC721UniversalContract, error) {
-	contractGlobalConsensus, err := event.GlobalConsensus()
+	location, err := uloc.Parse(event.BaseURI)
 	if err != nil {
-		slog.Warn("error parsing global consensus for contract", "contract", event.NewContractAddress,
-			"base_uri", event.BaseURI)
-		return model.ERC721UniversalContract{}, err
-	}
-	contractParachain, err := event.Parachain()
-	if err != nil {
-		slog.Warn("error parsing parachain for contract", "contract", event.NewContractAddress,
-			"base_uri", event.BaseURI)
-		return model.ERC721UniversalContract{}, err
-	}
-	collectionAddress, err := event.CollectionAddress()
-	if err != nil {
-		slog.Warn("error parsing collection address for contract", "contract", event.NewContractAddress,
-			"base_uri", event.BaseURI)
-		return model.ERC721UniversalContract{}, err
+		slog.Warn("error parsing base URI for contract", "contract", event.NewContractAddress,
+			"base_uri", event.BaseURI, "err", err)
+		return model.ERC721UniversalContract{}, fmt.Errorf("error parsing base URI %s: %w", event.BaseURI, err)
 	}
 
-	if contractGlobalConsensus != v.globalConsensus || contractParachain != v.parachain {
+	if location.GlobalConsensus != v.globalConsensus || location.Parachain != v.parachain {
 		slog.Debug("universal contract's base URI points to a collection in a different evochain, contract discarded",
 			"base_uri", event.BaseURI, "chain_global_consensus", v.globalConsensus, "chain_parachain", v.parachain)
 		return model.ERC721UniversalContract{}, fmt.Errorf("universal contract's base URI points to a collection in a different evochain, contract discarded")
@@ -52,7 +41,7 @@ func (v *validator) Validate(event scan.EventNewERC721Universal) (model.ERC721Un
 
 	return model.ERC721UniversalContract{
 		Address:           event.NewContractAddress,
-		CollectionAddress: collectionAddress,
+		CollectionAddress: location.AccountKey20,
 		BlockNumber:       event.BlockNumber,
 	}, nil
 }
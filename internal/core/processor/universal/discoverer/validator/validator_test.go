@@ -32,6 +32,20 @@ func TestValidate(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		{
+			name: "valid event with uloc scheme and pallet instance",
+			event: scan.EventNewERC721Universal{
+				BaseURI:            "uloc://GlobalConsensus(3)/Parachain(9999)/PalletInstance(51)/AccountKey20(0xfFfffFFFffFFFffffffFfFfe0000000000000001)",
+				BlockNumber:        12345,
+				NewContractAddress: common.HexToAddress("0xC3dd09D5387FA0Ab798e0ADC152d15b8d1a299DF"),
+			},
+			expectedContract: model.ERC721UniversalContract{
+				Address:           common.HexToAddress("0xc3dd09d5387fa0ab798e0adc152d15b8d1a299df"),
+				CollectionAddress: common.HexToAddress("0xfffffffffffffffffffffffe0000000000000001"),
+				BlockNumber:       12345,
+			},
+			expectedErr: nil,
+		},
 		{
 			name: "invalid parachain",
 			event: scan.EventNewERC721Universal{
@@ -57,7 +71,8 @@ func TestValidate(t *testing.T) {
 				BlockNumber:        100,
 				NewContractAddress: common.HexToAddress("0xC3dd09D5387FA0Ab798e0ADC152d15b8d1a299DF"),
 			},
-			expectedErr: fmt.Errorf("no global consensus ID found in base URI: https://uloc.io/Parachain(9999)/AccountKey20(0x0000000000000000000000000000000000000000)"),
+			expectedErr: fmt.Errorf("error parsing base URI https://uloc.io/Parachain(9999)/AccountKey20(0x0000000000000000000000000000000000000000): " +
+				"missing junction: expected GlobalConsensus at offset 16, found Parachain"),
 		},
 		{
 			name: "no parachain in BaseURI",
@@ -66,7 +81,8 @@ func TestValidate(t *testing.T) {
 				BlockNumber:        100,
 				NewContractAddress: common.HexToAddress("0xC3dd09D5387FA0Ab798e0ADC152d15b8d1a299DF"),
 			},
-			expectedErr: fmt.Errorf("no parachain ID found in base URI: https://uloc.io/GlobalConsensus(3)/AccountKey20(0x0000000000000000000000000000000000000000)"),
+			expectedErr: fmt.Errorf("error parsing base URI https://uloc.io/GlobalConsensus(3)/AccountKey20(0x0000000000000000000000000000000000000000): " +
+				"missing junction: expected Parachain at offset 35, found AccountKey20"),
 		},
 		{
 			name: "no collection address in BaseURI",
@@ -75,7 +91,18 @@ func TestValidate(t *testing.T) {
 				BlockNumber:        100,
 				NewContractAddress: common.HexToAddress("0xC3dd09D5387FA0Ab798e0ADC152d15b8d1a299DF"),
 			},
-			expectedErr: fmt.Errorf("no collection address found in base URI: https://uloc.io/GlobalConsensus(3)/Parachain(9999)/"),
+			expectedErr: fmt.Errorf("error parsing base URI https://uloc.io/GlobalConsensus(3)/Parachain(9999)/: " +
+				"missing junction: expected PalletInstance or AccountKey20 at offset 50"),
+		},
+		{
+			name: "duplicated junction in BaseURI",
+			event: scan.EventNewERC721Universal{
+				BaseURI:            "https://uloc.io/GlobalConsensus(3)/GlobalConsensus(3)/Parachain(9999)/AccountKey20(0x0000000000000000000000000000000000000000)",
+				BlockNumber:        100,
+				NewContractAddress: common.HexToAddress("0xC3dd09D5387FA0Ab798e0ADC152d15b8d1a299DF"),
+			},
+			expectedErr: fmt.Errorf("error parsing base URI https://uloc.io/GlobalConsensus(3)/GlobalConsensus(3)/Parachain(9999)/AccountKey20(0x0000000000000000000000000000000000000000): " +
+				"duplicated junction: GlobalConsensus at offset 35"),
 		},
 	}
 
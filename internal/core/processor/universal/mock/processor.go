@@ -12,6 +12,7 @@ import (
 	context "context"
 	reflect "reflect"
 
+	universal "github.com/freeverseio/laos-universal-node/internal/core/processor/universal"
 	model "github.com/freeverseio/laos-universal-node/internal/platform/model"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -39,6 +40,20 @@ func (m *MockProcessor) EXPECT() *MockProcessorMockRecorder {
 	return m.recorder
 }
 
+// ApplyBlockRange mocks base method.
+func (m *MockProcessor) ApplyBlockRange(ctx context.Context, prefetched *universal.PrefetchedRange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyBlockRange", ctx, prefetched)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplyBlockRange indicates an expected call of ApplyBlockRange.
+func (mr *MockProcessorMockRecorder) ApplyBlockRange(ctx, prefetched any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyBlockRange", reflect.TypeOf((*MockProcessor)(nil).ApplyBlockRange), ctx, prefetched)
+}
+
 // GetInitStartingBlock mocks base method.
 func (m *MockProcessor) GetInitStartingBlock(ctx context.Context) (uint64, error) {
 	m.ctrl.T.Helper()
@@ -84,6 +99,21 @@ func (mr *MockProcessorMockRecorder) IsEvoSyncedWithOwnership(ctx, lastOwnership
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsEvoSyncedWithOwnership", reflect.TypeOf((*MockProcessor)(nil).IsEvoSyncedWithOwnership), ctx, lastOwnershipBlock)
 }
 
+// PrefetchBlockRange mocks base method.
+func (m *MockProcessor) PrefetchBlockRange(ctx context.Context, startingBlock, lastBlock uint64) (*universal.PrefetchedRange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrefetchBlockRange", ctx, startingBlock, lastBlock)
+	ret0, _ := ret[0].(*universal.PrefetchedRange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PrefetchBlockRange indicates an expected call of PrefetchBlockRange.
+func (mr *MockProcessorMockRecorder) PrefetchBlockRange(ctx, startingBlock, lastBlock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrefetchBlockRange", reflect.TypeOf((*MockProcessor)(nil).PrefetchBlockRange), ctx, startingBlock, lastBlock)
+}
+
 // ProcessUniversalBlockRange mocks base method.
 func (m *MockProcessor) ProcessUniversalBlockRange(ctx context.Context, startingBlock, lastBlock uint64) error {
 	m.ctrl.T.Helper()
@@ -2,24 +2,25 @@ package universal
 
 import (
 	"context"
+	"errors"
+	"expvar"
 	"fmt"
 	"log/slog"
 	"math/big"
+	"slices"
 
 	"github.com/ethereum/go-ethereum/common"
 
 	"github.com/freeverseio/laos-universal-node/internal/config"
 	shared "github.com/freeverseio/laos-universal-node/internal/core/processor"
+	"github.com/freeverseio/laos-universal-node/internal/core/processor/universal/backfill"
 	contractDiscoverer "github.com/freeverseio/laos-universal-node/internal/core/processor/universal/discoverer"
 	contractUpdater "github.com/freeverseio/laos-universal-node/internal/core/processor/universal/updater"
 	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	"github.com/freeverseio/laos-universal-node/internal/platform/scan"
 	"github.com/freeverseio/laos-universal-node/internal/platform/state"
-)
-
-const (
-	safeBlockMargin = 250
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
 )
 
 type ReorgError struct {
@@ -32,12 +33,49 @@ func (e ReorgError) Error() string {
 	return "reorg error"
 }
 
+// DeepReorgError is returned when none of the retained ownership block hashes older than the reorged block
+// belongs to the canonical chain, so no safe block to recover from can be found
+type DeepReorgError struct {
+	Block       uint64
+	OldestBlock uint64
+}
+
+func (e DeepReorgError) Error() string {
+	if e.OldestBlock == 0 {
+		return fmt.Sprintf("reorg at block %d is deeper than the retained block history: no block hashes are stored before it", e.Block)
+	}
+	return fmt.Sprintf("reorg at block %d is deeper than the retained block history: the oldest retained block %d is not canonical",
+		e.Block, e.OldestBlock)
+}
+
+// ErrBlockRangeGap is returned when a block range does not start right after the last ownership block stored,
+// which would skip or apply twice the blocks in between
+var ErrBlockRangeGap = errors.New("block range does not follow the last ownership block")
+
+var (
+	// prunedBlockHashes is the number of ownership block hashes deleted out of the retention window
+	prunedBlockHashes = expvar.NewInt("ownership_block_hashes_pruned")
+	// blockHashPruneErrors is the number of block ranges whose old block hashes could not be pruned
+	blockHashPruneErrors = expvar.NewInt("ownership_block_hash_prune_errors")
+)
+
 type Processor interface {
 	GetInitStartingBlock(ctx context.Context) (uint64, error)
 	GetLastBlock(ctx context.Context, startingBlock uint64) (uint64, error)
 	RecoverFromReorg(ctx context.Context, startingBlock uint64) (*model.Block, error)
 	IsEvoSyncedWithOwnership(ctx context.Context, lastOwnershipBlock uint64) (bool, error)
 	ProcessUniversalBlockRange(ctx context.Context, startingBlock, lastBlock uint64) error
+	PrefetchBlockRange(ctx context.Context, startingBlock, lastBlock uint64) (*PrefetchedRange, error)
+	ApplyBlockRange(ctx context.Context, prefetched *PrefetchedRange) error
+}
+
+// PrefetchedRange holds the chain data of a block range fetched ahead of applying the range to the state
+type PrefetchedRange struct {
+	StartingBlock uint64
+	LastBlock     uint64
+	// Contracts are the contracts known when the range was fetched, whose transfer events are in TransferEvents
+	Contracts      []string
+	TransferEvents map[uint64]map[string][]model.ERC721Transfer
 }
 
 type processor struct {
@@ -47,6 +85,9 @@ type processor struct {
 	shared.BlockHelper
 	discoverer contractDiscoverer.Discoverer
 	updater    contractUpdater.Updater
+	retention  state.BlockRetention
+	// finality is the head of the ownership chain followed, the safe and finalized blocks are not checked for reorgs
+	finality string
 }
 
 func NewProcessor(client blockchain.EthClient,
@@ -66,9 +107,16 @@ func NewProcessor(client blockchain.EthClient,
 			uint64(c.BlocksRange),
 			uint64(c.BlocksMargin),
 			c.StartingBlock,
+			c.OwnershipFinality,
 		),
 		discoverer: discoverer,
 		updater:    updater,
+		retention: state.BlockRetention{
+			Blocks:             c.ReorgWindow,
+			Duration:           c.ReorgWindowDuration,
+			CheckpointInterval: c.CheckpointInterval,
+		},
+		finality: c.OwnershipFinality,
 	}
 }
 
@@ -76,12 +124,15 @@ func (p *processor) GetInitStartingBlock(ctx context.Context) (uint64, error) {
 	return p.GetOwnershipInitStartingBlock(ctx)
 }
 
-// RecoverFromReorg is called when a reorg is detected. It will recursively check for reorgs until it finds a block without reorg.
+// RecoverFromReorg is called when a reorg is detected. It searches the retained block hashes for the newest block without reorg.
 // It will set the last ownership block to the block without reorg and delete all block hashes and block numbers after the block without reorg.
 // It will also set the ownership block without reorg as the last mapped block.
-// It will checkout the merkle tree at the block without reorg, commit the transaction to flush the data to disk,
+// It will checkout the merkle tree at the block without reorg, rewind the contract backfills replayed after it,
+// commit the transaction to flush the data to disk,
 // and return the block without reorg.
+// If none of the retained blocks is canonical, it returns a DeepReorgError and leaves the state untouched.
 func (p *processor) RecoverFromReorg(ctx context.Context, currentBlock uint64) (*model.Block, error) {
+	blockchain.ResetHeaders(p.client)
 	// Start a transaction
 	tx, err := p.stateService.NewTransaction()
 	if err != nil {
@@ -93,7 +144,7 @@ func (p *processor) RecoverFromReorg(ctx context.Context, currentBlock uint64) (
 	if err != nil {
 		return nil, err
 	}
-	// Check for reorg recursively
+	// Search for the newest block without reorg
 	blockWithoutReorg, err := p.findBlockWithoutReorg(ctx, tx, currentBlock, storedBlockNumbers)
 	if err != nil {
 		return nil, err
@@ -120,6 +171,10 @@ func (p *processor) RecoverFromReorg(ctx context.Context, currentBlock uint64) (
 	if err != nil {
 		return nil, err
 	}
+	// backfills replayed after the block without reorg might hold events of the reorged blocks
+	if err = backfill.Rewind(tx, blockWithoutReorg.Number); err != nil {
+		return nil, err
+	}
 
 	if errCommit := tx.Commit(); errCommit != nil {
 		return nil, errCommit
@@ -128,51 +183,79 @@ func (p *processor) RecoverFromReorg(ctx context.Context, currentBlock uint64) (
 	return blockWithoutReorg, nil
 }
 
+// findBlockWithoutReorg returns the newest stored block older than currentBlock whose hash is still canonical.
+// The newest candidate is checked first, as most reorgs are shallow. Otherwise, since the reorged blocks are always
+// the newest ones, the candidates are binary searched, which also covers the sparse checkpoints kept beyond the reorg window.
 func (p *processor) findBlockWithoutReorg(ctx context.Context, tx state.Tx, currentBlock uint64, storedBlockNumbers []uint64) (*model.Block, error) {
-	blockNumberToCheck, found := getNextLowerBlockNumber(currentBlock, storedBlockNumbers)
-	if !found { // no lower block number found
-		// we get a safe block number to start from
-		return getSafeBlock(currentBlock), nil
+	candidates := getLowerBlockNumbers(currentBlock, storedBlockNumbers)
+	if len(candidates) == 0 {
+		return nil, DeepReorgError{Block: currentBlock}
 	}
 
-	blockToCheck, err := tx.GetOwnershipBlock(blockNumberToCheck)
+	newest := len(candidates) - 1
+	block, canonical, err := p.isCanonicalBlock(ctx, tx, candidates[newest])
 	if err != nil {
-		slog.Error("error retrieving block data", "blockNumber", blockNumberToCheck, "err", err.Error())
 		return nil, err
 	}
+	if canonical {
+		return block, nil
+	}
+
+	var blockWithoutReorg *model.Block
+	// candidates[low:high] are the ones not checked yet
+	low, high := 0, newest
+	for low < high {
+		mid := low + (high-low)/2
+		block, canonical, err := p.isCanonicalBlock(ctx, tx, candidates[mid])
+		if err != nil {
+			return nil, err
+		}
+		if canonical {
+			blockWithoutReorg = block
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+	if blockWithoutReorg == nil {
+		return nil, DeepReorgError{Block: currentBlock, OldestBlock: candidates[0]}
+	}
+	return blockWithoutReorg, nil
+}
+
+func (p *processor) isCanonicalBlock(ctx context.Context, tx state.Tx, blockNumber uint64) (*model.Block, bool, error) {
+	blockToCheck, err := tx.GetOwnershipBlock(blockNumber)
+	if err != nil {
+		slog.Error("error retrieving block data", "blockNumber", blockNumber, "err", err.Error())
+		return nil, false, err
+	}
 
 	err = p.checkBlockForReorg(ctx, blockToCheck)
-	switch e := err.(type) {
+	switch err.(type) {
 	case nil:
-		// no Reorg detected
-		return &blockToCheck, e
+		return &blockToCheck, true, nil
 	case ReorgError:
-		// reorg, continue checking the previous blocks
-		return p.findBlockWithoutReorg(ctx, tx, blockNumberToCheck, storedBlockNumbers)
+		return nil, false, nil
 	default:
-		// Other error occurred
-		return nil, err
+		return nil, false, err
 	}
 }
 
-func getNextLowerBlockNumber(currentBlock uint64, storedBlockNumbers []uint64) (blockNumber uint64, exist bool) {
-	var maxLowerBlock uint64
-	found := false
-
-	// Finding the maximum number lower than the current block in the copy
+// getLowerBlockNumbers returns the stored block numbers lower than currentBlock sorted from oldest to newest
+func getLowerBlockNumbers(currentBlock uint64, storedBlockNumbers []uint64) []uint64 {
+	var lowerBlockNumbers []uint64
 	for _, blockNumber := range storedBlockNumbers {
 		if blockNumber < currentBlock {
-			if !found || blockNumber > maxLowerBlock {
-				maxLowerBlock = blockNumber
-				found = true
-			}
+			lowerBlockNumbers = append(lowerBlockNumbers, blockNumber)
 		}
 	}
+	slices.Sort(lowerBlockNumbers)
+	return lowerBlockNumbers
+}
 
-	if !found {
-		return 0, false
-	}
-	return maxLowerBlock, found
+// checksReorgs tells whether the processed blocks can be reorganized, that is when the node follows the latest block or a margin behind it
+func (p *processor) checksReorgs() bool {
+	return p.finality != config.OwnershipFinalitySafe && p.finality != config.OwnershipFinalityFinalized
 }
 
 func (p *processor) checkBlockForReorg(ctx context.Context, lastBlockToCheck model.Block) error {
@@ -226,6 +309,92 @@ func (p *processor) IsEvoSyncedWithOwnership(ctx context.Context, lastOwnershipB
 }
 
 func (p *processor) ProcessUniversalBlockRange(ctx context.Context, startingBlock, lastBlock uint64) error {
+	// headers cached in the previous iteration might have been reorged
+	blockchain.ResetHeaders(p.client)
+	return p.applyBlockRange(ctx, &PrefetchedRange{StartingBlock: startingBlock, LastBlock: lastBlock})
+}
+
+// PrefetchBlockRange fetches the transfer events of the known contracts and the block headers of a block range
+// without modifying the state, so that upcoming ranges can be fetched while the previous ones are being applied.
+func (p *processor) PrefetchBlockRange(ctx context.Context, startingBlock, lastBlock uint64) (*PrefetchedRange, error) {
+	tx, err := p.stateService.NewTransaction()
+	if err != nil {
+		slog.Error("error occurred while creating transaction", "err", err.Error())
+		return nil, err
+	}
+	defer tx.Discard()
+
+	contracts, err := p.discoverer.GetContracts(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	transferEvents := make(map[uint64]map[string][]model.ERC721Transfer)
+	if len(contracts) > 0 {
+		transferEvents, err = p.updater.GetModelTransferEvents(ctx, startingBlock, lastBlock, contracts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the headers are cached for the timestamps and the hash of the last block needed to apply the range
+	if headerReader, ok := blockchain.As[blockchain.HeaderReader](p.client); ok {
+		if _, err := headerReader.HeadersByNumber(ctx, startingBlock, lastBlock); err != nil {
+			return nil, err
+		}
+	}
+
+	return &PrefetchedRange{
+		StartingBlock:  startingBlock,
+		LastBlock:      lastBlock,
+		Contracts:      contracts,
+		TransferEvents: transferEvents,
+	}, nil
+}
+
+// ApplyBlockRange applies a block range fetched by PrefetchBlockRange to the state.
+// Ranges must be applied in order, as the last block of the previous range is checked for reorgs.
+func (p *processor) ApplyBlockRange(ctx context.Context, prefetched *PrefetchedRange) error {
+	// the headers of the applied ranges are no longer needed, and the last one must be fetched again to check for reorgs
+	blockchain.PruneHeaders(p.client, prefetched.StartingBlock)
+	return p.applyBlockRange(ctx, prefetched)
+}
+
+// applyBlockRange applies the range in a single transaction. If the range does not fit in a transaction, it is split in halves
+// that are applied and committed one after the other, so that the last ownership block and the root tags always point to
+// the last committed half. A crash or an error in between leaves the first half committed, and the range is resumed after it.
+func (p *processor) applyBlockRange(ctx context.Context, prefetched *PrefetchedRange) error {
+	err := p.applyBlockRangeInTx(ctx, prefetched)
+	if !errors.Is(err, storage.ErrTxnTooBig) {
+		return err
+	}
+	if prefetched.StartingBlock == prefetched.LastBlock {
+		return fmt.Errorf("ownership block %d does not fit in a single transaction: %w", prefetched.StartingBlock, err)
+	}
+	first, second := splitBlockRange(prefetched)
+	slog.Warn("ownership block range does not fit in a single transaction, splitting it",
+		"startingBlock", prefetched.StartingBlock, "middleBlock", first.LastBlock, "lastBlock", prefetched.LastBlock)
+	if err := p.applyBlockRange(ctx, first); err != nil {
+		return err
+	}
+	return p.applyBlockRange(ctx, second)
+}
+
+// splitBlockRange splits the range in two halves that share the prefetched data, as it is looked up by block
+func splitBlockRange(prefetched *PrefetchedRange) (first, second *PrefetchedRange) {
+	middleBlock := prefetched.StartingBlock + (prefetched.LastBlock-prefetched.StartingBlock)/2
+	first, second = new(PrefetchedRange), new(PrefetchedRange)
+	*first, *second = *prefetched, *prefetched
+	first.LastBlock = middleBlock
+	second.StartingBlock = middleBlock + 1
+	return first, second
+}
+
+// applyBlockRangeInTx discovers the contracts deployed in the range, fetches the transfer events of the contracts
+// that are not prefetched and updates the state, checking that the previous range has not been reorged unless the node follows
+// the safe or finalized head.
+func (p *processor) applyBlockRangeInTx(ctx context.Context, prefetched *PrefetchedRange) error {
+	startingBlock, lastBlock := prefetched.StartingBlock, prefetched.LastBlock
 	tx, err := p.stateService.NewTransaction()
 	if err != nil {
 		slog.Error("error occurred while creating transaction", "err", err.Error())
@@ -239,6 +408,10 @@ func (p *processor) ProcessUniversalBlockRange(ctx context.Context, startingBloc
 		slog.Error("error occurred while reading ownership end range block hash", "err", err.Error())
 		return err
 	}
+	if previousLastBlockDB.Number != 0 && startingBlock != previousLastBlockDB.Number+1 {
+		return fmt.Errorf("%w: range starts at block %d and the last ownership block is %d",
+			ErrBlockRangeGap, startingBlock, previousLastBlockDB.Number)
+	}
 
 	lastBlockData, err := p.getBlockData(ctx, lastBlock)
 	if err != nil {
@@ -259,17 +432,27 @@ func (p *processor) ProcessUniversalBlockRange(ctx context.Context, startingBloc
 		}
 	}
 
+	// backfilled contracts that caught up with the previous range are applied from this one on
+	if err = backfill.Join(tx, startingBlock); err != nil {
+		return err
+	}
+
 	contracts, err := p.discoverer.GetContracts(tx)
 	if err != nil {
 		return err
 	}
 
-	transferEvents := make(map[uint64]map[string][]model.ERC721Transfer)
-	if len(contracts) > 0 {
-		transferEvents, err = p.updater.GetModelTransferEvents(ctx, startingBlock, lastBlock, contracts)
+	transferEvents := prefetched.TransferEvents
+	if transferEvents == nil {
+		transferEvents = make(map[uint64]map[string][]model.ERC721Transfer)
+	}
+	// contracts discovered after the range was prefetched
+	if missing := missingContracts(contracts, prefetched.Contracts); len(missing) > 0 {
+		missingEvents, err := p.updater.GetModelTransferEvents(ctx, startingBlock, lastBlock, missing)
 		if err != nil {
 			return err
 		}
+		transferEvents = mergeTransferEvents(transferEvents, missingEvents)
 	}
 
 	err = p.updater.UpdateState(ctx, tx, contracts, newContracts, transferEvents, startingBlock, lastBlockData)
@@ -292,7 +475,8 @@ func (p *processor) ProcessUniversalBlockRange(ctx context.Context, startingBloc
 
 	// check for reorgs
 	// During the initial iteration, no hash is stored in the database, so this code block is bypassed.
-	if (previousLastBlockDB.Hash != common.Hash{}) {
+	// The safe and finalized blocks are not expected to be reorganized, so they are not checked either.
+	if (previousLastBlockDB.Hash != common.Hash{}) && p.checksReorgs() {
 		// we check the previously stored last block and check if it is still on the same branch as the current last block
 		// otherwise we return a reorg error
 		err = p.checkBlockForReorg(ctx, previousLastBlockDB)
@@ -301,14 +485,56 @@ func (p *processor) ProcessUniversalBlockRange(ctx context.Context, startingBloc
 		}
 	}
 
+	// the block hashes are pruned along with the range that takes them out of the retention window
+	pruned := p.pruneBlockHashes(tx)
+
 	if err = tx.Commit(); err != nil {
 		slog.Error("error committing transaction", "err", err.Error())
 		return err
 	}
+	prunedBlockHashes.Add(int64(pruned))
 
 	return nil
 }
 
+// pruneBlockHashes deletes the block hashes out of the retention window and returns how many were deleted.
+// A failure does not stop the processing, as they are pruned again along with the next range.
+func (p *processor) pruneBlockHashes(tx state.Tx) int {
+	pruned, err := tx.DeleteOldStoredBlockNumbers(p.retention)
+	if err != nil {
+		blockHashPruneErrors.Add(1)
+		slog.Error("error occurred while pruning old ownership block hashes", "err", err.Error())
+	}
+	return pruned
+}
+
+// missingContracts returns the contracts that are not in prefetchedContracts
+func missingContracts(contracts, prefetchedContracts []string) []string {
+	var missing []string
+	for _, contract := range contracts {
+		if !slices.Contains(prefetchedContracts, contract) {
+			missing = append(missing, contract)
+		}
+	}
+	return missing
+}
+
+// mergeTransferEvents adds the transfer events of src to dst, which must hold the events of other contracts
+func mergeTransferEvents(dst, src map[uint64]map[string][]model.ERC721Transfer) map[uint64]map[string][]model.ERC721Transfer {
+	if len(dst) == 0 {
+		return src
+	}
+	for block, contractEvents := range src {
+		if _, ok := dst[block]; !ok {
+			dst[block] = make(map[string][]model.ERC721Transfer, len(contractEvents))
+		}
+		for contract, events := range contractEvents {
+			dst[block][contract] = events
+		}
+	}
+	return dst
+}
+
 func (p *processor) updateFirstBlockData(ctx context.Context, tx state.Tx, firstBlock uint64) error {
 	defaultBlock := model.Block{}
 	firstBlockStorage, err := tx.GetFirstOwnershipBlock()
@@ -346,10 +572,3 @@ func (p *processor) getBlockData(ctx context.Context, block uint64) (model.Block
 		Hash:      header.Hash(),
 	}, nil
 }
-
-func getSafeBlock(currentBlockNumber uint64) *model.Block {
-	if currentBlockNumber < safeBlockMargin {
-		return &model.Block{Number: 0}
-	}
-	return &model.Block{Number: currentBlockNumber - safeBlockMargin}
-}
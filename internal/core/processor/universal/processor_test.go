@@ -3,7 +3,9 @@ package universal_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -17,7 +19,9 @@ import (
 	mockClient "github.com/freeverseio/laos-universal-node/internal/platform/blockchain/mock"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	mockScan "github.com/freeverseio/laos-universal-node/internal/platform/scan/mock"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state"
 	mockTx "github.com/freeverseio/laos-universal-node/internal/platform/state/mock"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
 )
 
 func TestGetInitStartingBlock(t *testing.T) {
@@ -173,6 +177,7 @@ func TestProcessUniversalBlockRange(t *testing.T) {
 	ctx := context.TODO()
 	tests := []struct {
 		name                         string
+		finality                     string
 		startingBlock                uint64
 		previousBlockHeaderFromChain *types.Header
 		previousBlockDataFromDB      model.Block
@@ -188,11 +193,11 @@ func TestProcessUniversalBlockRange(t *testing.T) {
 			name:          "successful processing with discovery and update",
 			startingBlock: 100,
 			previousBlockHeaderFromChain: &types.Header{
-				Number: big.NewInt(90),
+				Number: big.NewInt(99),
 			},
 			previousBlockDataFromDB: model.Block{
-				Number: 90,
-				Hash:   common.HexToHash("0x62055b9639cbed71604205301891afe40ae0fe4f57ceadbf35d9a476361c48ed"),
+				Number: 99,
+				Hash:   common.HexToHash("0xd96846c9bb6d3a07b8e26d8c00c275643ff4e22412a79310650b139cacfad8b0"),
 			},
 			blockHeaderFromChain: &types.Header{
 				Number: big.NewInt(100),
@@ -211,10 +216,10 @@ func TestProcessUniversalBlockRange(t *testing.T) {
 			name:          "processing with reorg",
 			startingBlock: 100,
 			previousBlockHeaderFromChain: &types.Header{
-				Number: big.NewInt(90),
+				Number: big.NewInt(99),
 			},
 			previousBlockDataFromDB: model.Block{
-				Number: 90,
+				Number: 99,
 				Hash:   common.HexToHash("0x123"),
 			},
 			blockHeaderFromChain: &types.Header{
@@ -227,8 +232,78 @@ func TestProcessUniversalBlockRange(t *testing.T) {
 			discoverReturn: false,
 			updateReturn:   make(map[uint64]map[string][]model.ERC721Transfer),
 			expectedError: universal.ReorgError{
-				Block:       90,
-				ChainHash:   common.HexToHash("0x62055b9639cbed71604205301891afe40ae0fe4f57ceadbf35d9a476361c48ed"),
+				Block:       99,
+				ChainHash:   common.HexToHash("0xd96846c9bb6d3a07b8e26d8c00c275643ff4e22412a79310650b139cacfad8b0"),
+				StorageHash: common.HexToHash("0x123"),
+			},
+			expectedTxCommit:           0,
+			expectedNumberOfReorgCheck: 1,
+		},
+		{
+			name:          "skips the reorg check following the finalized block",
+			finality:      config.OwnershipFinalityFinalized,
+			startingBlock: 100,
+			previousBlockDataFromDB: model.Block{
+				Number: 99,
+				Hash:   common.HexToHash("0x123"),
+			},
+			blockHeaderFromChain: &types.Header{
+				Number: big.NewInt(100),
+			},
+			blockDataFromDB: model.Block{
+				Number: 100,
+				Hash:   common.HexToHash("0xb07e1289b32edefd8f3c702d016fb73c81d5950b2ebc790ad9d2cb8219066b4c"),
+			},
+			discoverReturn:             false,
+			updateReturn:               make(map[uint64]map[string][]model.ERC721Transfer),
+			expectedError:              nil,
+			expectedTxCommit:           1,
+			expectedNumberOfReorgCheck: 0,
+		},
+		{
+			name:          "skips the reorg check following the safe block",
+			finality:      config.OwnershipFinalitySafe,
+			startingBlock: 100,
+			previousBlockDataFromDB: model.Block{
+				Number: 99,
+				Hash:   common.HexToHash("0x123"),
+			},
+			blockHeaderFromChain: &types.Header{
+				Number: big.NewInt(100),
+			},
+			blockDataFromDB: model.Block{
+				Number: 100,
+				Hash:   common.HexToHash("0xb07e1289b32edefd8f3c702d016fb73c81d5950b2ebc790ad9d2cb8219066b4c"),
+			},
+			discoverReturn:             false,
+			updateReturn:               make(map[uint64]map[string][]model.ERC721Transfer),
+			expectedError:              nil,
+			expectedTxCommit:           1,
+			expectedNumberOfReorgCheck: 0,
+		},
+		{
+			name:          "checks reorgs following the latest block",
+			finality:      config.OwnershipFinalityLatest,
+			startingBlock: 100,
+			previousBlockHeaderFromChain: &types.Header{
+				Number: big.NewInt(99),
+			},
+			previousBlockDataFromDB: model.Block{
+				Number: 99,
+				Hash:   common.HexToHash("0x123"),
+			},
+			blockHeaderFromChain: &types.Header{
+				Number: big.NewInt(100),
+			},
+			blockDataFromDB: model.Block{
+				Number: 100,
+				Hash:   common.HexToHash("0xb07e1289b32edefd8f3c702d016fb73c81d5950b2ebc790ad9d2cb8219066b4c"),
+			},
+			discoverReturn: false,
+			updateReturn:   make(map[uint64]map[string][]model.ERC721Transfer),
+			expectedError: universal.ReorgError{
+				Block:       99,
+				ChainHash:   common.HexToHash("0xd96846c9bb6d3a07b8e26d8c00c275643ff4e22412a79310650b139cacfad8b0"),
 				StorageHash: common.HexToHash("0x123"),
 			},
 			expectedTxCommit:           0,
@@ -238,10 +313,10 @@ func TestProcessUniversalBlockRange(t *testing.T) {
 			name:          "successful processing with no hash in storage",
 			startingBlock: 100,
 			previousBlockHeaderFromChain: &types.Header{
-				Number: big.NewInt(90),
+				Number: big.NewInt(99),
 			},
 			previousBlockDataFromDB: model.Block{
-				Number: 90,
+				Number: 99,
 			},
 			blockHeaderFromChain: &types.Header{
 				Number: big.NewInt(100),
@@ -264,7 +339,7 @@ func TestProcessUniversalBlockRange(t *testing.T) {
 			t.Parallel()
 
 			stateService, tx, client, scanner, discoverer, updater := createMocks(t)
-			p := universal.NewProcessor(client, stateService, scanner, &config.Config{}, discoverer, updater)
+			p := universal.NewProcessor(client, stateService, scanner, &config.Config{OwnershipFinality: tt.finality}, discoverer, updater)
 			startingBlockData := model.Block{
 				Number:    100,
 				Hash:      common.HexToHash("0xb72b31eb84c4bbbbd62aff06a3c8c88991ac7c118c47aa6fba3609ed1baa8fd3"),
@@ -281,6 +356,7 @@ func TestProcessUniversalBlockRange(t *testing.T) {
 			// the last saved OwnershipBlock
 			tx.EXPECT().GetLastOwnershipBlock().Return(tt.previousBlockDataFromDB, nil)
 			discoverer.EXPECT().ShouldDiscover(tx, tt.startingBlock, tt.blockDataFromDB.Number).Return(tt.discoverReturn, nil)
+			tx.EXPECT().GetContractBackfills().Return(nil, nil)
 			discoverer.EXPECT().GetContracts(tx).Return([]string{"contract"}, nil)
 
 			updater.EXPECT().GetModelTransferEvents(ctx, tt.startingBlock, tt.blockDataFromDB.Number, []string{"contract"}).Return(tt.updateReturn, nil)
@@ -296,6 +372,7 @@ func TestProcessUniversalBlockRange(t *testing.T) {
 
 			updater.EXPECT().UpdateState(ctx, tx, []string{"contract"}, make(map[common.Address]uint64), tt.updateReturn, tt.startingBlock, tt.blockDataFromDB).Return(nil)
 
+			tx.EXPECT().DeleteOldStoredBlockNumbers(state.BlockRetention{}).Return(0, nil).Times(tt.expectedTxCommit)
 			tx.EXPECT().Commit().Return(nil).Times(tt.expectedTxCommit)
 			tx.EXPECT().Discard()
 
@@ -305,6 +382,205 @@ func TestProcessUniversalBlockRange(t *testing.T) {
 	}
 }
 
+func TestPrefetchBlockRange(t *testing.T) {
+	t.Parallel()
+	ctx := context.TODO()
+	stateService, tx, client, scanner, discoverer, updater := createMocks(t)
+	p := universal.NewProcessor(client, stateService, scanner, &config.Config{}, discoverer, updater)
+	transferEvents := map[uint64]map[string][]model.ERC721Transfer{
+		100: {"contract": {{From: common.HexToAddress("0x1"), To: common.HexToAddress("0x2")}}},
+	}
+
+	stateService.EXPECT().NewTransaction().Return(tx, nil)
+	tx.EXPECT().Discard()
+	discoverer.EXPECT().GetContracts(tx).Return([]string{"contract"}, nil)
+	updater.EXPECT().GetModelTransferEvents(ctx, uint64(100), uint64(110), []string{"contract"}).Return(transferEvents, nil)
+
+	prefetched, err := p.PrefetchBlockRange(ctx, 100, 110)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	expected := &universal.PrefetchedRange{
+		StartingBlock:  100,
+		LastBlock:      110,
+		Contracts:      []string{"contract"},
+		TransferEvents: transferEvents,
+	}
+	if !reflect.DeepEqual(prefetched, expected) {
+		t.Fatalf("got prefetched range %+v, expected %+v", prefetched, expected)
+	}
+}
+
+func TestApplyBlockRange(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		pruneErr error
+	}{
+		{
+			name: "applies the range and prunes the old block hashes",
+		},
+		{
+			name:     "commits the range when the old block hashes cannot be pruned",
+			pruneErr: errors.New("pruning error"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			testApplyBlockRange(t, tt.pruneErr)
+		})
+	}
+}
+
+func testApplyBlockRange(t *testing.T, pruneErr error) {
+	t.Helper()
+	ctx := context.TODO()
+	stateService, tx, client, scanner, discoverer, updater := createMocks(t)
+	c := &config.Config{ReorgWindow: 250, CheckpointInterval: 1000}
+	p := universal.NewProcessor(client, stateService, scanner, c, discoverer, updater)
+	lastBlockHeader := &types.Header{Number: big.NewInt(110), Time: 1100}
+	previousBlockHeader := &types.Header{Number: big.NewInt(99)}
+	previousBlockDB := model.Block{Number: 99, Hash: previousBlockHeader.Hash()}
+	lastBlockData := model.Block{Number: 110, Hash: lastBlockHeader.Hash(), Timestamp: 1100}
+	prefetchedTransfer := model.ERC721Transfer{From: common.HexToAddress("0x1"), To: common.HexToAddress("0x2")}
+	newContractTransfer := model.ERC721Transfer{From: common.HexToAddress("0x3"), To: common.HexToAddress("0x4")}
+	prefetched := &universal.PrefetchedRange{
+		StartingBlock: 100,
+		LastBlock:     110,
+		Contracts:     []string{"contract"},
+		TransferEvents: map[uint64]map[string][]model.ERC721Transfer{
+			100: {"contract": {prefetchedTransfer}},
+		},
+	}
+	newContracts := map[common.Address]uint64{common.HexToAddress("0x5"): 105}
+
+	stateService.EXPECT().NewTransaction().Return(tx, nil)
+	tx.EXPECT().Discard()
+	tx.EXPECT().GetLastOwnershipBlock().Return(previousBlockDB, nil)
+	client.EXPECT().HeaderByNumber(ctx, big.NewInt(110)).Return(lastBlockHeader, nil)
+	discoverer.EXPECT().ShouldDiscover(tx, uint64(100), uint64(110)).Return(true, nil)
+	discoverer.EXPECT().DiscoverContracts(ctx, tx, uint64(100), uint64(110)).Return(newContracts, nil)
+	tx.EXPECT().GetContractBackfills().Return(nil, nil)
+	discoverer.EXPECT().GetContracts(tx).Return([]string{"contract", "0x5"}, nil)
+	// only the events of the contract discovered after prefetching are fetched
+	updater.EXPECT().GetModelTransferEvents(ctx, uint64(100), uint64(110), []string{"0x5"}).
+		Return(map[uint64]map[string][]model.ERC721Transfer{105: {"0x5": {newContractTransfer}}}, nil)
+	updater.EXPECT().UpdateState(ctx, tx, []string{"contract", "0x5"}, newContracts, map[uint64]map[string][]model.ERC721Transfer{
+		100: {"contract": {prefetchedTransfer}},
+		105: {"0x5": {newContractTransfer}},
+	}, uint64(100), lastBlockData).Return(nil)
+	tx.EXPECT().GetFirstOwnershipBlock().Return(model.Block{Number: 1}, nil)
+	tx.EXPECT().SetLastOwnershipBlock(lastBlockData).Return(nil)
+	client.EXPECT().HeaderByNumber(ctx, big.NewInt(99)).Return(previousBlockHeader, nil)
+	tx.EXPECT().DeleteOldStoredBlockNumbers(state.BlockRetention{Blocks: 250, CheckpointInterval: 1000}).Return(10, pruneErr)
+	tx.EXPECT().Commit().Return(nil)
+
+	if err := p.ApplyBlockRange(ctx, prefetched); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+}
+
+func TestApplyBlockRangeTooBig(t *testing.T) {
+	t.Parallel()
+	ctx := context.TODO()
+	// block n is mined at n*10
+	header := func(number uint64) *types.Header {
+		return &types.Header{Number: new(big.Int).SetUint64(number), Time: number * 10}
+	}
+	blockData := func(number uint64) model.Block {
+		return model.Block{Number: number, Hash: header(number).Hash(), Timestamp: number * 10}
+	}
+	// expectRange expects the range to be applied in a transaction, which is committed unless updateErr is returned
+	expectRange := func(tx *mockTx.MockTx, discoverer *mockDiscoverer.MockDiscoverer, updater *mockUpdater.MockUpdater,
+		previousBlock, startingBlock, lastBlock uint64, updateErr error,
+	) {
+		tx.EXPECT().GetLastOwnershipBlock().Return(blockData(previousBlock), nil)
+		discoverer.EXPECT().ShouldDiscover(tx, startingBlock, lastBlock).Return(false, nil)
+		tx.EXPECT().GetContractBackfills().Return(nil, nil)
+		discoverer.EXPECT().GetContracts(tx).Return([]string{"contract"}, nil)
+		updater.EXPECT().UpdateState(ctx, tx, []string{"contract"}, map[common.Address]uint64{},
+			gomock.Any(), startingBlock, blockData(lastBlock)).Return(updateErr)
+		if updateErr != nil {
+			return
+		}
+		tx.EXPECT().GetFirstOwnershipBlock().Return(model.Block{Number: 1}, nil)
+		tx.EXPECT().SetLastOwnershipBlock(blockData(lastBlock)).Return(nil)
+		tx.EXPECT().DeleteOldStoredBlockNumbers(state.BlockRetention{}).Return(0, nil)
+		tx.EXPECT().Commit().Return(nil)
+	}
+
+	t.Run("splits the range and commits every half", func(t *testing.T) {
+		t.Parallel()
+		stateService, tx, client, scanner, discoverer, updater := createMocks(t)
+		p := universal.NewProcessor(client, stateService, scanner, &config.Config{}, discoverer, updater)
+		client.EXPECT().HeaderByNumber(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, number *big.Int) (*types.Header, error) {
+			return header(number.Uint64()), nil
+		}).AnyTimes()
+		stateService.EXPECT().NewTransaction().Return(tx, nil).Times(3)
+		tx.EXPECT().Discard().Times(3)
+		expectRange(tx, discoverer, updater, 99, 100, 101, fmt.Errorf("error tagging root: %w", storage.ErrTxnTooBig))
+		expectRange(tx, discoverer, updater, 99, 100, 100, nil)
+		// the second half is checked for reorgs against the first one
+		expectRange(tx, discoverer, updater, 100, 101, 101, nil)
+
+		err := p.ApplyBlockRange(ctx, &universal.PrefetchedRange{StartingBlock: 100, LastBlock: 101, Contracts: []string{"contract"}})
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+	})
+
+	t.Run("fails when a single block does not fit", func(t *testing.T) {
+		t.Parallel()
+		stateService, tx, client, scanner, discoverer, updater := createMocks(t)
+		p := universal.NewProcessor(client, stateService, scanner, &config.Config{}, discoverer, updater)
+		client.EXPECT().HeaderByNumber(ctx, big.NewInt(100)).Return(header(100), nil)
+		stateService.EXPECT().NewTransaction().Return(tx, nil)
+		tx.EXPECT().Discard()
+		expectRange(tx, discoverer, updater, 99, 100, 100, storage.ErrTxnTooBig)
+
+		err := p.ApplyBlockRange(ctx, &universal.PrefetchedRange{StartingBlock: 100, LastBlock: 100, Contracts: []string{"contract"}})
+		if !errors.Is(err, storage.ErrTxnTooBig) {
+			t.Fatalf(`got error "%v" when storage.ErrTxnTooBig was expected`, err)
+		}
+	})
+}
+
+func TestApplyBlockRangeGap(t *testing.T) {
+	t.Parallel()
+	ctx := context.TODO()
+	tests := []struct {
+		name          string
+		previousBlock uint64
+	}{
+		{
+			name:          "rejects a range applied twice",
+			previousBlock: 105,
+		},
+		{
+			name:          "rejects a range skipping blocks",
+			previousBlock: 90,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stateService, tx, client, scanner, discoverer, updater := createMocks(t)
+			p := universal.NewProcessor(client, stateService, scanner, &config.Config{}, discoverer, updater)
+			stateService.EXPECT().NewTransaction().Return(tx, nil)
+			tx.EXPECT().Discard()
+			tx.EXPECT().GetLastOwnershipBlock().Return(model.Block{Number: tt.previousBlock, Hash: common.HexToHash("0x1")}, nil)
+
+			err := p.ApplyBlockRange(ctx, &universal.PrefetchedRange{StartingBlock: 100, LastBlock: 110})
+			if !errors.Is(err, universal.ErrBlockRangeGap) {
+				t.Fatalf(`got error "%v" when universal.ErrBlockRangeGap was expected`, err)
+			}
+		})
+	}
+}
+
 func TestIsEvoSyncedWithOwnership(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -406,17 +682,6 @@ func TestRecoverFromReorg(t *testing.T) {
 			checkoutError: nil,
 			expectedError: nil,
 		},
-		{
-			name:                     "successful reorg recovery with no blocks to checkout",
-			startingBlock:            100,
-			numberOfRecursions:       0,
-			safeBlockNumber:          0,
-			checkReorgError:          nil,
-			getAllStoredBlockNumbers: []uint64{100},
-			getAllContracts:          []string{"contract1", "contract2"},
-			checkoutError:            nil,
-			expectedError:            nil,
-		},
 		{
 			name:                     "successful reorg recovery with 2 recursions",
 			startingBlock:            100,
@@ -448,6 +713,46 @@ func TestRecoverFromReorg(t *testing.T) {
 			checkoutError: nil,
 			expectedError: nil,
 		},
+		{
+			name:                     "successful reorg recovery binary searching the stored blocks",
+			startingBlock:            100,
+			safeBlockNumber:          92,
+			numberOfRecursions:       3,
+			getAllStoredBlockNumbers: []uint64{100, 98, 96, 94, 92, 90},
+			getBlockHeadersDB: []*types.Header{
+				{Number: big.NewInt(98), Time: 99},
+				{Number: big.NewInt(94), Time: 99},
+				{Number: big.NewInt(92), Time: 100},
+			},
+			getBlockHeadersL1: []*types.Header{
+				{Number: big.NewInt(98), Time: 88},
+				{Number: big.NewInt(94), Time: 88},
+				{Number: big.NewInt(92), Time: 100},
+			},
+		},
+		{
+			name:                     "refuses to recover with no blocks to checkout",
+			startingBlock:            100,
+			numberOfRecursions:       0,
+			getAllStoredBlockNumbers: []uint64{100},
+			getAllContracts:          []string{"contract1", "contract2"},
+			expectedError:            universal.DeepReorgError{Block: 100},
+		},
+		{
+			name:                     "refuses to recover when the reorg is deeper than the retained blocks",
+			startingBlock:            100,
+			numberOfRecursions:       2,
+			getAllStoredBlockNumbers: []uint64{100, 98, 95},
+			getBlockHeadersDB: []*types.Header{
+				{Number: big.NewInt(98), Time: 99},
+				{Number: big.NewInt(95), Time: 99},
+			},
+			getBlockHeadersL1: []*types.Header{
+				{Number: big.NewInt(98), Time: 88},
+				{Number: big.NewInt(95), Time: 88},
+			},
+			expectedError: universal.DeepReorgError{Block: 100, OldestBlock: 95},
+		},
 	}
 
 	for _, tt := range tests {
@@ -461,7 +766,6 @@ func TestRecoverFromReorg(t *testing.T) {
 			}
 			stateService.EXPECT().NewTransaction().Return(tx, nil).Times(1)
 			tx.EXPECT().Discard().Times(1)
-			tx.EXPECT().Commit().Times(1)
 			tx.EXPECT().GetAllStoredBlockNumbers().Return(tt.getAllStoredBlockNumbers, nil).Times(1)
 			for i := 0; i < int(tt.numberOfRecursions); i++ {
 				block := tt.getBlockHeadersDB[i]
@@ -471,17 +775,30 @@ func TestRecoverFromReorg(t *testing.T) {
 				}, nil).Times(1)
 			}
 
+			p := universal.NewProcessor(client, stateService, nil, &config.Config{}, nil, nil)
+			if tt.expectedError != nil {
+				block, err := p.RecoverFromReorg(ctx, tt.startingBlock)
+				if err == nil || err.Error() != tt.expectedError.Error() {
+					t.Fatalf("RecoverFromReorg() error = %v, wantErr %v", err, tt.expectedError)
+				}
+				if block != nil {
+					t.Fatalf("RecoverFromReorg() block = %v, want nil", block)
+				}
+				return
+			}
+
+			tx.EXPECT().Commit().Times(1)
 			tx.EXPECT().SetLastOwnershipBlock(gomock.Any()).Return(nil).Times(1)
 			tx.EXPECT().DeleteOrphanBlockData(tt.safeBlockNumber).Return(nil).Times(1)
 			tx.EXPECT().DeleteOrphanRootTags(int64(tt.safeBlockNumber)+1, int64(tt.startingBlock)).Return(nil).Times(1)
 			tx.EXPECT().SetLastMappedOwnershipBlockNumber(gomock.Any()).Return(nil).Times(1)
 
 			tx.EXPECT().Checkout(int64(tt.safeBlockNumber)).Return(tt.checkoutError).Times(1)
+			tx.EXPECT().GetContractBackfills().Return(nil, nil).Times(1)
 
-			p := universal.NewProcessor(client, stateService, nil, &config.Config{}, nil, nil)
 			block, err := p.RecoverFromReorg(ctx, tt.startingBlock)
-			if (err != nil) != (tt.expectedError != nil) {
-				t.Errorf("RecoverFromReorg() error = %v, wantErr %v", err, tt.expectedError)
+			if err != nil {
+				t.Fatalf("RecoverFromReorg() error = %v, wantErr %v", err, tt.expectedError)
 			}
 			if block.Number != tt.safeBlockNumber {
 				t.Errorf("RecoverFromReorg() block = %v, want %v", block, tt.safeBlockNumber)
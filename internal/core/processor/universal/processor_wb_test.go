@@ -20,59 +20,44 @@ import (
 	"go.uber.org/mock/gomock"
 )
 
-func TestGetNextLowerBlockNumber(t *testing.T) {
+func TestGetLowerBlockNumbers(t *testing.T) {
 	testCases := []struct {
 		name                  string
 		currentBlock          uint64
 		storedBlockNumbers    []uint64
-		expectedBlockNumber   uint64
+		expectedBlockNumbers  []uint64
 		expectedModifiedSlice []uint64
-		expectedFound         bool
 	}{
 		{
-			name:                  "FindLowerBlock",
+			name:                  "FindLowerBlocks",
 			currentBlock:          5,
-			storedBlockNumbers:    []uint64{3, 4, 5, 6, 7},
-			expectedBlockNumber:   4,
-			expectedModifiedSlice: []uint64{3, 4, 5, 6, 7},
-			expectedFound:         true,
+			storedBlockNumbers:    []uint64{7, 6, 5, 4, 3},
+			expectedBlockNumbers:  []uint64{3, 4},
+			expectedModifiedSlice: []uint64{7, 6, 5, 4, 3},
 		},
 		{
-			name:                  "FindLowerBlock",
-			currentBlock:          7,
-			storedBlockNumbers:    []uint64{3, 4, 5, 6, 7},
-			expectedBlockNumber:   6,
-			expectedModifiedSlice: []uint64{3, 4, 5, 6, 7},
-			expectedFound:         true,
-		},
-		{
-			name:                  "FindLowerBlock",
+			name:                  "FindLowerBlocksWithGaps",
 			currentBlock:          9,
-			storedBlockNumbers:    []uint64{3, 4, 5, 6, 8},
-			expectedBlockNumber:   8,
-			expectedModifiedSlice: []uint64{3, 4, 5, 6, 8},
-			expectedFound:         true,
+			storedBlockNumbers:    []uint64{8, 6, 5, 4, 3},
+			expectedBlockNumbers:  []uint64{3, 4, 5, 6, 8},
+			expectedModifiedSlice: []uint64{8, 6, 5, 4, 3},
 		},
 		{
 			name:                  "BlockNotFound",
 			currentBlock:          3,
-			storedBlockNumbers:    []uint64{3, 4, 5, 6, 8},
-			expectedBlockNumber:   0,
-			expectedModifiedSlice: []uint64{3, 4, 5, 6, 8},
-			expectedFound:         false,
+			storedBlockNumbers:    []uint64{8, 6, 5, 4, 3},
+			expectedBlockNumbers:  nil,
+			expectedModifiedSlice: []uint64{8, 6, 5, 4, 3},
 		},
 	}
 
 	for _, tc := range testCases {
 		tc := tc // Capture range variable
 		t.Run(tc.name, func(t *testing.T) {
-			gotBlockNumber, found := getNextLowerBlockNumber(tc.currentBlock, tc.storedBlockNumbers)
+			gotBlockNumbers := getLowerBlockNumbers(tc.currentBlock, tc.storedBlockNumbers)
 
-			if tc.expectedFound != found {
-				t.Errorf("got %v, expected %v", found, tc.expectedFound)
-			}
-			if gotBlockNumber != tc.expectedBlockNumber {
-				t.Errorf("got %v, expected %v", gotBlockNumber, tc.expectedBlockNumber)
+			if !reflect.DeepEqual(gotBlockNumbers, tc.expectedBlockNumbers) {
+				t.Errorf("got %v, expected %v", gotBlockNumbers, tc.expectedBlockNumbers)
 			}
 			if !reflect.DeepEqual(tc.storedBlockNumbers, tc.expectedModifiedSlice) {
 				t.Errorf("slice was modified to %v, expected %v", tc.storedBlockNumbers, tc.expectedModifiedSlice)
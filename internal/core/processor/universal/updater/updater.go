@@ -6,7 +6,6 @@ import (
 	"log/slog"
 	"math/big"
 	"strings"
-	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 
@@ -101,6 +100,10 @@ func (u *updater) UpdateState(
 
 	for block := startingBlock; block <= lastBlockData.Number; block++ {
 		blockTime := blockTimestamps[block]
+		// the block mapper looks up the indexed timestamps instead of searching them on chain
+		if err := tx.SetOwnershipBlockTimestamp(model.Block{Number: block, Timestamp: blockTime}); err != nil {
+			return fmt.Errorf("error occurred indexing the timestamp of block %d: %w", block, err)
+		}
 		for _, contract := range contracts {
 			if blockWhenDiscovered, ok := newContracts[common.HexToAddress(contract)]; ok {
 				if block < blockWhenDiscovered {
@@ -136,6 +139,19 @@ func UpdateContract(tx state.Tx,
 		return fmt.Errorf("error occurred while loading merkle trees for contract %s: %w", contract, err)
 	}
 
+	if err := ApplyEvents(tx, contract, evoEvents, transferEvents); err != nil {
+		return err
+	}
+
+	return tx.UpdateContractState(common.HexToAddress(contract), evoBlock)
+}
+
+// ApplyEvents mints the evo events and applies the transfer events to the contract trees loaded in tx
+func ApplyEvents(tx state.Tx,
+	contract string,
+	evoEvents []model.MintedWithExternalURI,
+	transferEvents []model.ERC721Transfer,
+) error {
 	for i := range evoEvents {
 		mintEvent := evoEvents[i]
 		if err := tx.Mint(common.HexToAddress(contract), &mintEvent); err != nil {
@@ -149,8 +165,7 @@ func UpdateContract(tx state.Tx,
 			return fmt.Errorf("error occurred while updating state with transfer event %v: %w", transferEvent, err)
 		}
 	}
-
-	return tx.UpdateContractState(common.HexToAddress(contract), evoBlock)
+	return nil
 }
 
 func GetEvoEvents(tx state.Tx, contract string, blockTime uint64) (uint64, []model.MintedWithExternalURI, error) {
@@ -163,8 +178,12 @@ func GetEvoEvents(tx state.Tx, contract string, blockTime uint64) (uint64, []mod
 	if err != nil {
 		return 0, nil, fmt.Errorf("error occurred retrieving the last processed evo block for ownership contract %s: %w", contract, err)
 	}
-	evoBlock := accountData.LastProcessedEvoBlock
+	return GetEvoEventsFrom(tx, contract, collection, accountData.LastProcessedEvoBlock, blockTime)
+}
 
+// GetEvoEventsFrom returns the events minted in the collection of the contract after evoBlock until blockTime,
+// and the last evo block they were minted in
+func GetEvoEventsFrom(tx state.Tx, contract string, collection common.Address, evoBlock, blockTime uint64) (uint64, []model.MintedWithExternalURI, error) {
 	evoBlockTimestamp := uint64(0)
 	evoEvents := make([]model.MintedWithExternalURI, 0)
 	for evoBlockTimestamp < blockTime {
@@ -183,76 +202,43 @@ func GetEvoEvents(tx state.Tx, contract string, blockTime uint64) (uint64, []mod
 				contract, collection.String(), err)
 		}
 
-		// we get timestamp from event 0 because we don't store evo block separately in db.
-		// TODO store evo block data in db when scanning evo chain. recalling block timestamp
-		// should be faster then from the function that reads all events
-		evoBlockTimestamp = mintedEvents[0].Timestamp
+		evoBlockTimestamp, err = getEvoBlockTimestamp(tx, newBlock, mintedEvents)
+		if err != nil {
+			return 0, nil, err
+		}
 		evoBlock = newBlock
 		evoEvents = append(evoEvents, mintedEvents...)
 	}
 	return evoBlock, evoEvents, nil
 }
 
-// GetBlockTimestampsParallel returns a map of block numbers to timestamps in parallel.
-// This can increase the speed of this code 2-3x
-// TODO we should see if we can get further improvements if instead of making calls in parallel we made one call in
-// a batch for all block numbers in a range. possibly this could execute faster because it is only one request
+// getEvoBlockTimestamp returns the timestamp of the stored evo block. Blocks processed before evo blocks were stored
+// are not found, so their timestamp is taken from their minted events.
+func getEvoBlockTimestamp(tx state.Tx, blockNumber uint64, mintedEvents []model.MintedWithExternalURI) (uint64, error) {
+	block, err := tx.GetEvoBlock(blockNumber)
+	if err != nil {
+		return 0, fmt.Errorf("error occurred retrieving evo block %d: %w", blockNumber, err)
+	}
+	if block.Timestamp == 0 && len(mintedEvents) > 0 {
+		return mintedEvents[0].Timestamp, nil
+	}
+	return block.Timestamp, nil
+}
+
+// GetBlockTimestampsParallel returns a map of block numbers to timestamps, fetching the headers with blockchain.HeadersByNumber
 func GetBlockTimestampsParallel(
 	ctx context.Context,
 	client blockchain.EthClient,
 	startingBlock,
 	lastBlock uint64,
 ) (map[uint64]uint64, error) {
-	var wg sync.WaitGroup
-	timestampsChan := make(chan map[uint64]uint64, lastBlock-startingBlock+1)
-	errCh := make(chan error, lastBlock-startingBlock+1)
-
-	for blockNumber := startingBlock; blockNumber <= lastBlock; blockNumber++ {
-		wg.Add(1)
-		go getBlockTimestamp(ctx, client, blockNumber, &wg, timestampsChan, errCh)
-	}
-
-	// Close the channel when all goroutines are done
-	go func() {
-		wg.Wait()
-		close(timestampsChan)
-		close(errCh)
-	}()
-
-	timestamps := make(map[uint64]uint64)
-
-	for err := range errCh {
-		if err != nil {
-			return nil, err
-		}
+	headers, err := blockchain.HeadersByNumber(ctx, client, startingBlock, lastBlock)
+	if err != nil {
+		return nil, err
 	}
-	// Collect results from the channel
-	for result := range timestampsChan {
-		for blockNumber, timestamp := range result {
-			timestamps[blockNumber] = timestamp
-		}
+	timestamps := make(map[uint64]uint64, len(headers))
+	for blockNumber, header := range headers {
+		timestamps[blockNumber] = header.Time
 	}
-
 	return timestamps, nil
 }
-
-func getBlockTimestamp(ctx context.Context,
-	client blockchain.EthClient,
-	blockNumber uint64,
-	wg *sync.WaitGroup,
-	timestamps chan<- map[uint64]uint64,
-	errCh chan<- error,
-) {
-	defer wg.Done()
-
-	header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
-	if err != nil {
-		errCh <- err
-		return
-	}
-
-	timestamp := header.Time
-
-	// Send the result through the channel
-	timestamps <- map[uint64]uint64{blockNumber: timestamp}
-}
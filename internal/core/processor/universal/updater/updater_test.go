@@ -82,6 +82,8 @@ func TestGetEvoEvents(t *testing.T) {
 		}, nil)
 		tx.EXPECT().GetNextEvoEventBlock(common.HexToAddress("0x4444").String(), uint64(351)).Return(uint64(352), nil)
 		tx.EXPECT().GetMintedWithExternalURIEvents(common.HexToAddress("0x4444").String(), uint64(352)).Return(events, nil)
+		// the evo block was processed before evo blocks were stored, so its timestamp is taken from its events
+		tx.EXPECT().GetEvoBlock(uint64(352)).Return(model.Block{}, nil)
 
 		evoBlock, events, err := uUpdater.GetEvoEvents(tx, "0x000005555", uint64(352))
 		assertError(t, err, nil)
@@ -92,6 +94,33 @@ func TestGetEvoEvents(t *testing.T) {
 			t.Fatal("wrong number of events")
 		}
 	})
+
+	t.Run("walks the stored evo blocks until the block time", func(t *testing.T) {
+		t.Parallel()
+
+		tx, _, _ := createMocks(t)
+
+		collection := common.HexToAddress("0x4444").String()
+		tx.EXPECT().GetCollectionAddress("0x000005555").Return(common.HexToAddress("0x4444"), nil)
+		tx.EXPECT().AccountData(common.HexToAddress("0x000005555")).Return(&account.AccountData{
+			LastProcessedEvoBlock: 351,
+		}, nil)
+		tx.EXPECT().GetNextEvoEventBlock(collection, uint64(351)).Return(uint64(352), nil)
+		tx.EXPECT().GetMintedWithExternalURIEvents(collection, uint64(352)).Return(getMockMintedEvents(352, 352), nil)
+		tx.EXPECT().GetEvoBlock(uint64(352)).Return(model.Block{Number: 352, Timestamp: 100}, nil)
+		tx.EXPECT().GetNextEvoEventBlock(collection, uint64(352)).Return(uint64(360), nil)
+		tx.EXPECT().GetMintedWithExternalURIEvents(collection, uint64(360)).Return(getMockMintedEvents(360, 360), nil)
+		tx.EXPECT().GetEvoBlock(uint64(360)).Return(model.Block{Number: 360, Timestamp: 148}, nil)
+
+		evoBlock, events, err := uUpdater.GetEvoEvents(tx, "0x000005555", uint64(120))
+		assertError(t, err, nil)
+		if evoBlock != uint64(360) {
+			t.Fatalf(`wrong evo block got %v expected %v"`, evoBlock, uint64(360))
+		}
+		if len(events) != 2 {
+			t.Fatalf("got %d events, expected 2", len(events))
+		}
+	})
 }
 
 func TestUpdateContract(t *testing.T) {
@@ -165,6 +194,26 @@ func TestGetBlockTimestampsParallel(t *testing.T) {
 		_, err := uUpdater.GetBlockTimestampsParallel(context.Background(), client, 10, 10)
 		assertError(t, err, fmt.Errorf("some error"))
 	})
+	t.Run("get block timestamps in a batch when the client is a header reader", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		headerReader := mockClient.NewMockHeaderReader(ctrl)
+		client := struct {
+			*mockClient.MockEthClient
+			*mockClient.MockHeaderReader
+		}{mockClient.NewMockEthClient(ctrl), headerReader}
+
+		headerReader.EXPECT().HeadersByNumber(context.Background(), uint64(10), uint64(11)).Return(map[uint64]*types.Header{
+			10: {Time: 100, Number: big.NewInt(10)},
+			11: {Time: 110, Number: big.NewInt(11)},
+		}, nil)
+
+		blockTimestamps, err := uUpdater.GetBlockTimestampsParallel(context.Background(), client, 10, 11)
+		assertError(t, err, nil)
+		if len(blockTimestamps) != 2 || blockTimestamps[10] != 100 || blockTimestamps[11] != 110 {
+			t.Fatalf("got timestamps %v, expected map[10:100 11:110]", blockTimestamps)
+		}
+	})
 }
 
 func TestUpdateState(t *testing.T) {
@@ -192,6 +241,8 @@ func TestUpdateState(t *testing.T) {
 		LastProcessedEvoBlock: 351,
 	}, nil).Times(1)
 	tx.EXPECT().GetNextEvoEventBlock(common.HexToAddress("0x04").String(), uint64(351)).Return(uint64(0), nil).Times(1)
+	tx.EXPECT().SetOwnershipBlockTimestamp(model.Block{Number: 100, Timestamp: 10}).Return(nil)
+	tx.EXPECT().SetOwnershipBlockTimestamp(model.Block{Number: 101, Timestamp: 11}).Return(nil)
 	tx.EXPECT().TagRoot(int64(100)).Return(nil).Times(1)
 	tx.EXPECT().TagRoot(int64(101)).Return(nil).Times(1)
 
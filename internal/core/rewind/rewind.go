@@ -0,0 +1,151 @@
+package rewind
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/freeverseio/laos-universal-node/internal/core/processor/universal/backfill"
+	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state"
+)
+
+// EvoPointer describes how the last processed evo block of a contract changes after rewinding
+type EvoPointer struct {
+	Contract string
+	From     uint64
+	To       uint64
+}
+
+// Summary describes all the data that is deleted or reset when rewinding the state
+type Summary struct {
+	FromBlock        uint64
+	ToBlock          model.Block
+	OldestBlock      uint64
+	DeletedBlocks    []uint64
+	DeletedRootTags  uint64
+	LastMappedBefore uint64
+	LastMappedAfter  uint64
+	DeletedMappings  uint64
+	EvoPointers      []EvoPointer
+}
+
+func (s *Summary) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "rewinding ownership state from block %d to block %d (oldest retained block: %d)\n", s.FromBlock, s.ToBlock.Number, s.OldestBlock)
+	fmt.Fprintf(&b, "  stored ownership block hashes to delete: %d\n", len(s.DeletedBlocks))
+	fmt.Fprintf(&b, "  root tags to delete: %d (blocks %d to %d)\n", s.DeletedRootTags, s.ToBlock.Number+1, s.FromBlock)
+	fmt.Fprintf(&b, "  ownership-evo block mappings to delete: %d (last mapped block %d -> %d)\n", s.DeletedMappings, s.LastMappedBefore, s.LastMappedAfter)
+	fmt.Fprintf(&b, "  contracts whose consumed evo events are rewound: %d\n", len(s.EvoPointers))
+	for _, p := range s.EvoPointers {
+		fmt.Fprintf(&b, "    %s: last processed evo block %d -> %d\n", p.Contract, p.From, p.To)
+	}
+	return b.String()
+}
+
+// Rewind resets the ownership state of tx to toBlock. It sets toBlock as the last ownership block and the last mapped block,
+// deletes the block hashes, root tags and block mappings after toBlock, and checks out the merkle trees tagged at toBlock,
+// which also restores the evo blocks consumed by every contract. Contract backfills replayed after toBlock are rewound too.
+// Rewind does not commit tx: the caller decides whether to commit or discard the changes after inspecting the summary.
+func Rewind(tx state.Tx, toBlock uint64) (*Summary, error) {
+	lastBlock, err := tx.GetLastOwnershipBlock()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving the last ownership block: %w", err)
+	}
+	if toBlock >= lastBlock.Number {
+		return nil, fmt.Errorf("block %d is not older than the last processed ownership block %d", toBlock, lastBlock.Number)
+	}
+
+	// stored block numbers are sorted from newest to oldest
+	storedBlockNumbers, err := tx.GetAllStoredBlockNumbers()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving the stored ownership block numbers: %w", err)
+	}
+	if len(storedBlockNumbers) == 0 {
+		return nil, fmt.Errorf("no ownership block history is retained")
+	}
+	oldestBlock := storedBlockNumbers[len(storedBlockNumbers)-1]
+	if toBlock < oldestBlock {
+		return nil, fmt.Errorf("block %d is older than the retained history, the oldest retained block is %d", toBlock, oldestBlock)
+	}
+
+	summary := &Summary{
+		FromBlock:   lastBlock.Number,
+		ToBlock:     model.Block{Number: toBlock},
+		OldestBlock: oldestBlock,
+	}
+	for _, blockNumber := range storedBlockNumbers {
+		if blockNumber > toBlock {
+			summary.DeletedBlocks = append(summary.DeletedBlocks, blockNumber)
+		}
+	}
+	// block data is only stored at the end of each processed range, if there is none for toBlock
+	// the hash is left empty and the reorg check is skipped for the next range (same as RecoverFromReorg)
+	storedBlock, err := tx.GetOwnershipBlock(toBlock)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving ownership block %d: %w", toBlock, err)
+	}
+	if storedBlock.Number == toBlock {
+		summary.ToBlock = storedBlock
+	}
+	summary.DeletedRootTags = lastBlock.Number - toBlock
+
+	summary.LastMappedBefore, err = tx.GetLastMappedOwnershipBlockNumber()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving the last mapped ownership block: %w", err)
+	}
+	summary.LastMappedAfter = min(summary.LastMappedBefore, toBlock)
+	summary.DeletedMappings = summary.LastMappedBefore - summary.LastMappedAfter
+
+	contracts := tx.GetAllERC721UniversalContracts()
+	evoBlocksBefore := make(map[string]uint64, len(contracts))
+	for _, contract := range contracts {
+		accountData, err := tx.AccountData(common.HexToAddress(contract))
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving account data for contract %s: %w", contract, err)
+		}
+		evoBlocksBefore[contract] = accountData.LastProcessedEvoBlock
+	}
+
+	if err := tx.Checkout(int64(toBlock)); err != nil {
+		return nil, fmt.Errorf("error checking out state at block %d: %w", toBlock, err)
+	}
+
+	for _, contract := range contracts {
+		accountData, err := tx.AccountData(common.HexToAddress(contract))
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving account data for contract %s: %w", contract, err)
+		}
+		if accountData.LastProcessedEvoBlock != evoBlocksBefore[contract] {
+			summary.EvoPointers = append(summary.EvoPointers, EvoPointer{
+				Contract: contract,
+				From:     evoBlocksBefore[contract],
+				To:       accountData.LastProcessedEvoBlock,
+			})
+		}
+	}
+
+	slog.Debug("rewinding state", "fromBlock", summary.FromBlock, "toBlock", toBlock)
+	if err := tx.SetLastOwnershipBlock(summary.ToBlock); err != nil {
+		return nil, err
+	}
+	if err := tx.DeleteOrphanBlockData(toBlock); err != nil {
+		return nil, err
+	}
+	if err := tx.DeleteOrphanRootTags(int64(toBlock)+1, int64(lastBlock.Number)); err != nil {
+		return nil, err
+	}
+	if err := tx.DeleteOrphanMappedBlocks(summary.LastMappedAfter); err != nil {
+		return nil, err
+	}
+	if err := tx.SetLastMappedOwnershipBlockNumber(summary.LastMappedAfter); err != nil {
+		return nil, err
+	}
+	if err := backfill.Rewind(tx, toBlock); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,214 @@
+package rewind_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/freeverseio/laos-universal-node/internal/core/rewind"
+	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state"
+	v1 "github.com/freeverseio/laos-universal-node/internal/platform/state/v1"
+	badgerStorage "github.com/freeverseio/laos-universal-node/internal/platform/storage/badger"
+)
+
+var contract = common.HexToAddress("0x500")
+
+func TestRewind(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name                string
+		toBlock             uint64
+		expectedErr         string
+		expectedLastBlock   model.Block
+		expectedLastMapped  uint64
+		expectedEvoPointers []rewind.EvoPointer
+		expectedTotalSupply int64
+	}{
+		{
+			name:                "rewinds to a block in the middle of a range",
+			toBlock:             15,
+			expectedLastBlock:   model.Block{Number: 15},
+			expectedLastMapped:  15,
+			expectedEvoPointers: []rewind.EvoPointer{{Contract: contract.String(), From: 7, To: 5}},
+			expectedTotalSupply: 1,
+		},
+		{
+			name:                "rewinds to the end of a stored range",
+			toBlock:             10,
+			expectedLastBlock:   model.Block{Number: 10, Timestamp: 100, Hash: common.HexToHash("0x10")},
+			expectedLastMapped:  10,
+			expectedEvoPointers: []rewind.EvoPointer{{Contract: contract.String(), From: 7, To: 5}},
+			expectedTotalSupply: 1,
+		},
+		{
+			name:        "refuses to rewind to a block older than the retained history",
+			toBlock:     9,
+			expectedErr: "block 9 is older than the retained history, the oldest retained block is 10",
+		},
+		{
+			name:        "refuses to rewind to the last processed block",
+			toBlock:     20,
+			expectedErr: "block 20 is not older than the last processed ownership block 20",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			db := createBadger(t)
+			populateState(t, db)
+
+			tx := createTransaction(t, db)
+			summary, err := rewind.Rewind(tx, tt.toBlock)
+			if tt.expectedErr != "" {
+				if err == nil || err.Error() != tt.expectedErr {
+					t.Fatalf(`got error "%v", expected "%s"`, err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			if len(summary.EvoPointers) != len(tt.expectedEvoPointers) {
+				t.Fatalf("got %d evo pointers, expected %d", len(summary.EvoPointers), len(tt.expectedEvoPointers))
+			}
+			for i := range tt.expectedEvoPointers {
+				if summary.EvoPointers[i] != tt.expectedEvoPointers[i] {
+					t.Fatalf("got evo pointer %v, expected %v", summary.EvoPointers[i], tt.expectedEvoPointers[i])
+				}
+			}
+			if summary.DeletedRootTags != 20-tt.toBlock {
+				t.Fatalf("got %d root tags to delete, expected %d", summary.DeletedRootTags, 20-tt.toBlock)
+			}
+			if err := tx.Commit(); err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+
+			tx = createTransaction(t, db)
+			defer tx.Discard()
+			lastBlock, err := tx.GetLastOwnershipBlock()
+			if err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			if lastBlock != tt.expectedLastBlock {
+				t.Fatalf("got last ownership block %v, expected %v", lastBlock, tt.expectedLastBlock)
+			}
+			lastMapped, err := tx.GetLastMappedOwnershipBlockNumber()
+			if err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			if lastMapped != tt.expectedLastMapped {
+				t.Fatalf("got last mapped block %d, expected %d", lastMapped, tt.expectedLastMapped)
+			}
+			mapped, err := tx.GetMappedEvoBlockNumber(tt.toBlock + 1)
+			if err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			if mapped != 0 {
+				t.Fatalf("got mapped evo block %d after the rewound block, expected none", mapped)
+			}
+			if err := tx.Checkout(int64(tt.toBlock) + 1); err == nil {
+				t.Fatalf("got no error checking out a deleted root tag")
+			}
+			if err := tx.LoadContractTrees(contract); err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			totalSupply, err := tx.TotalSupply(contract)
+			if err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			if totalSupply != tt.expectedTotalSupply {
+				t.Fatalf("got total supply %d, expected %d", totalSupply, tt.expectedTotalSupply)
+			}
+		})
+	}
+}
+
+// populateState processes two ranges (10 and 11-20) with one mint each and maps all the processed blocks
+func populateState(t *testing.T, db *badger.DB) {
+	t.Helper()
+	tx := createTransaction(t, db)
+	err := tx.StoreERC721UniversalContracts([]model.ERC721UniversalContract{{Address: contract, CollectionAddress: common.HexToAddress("0x501")}})
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	mint(t, tx, 1, 5)
+	if err := tx.TagRoot(10); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if err := tx.SetLastOwnershipBlock(model.Block{Number: 10, Timestamp: 100, Hash: common.HexToHash("0x10")}); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	for block := int64(11); block < 20; block++ {
+		if err := tx.TagRoot(block); err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+	}
+	mint(t, tx, 2, 7)
+	if err := tx.TagRoot(20); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if err := tx.SetLastOwnershipBlock(model.Block{Number: 20, Timestamp: 200, Hash: common.HexToHash("0x20")}); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	for block := uint64(10); block <= 20; block++ {
+		if err := tx.SetOwnershipEvoBlockMapping(block, block/2); err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+	}
+	if err := tx.SetLastMappedOwnershipBlockNumber(20); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+}
+
+func mint(t *testing.T, tx state.Tx, tokenId int64, evoBlock uint64) {
+	t.Helper()
+	if err := tx.LoadContractTrees(contract); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	err := tx.Mint(contract, &model.MintedWithExternalURI{
+		Slot:     big.NewInt(1),
+		To:       common.HexToAddress("0x1"),
+		TokenURI: "tokenURI",
+		TokenId:  big.NewInt(tokenId),
+	})
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if err := tx.UpdateContractState(contract, evoBlock); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+}
+
+func createBadger(t *testing.T) *badger.DB {
+	t.Helper()
+	db, err := badger.Open(
+		badger.DefaultOptions("").
+			WithInMemory(true).
+			WithLoggingLevel(badger.ERROR))
+	if err != nil {
+		t.Fatalf("error initializing storage: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("error closing db: %v", err)
+		}
+	})
+	return db
+}
+
+func createTransaction(t *testing.T, db *badger.DB) state.Tx {
+	t.Helper()
+	tx, err := v1.NewStateService(badgerStorage.NewService(db)).NewTransaction()
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	return tx
+}
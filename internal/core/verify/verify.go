@@ -0,0 +1,267 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	contractUpdater "github.com/freeverseio/laos-universal-node/internal/core/processor/universal/updater"
+	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/tree/account"
+	v1 "github.com/freeverseio/laos-universal-node/internal/platform/state/v1"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/memory"
+)
+
+// Divergence describes the first inconsistency found in the state
+type Divergence struct {
+	Block    uint64
+	Contract string
+	Reason   string
+}
+
+func (d *Divergence) String() string {
+	return fmt.Sprintf("state diverges at block %d for contract %s: %s", d.Block, d.Contract, d.Reason)
+}
+
+// replayTx is a fresh in-memory state transaction that reads the contracts and the stored evo events from the live state
+type replayTx struct {
+	state.Tx
+	live state.Tx
+}
+
+func (r replayTx) GetCollectionAddress(contract string) (common.Address, error) {
+	return r.live.GetCollectionAddress(contract)
+}
+
+func (r replayTx) GetNextEvoEventBlock(contract string, blockNumber uint64) (uint64, error) {
+	return r.live.GetNextEvoEventBlock(contract, blockNumber)
+}
+
+func (r replayTx) GetMintedWithExternalURIEvents(contract string, blockNumber uint64) ([]model.MintedWithExternalURI, error) {
+	return r.live.GetMintedWithExternalURIEvents(contract, blockNumber)
+}
+
+type Replayer struct {
+	updater     contractUpdater.Updater
+	blocksRange uint64
+}
+
+func NewReplayer(updater contractUpdater.Updater, blocksRange uint64) *Replayer {
+	return &Replayer{
+		updater:     updater,
+		blocksRange: max(blocksRange, 1),
+	}
+}
+
+// Replay rebuilds the state from scratch into an in-memory storage, applying the stored evo events and the ownership
+// Transfer logs block by block through the same path used by the universal processor. After every block in [fromBlock, toBlock]
+// the account data of every contract is compared against the one tagged in the live state.
+// Since the state is rebuilt from scratch, the replay always starts at the first processed ownership block.
+// It returns the first divergence found, or nil if the replayed state matches the live one.
+func (r *Replayer) Replay(ctx context.Context, live state.Service, fromBlock, toBlock uint64) (*Divergence, error) {
+	liveTx, err := live.NewTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("error creating a new transaction: %w", err)
+	}
+	defer liveTx.Discard()
+
+	firstBlock, lastBlock, err := processedRange(liveTx)
+	if err != nil {
+		return nil, err
+	}
+	if toBlock == 0 || toBlock > lastBlock {
+		toBlock = lastBlock
+	}
+	fromBlock = max(fromBlock, firstBlock)
+
+	memTx, err := v1.NewStateService(memory.New()).NewTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("error creating in-memory transaction: %w", err)
+	}
+	defer memTx.Discard()
+	tx := replayTx{Tx: memTx, live: liveTx}
+
+	contracts := liveTx.GetAllERC721UniversalContracts()
+	// contracts are only replayed from the block they have state in the live DB, as they were
+	// skipped by the universal processor before being discovered
+	active := make(map[string]bool, len(contracts))
+
+	slog.Info("replaying ownership state", "firstBlock", firstBlock, "fromBlock", fromBlock, "toBlock", toBlock, "contracts", len(contracts))
+	for start := firstBlock; start <= toBlock; start += r.blocksRange {
+		end := min(start+r.blocksRange-1, toBlock)
+		transferEvents := make(map[uint64]map[string][]model.ERC721Transfer)
+		if len(contracts) > 0 {
+			transferEvents, err = r.updater.GetModelTransferEvents(ctx, start, end, contracts)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for block := start; block <= end; block++ {
+			if err := liveTx.Checkout(int64(block)); err != nil {
+				return nil, fmt.Errorf("error checking out live state at block %d: %w", block, err)
+			}
+			liveData := make(map[string]*account.AccountData, len(contracts))
+			var activeContracts []string
+			for _, contract := range contracts {
+				liveData[contract], err = liveTx.AccountData(common.HexToAddress(contract))
+				if err != nil {
+					return nil, err
+				}
+				if !active[contract] && *liveData[contract] != (account.AccountData{}) {
+					active[contract] = true
+				}
+				if active[contract] {
+					activeContracts = append(activeContracts, contract)
+				}
+			}
+
+			err = r.updater.UpdateState(ctx, tx, activeContracts, nil, transferEvents, block, model.Block{Number: block})
+			if err != nil {
+				return nil, fmt.Errorf("error replaying block %d: %w", block, err)
+			}
+
+			if block < fromBlock {
+				continue
+			}
+			for _, contract := range contracts {
+				replayData, err := tx.AccountData(common.HexToAddress(contract))
+				if err != nil {
+					return nil, err
+				}
+				if *replayData != *liveData[contract] {
+					return &Divergence{
+						Block:    block,
+						Contract: contract,
+						Reason:   fmt.Sprintf("replayed account data %+v does not match stored account data %+v", *replayData, *liveData[contract]),
+					}, nil
+				}
+			}
+		}
+		slog.Debug("replayed block range", "startingBlock", start, "lastBlock", end)
+	}
+
+	return nil, nil
+}
+
+// CheckInvariants checks the internal invariants of the trees of every contract for every block in [fromBlock, toBlock]:
+// the enumeratedtotal indexes are contiguous, every TokenData.Idx points back to its index, and the enumerated
+// balances and tokens of every owner match the ownership tree.
+// It returns the first divergence found, or nil if all the invariants hold.
+func CheckInvariants(tx state.Tx, fromBlock, toBlock uint64) (*Divergence, error) {
+	_, lastBlock, err := processedRange(tx)
+	if err != nil {
+		return nil, err
+	}
+	if toBlock == 0 || toBlock > lastBlock {
+		toBlock = lastBlock
+	}
+	if fromBlock == 0 {
+		fromBlock = toBlock
+	}
+
+	contracts := tx.GetAllERC721UniversalContracts()
+	slog.Info("checking state invariants", "fromBlock", fromBlock, "toBlock", toBlock, "contracts", len(contracts))
+	for block := fromBlock; block <= toBlock; block++ {
+		if err := tx.Checkout(int64(block)); err != nil {
+			return nil, fmt.Errorf("error checking out state at block %d: %w", block, err)
+		}
+		for _, contract := range contracts {
+			reason, err := checkContractInvariants(tx, common.HexToAddress(contract))
+			if err != nil {
+				return nil, fmt.Errorf("error checking invariants of contract %s at block %d: %w", contract, block, err)
+			}
+			if reason != "" {
+				return &Divergence{Block: block, Contract: contract, Reason: reason}, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// checkContractInvariants returns the reason why the invariants do not hold, or an empty string if they do
+func checkContractInvariants(tx state.Tx, contract common.Address) (string, error) {
+	if err := tx.LoadContractTrees(contract); err != nil {
+		return "", err
+	}
+
+	totalSupply, err := tx.TotalSupply(contract)
+	if err != nil {
+		return "", err
+	}
+
+	ownedTokens := make(map[common.Address]map[string]bool)
+	seen := make(map[string]bool, totalSupply)
+	for idx := 0; idx < int(totalSupply); idx++ {
+		tokenId, err := tx.TokenByIndex(contract, idx)
+		if err != nil {
+			return "", err
+		}
+		if tokenId == nil {
+			return fmt.Sprintf("enumeratedtotal index %d is empty with total supply %d", idx, totalSupply), nil
+		}
+		if seen[tokenId.String()] {
+			return fmt.Sprintf("token %s is enumerated more than once", tokenId.String()), nil
+		}
+		seen[tokenId.String()] = true
+
+		tokenData, err := tx.TokenData(contract, tokenId)
+		if err != nil {
+			return "", err
+		}
+		if !tokenData.Minted {
+			return fmt.Sprintf("token %s at enumeratedtotal index %d is not minted", tokenId.String(), idx), nil
+		}
+		if tokenData.Idx != idx {
+			return fmt.Sprintf("token %s at enumeratedtotal index %d points to index %d", tokenId.String(), idx, tokenData.Idx), nil
+		}
+
+		if _, ok := ownedTokens[tokenData.SlotOwner]; !ok {
+			ownedTokens[tokenData.SlotOwner] = make(map[string]bool)
+		}
+		ownedTokens[tokenData.SlotOwner][tokenId.String()] = true
+	}
+
+	for owner, tokens := range ownedTokens {
+		balance, err := tx.BalanceOf(contract, owner)
+		if err != nil {
+			return "", err
+		}
+		if balance.Cmp(big.NewInt(int64(len(tokens)))) != 0 {
+			return fmt.Sprintf("enumerated balance of owner %s is %s but owns %d tokens in the ownership tree",
+				owner.String(), balance.String(), len(tokens)), nil
+		}
+		for idx := 0; idx < len(tokens); idx++ {
+			tokenId, err := tx.TokenOfOwnerByIndex(contract, owner, idx)
+			if err != nil {
+				return "", err
+			}
+			if !tokens[tokenId.String()] {
+				return fmt.Sprintf("enumerated token %s at index %d of owner %s is not owned in the ownership tree",
+					tokenId.String(), idx, owner.String()), nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+func processedRange(tx state.Tx) (firstBlock, lastBlock uint64, err error) {
+	first, err := tx.GetFirstOwnershipBlock()
+	if err != nil {
+		return 0, 0, fmt.Errorf("error retrieving the first ownership block: %w", err)
+	}
+	last, err := tx.GetLastOwnershipBlock()
+	if err != nil {
+		return 0, 0, fmt.Errorf("error retrieving the last ownership block: %w", err)
+	}
+	if last.Number == 0 {
+		return 0, 0, fmt.Errorf("no ownership block has been processed")
+	}
+	return first.Number, last.Number, nil
+}
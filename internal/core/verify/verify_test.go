@@ -0,0 +1,267 @@
+package verify_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.uber.org/mock/gomock"
+
+	uUpdater "github.com/freeverseio/laos-universal-node/internal/core/processor/universal/updater"
+	"github.com/freeverseio/laos-universal-node/internal/core/verify"
+	mockClient "github.com/freeverseio/laos-universal-node/internal/platform/blockchain/mock"
+	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+	"github.com/freeverseio/laos-universal-node/internal/platform/scan"
+	mockScan "github.com/freeverseio/laos-universal-node/internal/platform/scan/mock"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state"
+	mockTx "github.com/freeverseio/laos-universal-node/internal/platform/state/mock"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/tree/ownership"
+	v1 "github.com/freeverseio/laos-universal-node/internal/platform/state/v1"
+	badgerStorage "github.com/freeverseio/laos-universal-node/internal/platform/storage/badger"
+)
+
+var (
+	contract   = common.HexToAddress("0x500")
+	collection = common.HexToAddress("0x501")
+	owner      = common.HexToAddress("0x1")
+	receiver   = common.HexToAddress("0x2")
+)
+
+const (
+	firstBlock = uint64(1)
+	lastBlock  = uint64(6)
+)
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+	t.Run("replayed state matches the live state", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+		stateService := createStateService(t)
+		updater := createUpdater(t, ctx)
+		populateLiveState(t, ctx, stateService, updater)
+
+		divergence, err := verify.NewReplayer(updater, 3).Replay(ctx, stateService, 0, 0)
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if divergence != nil {
+			t.Fatalf("got divergence %s when no divergence was expected", divergence.String())
+		}
+	})
+
+	t.Run("reports the first divergent block", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+		stateService := createStateService(t)
+		updater := createUpdater(t, ctx)
+		populateLiveState(t, ctx, stateService, updater)
+
+		// corrupt the live state from block 5 on by minting a token that has no evo event
+		tx, err := stateService.NewTransaction()
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		mint(t, tx, 3, 4)
+		for block := int64(5); block <= int64(lastBlock); block++ {
+			if err := tx.TagRoot(block); err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+
+		divergence, err := verify.NewReplayer(updater, 3).Replay(ctx, stateService, 0, 0)
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if divergence == nil {
+			t.Fatalf("got no divergence when a divergence was expected")
+		}
+		if divergence.Block != 5 || divergence.Contract != strings.ToLower(contract.String()) {
+			t.Fatalf("got divergence at block %d for contract %s, expected block 5 and contract %s",
+				divergence.Block, divergence.Contract, strings.ToLower(contract.String()))
+		}
+	})
+}
+
+func TestCheckInvariants(t *testing.T) {
+	t.Parallel()
+	t.Run("invariants hold for a consistent state", func(t *testing.T) {
+		t.Parallel()
+		ctx := context.Background()
+		stateService := createStateService(t)
+		populateLiveState(t, ctx, stateService, createUpdater(t, ctx))
+
+		tx, err := stateService.NewTransaction()
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		defer tx.Discard()
+		divergence, err := verify.CheckInvariants(tx, firstBlock, 0)
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if divergence != nil {
+			t.Fatalf("got divergence %s when no divergence was expected", divergence.String())
+		}
+	})
+
+	t.Run("reports a token whose index does not point back to it", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		tx := mockTx.NewMockTx(ctrl)
+
+		tx.EXPECT().GetFirstOwnershipBlock().Return(model.Block{Number: 1}, nil)
+		tx.EXPECT().GetLastOwnershipBlock().Return(model.Block{Number: 10}, nil)
+		tx.EXPECT().GetAllERC721UniversalContracts().Return([]string{contract.String()})
+		tx.EXPECT().Checkout(int64(10)).Return(nil)
+		tx.EXPECT().LoadContractTrees(contract).Return(nil)
+		tx.EXPECT().TotalSupply(contract).Return(int64(1), nil)
+		tx.EXPECT().TokenByIndex(contract, 0).Return(big.NewInt(7), nil)
+		tx.EXPECT().TokenData(contract, big.NewInt(7)).Return(&ownership.TokenData{SlotOwner: owner, Minted: true, Idx: 3}, nil)
+
+		divergence, err := verify.CheckInvariants(tx, 0, 0)
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		expected := "state diverges at block 10 for contract " + contract.String() + ": token 7 at enumeratedtotal index 0 points to index 3"
+		if divergence == nil || divergence.String() != expected {
+			t.Fatalf(`got divergence "%v", expected "%s"`, divergence, expected)
+		}
+	})
+
+	t.Run("reports an enumerated balance that does not match the ownership tree", func(t *testing.T) {
+		t.Parallel()
+		ctrl := gomock.NewController(t)
+		tx := mockTx.NewMockTx(ctrl)
+
+		tx.EXPECT().GetFirstOwnershipBlock().Return(model.Block{Number: 1}, nil)
+		tx.EXPECT().GetLastOwnershipBlock().Return(model.Block{Number: 10}, nil)
+		tx.EXPECT().GetAllERC721UniversalContracts().Return([]string{contract.String()})
+		tx.EXPECT().Checkout(int64(10)).Return(nil)
+		tx.EXPECT().LoadContractTrees(contract).Return(nil)
+		tx.EXPECT().TotalSupply(contract).Return(int64(1), nil)
+		tx.EXPECT().TokenByIndex(contract, 0).Return(big.NewInt(7), nil)
+		tx.EXPECT().TokenData(contract, big.NewInt(7)).Return(&ownership.TokenData{SlotOwner: owner, Minted: true, Idx: 0}, nil)
+		tx.EXPECT().BalanceOf(contract, owner).Return(big.NewInt(2), nil)
+
+		divergence, err := verify.CheckInvariants(tx, 0, 0)
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		expected := "state diverges at block 10 for contract " + contract.String() +
+			": enumerated balance of owner " + owner.String() + " is 2 but owns 1 tokens in the ownership tree"
+		if divergence == nil || divergence.String() != expected {
+			t.Fatalf(`got divergence "%v", expected "%s"`, divergence, expected)
+		}
+	})
+}
+
+// populateLiveState processes blocks 1 to 6 through the updater: two tokens minted on the evochain (evo blocks 2 and 3)
+// and a transfer of the first token at block 4
+func populateLiveState(t *testing.T, ctx context.Context, stateService state.Service, updater uUpdater.Updater) {
+	t.Helper()
+	tx, err := stateService.NewTransaction()
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	err = tx.StoreERC721UniversalContracts([]model.ERC721UniversalContract{{Address: contract, CollectionAddress: collection}})
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	for i, evoBlock := range []uint64{2, 3} {
+		event := &model.MintedWithExternalURI{
+			Slot:        big.NewInt(1),
+			To:          owner,
+			TokenURI:    "tokenURI",
+			TokenId:     big.NewInt(int64(i + 1)),
+			BlockNumber: evoBlock,
+			Timestamp:   evoBlock * 10,
+		}
+		if err := tx.StoreMintedWithExternalURIEvent(collection.String(), event); err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if err := tx.SetNextEvoEventBlock(collection.String(), evoBlock); err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+	}
+
+	contracts := tx.GetAllERC721UniversalContracts()
+	transferEvents, err := updater.GetModelTransferEvents(ctx, firstBlock, lastBlock, contracts)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	err = updater.UpdateState(ctx, tx, contracts, nil, transferEvents, firstBlock, model.Block{Number: lastBlock})
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if err := tx.SetFirstOwnershipBlock(model.Block{Number: firstBlock}); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if err := tx.SetLastOwnershipBlock(model.Block{Number: lastBlock}); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+}
+
+func mint(t *testing.T, tx state.Tx, tokenId int64, evoBlock uint64) {
+	t.Helper()
+	if err := tx.LoadContractTrees(contract); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	err := tx.Mint(contract, &model.MintedWithExternalURI{Slot: big.NewInt(1), To: owner, TokenId: big.NewInt(tokenId)})
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if err := tx.UpdateContractState(contract, evoBlock); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+}
+
+// createUpdater returns an updater whose ownership block N has timestamp N*10 and whose only Transfer log
+// moves token 1 from owner to receiver at block 4
+func createUpdater(t *testing.T, ctx context.Context) uUpdater.Updater {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	client := mockClient.NewMockEthClient(ctrl)
+	scanner := mockScan.NewMockScanner(ctrl)
+
+	client.EXPECT().HeaderByNumber(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, number *big.Int) (*types.Header, error) {
+			return &types.Header{Number: number, Time: number.Uint64() * 10}, nil
+		}).AnyTimes()
+	scanner.EXPECT().ScanEvents(ctx, gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, fromBlock, toBlock *big.Int, _ []string) ([]scan.Event, error) {
+			if fromBlock.Uint64() > 4 || toBlock.Uint64() < 4 {
+				return nil, nil
+			}
+			return []scan.Event{scan.EventTransfer{From: owner, To: receiver, TokenId: big.NewInt(1), BlockNumber: 4, Contract: contract}}, nil
+		}).AnyTimes()
+
+	return uUpdater.New(client, scanner)
+}
+
+func createStateService(t *testing.T) state.Service {
+	t.Helper()
+	db, err := badger.Open(
+		badger.DefaultOptions("").
+			WithInMemory(true).
+			WithLoggingLevel(badger.ERROR))
+	if err != nil {
+		t.Fatalf("error initializing storage: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("error closing db: %v", err)
+		}
+	})
+	return v1.NewStateService(badgerStorage.NewService(db))
+}
@@ -0,0 +1,59 @@
+package backfill
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/freeverseio/laos-universal-node/internal/core/processor/universal/backfill"
+	shared "github.com/freeverseio/laos-universal-node/internal/core/worker"
+)
+
+type Worker interface {
+	Run(ctx context.Context) error
+}
+
+type worker struct {
+	processor   backfill.Processor
+	waitingTime time.Duration
+	backoff     *shared.Backoff
+}
+
+func New(waitingTime time.Duration, processor backfill.Processor) Worker {
+	return &worker{
+		processor:   processor,
+		waitingTime: waitingTime,
+		backoff:     shared.NewBackoff(shared.InitialBackoff, shared.MaxBackoff),
+	}
+}
+
+func (w *worker) Run(ctx context.Context) error {
+	slog.Info("starting backfill worker")
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("context canceled")
+			return nil
+		default:
+			if err := w.executeBackfills(ctx); err != nil {
+				// conflicts with the ranges applied meanwhile by the universal worker are retried as well
+				slog.Error("error occurred while backfilling contracts", "err", err)
+				w.backoff.Wait(ctx)
+				break
+			}
+			w.backoff.Reset()
+		}
+	}
+}
+
+func (w *worker) executeBackfills(ctx context.Context) error {
+	replayed, err := w.processor.ProcessBackfills(ctx)
+	if err != nil {
+		return err
+	}
+	if !replayed {
+		slog.Debug("no contract backfill behind the last processed ownership block, waiting...")
+		shared.Wait(ctx, w.waitingTime)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	backfillMock "github.com/freeverseio/laos-universal-node/internal/core/processor/universal/backfill/mock"
+)
+
+func TestExecuteBackfills(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		replayed      bool
+		processorErr  error
+		expectedError error
+	}{
+		{
+			name:     "backfills are replayed",
+			replayed: true,
+		},
+		{
+			name:     "no backfill is behind the last processed block",
+			replayed: false,
+		},
+		{
+			name:          "ProcessBackfills returns an error",
+			processorErr:  fmt.Errorf("backfill error"),
+			expectedError: fmt.Errorf("backfill error"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			processor := backfillMock.NewMockProcessor(ctrl)
+			processor.EXPECT().ProcessBackfills(context.Background()).Return(tt.replayed, tt.processorErr)
+
+			w := worker{
+				processor:   processor,
+				waitingTime: time.Nanosecond,
+			}
+			err := w.executeBackfills(context.Background())
+			if tt.expectedError == nil && err != nil {
+				t.Fatalf("got error '%v' while no error was expected", err)
+			}
+			if tt.expectedError != nil && (err == nil || err.Error() != tt.expectedError.Error()) {
+				t.Fatalf("got error '%v' while error '%v' was expected", err, tt.expectedError)
+			}
+		})
+	}
+}
@@ -16,12 +16,14 @@ type Worker interface {
 type worker struct {
 	processor   blockmapper.Processor
 	waitingTime time.Duration
+	backoff     *shared.Backoff
 }
 
 func New(waitingTime time.Duration, processor blockmapper.Processor) Worker {
 	return &worker{
 		processor:   processor,
 		waitingTime: waitingTime,
+		backoff:     shared.NewBackoff(shared.InitialBackoff, shared.MaxBackoff),
 	}
 }
 
@@ -34,7 +36,10 @@ func (w *worker) Run(ctx context.Context) error {
 		default:
 			if err := w.executeMapping(ctx); err != nil {
 				slog.Error("error occurred while performing block mapping", "err", err)
+				w.backoff.Wait(ctx)
+				break
 			}
+			w.backoff.Reset()
 		}
 	}
 }
@@ -49,7 +54,7 @@ func (w *worker) executeMapping(ctx context.Context) error {
 		shared.Wait(ctx, w.waitingTime)
 		return nil
 	}
-	err = w.processor.MapNextBlock(ctx)
+	err = w.processor.MapNextBlocks(ctx)
 	if err != nil {
 		return err
 	}
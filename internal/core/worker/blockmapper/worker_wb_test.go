@@ -18,7 +18,7 @@ func TestExecuteMapping(t *testing.T) {
 		defer ctrl.Finish()
 
 		blockMapper.EXPECT().IsMappingSyncedWithProcessing().Return(false, nil)
-		blockMapper.EXPECT().MapNextBlock(context.Background()).Return(nil)
+		blockMapper.EXPECT().MapNextBlocks(context.Background()).Return(nil)
 
 		w := worker{
 			processor:   blockMapper,
@@ -72,7 +72,7 @@ func TestExecuteMapping(t *testing.T) {
 		defer ctrl.Finish()
 
 		blockMapper.EXPECT().IsMappingSyncedWithProcessing().Return(false, nil)
-		blockMapper.EXPECT().MapNextBlock(context.Background()).Return(expectedErr)
+		blockMapper.EXPECT().MapNextBlocks(context.Background()).Return(expectedErr)
 
 		w := worker{
 			processor:   blockMapper,
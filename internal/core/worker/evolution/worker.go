@@ -18,12 +18,15 @@ type Worker interface {
 type worker struct {
 	waitingTime time.Duration
 	processor   evolution.Processor
+	backoff     *shared.Backoff
 }
 
 func New(c *config.Config, processor evolution.Processor) Worker {
 	return &worker{
-		waitingTime: c.WaitingTime,
+		// the evolution worker waits for the finalized head of the LAOS parachain to move on
+		waitingTime: c.WaitingRPCRequestTime,
 		processor:   processor,
+		backoff:     shared.NewBackoff(shared.InitialBackoff, shared.MaxBackoff),
 	}
 }
 
@@ -51,8 +54,18 @@ func (w *worker) Run(ctx context.Context) error {
 					slog.Info("***********************************************************************************************")
 					return reorgErr
 				}
+				w.backoff.Wait(ctx)
+				if ctx.Err() != nil {
+					break
+				}
+				// a range split in several transactions might have been partially committed, so the worker resumes after the last committed block
+				if startingBlock, err = w.processor.GetInitStartingBlock(ctx); err != nil {
+					slog.Error("error occurred while reading the block to resume from", "err", err.Error())
+					return err
+				}
 				break
 			}
+			w.backoff.Reset()
 
 			startingBlock = lastBlock + 1
 		}
@@ -65,7 +78,7 @@ func executeEvoBlockRange(ctx context.Context, w *worker, startingBlock uint64)
 		return 0, err
 	}
 	if lastBlock < startingBlock {
-		slog.Debug("evolution worker, last calculated block is behind starting block, waiting...",
+		slog.Debug("evolution worker, last finalized block is behind starting block, waiting...",
 			"lastBlock", lastBlock, "startingBlock", startingBlock)
 		shared.Wait(ctx, w.waitingTime)
 		return startingBlock - 1, nil // return lastBlock from previous range to avoid skipping a block
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/freeverseio/laos-universal-node/internal/config"
 	"github.com/freeverseio/laos-universal-node/internal/core/processor/evolution"
 	evoProcessMock "github.com/freeverseio/laos-universal-node/internal/core/processor/evolution/mock"
 
@@ -143,3 +144,30 @@ func assertError(t *testing.T, expectedError, err error) {
 		}
 	}
 }
+
+func TestRunResumesAfterLastCommittedBlock(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	processor := evoProcessMock.NewMockProcessor(gomock.NewController(t))
+	w := New(&config.Config{}, processor)
+
+	// the first half of the range 100-110 is committed before the second half fails
+	processor.EXPECT().GetInitStartingBlock(ctx).Return(uint64(100), nil)
+	processor.EXPECT().GetLastBlock(ctx, uint64(100)).Return(uint64(110), nil)
+	processor.EXPECT().VerifyChainConsistency(ctx, uint64(100)).Return(nil)
+	processor.EXPECT().ProcessEvoBlockRange(ctx, uint64(100), uint64(110)).Return(errors.New("error scanning events"))
+	processor.EXPECT().GetInitStartingBlock(ctx).Return(uint64(106), nil)
+	processor.EXPECT().GetLastBlock(ctx, uint64(106)).Return(uint64(110), nil)
+	processor.EXPECT().VerifyChainConsistency(ctx, uint64(106)).Return(nil)
+	processor.EXPECT().ProcessEvoBlockRange(ctx, uint64(106), uint64(110)).
+		DoAndReturn(func(context.Context, uint64, uint64) error {
+			cancel()
+			return nil
+		})
+
+	if err := w.Run(ctx); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+}
@@ -13,3 +13,34 @@ func Wait(ctx context.Context, waitingTime time.Duration) {
 	case <-timer.C:
 	}
 }
+
+const (
+	InitialBackoff = time.Second
+	MaxBackoff     = time.Minute
+)
+
+// Backoff is the waiting time between retries after errors, which doubles on every consecutive error up to a maximum
+type Backoff struct {
+	initial time.Duration
+	max     time.Duration
+	next    time.Duration
+}
+
+func NewBackoff(initial, max time.Duration) *Backoff {
+	return &Backoff{
+		initial: initial,
+		max:     max,
+		next:    initial,
+	}
+}
+
+// Wait waits for the current backoff and doubles it for the next consecutive error
+func (b *Backoff) Wait(ctx context.Context) {
+	Wait(ctx, b.next)
+	b.next = min(b.next*2, b.max)
+}
+
+// Reset restores the initial backoff after a successful execution
+func (b *Backoff) Reset() {
+	b.next = b.initial
+}
@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/freeverseio/laos-universal-node/internal/core/processor/universal"
+)
+
+type fetchResult struct {
+	prefetched *universal.PrefetchedRange
+	err        error
+}
+
+// catchUp processes the block ranges from startingBlock while there are blocks to process, fetching up to catchUpDepth
+// upcoming ranges in parallel while the previous ones are applied. Ranges are applied in order by a single applier,
+// which checks for reorgs at every range boundary.
+// It stops when the chain head is reached, the evolution chain is behind or an error occurs, and returns the last applied block.
+func (w *worker) catchUp(ctx context.Context, startingBlock uint64) (lastAppliedBlock uint64, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	// every range is handed through its own channel, which receives its data once fetched, so that ranges are applied in order
+	ranges := make(chan chan fetchResult, w.catchUpDepth)
+	go w.fetchRanges(ctx, startingBlock, ranges)
+	defer func() {
+		// stop fetching and wait for the fetchers to finish
+		cancel()
+		for range ranges {
+		}
+	}()
+
+	lastAppliedBlock = startingBlock - 1
+	for result := range ranges {
+		fetched := <-result
+		if fetched.err != nil {
+			return lastAppliedBlock, contextError(ctx, fetched.err)
+		}
+		prefetched := fetched.prefetched
+		slog.Debug("applying prefetched block range", "startingBlock", prefetched.StartingBlock, "lastBlock", prefetched.LastBlock)
+		if err := w.processor.ApplyBlockRange(ctx, prefetched); err != nil {
+			return lastAppliedBlock, contextError(ctx, err)
+		}
+		lastAppliedBlock = prefetched.LastBlock
+	}
+	return lastAppliedBlock, nil
+}
+
+// fetchRanges sends the upcoming block ranges to ranges in order and fetches them in parallel. The capacity of ranges
+// bounds the number of ranges fetched ahead. A range is only fetched if the evolution chain is synced with its last block,
+// so no prefetched range is discarded when the evolution chain is behind. It closes ranges once the chain head is reached,
+// the evolution chain is behind, fetching a range fails or ctx is canceled, and all the fetchers have finished.
+func (w *worker) fetchRanges(ctx context.Context, startingBlock uint64, ranges chan<- chan fetchResult) {
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		close(ranges)
+	}()
+
+	for ctx.Err() == nil {
+		lastBlock, ok, err := w.nextRange(ctx, startingBlock)
+		if err == nil && !ok {
+			return
+		}
+		result := make(chan fetchResult, 1)
+		if err != nil {
+			result <- fetchResult{err: err}
+		}
+
+		select {
+		case ranges <- result:
+		case <-ctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+
+		wg.Add(1)
+		go func(startingBlock, lastBlock uint64) {
+			defer wg.Done()
+			prefetched, err := w.processor.PrefetchBlockRange(ctx, startingBlock, lastBlock)
+			result <- fetchResult{prefetched: prefetched, err: err}
+		}(startingBlock, lastBlock)
+		startingBlock = lastBlock + 1
+	}
+}
+
+// nextRange returns the last block of the range from startingBlock, and false when the chain head is reached or the evolution
+// chain is not synced with the range. The evolution chain only moves forward, so the range stays synced until it is applied.
+func (w *worker) nextRange(ctx context.Context, startingBlock uint64) (lastBlock uint64, ok bool, err error) {
+	lastBlock, err = w.processor.GetLastBlock(ctx, startingBlock)
+	if err != nil || lastBlock < startingBlock {
+		return lastBlock, false, err
+	}
+	evoSynced, err := w.processor.IsEvoSyncedWithOwnership(ctx, lastBlock)
+	if err != nil {
+		slog.Error("error occurred while checking if evolution chain is synced with ownership chain", "err", err.Error())
+		return lastBlock, false, err
+	}
+	if !evoSynced {
+		slog.Debug("evolution chain is not synced with ownership chain, stopping catch-up", "lastBlock", lastBlock)
+	}
+	return lastBlock, evoSynced, nil
+}
+
+// contextError returns nil if err is caused by the cancellation of ctx, as the worker is stopping
+func contextError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
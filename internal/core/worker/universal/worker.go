@@ -16,16 +16,20 @@ type Worker interface {
 }
 
 type worker struct {
-	waitingTime time.Duration
-	processor   universal.Processor
+	waitingTime  time.Duration
+	catchUpDepth uint
+	processor    universal.Processor
+	backoff      *shared.Backoff
 }
 
 func New(c *config.Config,
 	processor universal.Processor,
 ) Worker {
 	w := &worker{
-		waitingTime: c.WaitingTime,
-		processor:   processor,
+		waitingTime:  c.WaitingTime,
+		catchUpDepth: c.CatchUpDepth,
+		processor:    processor,
+		backoff:      shared.NewBackoff(shared.InitialBackoff, shared.MaxBackoff),
 	}
 
 	return w
@@ -46,27 +50,34 @@ func (w *worker) Run(ctx context.Context) error {
 			slog.Info("context canceled")
 			return nil
 		default:
+			if w.catchUpDepth > 0 && evoSynced {
+				lastAppliedBlock, err := w.catchUp(ctx, startingBlock)
+				progressed := lastAppliedBlock+1 != startingBlock
+				startingBlock = lastAppliedBlock + 1
+				if err != nil {
+					if startingBlock, err = w.handleError(ctx, err, startingBlock); err != nil {
+						return err
+					}
+					break
+				}
+				if progressed || ctx.Err() != nil {
+					w.backoff.Reset()
+					break
+				}
+				// the chain head is reached or the evolution chain is behind, blocks are processed one range at a time
+			}
+
 			slog.Debug("executing block range", "startingBlock", startingBlock, "lastBlock", lastBlock, "evoSynced", evoSynced)
 			prevLastBlock, wasEvoSynced, err := w.executeUniversalBlockRange(ctx, evoSynced, startingBlock, lastBlock)
 			if err != nil {
-				slog.Error("error occurred while processing universal block range", "err", err.Error())
-				var reorgErr universal.ReorgError
-				if errors.As(err, &reorgErr) {
-					slog.Error("ownership chain reorganization detected",
-						"blockNumber", reorgErr.Block,
-						"chainHash", reorgErr.ChainHash.String(),
-						"storageHash", reorgErr.StorageHash.String())
-					blockWithouReorg, err := w.processor.RecoverFromReorg(ctx, reorgErr.Block)
-					if err != nil {
-						slog.Error("error occurred while recovering from reorg", "err", err.Error())
-						return err
-					}
-					slog.Info("recovered successfully from reorg: HURRAY!")
-					startingBlock = blockWithouReorg.Number
-					lastBlock = blockWithouReorg.Number
+				if startingBlock, err = w.handleError(ctx, err, startingBlock); err != nil {
+					return err
 				}
+				// the last block is calculated again for the block to resume from
+				evoSynced = true
 				break
 			}
+			w.backoff.Reset()
 
 			evoSynced = wasEvoSynced
 			// if evo is not synced (evoSynced == false) the lastBlock should be the one that is previously and don't update startingBlock
@@ -80,6 +91,57 @@ func (w *worker) Run(ctx context.Context) error {
 	}
 }
 
+// handleError recovers from reorgs and backs off on any other error. It returns the block to resume from, which follows
+// the last block committed to the state, as a range split in several transactions might have been partially committed.
+// It only returns an error when the worker can not continue.
+func (w *worker) handleError(ctx context.Context, err error, startingBlock uint64) (uint64, error) {
+	slog.Error("error occurred while processing universal block range", "err", err.Error())
+	var reorgErr universal.ReorgError
+	if errors.As(err, &reorgErr) {
+		if err := w.recoverFromReorg(ctx, reorgErr); err != nil {
+			return 0, err
+		}
+	} else {
+		w.backoff.Wait(ctx)
+	}
+	if ctx.Err() != nil {
+		return startingBlock, nil
+	}
+
+	resumeBlock, err := w.processor.GetInitStartingBlock(ctx)
+	if err != nil {
+		slog.Error("error occurred while reading the block to resume from", "err", err.Error())
+		return 0, err
+	}
+	if resumeBlock != startingBlock {
+		slog.Info("resuming after the last committed ownership block", "startingBlock", resumeBlock)
+	}
+	return resumeBlock, nil
+}
+
+func (w *worker) recoverFromReorg(ctx context.Context, reorgErr universal.ReorgError) error {
+	slog.Error("ownership chain reorganization detected",
+		"blockNumber", reorgErr.Block,
+		"chainHash", reorgErr.ChainHash.String(),
+		"storageHash", reorgErr.StorageHash.String())
+	blockWithoutReorg, err := w.processor.RecoverFromReorg(ctx, reorgErr.Block)
+	if err != nil {
+		var deepReorgErr universal.DeepReorgError
+		if errors.As(err, &deepReorgErr) {
+			slog.Error("ALERT: ownership chain reorganization is deeper than the retained block history, "+
+				"refusing to recover automatically. Rewind the state to a canonical block with the rewind command or resync from scratch",
+				"blockNumber", deepReorgErr.Block,
+				"oldestRetainedBlock", deepReorgErr.OldestBlock,
+				"err", err.Error())
+			return err
+		}
+		slog.Error("error occurred while recovering from reorg", "err", err.Error())
+		return err
+	}
+	slog.Info("recovered successfully from reorg: HURRAY!", "blockWithoutReorg", blockWithoutReorg.Number)
+	return nil
+}
+
 func (w *worker) executeUniversalBlockRange(ctx context.Context,
 	evoSynced bool,
 	startingBlock,
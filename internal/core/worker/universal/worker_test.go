@@ -2,6 +2,7 @@ package worker_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -25,13 +26,15 @@ func TestRun_SuccessfulExecutionWithReorgAndRecovery(t *testing.T) {
 
 	mockProcessorService := mockProcessor.NewMockProcessor(mockCtrl)
 
-	startingBlocks := []uint64{90, 80}
+	// the worker resumes after the block without reorg
+	startingBlocks := []uint64{90, 81}
 	verifyReorgErrors := []error{
 		universal.ReorgError{Block: 90, ChainHash: common.HexToHash("0x558af54aec2a3b01640511cfc1d2b5772373b7b73ff621225031de3cae9a2c3e"), StorageHash: common.HexToHash("0x123")},
 		nil,
 	}
 
 	mockProcessorService.EXPECT().GetInitStartingBlock(gomock.Any()).Return(startingBlocks[0], nil)
+	mockProcessorService.EXPECT().GetInitStartingBlock(gomock.Any()).Return(startingBlocks[1], nil)
 
 	for i := 0; i < len(startingBlocks); i++ {
 		mockProcessorService.EXPECT().GetLastBlock(ctx, startingBlocks[i]).Return(startingBlocks[i], nil)
@@ -45,7 +48,7 @@ func TestRun_SuccessfulExecutionWithReorgAndRecovery(t *testing.T) {
 			})
 	}
 	mockProcessorService.EXPECT().RecoverFromReorg(ctx, startingBlocks[0]).Return(&model.Block{
-		Number: startingBlocks[1],
+		Number: startingBlocks[1] - 1,
 		Hash:   common.HexToHash("0x558af54aec2a3b01640511cfc1d2b5772373b7b73ff621225031de3cae9a2c3e"),
 	}, nil).Times(1)
 	w := worker.New(&config.Config{WaitingTime: 1 * time.Second}, mockProcessorService)
@@ -55,3 +58,35 @@ func TestRun_SuccessfulExecutionWithReorgAndRecovery(t *testing.T) {
 		t.Errorf("expected no error, got: %v", err)
 	}
 }
+
+func TestRun_ResumesAfterLastCommittedBlockOnError(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockProcessorService := mockProcessor.NewMockProcessor(mockCtrl)
+
+	// the first half of the range 100-110 is committed before the second half fails
+	mockProcessorService.EXPECT().GetInitStartingBlock(gomock.Any()).Return(uint64(100), nil)
+	mockProcessorService.EXPECT().GetLastBlock(ctx, uint64(100)).Return(uint64(110), nil)
+	mockProcessorService.EXPECT().IsEvoSyncedWithOwnership(ctx, uint64(110)).Return(true, nil)
+	mockProcessorService.EXPECT().ProcessUniversalBlockRange(ctx, uint64(100), uint64(110)).Return(errors.New("error fetching logs"))
+	mockProcessorService.EXPECT().GetInitStartingBlock(gomock.Any()).Return(uint64(106), nil)
+	mockProcessorService.EXPECT().GetLastBlock(ctx, uint64(106)).Return(uint64(110), nil)
+	mockProcessorService.EXPECT().IsEvoSyncedWithOwnership(ctx, uint64(110)).Return(true, nil)
+	mockProcessorService.EXPECT().ProcessUniversalBlockRange(ctx, uint64(106), uint64(110)).
+		DoAndReturn(func(context.Context, uint64, uint64) error {
+			cancel()
+			return nil
+		})
+	w := worker.New(&config.Config{WaitingTime: 1 * time.Second}, mockProcessorService)
+
+	err := w.Run(ctx)
+	if err != nil {
+		t.Errorf("expected no error, got: %v", err)
+	}
+}
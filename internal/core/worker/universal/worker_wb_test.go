@@ -3,9 +3,12 @@ package worker
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/freeverseio/laos-universal-node/internal/core/processor/universal"
 	processMock "github.com/freeverseio/laos-universal-node/internal/core/processor/universal/mock"
 
 	"go.uber.org/mock/gomock"
@@ -130,6 +133,132 @@ func TestExecuteUniversalBlockRange(t *testing.T) {
 	})
 }
 
+func TestCatchUp(t *testing.T) {
+	t.Parallel()
+	// ranges of 10 blocks up to the chain head at block 40
+	lastBlockOf := func(_ context.Context, startingBlock uint64) (uint64, error) {
+		return min(startingBlock+9, 40), nil
+	}
+	tests := []struct {
+		name                     string
+		startingBlock            uint64
+		evoSyncedUntil           uint64
+		applyErrors              map[uint64]error
+		expectedAppliedRanges    [][2]uint64
+		expectedLastAppliedBlock uint64
+		expectedLastFetchedBlock uint64
+		expectedErr              error
+	}{
+		{
+			name:                     "applies the ranges in order until the chain head",
+			startingBlock:            1,
+			evoSyncedUntil:           40,
+			expectedAppliedRanges:    [][2]uint64{{1, 10}, {11, 20}, {21, 30}, {31, 40}},
+			expectedLastAppliedBlock: 40,
+		},
+		{
+			name:                     "applies nothing when the chain head is reached",
+			startingBlock:            41,
+			evoSyncedUntil:           40,
+			expectedLastAppliedBlock: 40,
+		},
+		{
+			name:                     "stops when the evolution chain is behind",
+			startingBlock:            1,
+			evoSyncedUntil:           25,
+			expectedAppliedRanges:    [][2]uint64{{1, 10}, {11, 20}},
+			expectedLastAppliedBlock: 20,
+			expectedLastFetchedBlock: 20,
+		},
+		{
+			name:                     "stops at the range boundary where a reorg is detected",
+			startingBlock:            1,
+			evoSyncedUntil:           40,
+			applyErrors:              map[uint64]error{21: universal.ReorgError{Block: 20}},
+			expectedAppliedRanges:    [][2]uint64{{1, 10}, {11, 20}, {21, 30}},
+			expectedLastAppliedBlock: 20,
+			expectedErr:              universal.ReorgError{Block: 20},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			processor := processMock.NewMockProcessor(gomock.NewController(t))
+			w := &worker{catchUpDepth: 2, processor: processor}
+
+			processor.EXPECT().GetLastBlock(gomock.Any(), gomock.Any()).DoAndReturn(lastBlockOf).AnyTimes()
+			var fetchedMu sync.Mutex
+			var lastFetchedBlock uint64
+			processor.EXPECT().PrefetchBlockRange(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, startingBlock, lastBlock uint64) (*universal.PrefetchedRange, error) {
+					fetchedMu.Lock()
+					lastFetchedBlock = max(lastFetchedBlock, lastBlock)
+					fetchedMu.Unlock()
+					return &universal.PrefetchedRange{StartingBlock: startingBlock, LastBlock: lastBlock}, nil
+				}).AnyTimes()
+			processor.EXPECT().IsEvoSyncedWithOwnership(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, lastBlock uint64) (bool, error) {
+					return lastBlock <= tt.evoSyncedUntil, nil
+				}).AnyTimes()
+			var appliedRanges [][2]uint64
+			processor.EXPECT().ApplyBlockRange(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, prefetched *universal.PrefetchedRange) error {
+					appliedRanges = append(appliedRanges, [2]uint64{prefetched.StartingBlock, prefetched.LastBlock})
+					return tt.applyErrors[prefetched.StartingBlock]
+				}).AnyTimes()
+
+			lastAppliedBlock, err := w.catchUp(ctx, tt.startingBlock)
+			assertError(t, tt.expectedErr, err)
+			if lastAppliedBlock != tt.expectedLastAppliedBlock {
+				t.Fatalf("got last applied block %d, expected %d", lastAppliedBlock, tt.expectedLastAppliedBlock)
+			}
+			if fmt.Sprint(appliedRanges) != fmt.Sprint(tt.expectedAppliedRanges) {
+				t.Fatalf("got applied ranges %v, expected %v", appliedRanges, tt.expectedAppliedRanges)
+			}
+			// catchUp returns once the fetchers have finished
+			if tt.expectedLastFetchedBlock > 0 && lastFetchedBlock != tt.expectedLastFetchedBlock {
+				t.Fatalf("got last fetched block %d, expected %d", lastFetchedBlock, tt.expectedLastFetchedBlock)
+			}
+		})
+	}
+}
+
+func TestCatchUpStopsOnContextCancellation(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	processor := processMock.NewMockProcessor(gomock.NewController(t))
+	w := &worker{catchUpDepth: 2, processor: processor}
+
+	processor.EXPECT().GetLastBlock(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, startingBlock uint64) (uint64, error) {
+			return startingBlock + 9, nil
+		}).AnyTimes()
+	processor.EXPECT().PrefetchBlockRange(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, startingBlock, lastBlock uint64) (*universal.PrefetchedRange, error) {
+			if startingBlock > 1 {
+				// upcoming ranges only finish fetching once canceled
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return &universal.PrefetchedRange{StartingBlock: startingBlock, LastBlock: lastBlock}, nil
+		}).AnyTimes()
+	processor.EXPECT().IsEvoSyncedWithOwnership(gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+	processor.EXPECT().ApplyBlockRange(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ *universal.PrefetchedRange) error {
+			cancel()
+			return nil
+		}).Times(1)
+
+	lastAppliedBlock, err := w.catchUp(ctx, 1)
+	assertError(t, nil, err)
+	if lastAppliedBlock != 10 {
+		t.Fatalf("got last applied block %d, expected 10", lastAppliedBlock)
+	}
+}
+
 func assertError(t *testing.T, expectedError, err error) {
 	t.Helper()
 	if expectedError != nil {
@@ -0,0 +1,230 @@
+package blockrange
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain"
+)
+
+const (
+	defaultFewLogs      = 1000
+	defaultFastResponse = 2 * time.Second
+	defaultRetries      = 5
+	defaultRetryDelay   = time.Second
+)
+
+// messages returned by the providers when an eth_getLogs query spans too many blocks or logs. Rate limit errors
+// also mention exceeded limits, so the messages are specific to the size of the query and rate limits are checked first.
+var tooManyResultsMessages = []string{
+	"too many results",
+	"query returned more than",
+	"block range is too",
+	"block range too",
+	"maximum block range",
+	"limited to a",
+	"range too large",
+	"response size exceeded",
+	"log response size",
+}
+
+// Client is a blockchain.EthClient that adapts the number of blocks to scan to the responses of FilterLogs:
+// the range grows when few logs are returned quickly, and shrinks on too many results, timeouts and rate limits.
+// Queries spanning more than the maximum range are split, and rate limited queries are retried with backoff.
+type Client struct {
+	blockchain.EthClient
+	fewLogs      int
+	fastResponse time.Duration
+	retries      int
+	retryDelay   time.Duration
+
+	mu       sync.Mutex
+	size     uint64
+	maxRange uint64
+}
+
+type Option func(*Client)
+
+// WithGrowthThresholds sets the responses that make the range grow: less than fewLogs logs returned in less than fastResponse
+func WithGrowthThresholds(fewLogs int, fastResponse time.Duration) Option {
+	return func(c *Client) {
+		c.fewLogs = fewLogs
+		c.fastResponse = fastResponse
+	}
+}
+
+// WithRetries sets how many times a rate limited query is retried and the delay before the first retry, which doubles on every retry
+func WithRetries(retries int, retryDelay time.Duration) Option {
+	return func(c *Client) {
+		c.retries = max(retries, 0)
+		c.retryDelay = retryDelay
+	}
+}
+
+// NewClient returns a Client wrapping client that starts scanning initialRange blocks per iteration
+// and never scans more than maxRange blocks per eth_getLogs query. The range does not grow if maxRange is not bigger than initialRange.
+func NewClient(client blockchain.EthClient, initialRange, maxRange uint64, opts ...Option) *Client {
+	c := &Client{
+		EthClient:    client,
+		fewLogs:      defaultFewLogs,
+		fastResponse: defaultFastResponse,
+		retries:      defaultRetries,
+		retryDelay:   defaultRetryDelay,
+		size:         initialRange,
+		maxRange:     max(initialRange, maxRange),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Unwrap returns the wrapped client
+func (c *Client) Unwrap() blockchain.EthClient {
+	return c.EthClient
+}
+
+// BlockRange returns the current number of blocks to scan after the starting block
+func (c *Client) BlockRange() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// FilterLogs returns the logs matching q. Queries over a block range are split in chunks of at most the maximum range,
+// and chunks failing with too many results or timeouts are split in halves.
+// The range grows once per call if no query failed and every query returned few logs quickly.
+func (c *Client) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	if q.BlockHash != nil || q.FromBlock == nil || q.ToBlock == nil || q.FromBlock.Sign() < 0 || q.ToBlock.Sign() < 0 {
+		return c.EthClient.FilterLogs(ctx, q)
+	}
+
+	var logs []types.Log
+	canGrow := true
+	fromBlock, toBlock := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+	for start := fromBlock; start <= toBlock; start += c.maxRange + 1 {
+		chunkLogs, err := c.filterLogs(ctx, q, start, min(start+c.maxRange, toBlock), &canGrow)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, chunkLogs...)
+	}
+	// only queries spanning the whole range prove that it can grow
+	if canGrow && toBlock-fromBlock >= c.BlockRange() {
+		c.grow()
+	}
+	return logs, nil
+}
+
+func (c *Client) filterLogs(ctx context.Context, q ethereum.FilterQuery, fromBlock, toBlock uint64, canGrow *bool) ([]types.Log, error) {
+	q.FromBlock = new(big.Int).SetUint64(fromBlock)
+	q.ToBlock = new(big.Int).SetUint64(toBlock)
+
+	delay := c.retryDelay
+	for attempt := 0; ; attempt++ {
+		start := time.Now()
+		logs, err := c.EthClient.FilterLogs(ctx, q)
+		if err == nil {
+			if len(logs) >= c.fewLogs || time.Since(start) >= c.fastResponse {
+				*canGrow = false
+			}
+			return logs, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		*canGrow = false
+		switch {
+		case IsRateLimited(err):
+			if attempt >= c.retries {
+				return nil, err
+			}
+			c.shrink()
+			slog.Warn("logs query rate limited, retrying", "attempt", attempt+1, "delay", delay, "err", err.Error())
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+		case IsTooManyResults(err) || IsTimeout(err):
+			c.shrink()
+			if fromBlock == toBlock {
+				return nil, err
+			}
+			slog.Debug("splitting logs query", "fromBlock", fromBlock, "toBlock", toBlock, "err", err.Error())
+			middle := fromBlock + (toBlock-fromBlock)/2
+			firstLogs, err := c.filterLogs(ctx, q, fromBlock, middle, canGrow)
+			if err != nil {
+				return nil, err
+			}
+			lastLogs, err := c.filterLogs(ctx, q, middle+1, toBlock, canGrow)
+			if err != nil {
+				return nil, err
+			}
+			return append(firstLogs, lastLogs...), nil
+		default:
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) grow() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size < c.maxRange {
+		c.size = min(max(c.size*2, 1), c.maxRange)
+		slog.Debug("block range increased", "blockRange", c.size)
+	}
+}
+
+func (c *Client) shrink() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size > 0 {
+		c.size /= 2
+		slog.Debug("block range decreased", "blockRange", c.size)
+	}
+}
+
+// IsTooManyResults returns whether err is returned by a provider because a query spans too many blocks or logs
+func IsTooManyResults(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, m := range tooManyResultsMessages {
+		if strings.Contains(message, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTimeout returns whether err is caused by a query taking too long
+func IsTimeout(err error) bool {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "timeout")
+}
+
+// IsRateLimited returns whether err is returned by a provider because too many requests were sent
+func IsRateLimited(err error) bool {
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "429") || strings.Contains(message, "rate limit") || strings.Contains(message, "too many requests")
+}
@@ -0,0 +1,208 @@
+package blockrange_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/mock/gomock"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain"
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/blockrange"
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/header"
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/mock"
+)
+
+func TestFilterLogs(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name               string
+		initialRange       uint64
+		maxRange           uint64
+		fromBlock, toBlock uint64
+		// filterLogs returns the error for the queried range, or nil to return one log per block
+		filterLogs         func(fromBlock, toBlock uint64) error
+		expectedQueries    [][2]uint64
+		expectedBlockRange uint64
+		expectedErr        string
+	}{
+		{
+			name:               "grows the range when few logs are returned quickly",
+			initialRange:       10,
+			maxRange:           100,
+			fromBlock:          1,
+			toBlock:            11,
+			filterLogs:         func(_, _ uint64) error { return nil },
+			expectedQueries:    [][2]uint64{{1, 11}},
+			expectedBlockRange: 20,
+		},
+		{
+			name:               "never grows the range over the maximum",
+			initialRange:       60,
+			maxRange:           100,
+			fromBlock:          1,
+			toBlock:            61,
+			filterLogs:         func(_, _ uint64) error { return nil },
+			expectedQueries:    [][2]uint64{{1, 61}},
+			expectedBlockRange: 100,
+		},
+		{
+			name:               "splits the queries over the maximum range",
+			initialRange:       10,
+			maxRange:           10,
+			fromBlock:          1,
+			toBlock:            25,
+			filterLogs:         func(_, _ uint64) error { return nil },
+			expectedQueries:    [][2]uint64{{1, 11}, {12, 22}, {23, 25}},
+			expectedBlockRange: 10,
+		},
+		{
+			name:         "shrinks the range and splits the query on too many results",
+			initialRange: 8,
+			maxRange:     8,
+			fromBlock:    1,
+			toBlock:      9,
+			filterLogs: func(fromBlock, toBlock uint64) error {
+				if toBlock-fromBlock > 4 {
+					return fmt.Errorf("query returned more than 10000 results")
+				}
+				return nil
+			},
+			expectedQueries:    [][2]uint64{{1, 9}, {1, 5}, {6, 9}},
+			expectedBlockRange: 4,
+		},
+		{
+			name:         "retries rate limited queries",
+			initialRange: 8,
+			maxRange:     8,
+			fromBlock:    1,
+			toBlock:      9,
+			filterLogs: func() func(_, _ uint64) error {
+				calls := 0
+				return func(_, _ uint64) error {
+					calls++
+					if calls == 1 {
+						return rpc.HTTPError{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests"}
+					}
+					return nil
+				}
+			}(),
+			expectedQueries:    [][2]uint64{{1, 9}, {1, 9}},
+			expectedBlockRange: 4,
+		},
+		{
+			name:               "returns other errors",
+			initialRange:       8,
+			maxRange:           8,
+			fromBlock:          1,
+			toBlock:            9,
+			filterLogs:         func(_, _ uint64) error { return fmt.Errorf("invalid argument") },
+			expectedQueries:    [][2]uint64{{1, 9}},
+			expectedBlockRange: 8,
+			expectedErr:        "invalid argument",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			client := mock.NewMockEthClient(ctrl)
+			var queries [][2]uint64
+			client.EXPECT().FilterLogs(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+					fromBlock, toBlock := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+					queries = append(queries, [2]uint64{fromBlock, toBlock})
+					if err := tt.filterLogs(fromBlock, toBlock); err != nil {
+						return nil, err
+					}
+					var logs []types.Log
+					for block := fromBlock; block <= toBlock; block++ {
+						logs = append(logs, types.Log{BlockNumber: block})
+					}
+					return logs, nil
+				}).AnyTimes()
+
+			c := blockrange.NewClient(client, tt.initialRange, tt.maxRange, blockrange.WithRetries(1, time.Millisecond))
+			logs, err := c.FilterLogs(context.Background(), ethereum.FilterQuery{
+				FromBlock: new(big.Int).SetUint64(tt.fromBlock),
+				ToBlock:   new(big.Int).SetUint64(tt.toBlock),
+			})
+			if tt.expectedErr != "" {
+				if err == nil || err.Error() != tt.expectedErr {
+					t.Fatalf(`got error "%v", expected "%s"`, err, tt.expectedErr)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf(`got error "%v" when no error was expected`, err)
+				}
+				if uint64(len(logs)) != tt.toBlock-tt.fromBlock+1 {
+					t.Fatalf("got %d logs, expected %d", len(logs), tt.toBlock-tt.fromBlock+1)
+				}
+			}
+			if fmt.Sprint(queries) != fmt.Sprint(tt.expectedQueries) {
+				t.Fatalf("got queries %v, expected %v", queries, tt.expectedQueries)
+			}
+			if c.BlockRange() != tt.expectedBlockRange {
+				t.Fatalf("got block range %d, expected %d", c.BlockRange(), tt.expectedBlockRange)
+			}
+		})
+	}
+}
+
+func TestBlockRangeThroughWrappers(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	client := header.NewCache(blockrange.NewClient(mock.NewMockEthClient(ctrl), 10, 100), nil)
+
+	ranger, ok := blockchain.As[blockchain.BlockRanger](client)
+	if !ok {
+		t.Fatalf("got no block ranger from the wrapped clients")
+	}
+	if ranger.BlockRange() != 10 {
+		t.Fatalf("got block range %d, expected 10", ranger.BlockRange())
+	}
+	if _, ok := blockchain.As[blockchain.HeaderReader](client); !ok {
+		t.Fatalf("got no header reader from the wrapped clients")
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		err            error
+		tooManyResults bool
+		timeout        bool
+		rateLimited    bool
+	}{
+		{err: fmt.Errorf("query returned more than 10000 results"), tooManyResults: true},
+		{err: fmt.Errorf("eth_getLogs is limited to a 10,000 block range"), tooManyResults: true},
+		{err: fmt.Errorf("error: %w", context.DeadlineExceeded), timeout: true},
+		{err: rpc.HTTPError{StatusCode: http.StatusTooManyRequests}, rateLimited: true},
+		{err: fmt.Errorf("project ID request rate exceeded: rate limit"), rateLimited: true},
+		{err: fmt.Errorf("daily request count limit exceeded, request rate limited"), rateLimited: true},
+		{err: fmt.Errorf("429 Too Many Requests: compute units limit exceeded"), rateLimited: true},
+		{err: fmt.Errorf("block range is too wide"), tooManyResults: true},
+		{err: fmt.Errorf("exceed maximum block range: 5000"), tooManyResults: true},
+		{err: fmt.Errorf("invalid block range params")},
+	}
+
+	for _, tt := range tests {
+		if got := blockrange.IsTooManyResults(tt.err); got != tt.tooManyResults {
+			t.Errorf("got IsTooManyResults %v for %q, expected %v", got, tt.err, tt.tooManyResults)
+		}
+		if got := blockrange.IsTimeout(tt.err); got != tt.timeout {
+			t.Errorf("got IsTimeout %v for %q, expected %v", got, tt.err, tt.timeout)
+		}
+		if got := blockrange.IsRateLimited(tt.err); got != tt.rateLimited {
+			t.Errorf("got IsRateLimited %v for %q, expected %v", got, tt.err, tt.rateLimited)
+		}
+	}
+}
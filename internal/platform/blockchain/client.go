@@ -3,11 +3,17 @@ package blockchain
 import (
 	"context"
 	"math/big"
+	"sync"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/sync/errgroup"
 )
 
+// maxParallelHeaderRequests bounds the concurrent header requests when the client can not fetch them in batches
+const maxParallelHeaderRequests = 10
+
 // EthClient is an interface for interacting with Ethereum.
 // https://github.com/ethereum/go-ethereum/pull/23884
 type EthClient interface {
@@ -25,3 +31,81 @@ type EthClient interface {
 	BlockNumber(ctx context.Context) (uint64, error)
 	Close()
 }
+
+// HeaderReader fetches the headers of a range of blocks at once and caches them until they are reset or pruned
+type HeaderReader interface {
+	HeadersByNumber(ctx context.Context, fromBlock, toBlock uint64) (map[uint64]*types.Header, error)
+	ResetHeaders()
+	PruneHeaders(belowBlock uint64)
+}
+
+// BlockRanger returns the number of blocks that should be scanned in the next iteration
+type BlockRanger interface {
+	BlockRange() uint64
+}
+
+// Wrapper is implemented by the clients that add a capability on top of another client
+type Wrapper interface {
+	Unwrap() EthClient
+}
+
+// As returns the first client in the chain of wrapped clients that implements T
+func As[T any](client EthClient) (T, bool) {
+	for client != nil {
+		if t, ok := client.(T); ok {
+			return t, true
+		}
+		wrapper, ok := client.(Wrapper)
+		if !ok {
+			break
+		}
+		client = wrapper.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// ResetHeaders empties the header cache of client, if it has one
+func ResetHeaders(client EthClient) {
+	if headerReader, ok := As[HeaderReader](client); ok {
+		headerReader.ResetHeaders()
+	}
+}
+
+// PruneHeaders removes the headers of the blocks lower than belowBlock from the header cache of client, if it has one
+func PruneHeaders(client EthClient, belowBlock uint64) {
+	if headerReader, ok := As[HeaderReader](client); ok {
+		headerReader.PruneHeaders(belowBlock)
+	}
+}
+
+// HeadersByNumber returns the headers of the blocks from fromBlock to toBlock, both included.
+// If client wraps a HeaderReader, headers are fetched in batches and shared with the other users of the client.
+// Otherwise, they are fetched in parallel with at most maxParallelHeaderRequests requests at a time.
+func HeadersByNumber(ctx context.Context, client EthClient, fromBlock, toBlock uint64) (map[uint64]*types.Header, error) {
+	if headerReader, ok := As[HeaderReader](client); ok {
+		return headerReader.HeadersByNumber(ctx, fromBlock, toBlock)
+	}
+
+	headers := make(map[uint64]*types.Header, toBlock-fromBlock+1)
+	var mu sync.Mutex
+	var group errgroup.Group
+	group.SetLimit(maxParallelHeaderRequests)
+	for blockNumber := fromBlock; blockNumber <= toBlock; blockNumber++ {
+		blockNumber := blockNumber
+		group.Go(func() error {
+			header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			headers[blockNumber] = header
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
@@ -0,0 +1,237 @@
+package header
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain"
+)
+
+const (
+	defaultBatchSize   = 100
+	defaultConcurrency = 4
+	defaultRetries     = 3
+	defaultRetryDelay  = 500 * time.Millisecond
+)
+
+// BatchCaller sends several JSON-RPC requests in a single call, as implemented by rpc.Client
+type BatchCaller interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
+
+// Cache is a blockchain.EthClient that caches the block headers it fetches, so that no header is requested twice
+// until the cache is reset. Headers of block ranges are fetched in JSON-RPC batches.
+type Cache struct {
+	blockchain.EthClient
+	batcher     BatchCaller
+	batchSize   int
+	concurrency int
+	retries     int
+	retryDelay  time.Duration
+
+	mu      sync.Mutex
+	headers map[uint64]*types.Header
+}
+
+type Option func(*Cache)
+
+// WithBatchSize sets the number of headers requested in every batch
+func WithBatchSize(batchSize int) Option {
+	return func(c *Cache) {
+		c.batchSize = max(batchSize, 1)
+	}
+}
+
+// WithConcurrency sets the maximum number of requests sent in parallel
+func WithConcurrency(concurrency int) Option {
+	return func(c *Cache) {
+		c.concurrency = max(concurrency, 1)
+	}
+}
+
+// WithRetries sets how many times a failed request is retried and the delay before the first retry, which doubles on every retry
+func WithRetries(retries int, retryDelay time.Duration) Option {
+	return func(c *Cache) {
+		c.retries = max(retries, 0)
+		c.retryDelay = retryDelay
+	}
+}
+
+// NewCache returns a Cache wrapping client. Headers of block ranges are fetched through batcher,
+// or with one request per header if batcher is nil.
+func NewCache(client blockchain.EthClient, batcher BatchCaller, opts ...Option) *Cache {
+	c := &Cache{
+		EthClient:   client,
+		batcher:     batcher,
+		batchSize:   defaultBatchSize,
+		concurrency: defaultConcurrency,
+		retries:     defaultRetries,
+		retryDelay:  defaultRetryDelay,
+		headers:     make(map[uint64]*types.Header),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Unwrap returns the wrapped client
+func (c *Cache) Unwrap() blockchain.EthClient {
+	return c.EthClient
+}
+
+// HeaderByNumber returns the cached header of the block, fetching it if it is not cached yet.
+// Block tags (latest, safe, finalized...) are never cached.
+func (c *Cache) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if number == nil || number.Sign() < 0 {
+		return c.EthClient.HeaderByNumber(ctx, number)
+	}
+	blockNumber := number.Uint64()
+	if header, ok := c.get(blockNumber); ok {
+		return header, nil
+	}
+
+	var header *types.Header
+	err := c.retry(ctx, func() error {
+		var err error
+		header, err = c.EthClient.HeaderByNumber(ctx, number)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.set(blockNumber, header)
+	return header, nil
+}
+
+// HeadersByNumber returns the headers of the blocks in [fromBlock, toBlock]. The headers that are not cached yet
+// are fetched in batches of at most batchSize, sending up to concurrency batches in parallel.
+func (c *Cache) HeadersByNumber(ctx context.Context, fromBlock, toBlock uint64) (map[uint64]*types.Header, error) {
+	headers := make(map[uint64]*types.Header, toBlock-fromBlock+1)
+	var missing []uint64
+	for blockNumber := fromBlock; blockNumber <= toBlock; blockNumber++ {
+		if header, ok := c.get(blockNumber); ok {
+			headers[blockNumber] = header
+		} else {
+			missing = append(missing, blockNumber)
+		}
+	}
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(c.concurrency)
+	for start := 0; start < len(missing); start += c.batchSize {
+		chunk := missing[start:min(start+c.batchSize, len(missing))]
+		group.Go(func() error {
+			return c.retry(groupCtx, func() error {
+				return c.fetch(groupCtx, chunk)
+			})
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	for _, blockNumber := range missing {
+		header, _ := c.get(blockNumber)
+		headers[blockNumber] = header
+	}
+	return headers, nil
+}
+
+// ResetHeaders empties the cache. It must be called whenever the cached headers might have been reorged.
+func (c *Cache) ResetHeaders() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.headers = make(map[uint64]*types.Header)
+}
+
+// PruneHeaders removes the cached headers of the blocks lower than belowBlock, which are no longer needed
+// or must be fetched again to check whether they have been reorged
+func (c *Cache) PruneHeaders(belowBlock uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for blockNumber := range c.headers {
+		if blockNumber < belowBlock {
+			delete(c.headers, blockNumber)
+		}
+	}
+}
+
+func (c *Cache) fetch(ctx context.Context, blockNumbers []uint64) error {
+	if c.batcher == nil {
+		for _, blockNumber := range blockNumbers {
+			header, err := c.EthClient.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+			if err != nil {
+				return err
+			}
+			c.set(blockNumber, header)
+		}
+		return nil
+	}
+
+	headers := make([]*types.Header, len(blockNumbers))
+	batch := make([]rpc.BatchElem, len(blockNumbers))
+	for i, blockNumber := range blockNumbers {
+		batch[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeUint64(blockNumber), false},
+			Result: &headers[i],
+		}
+	}
+	if err := c.batcher.BatchCallContext(ctx, batch); err != nil {
+		return err
+	}
+	for i, blockNumber := range blockNumbers {
+		if batch[i].Error != nil {
+			return fmt.Errorf("error fetching header of block %d: %w", blockNumber, batch[i].Error)
+		}
+		if headers[i] == nil {
+			return fmt.Errorf("error fetching header of block %d: %w", blockNumber, ethereum.NotFound)
+		}
+	}
+	// headers are only cached once the whole batch succeeded
+	for i, blockNumber := range blockNumbers {
+		c.set(blockNumber, headers[i])
+	}
+	return nil
+}
+
+func (c *Cache) retry(ctx context.Context, f func() error) error {
+	delay := c.retryDelay
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = f(); err == nil || attempt == c.retries {
+			return err
+		}
+		slog.Warn("error fetching block headers, retrying", "attempt", attempt+1, "delay", delay, "err", err.Error())
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (c *Cache) get(blockNumber uint64) (*types.Header, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	header, ok := c.headers[blockNumber]
+	return header, ok
+}
+
+func (c *Cache) set(blockNumber uint64, header *types.Header) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.headers[blockNumber] = header
+}
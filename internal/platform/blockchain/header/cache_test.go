@@ -0,0 +1,208 @@
+package header_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/mock/gomock"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/header"
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/mock"
+)
+
+// batcher answers eth_getBlockByNumber batches with headers whose time is 10 times the block number.
+// The first failures calls return an error.
+type batcher struct {
+	mu       sync.Mutex
+	failures int
+	batches  [][]uint64
+}
+
+func (b *batcher) BatchCallContext(_ context.Context, batch []rpc.BatchElem) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures > 0 {
+		b.failures--
+		return fmt.Errorf("429 too many requests")
+	}
+	var blockNumbers []uint64
+	for i := range batch {
+		blockNumber, err := hexutil.DecodeUint64(batch[i].Args[0].(string))
+		if err != nil {
+			return err
+		}
+		blockNumbers = append(blockNumbers, blockNumber)
+		*batch[i].Result.(**types.Header) = &types.Header{Number: new(big.Int).SetUint64(blockNumber), Time: blockNumber * 10}
+	}
+	b.batches = append(b.batches, blockNumbers)
+	return nil
+}
+
+func TestHeadersByNumber(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		failures        int
+		expectedBatches int
+		expectedErr     string
+	}{
+		{
+			name:            "fetches the headers in chunks",
+			expectedBatches: 3,
+		},
+		{
+			name:            "retries the failed batches",
+			failures:        2,
+			expectedBatches: 3,
+		},
+		{
+			name:        "returns the error once the retries are exhausted",
+			failures:    10,
+			expectedErr: "429 too many requests",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+			b := &batcher{failures: tt.failures}
+			cache := header.NewCache(mock.NewMockEthClient(ctrl), b,
+				header.WithBatchSize(4), header.WithConcurrency(2), header.WithRetries(2, time.Millisecond))
+
+			headers, err := cache.HeadersByNumber(context.Background(), 1, 10)
+			if tt.expectedErr != "" {
+				if err == nil || err.Error() != tt.expectedErr {
+					t.Fatalf(`got error "%v", expected "%s"`, err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			if len(headers) != 10 {
+				t.Fatalf("got %d headers, expected 10", len(headers))
+			}
+			for blockNumber, h := range headers {
+				if h.Time != blockNumber*10 {
+					t.Fatalf("got time %d for block %d, expected %d", h.Time, blockNumber, blockNumber*10)
+				}
+			}
+			if len(b.batches) != tt.expectedBatches {
+				t.Fatalf("got %d batches, expected %d", len(b.batches), tt.expectedBatches)
+			}
+			for _, batch := range b.batches {
+				if len(batch) > 4 {
+					t.Fatalf("got a batch of %d headers, expected at most 4", len(batch))
+				}
+			}
+		})
+	}
+}
+
+func TestHeadersAreFetchedOnce(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	client := mock.NewMockEthClient(ctrl)
+	b := &batcher{}
+	cache := header.NewCache(client, b, header.WithBatchSize(100))
+	ctx := context.Background()
+
+	client.EXPECT().HeaderByNumber(ctx, big.NewInt(12)).Return(&types.Header{Number: big.NewInt(12), Time: 120}, nil).Times(1)
+	h, err := cache.HeaderByNumber(ctx, big.NewInt(12))
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if h.Time != 120 {
+		t.Fatalf("got time %d, expected 120", h.Time)
+	}
+
+	// block 12 is already cached, only 10, 11 and 13 are batched
+	if _, err := cache.HeadersByNumber(ctx, 10, 13); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if len(b.batches) != 1 || len(b.batches[0]) != 3 {
+		t.Fatalf("got batches %v, expected a single batch of 3 headers", b.batches)
+	}
+
+	// cached headers are not requested again
+	for _, blockNumber := range []int64{10, 11, 12, 13} {
+		if _, err := cache.HeaderByNumber(ctx, big.NewInt(blockNumber)); err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+	}
+
+	cache.ResetHeaders()
+	client.EXPECT().HeaderByNumber(ctx, big.NewInt(12)).Return(&types.Header{Number: big.NewInt(12), Time: 121}, nil).Times(1)
+	h, err = cache.HeaderByNumber(ctx, big.NewInt(12))
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if h.Time != 121 {
+		t.Fatalf("got time %d after reset, expected 121", h.Time)
+	}
+}
+
+func TestPruneHeaders(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	b := &batcher{}
+	cache := header.NewCache(mock.NewMockEthClient(ctrl), b)
+	ctx := context.Background()
+
+	if _, err := cache.HeadersByNumber(ctx, 1, 10); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	cache.PruneHeaders(6)
+	// only the pruned headers 5 and below are requested again
+	if _, err := cache.HeadersByNumber(ctx, 5, 10); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if len(b.batches) != 2 || fmt.Sprint(b.batches[1]) != "[5]" {
+		t.Fatalf("got batches %v, expected a second batch with block 5", b.batches)
+	}
+}
+
+func TestBlockTagsAreNotCached(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	client := mock.NewMockEthClient(ctrl)
+	cache := header.NewCache(client, nil)
+	ctx := context.Background()
+	finalized := big.NewInt(int64(rpc.FinalizedBlockNumber))
+
+	client.EXPECT().HeaderByNumber(ctx, finalized).Return(&types.Header{Number: big.NewInt(5)}, nil).Times(2)
+	for i := 0; i < 2; i++ {
+		if _, err := cache.HeaderByNumber(ctx, finalized); err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+	}
+}
+
+func TestHeadersByNumberWithoutBatcher(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	client := mock.NewMockEthClient(ctrl)
+	cache := header.NewCache(client, nil, header.WithConcurrency(1))
+	ctx := context.Background()
+
+	for blockNumber := int64(1); blockNumber <= 3; blockNumber++ {
+		client.EXPECT().HeaderByNumber(gomock.Any(), big.NewInt(blockNumber)).
+			Return(&types.Header{Number: big.NewInt(blockNumber), Time: uint64(blockNumber)}, nil).Times(1)
+	}
+	headers, err := cache.HeadersByNumber(ctx, 1, 3)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if len(headers) != 3 {
+		t.Fatalf("got %d headers, expected 3", len(headers))
+	}
+}
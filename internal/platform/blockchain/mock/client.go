@@ -16,6 +16,7 @@ import (
 	ethereum "github.com/ethereum/go-ethereum"
 	common "github.com/ethereum/go-ethereum/common"
 	types "github.com/ethereum/go-ethereum/core/types"
+	blockchain "github.com/freeverseio/laos-universal-node/internal/platform/blockchain"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -398,3 +399,139 @@ func (mr *MockEthClientMockRecorder) TransactionReceipt(ctx, txHash any) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransactionReceipt", reflect.TypeOf((*MockEthClient)(nil).TransactionReceipt), ctx, txHash)
 }
+
+// MockHeaderReader is a mock of HeaderReader interface.
+type MockHeaderReader struct {
+	ctrl     *gomock.Controller
+	recorder *MockHeaderReaderMockRecorder
+}
+
+// MockHeaderReaderMockRecorder is the mock recorder for MockHeaderReader.
+type MockHeaderReaderMockRecorder struct {
+	mock *MockHeaderReader
+}
+
+// NewMockHeaderReader creates a new mock instance.
+func NewMockHeaderReader(ctrl *gomock.Controller) *MockHeaderReader {
+	mock := &MockHeaderReader{ctrl: ctrl}
+	mock.recorder = &MockHeaderReaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHeaderReader) EXPECT() *MockHeaderReaderMockRecorder {
+	return m.recorder
+}
+
+// HeadersByNumber mocks base method.
+func (m *MockHeaderReader) HeadersByNumber(ctx context.Context, fromBlock, toBlock uint64) (map[uint64]*types.Header, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HeadersByNumber", ctx, fromBlock, toBlock)
+	ret0, _ := ret[0].(map[uint64]*types.Header)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HeadersByNumber indicates an expected call of HeadersByNumber.
+func (mr *MockHeaderReaderMockRecorder) HeadersByNumber(ctx, fromBlock, toBlock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HeadersByNumber", reflect.TypeOf((*MockHeaderReader)(nil).HeadersByNumber), ctx, fromBlock, toBlock)
+}
+
+// PruneHeaders mocks base method.
+func (m *MockHeaderReader) PruneHeaders(belowBlock uint64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PruneHeaders", belowBlock)
+}
+
+// PruneHeaders indicates an expected call of PruneHeaders.
+func (mr *MockHeaderReaderMockRecorder) PruneHeaders(belowBlock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PruneHeaders", reflect.TypeOf((*MockHeaderReader)(nil).PruneHeaders), belowBlock)
+}
+
+// ResetHeaders mocks base method.
+func (m *MockHeaderReader) ResetHeaders() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ResetHeaders")
+}
+
+// ResetHeaders indicates an expected call of ResetHeaders.
+func (mr *MockHeaderReaderMockRecorder) ResetHeaders() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetHeaders", reflect.TypeOf((*MockHeaderReader)(nil).ResetHeaders))
+}
+
+// MockBlockRanger is a mock of BlockRanger interface.
+type MockBlockRanger struct {
+	ctrl     *gomock.Controller
+	recorder *MockBlockRangerMockRecorder
+}
+
+// MockBlockRangerMockRecorder is the mock recorder for MockBlockRanger.
+type MockBlockRangerMockRecorder struct {
+	mock *MockBlockRanger
+}
+
+// NewMockBlockRanger creates a new mock instance.
+func NewMockBlockRanger(ctrl *gomock.Controller) *MockBlockRanger {
+	mock := &MockBlockRanger{ctrl: ctrl}
+	mock.recorder = &MockBlockRangerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBlockRanger) EXPECT() *MockBlockRangerMockRecorder {
+	return m.recorder
+}
+
+// BlockRange mocks base method.
+func (m *MockBlockRanger) BlockRange() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockRange")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// BlockRange indicates an expected call of BlockRange.
+func (mr *MockBlockRangerMockRecorder) BlockRange() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockRange", reflect.TypeOf((*MockBlockRanger)(nil).BlockRange))
+}
+
+// MockWrapper is a mock of Wrapper interface.
+type MockWrapper struct {
+	ctrl     *gomock.Controller
+	recorder *MockWrapperMockRecorder
+}
+
+// MockWrapperMockRecorder is the mock recorder for MockWrapper.
+type MockWrapperMockRecorder struct {
+	mock *MockWrapper
+}
+
+// NewMockWrapper creates a new mock instance.
+func NewMockWrapper(ctrl *gomock.Controller) *MockWrapper {
+	mock := &MockWrapper{ctrl: ctrl}
+	mock.recorder = &MockWrapperMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWrapper) EXPECT() *MockWrapperMockRecorder {
+	return m.recorder
+}
+
+// Unwrap mocks base method.
+func (m *MockWrapper) Unwrap() blockchain.EthClient {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unwrap")
+	ret0, _ := ret[0].(blockchain.EthClient)
+	return ret0
+}
+
+// Unwrap indicates an expected call of Unwrap.
+func (mr *MockWrapperMockRecorder) Unwrap() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unwrap", reflect.TypeOf((*MockWrapper)(nil).Unwrap))
+}
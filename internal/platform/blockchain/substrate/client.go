@@ -0,0 +1,280 @@
+package substrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const (
+	jsonContentType  = "application/json"
+	jsonRPCVersion   = "2.0"
+	defaultBatchSize = 100
+)
+
+// ErrNotFound is returned when the requested block is not known by the node
+var ErrNotFound = errors.New("not found")
+
+// Client talks to a Substrate node through its JSON-RPC API
+type Client interface {
+	// FinalizedHead returns the hash of the last finalized block
+	FinalizedHead(ctx context.Context) (common.Hash, error)
+	// BlockHash returns the hash of the canonical block blockNumber
+	BlockHash(ctx context.Context, blockNumber uint64) (common.Hash, error)
+	// Header returns the header of the block blockHash
+	Header(ctx context.Context, blockHash common.Hash) (*Header, error)
+	// HeadersByNumber returns the headers of the canonical blocks in [fromBlock, toBlock], fetched in JSON-RPC batches
+	HeadersByNumber(ctx context.Context, fromBlock, toBlock uint64) (map[uint64]*Header, error)
+	// SubscribeFinalizedHeads sends the finalized heads to heads until the subscription is closed or fails
+	SubscribeFinalizedHeads(ctx context.Context, heads chan<- *Header) (*Subscription, error)
+	// SubscribeNewHeads sends the new heads to heads until the subscription is closed or fails
+	SubscribeNewHeads(ctx context.Context, heads chan<- *Header) (*Subscription, error)
+}
+
+// HTTPClient sends the HTTP requests of the client, as implemented by http.Client
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Error is the error object of a JSON-RPC response
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+type request struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// message is either the response to a request or a subscription notification
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *uint64         `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type client struct {
+	httpClient HTTPClient
+	url        string
+	batchSize  int
+	lastID     atomic.Uint64
+}
+
+// NewClient returns a Client sending its requests to the HTTP(S) endpoint url through httpClient.
+// Subscriptions are sent to the WS(S) endpoint of the same URL.
+func NewClient(httpClient HTTPClient, url string) *client {
+	return &client{
+		httpClient: httpClient,
+		url:        url,
+		batchSize:  defaultBatchSize,
+	}
+}
+
+func (c *client) FinalizedHead(ctx context.Context) (common.Hash, error) {
+	var hash common.Hash
+	if err := c.call(ctx, &hash, "chain_getFinalizedHead"); err != nil {
+		return common.Hash{}, err
+	}
+	return hash, nil
+}
+
+func (c *client) BlockHash(ctx context.Context, blockNumber uint64) (common.Hash, error) {
+	var hash *common.Hash
+	if err := c.call(ctx, &hash, "chain_getBlockHash", hexutil.EncodeUint64(blockNumber)); err != nil {
+		return common.Hash{}, err
+	}
+	if hash == nil {
+		return common.Hash{}, fmt.Errorf("error fetching hash of block %d: %w", blockNumber, ErrNotFound)
+	}
+	return *hash, nil
+}
+
+func (c *client) Header(ctx context.Context, blockHash common.Hash) (*Header, error) {
+	var header *Header
+	if err := c.call(ctx, &header, "chain_getHeader", blockHash); err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("error fetching header of block %s: %w", blockHash, ErrNotFound)
+	}
+	return header, nil
+}
+
+// HeadersByNumber requests the hashes of the blocks and then their headers, in batches of at most batchSize requests.
+// Every header is checked against the hash it was requested with.
+func (c *client) HeadersByNumber(ctx context.Context, fromBlock, toBlock uint64) (map[uint64]*Header, error) {
+	headers := make(map[uint64]*Header, toBlock-fromBlock+1)
+	for start := fromBlock; start <= toBlock; start += uint64(c.batchSize) {
+		end := min(start+uint64(c.batchSize)-1, toBlock)
+
+		hashes := make([]*common.Hash, end-start+1)
+		calls := make([]batchCall, len(hashes))
+		for i := range hashes {
+			calls[i] = batchCall{method: "chain_getBlockHash", params: []interface{}{hexutil.EncodeUint64(start + uint64(i))}, result: &hashes[i]}
+		}
+		if err := c.batch(ctx, calls); err != nil {
+			return nil, err
+		}
+
+		chunk := make([]*Header, len(hashes))
+		for i, hash := range hashes {
+			if calls[i].err != nil {
+				return nil, fmt.Errorf("error fetching hash of block %d: %w", start+uint64(i), calls[i].err)
+			}
+			if hash == nil {
+				return nil, fmt.Errorf("error fetching hash of block %d: %w", start+uint64(i), ErrNotFound)
+			}
+			calls[i] = batchCall{method: "chain_getHeader", params: []interface{}{*hash}, result: &chunk[i]}
+		}
+		if err := c.batch(ctx, calls); err != nil {
+			return nil, err
+		}
+
+		for i, header := range chunk {
+			blockNumber := start + uint64(i)
+			if calls[i].err != nil {
+				return nil, fmt.Errorf("error fetching header of block %d: %w", blockNumber, calls[i].err)
+			}
+			if header == nil {
+				return nil, fmt.Errorf("error fetching header of block %d: %w", blockNumber, ErrNotFound)
+			}
+			if header.Number != blockNumber || header.Hash() != *hashes[i] {
+				return nil, fmt.Errorf("header of block %d does not match its hash %s", blockNumber, hashes[i])
+			}
+			headers[blockNumber] = header
+		}
+	}
+	return headers, nil
+}
+
+func (c *client) call(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	calls := []batchCall{{method: method, params: params, result: result}}
+	if err := c.send(ctx, calls, false); err != nil {
+		return err
+	}
+	return calls[0].err
+}
+
+type batchCall struct {
+	method string
+	params []interface{}
+	result interface{}
+	err    error
+}
+
+// batch sends calls in a single JSON-RPC batch. Errors of the individual calls are set in their err field.
+func (c *client) batch(ctx context.Context, calls []batchCall) error {
+	return c.send(ctx, calls, true)
+}
+
+func (c *client) send(ctx context.Context, calls []batchCall, batch bool) error {
+	requests := make([]request, len(calls))
+	callsByID := make(map[uint64]*batchCall, len(calls))
+	for i := range calls {
+		requests[i] = request{JSONRPC: jsonRPCVersion, ID: c.lastID.Add(1), Method: calls[i].method, Params: calls[i].params}
+		if requests[i].Params == nil {
+			requests[i].Params = []interface{}{}
+		}
+		callsByID[requests[i].ID] = &calls[i]
+	}
+
+	var payload interface{} = requests
+	if !batch {
+		payload = requests[0]
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding request: %w", err)
+	}
+	responseBody, err := c.post(ctx, body)
+	if err != nil {
+		return err
+	}
+
+	var responses []message
+	if batch {
+		err = json.Unmarshal(responseBody, &responses)
+	} else {
+		responses = make([]message, 1)
+		err = json.Unmarshal(responseBody, &responses[0])
+	}
+	if err != nil {
+		return fmt.Errorf("error decoding response from %s: %w", c.url, err)
+	}
+
+	// responses of a batch might come in any order, so they are correlated with their call by ID
+	for _, response := range responses {
+		if response.ID == nil {
+			if response.Error != nil {
+				return response.Error
+			}
+			continue
+		}
+		call, ok := callsByID[*response.ID]
+		if !ok {
+			return fmt.Errorf("unexpected response with id %d from %s", *response.ID, c.url)
+		}
+		delete(callsByID, *response.ID)
+		call.err = decodeResult(response, call.result)
+	}
+	for id, call := range callsByID {
+		call.err = fmt.Errorf("no response to request %d (%s) from %s", id, call.method, c.url)
+	}
+	return nil
+}
+
+func (c *client) post(ctx context.Context, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", jsonContentType)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request to %s: %w", c.url, err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			slog.Error("error closing response body", "err", err)
+		}
+	}()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("error in request to %s, got status code: %d", c.url, resp.StatusCode)
+	}
+	var responseBody bytes.Buffer
+	if _, err := responseBody.ReadFrom(resp.Body); err != nil {
+		return nil, fmt.Errorf("error reading response from %s: %w", c.url, err)
+	}
+	return responseBody.Bytes(), nil
+}
+
+func decodeResult(response message, result interface{}) error {
+	if response.Error != nil {
+		return response.Error
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("error decoding result: %w", err)
+	}
+	return nil
+}
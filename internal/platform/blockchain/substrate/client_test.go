@@ -0,0 +1,354 @@
+package substrate_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gorilla/websocket"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/substrate"
+)
+
+type rpcRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	ID      uint64            `json:"id"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      uint64           `json:"id"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *substrate.Error `json:"error,omitempty"`
+}
+
+// chainStub is a Substrate JSON-RPC server of a chain of blocks [0, len(headers)). It answers batches in reverse order.
+type chainStub struct {
+	headers   []*substrate.Header
+	finalized uint64
+	// corrupt replaces the header returned for that block number
+	corrupt map[uint64]*substrate.Header
+
+	mu      sync.Mutex
+	batches int
+	heads   chan *substrate.Header
+}
+
+func newChainStub(length int) *chainStub {
+	stub := &chainStub{heads: make(chan *substrate.Header)}
+	var parentHash common.Hash
+	for n := 0; n < length; n++ {
+		header := &substrate.Header{ParentHash: parentHash, Number: uint64(n), StateRoot: common.BigToHash(common.Big1)}
+		stub.headers = append(stub.headers, header)
+		parentHash = header.Hash()
+	}
+	return stub
+}
+
+func (s *chainStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if websocket.IsWebSocketUpgrade(r) {
+		s.serveSubscription(w, r)
+		return
+	}
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var response interface{}
+	if strings.HasPrefix(string(raw), "[") {
+		var requests []rpcRequest
+		if err := json.Unmarshal(raw, &requests); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.batches++
+		s.mu.Unlock()
+		responses := make([]rpcResponse, len(requests))
+		for i, request := range requests {
+			responses[len(requests)-1-i] = s.answer(request)
+		}
+		response = responses
+	} else {
+		var request rpcRequest
+		if err := json.Unmarshal(raw, &request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		response = s.answer(request)
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *chainStub) answer(request rpcRequest) rpcResponse {
+	response := rpcResponse{JSONRPC: "2.0", ID: request.ID}
+	switch request.Method {
+	case "chain_getFinalizedHead":
+		response.Result = s.headers[s.finalized].Hash()
+	case "chain_getBlockHash":
+		var number hexutil.Uint64
+		if err := json.Unmarshal(request.Params[0], &number); err != nil {
+			response.Error = &substrate.Error{Code: -32602, Message: err.Error()}
+		} else if uint64(number) < uint64(len(s.headers)) {
+			response.Result = s.headers[number].Hash()
+		} else {
+			response.Result = json.RawMessage("null")
+		}
+	case "chain_getHeader":
+		var hash common.Hash
+		if err := json.Unmarshal(request.Params[0], &hash); err != nil {
+			response.Error = &substrate.Error{Code: -32602, Message: err.Error()}
+			break
+		}
+		response.Result = json.RawMessage("null")
+		for _, header := range s.headers {
+			if header.Hash() == hash {
+				response.Result = header
+				if corrupt, ok := s.corrupt[header.Number]; ok {
+					response.Result = corrupt
+				}
+			}
+		}
+	default:
+		response.Error = &substrate.Error{Code: -32601, Message: "Method not found"}
+	}
+	return response
+}
+
+func (s *chainStub) serveSubscription(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var request rpcRequest
+	if err := conn.ReadJSON(&request); err != nil {
+		return
+	}
+	if request.Method != "chain_subscribeFinalizedHeads" {
+		_ = conn.WriteJSON(rpcResponse{JSONRPC: "2.0", ID: request.ID, Error: &substrate.Error{Code: -32601, Message: "Method not found"}})
+		return
+	}
+	if err := conn.WriteJSON(rpcResponse{JSONRPC: "2.0", ID: request.ID, Result: "sub"}); err != nil {
+		return
+	}
+	for {
+		select {
+		case header := <-s.heads:
+			notification := map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  "chain_finalizedHead",
+				"params":  map[string]interface{}{"subscription": "sub", "result": header},
+			}
+			if err := conn.WriteJSON(notification); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func TestFinalizedHeadAndHeader(t *testing.T) {
+	t.Parallel()
+	stub := newChainStub(10)
+	stub.finalized = 7
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	client := substrate.NewClient(&http.Client{}, server.URL)
+
+	hash, err := client.FinalizedHead(context.Background())
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	header, err := client.Header(context.Background(), hash)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if header.Number != 7 || header.Hash() != hash {
+		t.Fatalf("got header %d with hash %s, expected header 7 with hash %s", header.Number, header.Hash(), hash)
+	}
+
+	blockHash, err := client.BlockHash(context.Background(), 7)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if blockHash != hash {
+		t.Fatalf("got hash %s for block 7, expected %s", blockHash, hash)
+	}
+}
+
+func TestBlockNotFound(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(newChainStub(10))
+	defer server.Close()
+	client := substrate.NewClient(&http.Client{}, server.URL)
+
+	if _, err := client.BlockHash(context.Background(), 10); !errors.Is(err, substrate.ErrNotFound) {
+		t.Fatalf(`got error "%v", expected "%v"`, err, substrate.ErrNotFound)
+	}
+	if _, err := client.Header(context.Background(), common.HexToHash("0x01")); !errors.Is(err, substrate.ErrNotFound) {
+		t.Fatalf(`got error "%v", expected "%v"`, err, substrate.ErrNotFound)
+	}
+}
+
+func TestHeadersByNumber(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		from, to        uint64
+		corrupt         map[uint64]*substrate.Header
+		expectedBatches int
+		expectedErr     string
+	}{
+		{
+			name:            "fetches the hashes and the headers in a batch each",
+			from:            5,
+			to:              50,
+			expectedBatches: 2,
+		},
+		{
+			name:            "splits the batches",
+			from:            0,
+			to:              249,
+			expectedBatches: 6,
+		},
+		{
+			name:        "fails on missing blocks",
+			from:        240,
+			to:          260,
+			expectedErr: "error fetching hash of block 250: not found",
+		},
+		{
+			name:        "fails on headers not matching their hash",
+			from:        10,
+			to:          20,
+			corrupt:     map[uint64]*substrate.Header{15: {Number: 15}},
+			expectedErr: "header of block 15 does not match its hash",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stub := newChainStub(250)
+			stub.corrupt = tt.corrupt
+			server := httptest.NewServer(stub)
+			defer server.Close()
+			client := substrate.NewClient(&http.Client{}, server.URL)
+
+			headers, err := client.HeadersByNumber(context.Background(), tt.from, tt.to)
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf(`got error "%v", expected "%s"`, err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			if len(headers) != int(tt.to-tt.from+1) {
+				t.Fatalf("got %d headers, expected %d", len(headers), tt.to-tt.from+1)
+			}
+			for n := tt.from; n <= tt.to; n++ {
+				if headers[n] == nil || headers[n].Hash() != stub.headers[n].Hash() {
+					t.Fatalf("got header %+v for block %d, expected %+v", headers[n], n, stub.headers[n])
+				}
+			}
+			if stub.batches != tt.expectedBatches {
+				t.Fatalf("got %d batches, expected %d", stub.batches, tt.expectedBatches)
+			}
+		})
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		response := rpcResponse{JSONRPC: "2.0", ID: request.ID, Error: &substrate.Error{Code: -32000, Message: "Client error", Data: json.RawMessage(`"unknown block"`)}}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	client := substrate.NewClient(&http.Client{}, server.URL)
+
+	_, err := client.FinalizedHead(context.Background())
+	var rpcErr *substrate.Error
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf(`got error "%v", expected a JSON-RPC error`, err)
+	}
+	if rpcErr.Code != -32000 || string(rpcErr.Data) != `"unknown block"` {
+		t.Fatalf("got error %+v, expected code -32000 with its data", rpcErr)
+	}
+}
+
+func TestSubscribeFinalizedHeads(t *testing.T) {
+	t.Parallel()
+	stub := newChainStub(10)
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	client := substrate.NewClient(&http.Client{}, server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	heads := make(chan *substrate.Header)
+	sub, err := client.SubscribeFinalizedHeads(ctx, heads)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+
+	for _, n := range []uint64{3, 4} {
+		stub.heads <- stub.headers[n]
+		select {
+		case head := <-heads:
+			if head.Hash() != stub.headers[n].Hash() {
+				t.Fatalf("got head %d, expected %d", head.Number, n)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("head %d was not received", n)
+		}
+	}
+
+	cancel()
+	select {
+	case err, ok := <-sub.Err():
+		if ok {
+			t.Fatalf(`got error "%v" after the context was canceled`, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("subscription did not end after the context was canceled")
+	}
+}
+
+func TestSubscriptionError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(newChainStub(10))
+	defer server.Close()
+	client := substrate.NewClient(&http.Client{}, server.URL)
+
+	_, err := client.SubscribeNewHeads(context.Background(), make(chan *substrate.Header))
+	var rpcErr *substrate.Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32601 {
+		t.Fatalf(`got error "%v", expected the JSON-RPC error of the subscription`, err)
+	}
+}
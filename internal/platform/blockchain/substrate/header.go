@@ -0,0 +1,158 @@
+package substrate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// consensusDigestItem is the index of the DigestItem::Consensus variant
+	consensusDigestItem = 4
+	// frontierPostLogHashes and frontierPostLogBlockHash are the indexes of the PostLog variants carrying the Ethereum block hash
+	frontierPostLogHashes    = 1
+	frontierPostLogBlockHash = 3
+)
+
+// frontierEngineID identifies the consensus digest items deposited by Frontier, the EVM layer of the chain
+var frontierEngineID = []byte("fron")
+
+// Header is the header of a Substrate block
+type Header struct {
+	ParentHash     common.Hash
+	Number         uint64
+	StateRoot      common.Hash
+	ExtrinsicsRoot common.Hash
+	// Digest holds the SCALE encoded digest items of the block
+	Digest []hexutil.Bytes
+}
+
+type jsonHeader struct {
+	ParentHash     common.Hash    `json:"parentHash"`
+	Number         hexutil.Uint64 `json:"number"`
+	StateRoot      common.Hash    `json:"stateRoot"`
+	ExtrinsicsRoot common.Hash    `json:"extrinsicsRoot"`
+	Digest         struct {
+		Logs []hexutil.Bytes `json:"logs"`
+	} `json:"digest"`
+}
+
+func (h *Header) UnmarshalJSON(data []byte) error {
+	var header jsonHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+	*h = Header{
+		ParentHash:     header.ParentHash,
+		Number:         uint64(header.Number),
+		StateRoot:      header.StateRoot,
+		ExtrinsicsRoot: header.ExtrinsicsRoot,
+		Digest:         header.Digest.Logs,
+	}
+	return nil
+}
+
+func (h *Header) MarshalJSON() ([]byte, error) {
+	var header jsonHeader
+	header.ParentHash = h.ParentHash
+	header.Number = hexutil.Uint64(h.Number)
+	header.StateRoot = h.StateRoot
+	header.ExtrinsicsRoot = h.ExtrinsicsRoot
+	header.Digest.Logs = h.Digest
+	if header.Digest.Logs == nil {
+		header.Digest.Logs = []hexutil.Bytes{}
+	}
+	return json.Marshal(header)
+}
+
+// Hash returns the Substrate block hash, the BLAKE2b-256 hash of the SCALE encoded header
+func (h *Header) Hash() common.Hash {
+	var buf bytes.Buffer
+	buf.Write(h.ParentHash.Bytes())
+	buf.Write(encodeCompact(h.Number))
+	buf.Write(h.StateRoot.Bytes())
+	buf.Write(h.ExtrinsicsRoot.Bytes())
+	buf.Write(encodeCompact(uint64(len(h.Digest))))
+	for _, item := range h.Digest {
+		buf.Write(item)
+	}
+	return blake2b.Sum256(buf.Bytes())
+}
+
+// EthereumBlockHash returns the hash of the Ethereum block built by Frontier in this block, as found in its digest.
+// It returns false if the digest does not carry it.
+func (h *Header) EthereumBlockHash() (common.Hash, bool) {
+	for _, item := range h.Digest {
+		if len(item) < 1+len(frontierEngineID) || item[0] != consensusDigestItem || !bytes.Equal(item[1:1+len(frontierEngineID)], frontierEngineID) {
+			continue
+		}
+		// the consensus item is followed by the SCALE encoded PostLog as a byte vector
+		_, prefixLength, err := decodeCompact(item[1+len(frontierEngineID):])
+		if err != nil {
+			continue
+		}
+		postLog := item[1+len(frontierEngineID)+prefixLength:]
+		if len(postLog) < 1+common.HashLength {
+			continue
+		}
+		switch postLog[0] {
+		case frontierPostLogHashes, frontierPostLogBlockHash:
+			return common.BytesToHash(postLog[1 : 1+common.HashLength]), true
+		}
+	}
+	return common.Hash{}, false
+}
+
+// encodeCompact returns the SCALE compact encoding of value
+func encodeCompact(value uint64) []byte {
+	switch {
+	case value < 1<<6:
+		return []byte{byte(value << 2)}
+	case value < 1<<14:
+		return binary.LittleEndian.AppendUint16(nil, uint16(value<<2|0b01))
+	case value < 1<<30:
+		return binary.LittleEndian.AppendUint32(nil, uint32(value<<2|0b10))
+	default:
+		length := (bits.Len64(value) + 7) / 8
+		encoded := []byte{byte((length-4)<<2 | 0b11)}
+		return append(encoded, binary.LittleEndian.AppendUint64(nil, value)[:length]...)
+	}
+}
+
+// decodeCompact decodes the SCALE compact encoded value at the beginning of data and returns it with its length in bytes
+func decodeCompact(data []byte) (uint64, int, error) {
+	if len(data) == 0 {
+		return 0, 0, errors.New("empty compact value")
+	}
+	var length int
+	switch data[0] & 0b11 {
+	case 0b00:
+		return uint64(data[0] >> 2), 1, nil
+	case 0b01:
+		length = 2
+	case 0b10:
+		length = 4
+	default:
+		length = int(data[0]>>2) + 5
+		if length > 9 {
+			return 0, 0, fmt.Errorf("compact value of %d bytes does not fit in 64 bits", length-1)
+		}
+	}
+	if len(data) < length {
+		return 0, 0, fmt.Errorf("compact value of %d bytes is truncated", length)
+	}
+	padded := make([]byte, 8)
+	if data[0]&0b11 == 0b11 {
+		copy(padded, data[1:length])
+		return binary.LittleEndian.Uint64(padded), length, nil
+	}
+	copy(padded, data[:length])
+	return binary.LittleEndian.Uint64(padded) >> 2, length, nil
+}
@@ -0,0 +1,109 @@
+package substrate_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/blockchain/substrate"
+)
+
+func TestHeaderHash(t *testing.T) {
+	t.Parallel()
+	// genesis header of Polkadot
+	data := `{
+		"parentHash": "0x0000000000000000000000000000000000000000000000000000000000000000",
+		"number": "0x0",
+		"stateRoot": "0x29d0d972cd27cbc511e9589fcb7a4506d5eb6a9e8df205f00472e5ab354a4e17",
+		"extrinsicsRoot": "0x03170a2e7597b7b7e3d84c05391d139a62b157e78786d8c082f29dcf4c111314",
+		"digest": {"logs": []}
+	}`
+	var header substrate.Header
+	if err := json.Unmarshal([]byte(data), &header); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	expected := common.HexToHash("0x91b171bb158e2d3848fa23a9f1c25182fb8e20313b2c1eb49219da7a70ce90c3")
+	if header.Hash() != expected {
+		t.Fatalf("got hash %s, expected %s", header.Hash(), expected)
+	}
+}
+
+func TestHeaderJSONRoundTrip(t *testing.T) {
+	t.Parallel()
+	header := substrate.Header{
+		ParentHash:     common.HexToHash("0x01"),
+		Number:         1_000_000,
+		StateRoot:      common.HexToHash("0x02"),
+		ExtrinsicsRoot: common.HexToHash("0x03"),
+		Digest:         []hexutil.Bytes{frontierDigestItem(3, common.HexToHash("0x04"))},
+	}
+	data, err := json.Marshal(&header)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	var decoded substrate.Header
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if decoded.Hash() != header.Hash() || decoded.Number != header.Number {
+		t.Fatalf("got header %+v after the round trip, expected %+v", decoded, header)
+	}
+}
+
+func TestEthereumBlockHash(t *testing.T) {
+	t.Parallel()
+	ethereumHash := common.HexToHash("0x4f1b6b9e3b6a29a5a2b0d4e9c3f1e2d0c8b7a6958473625140f0e1d2c3b4a596")
+	auraPreRuntime := hexutil.MustDecode("0x0661757261200c4a6b1100000000")
+	tests := []struct {
+		name         string
+		digest       []hexutil.Bytes
+		expectedHash common.Hash
+		expectedOk   bool
+	}{
+		{
+			name:         "block hash post log",
+			digest:       []hexutil.Bytes{auraPreRuntime, frontierDigestItem(3, ethereumHash)},
+			expectedHash: ethereumHash,
+			expectedOk:   true,
+		},
+		{
+			name:         "hashes post log",
+			digest:       []hexutil.Bytes{frontierDigestItem(1, ethereumHash)},
+			expectedHash: ethereumHash,
+			expectedOk:   true,
+		},
+		{
+			name:   "no frontier digest item",
+			digest: []hexutil.Bytes{auraPreRuntime},
+		},
+		{
+			name:   "truncated frontier digest item",
+			digest: []hexutil.Bytes{frontierDigestItem(3, ethereumHash)[:20]},
+		},
+		{
+			name:   "empty digest",
+			digest: nil,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			header := substrate.Header{Digest: tt.digest}
+			hash, ok := header.EthereumBlockHash()
+			if ok != tt.expectedOk || hash != tt.expectedHash {
+				t.Fatalf("got hash %s (%v), expected %s (%v)", hash, ok, tt.expectedHash, tt.expectedOk)
+			}
+		})
+	}
+}
+
+// frontierDigestItem returns the consensus digest item deposited by Frontier with the PostLog variant and hash
+func frontierDigestItem(variant byte, hash common.Hash) hexutil.Bytes {
+	postLog := append([]byte{variant}, hash.Bytes()...)
+	item := append([]byte{4}, "fron"...)
+	item = append(item, byte(len(postLog)<<2))
+	return append(item, postLog...)
+}
@@ -0,0 +1,47 @@
+package substrate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestCompact(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		value   uint64
+		encoded string
+	}{
+		{value: 0, encoded: "0x00"},
+		{value: 1, encoded: "0x04"},
+		{value: 42, encoded: "0xa8"},
+		{value: 69, encoded: "0x1501"},
+		{value: 65535, encoded: "0xfeff0300"},
+		{value: 1 << 30, encoded: "0x0300000040"},
+		{value: 100000000000000, encoded: "0x0b00407a10f35a"},
+		{value: 1<<64 - 1, encoded: "0x13ffffffffffffffff"},
+	}
+	for _, tt := range tests {
+		encoded := encodeCompact(tt.value)
+		if !bytes.Equal(encoded, hexutil.MustDecode(tt.encoded)) {
+			t.Fatalf("got encoding %s for %d, expected %s", hexutil.Encode(encoded), tt.value, tt.encoded)
+		}
+		value, length, err := decodeCompact(append(encoded, 0xff))
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if value != tt.value || length != len(encoded) {
+			t.Fatalf("got %d of length %d decoding %s, expected %d of length %d", value, length, tt.encoded, tt.value, len(encoded))
+		}
+	}
+}
+
+func TestDecodeCompactErrors(t *testing.T) {
+	t.Parallel()
+	for _, encoded := range []string{"0x", "0x01", "0xfeff03", "0x17ffffffffffffffffff"} {
+		if _, _, err := decodeCompact(hexutil.MustDecode(encoded)); err == nil {
+			t.Fatalf("got no error decoding %s while an error was expected", encoded)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: client.go
+//
+// Generated by this command:
+//
+//	mockgen -source=client.go -destination=mock/client.go -package=mock
+//
+// Package mock is a generated GoMock package.
+package mock
+
+import (
+	context "context"
+	http "net/http"
+	reflect "reflect"
+
+	common "github.com/ethereum/go-ethereum/common"
+	substrate "github.com/freeverseio/laos-universal-node/internal/platform/blockchain/substrate"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockClient is a mock of Client interface.
+type MockClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockClientMockRecorder
+}
+
+// MockClientMockRecorder is the mock recorder for MockClient.
+type MockClientMockRecorder struct {
+	mock *MockClient
+}
+
+// NewMockClient creates a new mock instance.
+func NewMockClient(ctrl *gomock.Controller) *MockClient {
+	mock := &MockClient{ctrl: ctrl}
+	mock.recorder = &MockClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClient) EXPECT() *MockClientMockRecorder {
+	return m.recorder
+}
+
+// BlockHash mocks base method.
+func (m *MockClient) BlockHash(ctx context.Context, blockNumber uint64) (common.Hash, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockHash", ctx, blockNumber)
+	ret0, _ := ret[0].(common.Hash)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockHash indicates an expected call of BlockHash.
+func (mr *MockClientMockRecorder) BlockHash(ctx, blockNumber any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockHash", reflect.TypeOf((*MockClient)(nil).BlockHash), ctx, blockNumber)
+}
+
+// FinalizedHead mocks base method.
+func (m *MockClient) FinalizedHead(ctx context.Context) (common.Hash, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinalizedHead", ctx)
+	ret0, _ := ret[0].(common.Hash)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinalizedHead indicates an expected call of FinalizedHead.
+func (mr *MockClientMockRecorder) FinalizedHead(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinalizedHead", reflect.TypeOf((*MockClient)(nil).FinalizedHead), ctx)
+}
+
+// Header mocks base method.
+func (m *MockClient) Header(ctx context.Context, blockHash common.Hash) (*substrate.Header, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Header", ctx, blockHash)
+	ret0, _ := ret[0].(*substrate.Header)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Header indicates an expected call of Header.
+func (mr *MockClientMockRecorder) Header(ctx, blockHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Header", reflect.TypeOf((*MockClient)(nil).Header), ctx, blockHash)
+}
+
+// HeadersByNumber mocks base method.
+func (m *MockClient) HeadersByNumber(ctx context.Context, fromBlock, toBlock uint64) (map[uint64]*substrate.Header, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HeadersByNumber", ctx, fromBlock, toBlock)
+	ret0, _ := ret[0].(map[uint64]*substrate.Header)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HeadersByNumber indicates an expected call of HeadersByNumber.
+func (mr *MockClientMockRecorder) HeadersByNumber(ctx, fromBlock, toBlock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HeadersByNumber", reflect.TypeOf((*MockClient)(nil).HeadersByNumber), ctx, fromBlock, toBlock)
+}
+
+// SubscribeFinalizedHeads mocks base method.
+func (m *MockClient) SubscribeFinalizedHeads(ctx context.Context, heads chan<- *substrate.Header) (*substrate.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeFinalizedHeads", ctx, heads)
+	ret0, _ := ret[0].(*substrate.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubscribeFinalizedHeads indicates an expected call of SubscribeFinalizedHeads.
+func (mr *MockClientMockRecorder) SubscribeFinalizedHeads(ctx, heads any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeFinalizedHeads", reflect.TypeOf((*MockClient)(nil).SubscribeFinalizedHeads), ctx, heads)
+}
+
+// SubscribeNewHeads mocks base method.
+func (m *MockClient) SubscribeNewHeads(ctx context.Context, heads chan<- *substrate.Header) (*substrate.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeNewHeads", ctx, heads)
+	ret0, _ := ret[0].(*substrate.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SubscribeNewHeads indicates an expected call of SubscribeNewHeads.
+func (mr *MockClientMockRecorder) SubscribeNewHeads(ctx, heads any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeNewHeads", reflect.TypeOf((*MockClient)(nil).SubscribeNewHeads), ctx, heads)
+}
+
+// MockHTTPClient is a mock of HTTPClient interface.
+type MockHTTPClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockHTTPClientMockRecorder
+}
+
+// MockHTTPClientMockRecorder is the mock recorder for MockHTTPClient.
+type MockHTTPClientMockRecorder struct {
+	mock *MockHTTPClient
+}
+
+// NewMockHTTPClient creates a new mock instance.
+func NewMockHTTPClient(ctrl *gomock.Controller) *MockHTTPClient {
+	mock := &MockHTTPClient{ctrl: ctrl}
+	mock.recorder = &MockHTTPClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHTTPClient) EXPECT() *MockHTTPClientMockRecorder {
+	return m.recorder
+}
+
+// Do mocks base method.
+func (m *MockHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Do", req)
+	ret0, _ := ret[0].(*http.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Do indicates an expected call of Do.
+func (mr *MockHTTPClientMockRecorder) Do(req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockHTTPClient)(nil).Do), req)
+}
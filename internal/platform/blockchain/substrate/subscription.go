@@ -0,0 +1,171 @@
+package substrate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// Subscription is an open head subscription of a Client
+type Subscription struct {
+	conn         *websocket.Conn
+	err          chan error
+	once         sync.Once
+	stop         func() bool
+	unsubscribed chan struct{}
+}
+
+// Err returns a channel receiving the error that ended the subscription. It is closed once the subscription ends,
+// so it receives nothing when the subscription is unsubscribed or its context canceled.
+func (s *Subscription) Err() <-chan error {
+	return s.err
+}
+
+// Unsubscribe closes the subscription
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() {
+		close(s.unsubscribed)
+		s.stop()
+		closeConn(s.conn)
+	})
+}
+
+type subscriptionNotification struct {
+	Subscription string          `json:"subscription"`
+	Result       json.RawMessage `json:"result"`
+}
+
+func (c *client) SubscribeFinalizedHeads(ctx context.Context, heads chan<- *Header) (*Subscription, error) {
+	return c.subscribeHeads(ctx, "chain_subscribeFinalizedHeads", heads)
+}
+
+func (c *client) SubscribeNewHeads(ctx context.Context, heads chan<- *Header) (*Subscription, error) {
+	return c.subscribeHeads(ctx, "chain_subscribeNewHeads", heads)
+}
+
+// subscribeHeads opens a WebSocket connection for the subscription method and forwards its headers to heads
+func (c *client) subscribeHeads(ctx context.Context, method string, heads chan<- *Header) (*Subscription, error) {
+	wsURL, err := toWebSocketURL(c.url)
+	if err != nil {
+		return nil, err
+	}
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %s: %w", wsURL, err)
+	}
+
+	id := c.lastID.Add(1)
+	if err := conn.WriteJSON(request{JSONRPC: jsonRPCVersion, ID: id, Method: method, Params: []interface{}{}}); err != nil {
+		closeConn(conn)
+		return nil, fmt.Errorf("error sending %s to %s: %w", method, wsURL, err)
+	}
+	subscriptionID, err := readSubscriptionID(conn, id)
+	if err != nil {
+		closeConn(conn)
+		return nil, fmt.Errorf("error in %s on %s: %w", method, wsURL, err)
+	}
+
+	sub := &Subscription{conn: conn, err: make(chan error, 1), unsubscribed: make(chan struct{})}
+	// closing the connection also unblocks the read once ctx is canceled
+	sub.stop = context.AfterFunc(ctx, func() { closeConn(conn) })
+	go sub.forward(ctx, subscriptionID, heads)
+	return sub, nil
+}
+
+// readSubscriptionID waits for the response to the subscription request id and returns the subscription it opened
+func readSubscriptionID(conn *websocket.Conn, id uint64) (string, error) {
+	for {
+		var response message
+		if err := conn.ReadJSON(&response); err != nil {
+			return "", err
+		}
+		if response.ID == nil || *response.ID != id {
+			continue
+		}
+		var subscriptionID string
+		if err := decodeResult(response, &subscriptionID); err != nil {
+			return "", err
+		}
+		return subscriptionID, nil
+	}
+}
+
+func (s *Subscription) forward(ctx context.Context, subscriptionID string, heads chan<- *Header) {
+	defer close(s.err)
+	defer s.Unsubscribe()
+
+	err := s.readHeads(ctx, subscriptionID, heads)
+	select {
+	case <-ctx.Done():
+		return
+	case <-s.unsubscribed:
+		return
+	default:
+	}
+	select {
+	case s.err <- err:
+	default:
+	}
+}
+
+func (s *Subscription) readHeads(ctx context.Context, subscriptionID string, heads chan<- *Header) error {
+	for {
+		var notification message
+		if err := s.conn.ReadJSON(&notification); err != nil {
+			return err
+		}
+		if notification.Error != nil {
+			return notification.Error
+		}
+		if notification.Method == "" {
+			continue
+		}
+
+		var params subscriptionNotification
+		if err := json.Unmarshal(notification.Params, &params); err != nil {
+			return fmt.Errorf("error decoding %s notification: %w", notification.Method, err)
+		}
+		if params.Subscription != subscriptionID {
+			continue
+		}
+		header := new(Header)
+		if err := json.Unmarshal(params.Result, header); err != nil {
+			return fmt.Errorf("error decoding %s notification: %w", notification.Method, err)
+		}
+		select {
+		case heads <- header:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.unsubscribed:
+			return nil
+		}
+	}
+}
+
+func toWebSocketURL(rpcURL string) (string, error) {
+	u, err := url.Parse(rpcURL)
+	if err != nil {
+		return "", fmt.Errorf("error parsing Substrate RPC URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	case "ws", "wss":
+	default:
+		return "", fmt.Errorf("unsupported Substrate RPC URL scheme: %s", u.Scheme)
+	}
+	return u.String(), nil
+}
+
+func closeConn(conn *websocket.Conn) {
+	if err := conn.Close(); err != nil {
+		slog.Debug("error closing Substrate subscription", "err", err)
+	}
+}
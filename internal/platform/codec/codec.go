@@ -0,0 +1,204 @@
+// Package codec encodes the stored state in a compact binary format.
+//
+// Numbers in keys are fixed-width big endian, so that keys sort by number. Values start with the version of the format,
+// followed by their fields in a fixed order: fixed-width big endian numbers, raw hashes and addresses,
+// and byte strings prefixed by their uvarint length.
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Version is the version of the format written by the Encoder
+const Version byte = 1
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported encoding version")
+	ErrInvalidEncoding    = errors.New("invalid encoding")
+)
+
+// Uint64Key encodes a number of a key, such as a block number
+func Uint64Key(n uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, n)
+}
+
+// ParseUint64Key decodes a number encoded by Uint64Key
+func ParseUint64Key(key []byte) (uint64, error) {
+	if len(key) != 8 {
+		return 0, fmt.Errorf("%w: got a number key of %d bytes, expected 8", ErrInvalidEncoding, len(key))
+	}
+	return binary.BigEndian.Uint64(key), nil
+}
+
+// IsEncoded tells whether value was written by the Encoder, as opposed to the encodings used before the codec,
+// which never start with a version byte
+func IsEncoded(value []byte) bool {
+	return len(value) > 0 && value[0] >= 1 && value[0] <= Version
+}
+
+// Encoder writes the fields of a value
+type Encoder struct {
+	buf []byte
+}
+
+func NewEncoder() *Encoder {
+	return &Encoder{buf: []byte{Version}}
+}
+
+// Bytes returns the encoded value
+func (e *Encoder) Bytes() []byte {
+	return e.buf
+}
+
+func (e *Encoder) Uint64(v uint64) *Encoder {
+	e.buf = binary.BigEndian.AppendUint64(e.buf, v)
+	return e
+}
+
+func (e *Encoder) Int64(v int64) *Encoder {
+	return e.Uint64(uint64(v))
+}
+
+func (e *Encoder) Bool(v bool) *Encoder {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+	return e
+}
+
+func (e *Encoder) Hash(h common.Hash) *Encoder {
+	e.buf = append(e.buf, h.Bytes()...)
+	return e
+}
+
+func (e *Encoder) Address(a common.Address) *Encoder {
+	e.buf = append(e.buf, a.Bytes()...)
+	return e
+}
+
+// ByteString writes b prefixed by its length
+func (e *Encoder) ByteString(b []byte) *Encoder {
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+	return e
+}
+
+func (e *Encoder) String(s string) *Encoder {
+	return e.ByteString([]byte(s))
+}
+
+// BigInt writes the sign and the big endian magnitude of v. A nil v is written as 0.
+func (e *Encoder) BigInt(v *big.Int) *Encoder {
+	if v == nil {
+		v = new(big.Int)
+	}
+	e.Bool(v.Sign() < 0)
+	return e.ByteString(v.Bytes())
+}
+
+// Decoder reads the fields of a value in the order they were written. The first error is kept,
+// the fields read after it are zero and Err returns it.
+type Decoder struct {
+	buf []byte
+	err error
+}
+
+func NewDecoder(value []byte) *Decoder {
+	d := &Decoder{}
+	switch {
+	case len(value) == 0:
+		d.err = fmt.Errorf("%w: empty value", ErrInvalidEncoding)
+	case !IsEncoded(value):
+		d.err = fmt.Errorf("%w: %d", ErrUnsupportedVersion, value[0])
+	default:
+		d.buf = value[1:]
+	}
+	return d
+}
+
+// Err returns the first error found while decoding, or an error if the value has bytes left
+func (d *Decoder) Err() error {
+	if d.err == nil && len(d.buf) > 0 {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalidEncoding, len(d.buf))
+	}
+	return d.err
+}
+
+func (d *Decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.buf) < n {
+		d.err = fmt.Errorf("%w: got %d bytes, expected at least %d", ErrInvalidEncoding, len(d.buf), n)
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+func (d *Decoder) Uint64() uint64 {
+	b := d.next(8)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+func (d *Decoder) Int64() int64 {
+	return int64(d.Uint64())
+}
+
+func (d *Decoder) Bool() bool {
+	b := d.next(1)
+	if b == nil {
+		return false
+	}
+	if b[0] > 1 {
+		d.err = fmt.Errorf("%w: invalid bool %d", ErrInvalidEncoding, b[0])
+		return false
+	}
+	return b[0] == 1
+}
+
+func (d *Decoder) Hash() common.Hash {
+	return common.BytesToHash(d.next(common.HashLength))
+}
+
+func (d *Decoder) Address() common.Address {
+	return common.BytesToAddress(d.next(common.AddressLength))
+}
+
+// ByteString reads a byte string written with its length. The returned slice shares the memory of the value.
+func (d *Decoder) ByteString() []byte {
+	if d.err != nil {
+		return nil
+	}
+	length, n := binary.Uvarint(d.buf)
+	if n <= 0 || length > uint64(len(d.buf)-n) {
+		d.err = fmt.Errorf("%w: invalid byte string length", ErrInvalidEncoding)
+		return nil
+	}
+	d.buf = d.buf[n:]
+	return d.next(int(length))
+}
+
+func (d *Decoder) String() string {
+	return string(d.ByteString())
+}
+
+func (d *Decoder) BigInt() *big.Int {
+	negative := d.Bool()
+	v := new(big.Int).SetBytes(d.ByteString())
+	if negative {
+		v.Neg(v)
+	}
+	return v
+}
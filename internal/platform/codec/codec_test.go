@@ -0,0 +1,235 @@
+package codec_test
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
+	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+)
+
+func TestUint64Key(t *testing.T) {
+	t.Parallel()
+	numbers := []uint64{0, 1, 255, 256, 1e18 - 1, 1e18, math.MaxUint64}
+	for i, n := range numbers {
+		key := codec.Uint64Key(n)
+		parsed, err := codec.ParseUint64Key(key)
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if parsed != n {
+			t.Fatalf("got %d, expected %d", parsed, n)
+		}
+		// keys sort like the numbers they encode
+		if i > 0 && bytes.Compare(codec.Uint64Key(numbers[i-1]), key) >= 0 {
+			t.Fatalf("key of %d does not sort before the key of %d", numbers[i-1], n)
+		}
+	}
+
+	if _, err := codec.ParseUint64Key([]byte("000000000000000001")); !errors.Is(err, codec.ErrInvalidEncoding) {
+		t.Fatalf(`got error "%v", expected "%v"`, err, codec.ErrInvalidEncoding)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	t.Parallel()
+	tokenId, _ := new(big.Int).SetString("46976592214550265587196140326218285710533017521213089440580744024326303399936", 10)
+	value := codec.NewEncoder().
+		Uint64(math.MaxUint64).
+		Int64(-5).
+		Bool(true).
+		Hash(common.HexToHash("0x123")).
+		Address(common.HexToAddress("0x456")).
+		String("ipfs://QmQeN4qhzPpG6jVqJoXo2e86eHYPbFpKeUkJcTfrA5hJwz").
+		ByteString(nil).
+		BigInt(tokenId).
+		BigInt(big.NewInt(-1)).
+		BigInt(nil).
+		Bytes()
+
+	d := codec.NewDecoder(value)
+	if got := d.Uint64(); got != math.MaxUint64 {
+		t.Fatalf("got %d, expected %d", got, uint64(math.MaxUint64))
+	}
+	if got := d.Int64(); got != -5 {
+		t.Fatalf("got %d, expected -5", got)
+	}
+	if !d.Bool() {
+		t.Fatal("got false, expected true")
+	}
+	if got := d.Hash(); got != common.HexToHash("0x123") {
+		t.Fatalf("got hash %s, expected 0x123", got.String())
+	}
+	if got := d.Address(); got != common.HexToAddress("0x456") {
+		t.Fatalf("got address %s, expected 0x456", got.String())
+	}
+	if got := d.String(); got != "ipfs://QmQeN4qhzPpG6jVqJoXo2e86eHYPbFpKeUkJcTfrA5hJwz" {
+		t.Fatalf("got string %s", got)
+	}
+	if got := d.ByteString(); len(got) != 0 {
+		t.Fatalf("got byte string %x, expected an empty one", got)
+	}
+	if got := d.BigInt(); got.Cmp(tokenId) != 0 {
+		t.Fatalf("got big int %s, expected %s", got.String(), tokenId.String())
+	}
+	if got := d.BigInt(); got.Cmp(big.NewInt(-1)) != 0 {
+		t.Fatalf("got big int %s, expected -1", got.String())
+	}
+	if got := d.BigInt(); got.Sign() != 0 {
+		t.Fatalf("got big int %s, expected 0", got.String())
+	}
+	if err := d.Err(); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		value         []byte
+		decode        func(d *codec.Decoder)
+		expectedErr   error
+		expectedError string
+	}{
+		{
+			name:          "empty value",
+			value:         nil,
+			decode:        func(d *codec.Decoder) { d.Uint64() },
+			expectedErr:   codec.ErrInvalidEncoding,
+			expectedError: "invalid encoding: empty value",
+		},
+		{
+			name:          "legacy decimal value",
+			value:         []byte("100"),
+			decode:        func(d *codec.Decoder) { d.Uint64() },
+			expectedErr:   codec.ErrUnsupportedVersion,
+			expectedError: "unsupported encoding version: 49",
+		},
+		{
+			name:          "newer version",
+			value:         []byte{codec.Version + 1, 0, 0, 0, 0, 0, 0, 0, 1},
+			decode:        func(d *codec.Decoder) { d.Uint64() },
+			expectedErr:   codec.ErrUnsupportedVersion,
+			expectedError: "unsupported encoding version: 2",
+		},
+		{
+			name:          "truncated number",
+			value:         []byte{codec.Version, 0, 1},
+			decode:        func(d *codec.Decoder) { d.Uint64() },
+			expectedErr:   codec.ErrInvalidEncoding,
+			expectedError: "invalid encoding: got 2 bytes, expected at least 8",
+		},
+		{
+			name:          "trailing bytes",
+			value:         []byte{codec.Version, 1, 0},
+			decode:        func(d *codec.Decoder) { d.Bool() },
+			expectedErr:   codec.ErrInvalidEncoding,
+			expectedError: "invalid encoding: 1 trailing bytes",
+		},
+		{
+			name:          "invalid bool",
+			value:         []byte{codec.Version, 2},
+			decode:        func(d *codec.Decoder) { d.Bool() },
+			expectedErr:   codec.ErrInvalidEncoding,
+			expectedError: "invalid encoding: invalid bool 2",
+		},
+		{
+			name:          "byte string longer than the value",
+			value:         []byte{codec.Version, 5, 'a'},
+			decode:        func(d *codec.Decoder) { d.ByteString() },
+			expectedErr:   codec.ErrInvalidEncoding,
+			expectedError: "invalid encoding: invalid byte string length",
+		},
+		{
+			name:  "first error is kept",
+			value: []byte{codec.Version, 0, 1},
+			decode: func(d *codec.Decoder) {
+				d.Uint64()
+				d.Bool()
+			},
+			expectedErr:   codec.ErrInvalidEncoding,
+			expectedError: "invalid encoding: got 2 bytes, expected at least 8",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			d := codec.NewDecoder(tt.value)
+			tt.decode(d)
+			err := d.Err()
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf(`got error "%v", expected error: "%v"`, err, tt.expectedErr)
+			}
+			if err.Error() != tt.expectedError {
+				t.Fatalf(`got error "%v", expected error: "%v"`, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestModel(t *testing.T) {
+	t.Parallel()
+	t.Run("block", func(t *testing.T) {
+		t.Parallel()
+		block := model.Block{Number: 100, Timestamp: 1700000000, Hash: common.HexToHash("0xabc")}
+		decoded, err := codec.DecodeBlock(codec.EncodeBlock(block))
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if decoded != block {
+			t.Fatalf("got block %v, expected %v", decoded, block)
+		}
+	})
+	t.Run("minted with external URI", func(t *testing.T) {
+		t.Parallel()
+		event := model.MintedWithExternalURI{
+			Slot:        big.NewInt(1),
+			To:          common.HexToAddress("0x3"),
+			TokenURI:    "tokenURI",
+			TokenId:     big.NewInt(1234),
+			BlockNumber: 10,
+			Timestamp:   1000,
+			TxIndex:     2,
+		}
+		decoded, err := codec.DecodeMintedWithExternalURI(codec.EncodeMintedWithExternalURI(&event))
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if decoded.Slot.Cmp(event.Slot) != 0 || decoded.TokenId.Cmp(event.TokenId) != 0 || decoded.To != event.To ||
+			decoded.TokenURI != event.TokenURI || decoded.BlockNumber != event.BlockNumber ||
+			decoded.Timestamp != event.Timestamp || decoded.TxIndex != event.TxIndex {
+			t.Fatalf("got event %v, expected %v", decoded, event)
+		}
+	})
+	t.Run("contract backfill", func(t *testing.T) {
+		t.Parallel()
+		backfill := model.ContractBackfill{
+			Contract: model.ERC721UniversalContract{
+				Address:           common.HexToAddress("0x1"),
+				CollectionAddress: common.HexToAddress("0x2"),
+				BlockNumber:       5,
+			},
+			NextBlock:             20,
+			EnumeratedRoot:        common.HexToHash("0x3"),
+			EnumeratedTotalRoot:   common.HexToHash("0x4"),
+			OwnershipRoot:         common.HexToHash("0x5"),
+			TotalSupply:           7,
+			LastProcessedEvoBlock: 30,
+		}
+		decoded, err := codec.DecodeContractBackfill(codec.EncodeContractBackfill(&backfill))
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if decoded != backfill {
+			t.Fatalf("got backfill %v, expected %v", decoded, backfill)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package codec
+
+import (
+	"math/big"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+)
+
+func EncodeUint64(v uint64) []byte {
+	return NewEncoder().Uint64(v).Bytes()
+}
+
+func DecodeUint64(value []byte) (uint64, error) {
+	d := NewDecoder(value)
+	v := d.Uint64()
+	return v, d.Err()
+}
+
+func EncodeInt64(v int64) []byte {
+	return NewEncoder().Int64(v).Bytes()
+}
+
+func DecodeInt64(value []byte) (int64, error) {
+	d := NewDecoder(value)
+	v := d.Int64()
+	return v, d.Err()
+}
+
+func EncodeBigInt(v *big.Int) []byte {
+	return NewEncoder().BigInt(v).Bytes()
+}
+
+func DecodeBigInt(value []byte) (*big.Int, error) {
+	d := NewDecoder(value)
+	v := d.BigInt()
+	return v, d.Err()
+}
+
+func EncodeBlock(block model.Block) []byte {
+	return NewEncoder().Uint64(block.Number).Uint64(block.Timestamp).Hash(block.Hash).Bytes()
+}
+
+func DecodeBlock(value []byte) (model.Block, error) {
+	d := NewDecoder(value)
+	block := model.Block{Number: d.Uint64(), Timestamp: d.Uint64(), Hash: d.Hash()}
+	return block, d.Err()
+}
+
+func EncodeMintedWithExternalURI(event *model.MintedWithExternalURI) []byte {
+	return NewEncoder().
+		BigInt(event.Slot).
+		Address(event.To).
+		String(event.TokenURI).
+		BigInt(event.TokenId).
+		Uint64(event.BlockNumber).
+		Uint64(event.Timestamp).
+		Uint64(event.TxIndex).
+		Bytes()
+}
+
+func DecodeMintedWithExternalURI(value []byte) (model.MintedWithExternalURI, error) {
+	d := NewDecoder(value)
+	event := model.MintedWithExternalURI{
+		Slot:        d.BigInt(),
+		To:          d.Address(),
+		TokenURI:    d.String(),
+		TokenId:     d.BigInt(),
+		BlockNumber: d.Uint64(),
+		Timestamp:   d.Uint64(),
+		TxIndex:     d.Uint64(),
+	}
+	return event, d.Err()
+}
+
+func EncodeContractBackfill(backfill *model.ContractBackfill) []byte {
+	return NewEncoder().
+		Address(backfill.Contract.Address).
+		Address(backfill.Contract.CollectionAddress).
+		Uint64(backfill.Contract.BlockNumber).
+		Uint64(backfill.NextBlock).
+		Hash(backfill.EnumeratedRoot).
+		Hash(backfill.EnumeratedTotalRoot).
+		Hash(backfill.OwnershipRoot).
+		Int64(backfill.TotalSupply).
+		Uint64(backfill.LastProcessedEvoBlock).
+		Bytes()
+}
+
+func DecodeContractBackfill(value []byte) (model.ContractBackfill, error) {
+	d := NewDecoder(value)
+	backfill := model.ContractBackfill{
+		Contract: model.ERC721UniversalContract{
+			Address:           d.Address(),
+			CollectionAddress: d.Address(),
+			BlockNumber:       d.Uint64(),
+		},
+		NextBlock:             d.Uint64(),
+		EnumeratedRoot:        d.Hash(),
+		EnumeratedTotalRoot:   d.Hash(),
+		OwnershipRoot:         d.Hash(),
+		TotalSupply:           d.Int64(),
+		LastProcessedEvoBlock: d.Uint64(),
+	}
+	return backfill, d.Err()
+}
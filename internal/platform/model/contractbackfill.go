@@ -0,0 +1,17 @@
+package model
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ContractBackfill is the progress of replaying the history of a contract that was followed after its deployment.
+// The roots are those of the contract trees once every block before NextBlock is replayed.
+type ContractBackfill struct {
+	Contract              ERC721UniversalContract
+	NextBlock             uint64
+	EnumeratedRoot        common.Hash
+	EnumeratedTotalRoot   common.Hash
+	OwnershipRoot         common.Hash
+	TotalSupply           int64
+	LastProcessedEvoBlock uint64
+}
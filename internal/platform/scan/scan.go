@@ -5,8 +5,6 @@ import (
 	"fmt"
 	"log/slog"
 	"math/big"
-	"regexp"
-	"strconv"
 	"strings"
 
 	"github.com/ethereum/go-ethereum"
@@ -64,61 +62,6 @@ type EventNewERC721Universal struct {
 	BlockNumber        uint64
 }
 
-func (e EventNewERC721Universal) GlobalConsensus() (string, error) {
-	// Define a regular expression pattern to match the desired content between parentheses
-	pattern := `GlobalConsensus\(([^)]+)\)`
-
-	// Compile the regular expression
-	re := regexp.MustCompile(pattern)
-
-	// Find the match in the input string
-	match := re.FindStringSubmatch(e.BaseURI)
-
-	if len(match) != 2 {
-		return "", fmt.Errorf("no global consensus ID found in base URI: %s", e.BaseURI)
-	}
-
-	return match[1], nil
-}
-
-func (e EventNewERC721Universal) Parachain() (uint64, error) {
-	// Define a regular expression pattern to match the desired content between parentheses
-	pattern := `Parachain\(([^)]+)\)`
-
-	// Compile the regular expression
-	re := regexp.MustCompile(pattern)
-
-	// Find the match in the input string
-	match := re.FindStringSubmatch(e.BaseURI)
-
-	if len(match) != 2 {
-		return 0, fmt.Errorf("no parachain ID found in base URI: %s", e.BaseURI)
-	}
-	parachain, err := strconv.ParseUint(match[1], 10, 64)
-	if err != nil {
-		return 0, fmt.Errorf("error parsing parachain value to uint: %w", err)
-	}
-
-	return parachain, nil
-}
-
-func (e EventNewERC721Universal) CollectionAddress() (common.Address, error) {
-	// Define a regular expression pattern to match the desired content between parentheses
-	pattern := `AccountKey20\(([^)]+)\)`
-
-	// Compile the regular expression
-	re := regexp.MustCompile(pattern)
-
-	// Find the match in the input string
-	match := re.FindStringSubmatch(e.BaseURI)
-
-	if len(match) != 2 {
-		return common.Address{}, fmt.Errorf("no collection address found in base URI: %s", e.BaseURI)
-	}
-
-	return common.HexToAddress(match[1]), nil
-}
-
 // EventNewCollecion is the LaosEvolution event emitted when a new collection is created
 type EventNewCollecion struct {
 	CollectionAddress common.Address
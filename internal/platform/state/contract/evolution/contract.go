@@ -1,20 +1,21 @@
 package evolution
 
 import (
-	"bytes"
-	"encoding/gob"
 	"fmt"
-	"strconv"
+	"math/big"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
 )
 
 const (
-	eventsPrefix      = "evo_events_"
-	blockNumberDigits = 18
-	txIndexDigits     = 8
+	eventsPrefix = "evo_events_"
+	// tokenEventsPrefix indexes the events of every token, pointing to their key under eventsPrefix
+	tokenEventsPrefix = "evo_token_events_"
 )
 
 type service struct {
@@ -28,49 +29,65 @@ func NewService(tx storage.Tx) *service {
 }
 
 func (s *service) StoreMintedWithExternalURIEvent(contract string, event *model.MintedWithExternalURI) error {
-	var buf bytes.Buffer
-	encoder := gob.NewEncoder(&buf)
-	if err := encoder.Encode(event); err != nil {
+	key := []byte(eventsKey(contract, event.BlockNumber) + string(codec.Uint64Key(event.TxIndex)))
+	if err := s.tx.Set(key, codec.EncodeMintedWithExternalURI(event)); err != nil {
 		return err
 	}
-	key := fmt.Sprintf("%s%s_%s_%s", eventsPrefix,
-		strings.ToLower(contract),
-		formatNumberForSorting(event.BlockNumber, blockNumberDigits),
-		formatNumberForSorting(event.TxIndex, txIndexDigits))
+	return s.tx.Set(TokenEventKey(contract, event), key)
+}
 
-	return s.tx.Set([]byte(key), buf.Bytes())
+// GetTokenMintedWithExternalURIEvent returns the event that minted tokenId in contract, nil if it has not been minted
+func (s *service) GetTokenMintedWithExternalURIEvent(contract string, tokenId *big.Int) (*model.MintedWithExternalURI, error) {
+	// a token is minted once, by the first event of the token
+	keys := s.tx.GetValuesWithPrefix([]byte(tokenEventsKey(contract, tokenId)))
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	value, err := s.tx.Get(keys[0])
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, fmt.Errorf("missing event %q indexed for token %s of contract %s", keys[0], tokenId.String(), contract)
+	}
+	event, err := codec.DecodeMintedWithExternalURI(value)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding event of token %s of contract %s: %w", tokenId.String(), contract, err)
+	}
+	return &event, nil
 }
 
 func (s *service) GetMintedWithExternalURIEvents(contract string, blockNumber uint64) ([]model.MintedWithExternalURI, error) {
-	key := fmt.Sprintf("%s%s_%s", eventsPrefix,
-		strings.ToLower(contract),
-		formatNumberForSorting(blockNumber, blockNumberDigits))
-
-	events := s.tx.GetValuesWithPrefix([]byte(key))
+	events := s.tx.GetValuesWithPrefix([]byte(eventsKey(contract, blockNumber)))
 	var mintedEvents []model.MintedWithExternalURI
 	if len(events) == 0 {
 		return mintedEvents, nil
 	}
 
 	for _, event := range events {
-		var mintedEvent model.MintedWithExternalURI
-		decoder := gob.NewDecoder(bytes.NewBuffer(event))
-		if err := decoder.Decode(&mintedEvent); err != nil {
-			return nil, err
+		mintedEvent, err := codec.DecodeMintedWithExternalURI(event)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding event of contract %s at block %d: %w", contract, blockNumber, err)
 		}
 		mintedEvents = append(mintedEvents, mintedEvent)
 	}
 	return mintedEvents, nil
 }
 
-// we add digits to the block number and tx index to make sure the keys are sorted correctly
-// since badger sorts the keys lexicographically
-func formatNumberForSorting(blockNumber uint64, blockNumberDigits uint16) string {
-	// Convert the block number to a string
-	blockNumberString := strconv.FormatUint(blockNumber, 10)
-	// Pad with leading zeros if shorter
-	for len(blockNumberString) < int(blockNumberDigits) {
-		blockNumberString = "0" + blockNumberString
-	}
-	return blockNumberString
+// eventsKey is the prefix of the keys of the events of contract at blockNumber. The block number and the tx index
+// that follows it are fixed-width big endian, so that the keys are sorted by block and tx index.
+func eventsKey(contract string, blockNumber uint64) string {
+	return eventsPrefix + strings.ToLower(contract) + "_" + string(codec.Uint64Key(blockNumber))
+}
+
+// TokenEventKey is the key that indexes event under the token it minted. The keys of the events of a token are sorted
+// by block and tx index like the events, so that the events that evolve a token can be indexed after its mint.
+func TokenEventKey(contract string, event *model.MintedWithExternalURI) []byte {
+	return []byte(tokenEventsKey(contract, event.TokenId) + string(codec.Uint64Key(event.BlockNumber)) + string(codec.Uint64Key(event.TxIndex)))
+}
+
+// tokenEventsKey is the prefix of the keys that index the events of tokenId in contract. The token id is 32 bytes long,
+// so that no token prefixes another.
+func tokenEventsKey(contract string, tokenId *big.Int) string {
+	return tokenEventsPrefix + strings.ToLower(contract) + "_" + string(common.BigToHash(tokenId).Bytes())
 }
@@ -2,6 +2,7 @@ package evolution_test
 
 import (
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/dgraph-io/badger/v4"
@@ -45,6 +46,81 @@ func TestStoreMintedWithExternalURIEvent(t *testing.T) {
 	})
 }
 
+func TestGetTokenMintedWithExternalURIEvent(t *testing.T) {
+	t.Parallel()
+	contract := common.HexToAddress("0x500").Hex()
+	tests := []struct {
+		name            string
+		contract        string
+		tokenId         *big.Int
+		expectedTxIndex uint64
+		expectedNil     bool
+	}{
+		{
+			name:            "returns the event that minted the token",
+			contract:        contract,
+			tokenId:         big.NewInt(2),
+			expectedTxIndex: 3,
+		},
+		{
+			name:            "finds the token with the contract in lower case",
+			contract:        strings.ToLower(contract),
+			tokenId:         big.NewInt(1),
+			expectedTxIndex: 1,
+		},
+		{
+			name:        "returns nil for a token that has not been minted",
+			contract:    contract,
+			tokenId:     big.NewInt(3),
+			expectedNil: true,
+		},
+		{
+			name:        "returns nil for a token of another contract",
+			contract:    common.HexToAddress("0x501").Hex(),
+			tokenId:     big.NewInt(1),
+			expectedNil: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tx, err := createBadgerTransaction(t, createBadger(t))
+			if err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			for i, tokenId := range []int64{1, 256, 2} {
+				err = tx.StoreMintedWithExternalURIEvent(contract, &model.MintedWithExternalURI{
+					Slot:        big.NewInt(int64(i)),
+					To:          common.HexToAddress("0x3"),
+					TokenURI:    "tokenURI",
+					TokenId:     big.NewInt(tokenId),
+					BlockNumber: 100,
+					Timestamp:   1000,
+					TxIndex:     uint64(i + 1),
+				})
+				if err != nil {
+					t.Fatalf(`got error "%v" when no error was expected`, err)
+				}
+			}
+
+			event, err := tx.GetTokenMintedWithExternalURIEvent(tt.contract, tt.tokenId)
+			if err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			if tt.expectedNil {
+				if event != nil {
+					t.Fatalf("got event %v, expected nil", event)
+				}
+				return
+			}
+			if event == nil || event.TxIndex != tt.expectedTxIndex || event.TokenId.Cmp(tt.tokenId) != 0 {
+				t.Fatalf("got event %v, expected the event of token %s at tx index %d", event, tt.tokenId, tt.expectedTxIndex)
+			}
+		})
+	}
+}
+
 func createBadgerTransaction(t *testing.T, db *badger.DB) (state.Tx, error) {
 	t.Helper()
 	badgerService := badgerStorage.NewService(db)
@@ -1,15 +1,19 @@
 package ownership
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
 )
 
 const (
-	contractPrefix = "contract_"
+	contractPrefix        = "contract_"
+	deploymentBlockPrefix = "deployment_block_"
+	backfillPrefix        = "backfill_"
 )
 
 type service struct {
@@ -29,10 +33,30 @@ func (s *service) StoreERC721UniversalContracts(universalContracts []model.ERC72
 		if err != nil {
 			return err
 		}
+		err = s.tx.Set([]byte(deploymentBlockPrefix+addressLowerCase), codec.EncodeUint64(universalContracts[i].BlockNumber))
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// GetERC721UniversalContractBlock returns the block where the contract was deployed, or 0 if it is unknown
+func (s *service) GetERC721UniversalContractBlock(contract string) (uint64, error) {
+	value, err := s.tx.Get([]byte(deploymentBlockPrefix + strings.ToLower(contract)))
+	if err != nil {
+		return 0, err
+	}
+	if value == nil {
+		return 0, nil
+	}
+	blockNumber, err := codec.DecodeUint64(value)
+	if err != nil {
+		return 0, fmt.Errorf("error decoding the deployment block of contract %s: %w", contract, err)
+	}
+	return blockNumber, nil
+}
+
 func (s *service) GetCollectionAddress(contract string) (common.Address, error) {
 	contractLowerCase := strings.ToLower(contract)
 	value, err := s.tx.Get([]byte(contractPrefix + contractLowerCase))
@@ -74,3 +98,45 @@ func (s *service) HasERC721UniversalContract(contract string) (bool, error) {
 	}
 	return value != nil, nil
 }
+
+// StoreContractBackfill stores the progress of the backfill of a contract
+func (s *service) StoreContractBackfill(backfill model.ContractBackfill) error {
+	return s.tx.Set([]byte(backfillPrefix+strings.ToLower(backfill.Contract.Address.String())), codec.EncodeContractBackfill(&backfill))
+}
+
+// GetContractBackfill returns the backfill of the contract, or nil if the contract is not being backfilled
+func (s *service) GetContractBackfill(contract string) (*model.ContractBackfill, error) {
+	value, err := s.tx.Get([]byte(backfillPrefix + strings.ToLower(contract)))
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	return decodeContractBackfill(value)
+}
+
+// GetContractBackfills returns the backfills of all the contracts being backfilled
+func (s *service) GetContractBackfills() ([]model.ContractBackfill, error) {
+	var backfills []model.ContractBackfill
+	for _, value := range s.tx.GetValuesWithPrefix([]byte(backfillPrefix)) {
+		backfill, err := decodeContractBackfill(value)
+		if err != nil {
+			return nil, err
+		}
+		backfills = append(backfills, *backfill)
+	}
+	return backfills, nil
+}
+
+func (s *service) DeleteContractBackfill(contract string) error {
+	return s.tx.Delete([]byte(backfillPrefix + strings.ToLower(contract)))
+}
+
+func decodeContractBackfill(value []byte) (*model.ContractBackfill, error) {
+	backfill, err := codec.DecodeContractBackfill(value)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding contract backfill: %w", err)
+	}
+	return &backfill, nil
+}
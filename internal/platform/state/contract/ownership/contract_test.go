@@ -36,6 +36,21 @@ func TestStoreGetERC721UniversalContracts(t *testing.T) {
 		if len(contracts) != 1 {
 			t.Errorf(`got %d contracts when 1 was expected`, len(contracts))
 		}
+
+		deploymentBlock, err := tx.GetERC721UniversalContractBlock(uEvent.Address.String())
+		if err != nil {
+			t.Errorf(`got error "%v" when no error was expected`, err)
+		}
+		if deploymentBlock != 10 {
+			t.Errorf(`got deployment block %d when 10 was expected`, deploymentBlock)
+		}
+		deploymentBlock, err = tx.GetERC721UniversalContractBlock(common.HexToAddress("0x502").String())
+		if err != nil {
+			t.Errorf(`got error "%v" when no error was expected`, err)
+		}
+		if deploymentBlock != 0 {
+			t.Errorf(`got deployment block %d for an unknown contract when 0 was expected`, deploymentBlock)
+		}
 	})
 }
 
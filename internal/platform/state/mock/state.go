@@ -16,6 +16,7 @@ import (
 	model "github.com/freeverseio/laos-universal-node/internal/platform/model"
 	state "github.com/freeverseio/laos-universal-node/internal/platform/state"
 	account "github.com/freeverseio/laos-universal-node/internal/platform/state/tree/account"
+	ownership "github.com/freeverseio/laos-universal-node/internal/platform/state/tree/ownership"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -138,18 +139,48 @@ func (mr *MockTxMockRecorder) Commit() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockTx)(nil).Commit))
 }
 
-// DeleteOldStoredBlockNumbers mocks base method.
-func (m *MockTx) DeleteOldStoredBlockNumbers() error {
+// ContractAccountData mocks base method.
+func (m *MockTx) ContractAccountData(contract common.Address, lastProcessedEvoBlock uint64) (*account.AccountData, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteOldStoredBlockNumbers")
+	ret := m.ctrl.Call(m, "ContractAccountData", contract, lastProcessedEvoBlock)
+	ret0, _ := ret[0].(*account.AccountData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ContractAccountData indicates an expected call of ContractAccountData.
+func (mr *MockTxMockRecorder) ContractAccountData(contract, lastProcessedEvoBlock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContractAccountData", reflect.TypeOf((*MockTx)(nil).ContractAccountData), contract, lastProcessedEvoBlock)
+}
+
+// DeleteContractBackfill mocks base method.
+func (m *MockTx) DeleteContractBackfill(contract string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteContractBackfill", contract)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// DeleteContractBackfill indicates an expected call of DeleteContractBackfill.
+func (mr *MockTxMockRecorder) DeleteContractBackfill(contract any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteContractBackfill", reflect.TypeOf((*MockTx)(nil).DeleteContractBackfill), contract)
+}
+
+// DeleteOldStoredBlockNumbers mocks base method.
+func (m *MockTx) DeleteOldStoredBlockNumbers(retention state.BlockRetention) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOldStoredBlockNumbers", retention)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // DeleteOldStoredBlockNumbers indicates an expected call of DeleteOldStoredBlockNumbers.
-func (mr *MockTxMockRecorder) DeleteOldStoredBlockNumbers() *gomock.Call {
+func (mr *MockTxMockRecorder) DeleteOldStoredBlockNumbers(retention any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOldStoredBlockNumbers", reflect.TypeOf((*MockTx)(nil).DeleteOldStoredBlockNumbers))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOldStoredBlockNumbers", reflect.TypeOf((*MockTx)(nil).DeleteOldStoredBlockNumbers), retention)
 }
 
 // DeleteOrphanBlockData mocks base method.
@@ -166,6 +197,20 @@ func (mr *MockTxMockRecorder) DeleteOrphanBlockData(blockNumberRef any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrphanBlockData", reflect.TypeOf((*MockTx)(nil).DeleteOrphanBlockData), blockNumberRef)
 }
 
+// DeleteOrphanMappedBlocks mocks base method.
+func (m *MockTx) DeleteOrphanMappedBlocks(blockNumberRef uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOrphanMappedBlocks", blockNumberRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOrphanMappedBlocks indicates an expected call of DeleteOrphanMappedBlocks.
+func (mr *MockTxMockRecorder) DeleteOrphanMappedBlocks(blockNumberRef any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrphanMappedBlocks", reflect.TypeOf((*MockTx)(nil).DeleteOrphanMappedBlocks), blockNumberRef)
+}
+
 // DeleteOrphanRootTags mocks base method.
 func (m *MockTx) DeleteOrphanRootTags(formBlock, toBlock int64) error {
 	m.ctrl.T.Helper()
@@ -236,6 +281,81 @@ func (mr *MockTxMockRecorder) GetCollectionAddress(contract any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCollectionAddress", reflect.TypeOf((*MockTx)(nil).GetCollectionAddress), contract)
 }
 
+// GetContractBackfill mocks base method.
+func (m *MockTx) GetContractBackfill(contract string) (*model.ContractBackfill, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContractBackfill", contract)
+	ret0, _ := ret[0].(*model.ContractBackfill)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContractBackfill indicates an expected call of GetContractBackfill.
+func (mr *MockTxMockRecorder) GetContractBackfill(contract any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContractBackfill", reflect.TypeOf((*MockTx)(nil).GetContractBackfill), contract)
+}
+
+// GetContractBackfills mocks base method.
+func (m *MockTx) GetContractBackfills() ([]model.ContractBackfill, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContractBackfills")
+	ret0, _ := ret[0].([]model.ContractBackfill)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContractBackfills indicates an expected call of GetContractBackfills.
+func (mr *MockTxMockRecorder) GetContractBackfills() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContractBackfills", reflect.TypeOf((*MockTx)(nil).GetContractBackfills))
+}
+
+// GetERC721UniversalContractBlock mocks base method.
+func (m *MockTx) GetERC721UniversalContractBlock(contract string) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetERC721UniversalContractBlock", contract)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetERC721UniversalContractBlock indicates an expected call of GetERC721UniversalContractBlock.
+func (mr *MockTxMockRecorder) GetERC721UniversalContractBlock(contract any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetERC721UniversalContractBlock", reflect.TypeOf((*MockTx)(nil).GetERC721UniversalContractBlock), contract)
+}
+
+// GetEvoBlock mocks base method.
+func (m *MockTx) GetEvoBlock(blockNumber uint64) (model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvoBlock", blockNumber)
+	ret0, _ := ret[0].(model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEvoBlock indicates an expected call of GetEvoBlock.
+func (mr *MockTxMockRecorder) GetEvoBlock(blockNumber any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvoBlock", reflect.TypeOf((*MockTx)(nil).GetEvoBlock), blockNumber)
+}
+
+// GetEvoBlocksByTimestamp mocks base method.
+func (m *MockTx) GetEvoBlocksByTimestamp(fromTimestamp, toTimestamp uint64) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvoBlocksByTimestamp", fromTimestamp, toTimestamp)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEvoBlocksByTimestamp indicates an expected call of GetEvoBlocksByTimestamp.
+func (mr *MockTxMockRecorder) GetEvoBlocksByTimestamp(fromTimestamp, toTimestamp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvoBlocksByTimestamp", reflect.TypeOf((*MockTx)(nil).GetEvoBlocksByTimestamp), fromTimestamp, toTimestamp)
+}
+
 // GetExistingERC721UniversalContracts mocks base method.
 func (m *MockTx) GetExistingERC721UniversalContracts(contracts []string) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -401,6 +521,36 @@ func (mr *MockTxMockRecorder) GetOwnershipBlock(blockNumber any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnershipBlock", reflect.TypeOf((*MockTx)(nil).GetOwnershipBlock), blockNumber)
 }
 
+// GetOwnershipBlocksByTimestamp mocks base method.
+func (m *MockTx) GetOwnershipBlocksByTimestamp(fromTimestamp, toTimestamp uint64) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOwnershipBlocksByTimestamp", fromTimestamp, toTimestamp)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOwnershipBlocksByTimestamp indicates an expected call of GetOwnershipBlocksByTimestamp.
+func (mr *MockTxMockRecorder) GetOwnershipBlocksByTimestamp(fromTimestamp, toTimestamp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnershipBlocksByTimestamp", reflect.TypeOf((*MockTx)(nil).GetOwnershipBlocksByTimestamp), fromTimestamp, toTimestamp)
+}
+
+// GetTokenMintedWithExternalURIEvent mocks base method.
+func (m *MockTx) GetTokenMintedWithExternalURIEvent(contract string, tokenId *big.Int) (*model.MintedWithExternalURI, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenMintedWithExternalURIEvent", contract, tokenId)
+	ret0, _ := ret[0].(*model.MintedWithExternalURI)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenMintedWithExternalURIEvent indicates an expected call of GetTokenMintedWithExternalURIEvent.
+func (mr *MockTxMockRecorder) GetTokenMintedWithExternalURIEvent(contract, tokenId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenMintedWithExternalURIEvent", reflect.TypeOf((*MockTx)(nil).GetTokenMintedWithExternalURIEvent), contract, tokenId)
+}
+
 // HasERC721UniversalContract mocks base method.
 func (m *MockTx) HasERC721UniversalContract(contract string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -430,6 +580,20 @@ func (mr *MockTxMockRecorder) LoadContractTrees(contractAddress any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadContractTrees", reflect.TypeOf((*MockTx)(nil).LoadContractTrees), contractAddress)
 }
 
+// LoadContractTreesAt mocks base method.
+func (m *MockTx) LoadContractTreesAt(contractAddress common.Address, data *account.AccountData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadContractTreesAt", contractAddress, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LoadContractTreesAt indicates an expected call of LoadContractTreesAt.
+func (mr *MockTxMockRecorder) LoadContractTreesAt(contractAddress, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadContractTreesAt", reflect.TypeOf((*MockTx)(nil).LoadContractTreesAt), contractAddress, data)
+}
+
 // Mint mocks base method.
 func (m *MockTx) Mint(contract common.Address, mintEvent *model.MintedWithExternalURI) error {
 	m.ctrl.T.Helper()
@@ -459,6 +623,34 @@ func (mr *MockTxMockRecorder) OwnerOf(contract, tokenId any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OwnerOf", reflect.TypeOf((*MockTx)(nil).OwnerOf), contract, tokenId)
 }
 
+// PatchRootTag mocks base method.
+func (m *MockTx) PatchRootTag(blockNumber int64, contract common.Address, data *account.AccountData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchRootTag", blockNumber, contract, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchRootTag indicates an expected call of PatchRootTag.
+func (mr *MockTxMockRecorder) PatchRootTag(blockNumber, contract, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchRootTag", reflect.TypeOf((*MockTx)(nil).PatchRootTag), blockNumber, contract, data)
+}
+
+// SetEvoBlock mocks base method.
+func (m *MockTx) SetEvoBlock(block model.Block) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEvoBlock", block)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEvoBlock indicates an expected call of SetEvoBlock.
+func (mr *MockTxMockRecorder) SetEvoBlock(block any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEvoBlock", reflect.TypeOf((*MockTx)(nil).SetEvoBlock), block)
+}
+
 // SetFirstEvoBlock mocks base method.
 func (m *MockTx) SetFirstEvoBlock(block model.Block) error {
 	m.ctrl.T.Helper()
@@ -557,6 +749,20 @@ func (mr *MockTxMockRecorder) SetOwnershipBlock(blockNumber, block any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOwnershipBlock", reflect.TypeOf((*MockTx)(nil).SetOwnershipBlock), blockNumber, block)
 }
 
+// SetOwnershipBlockTimestamp mocks base method.
+func (m *MockTx) SetOwnershipBlockTimestamp(block model.Block) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOwnershipBlockTimestamp", block)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetOwnershipBlockTimestamp indicates an expected call of SetOwnershipBlockTimestamp.
+func (mr *MockTxMockRecorder) SetOwnershipBlockTimestamp(block any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOwnershipBlockTimestamp", reflect.TypeOf((*MockTx)(nil).SetOwnershipBlockTimestamp), block)
+}
+
 // SetOwnershipEvoBlockMapping mocks base method.
 func (m *MockTx) SetOwnershipEvoBlockMapping(ownershipBlockNumber, evoBlockNumber uint64) error {
 	m.ctrl.T.Helper()
@@ -571,6 +777,20 @@ func (mr *MockTxMockRecorder) SetOwnershipEvoBlockMapping(ownershipBlockNumber,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOwnershipEvoBlockMapping", reflect.TypeOf((*MockTx)(nil).SetOwnershipEvoBlockMapping), ownershipBlockNumber, evoBlockNumber)
 }
 
+// StoreContractBackfill mocks base method.
+func (m *MockTx) StoreContractBackfill(backfill model.ContractBackfill) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreContractBackfill", backfill)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreContractBackfill indicates an expected call of StoreContractBackfill.
+func (mr *MockTxMockRecorder) StoreContractBackfill(backfill any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreContractBackfill", reflect.TypeOf((*MockTx)(nil).StoreContractBackfill), backfill)
+}
+
 // StoreERC721UniversalContracts mocks base method.
 func (m *MockTx) StoreERC721UniversalContracts(universalContracts []model.ERC721UniversalContract) error {
 	m.ctrl.T.Helper()
@@ -628,6 +848,21 @@ func (mr *MockTxMockRecorder) TokenByIndex(contract, idx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TokenByIndex", reflect.TypeOf((*MockTx)(nil).TokenByIndex), contract, idx)
 }
 
+// TokenData mocks base method.
+func (m *MockTx) TokenData(contract common.Address, tokenId *big.Int) (*ownership.TokenData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TokenData", contract, tokenId)
+	ret0, _ := ret[0].(*ownership.TokenData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TokenData indicates an expected call of TokenData.
+func (mr *MockTxMockRecorder) TokenData(contract, tokenId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TokenData", reflect.TypeOf((*MockTx)(nil).TokenData), contract, tokenId)
+}
+
 // TokenOfOwnerByIndex mocks base method.
 func (m *MockTx) TokenOfOwnerByIndex(contract, owner common.Address, idx int) (*big.Int, error) {
 	m.ctrl.T.Helper()
@@ -768,6 +1003,21 @@ func (mr *MockStateMockRecorder) Checkout(blockNumber any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Checkout", reflect.TypeOf((*MockState)(nil).Checkout), blockNumber)
 }
 
+// ContractAccountData mocks base method.
+func (m *MockState) ContractAccountData(contract common.Address, lastProcessedEvoBlock uint64) (*account.AccountData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ContractAccountData", contract, lastProcessedEvoBlock)
+	ret0, _ := ret[0].(*account.AccountData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ContractAccountData indicates an expected call of ContractAccountData.
+func (mr *MockStateMockRecorder) ContractAccountData(contract, lastProcessedEvoBlock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContractAccountData", reflect.TypeOf((*MockState)(nil).ContractAccountData), contract, lastProcessedEvoBlock)
+}
+
 // DeleteOrphanRootTags mocks base method.
 func (m *MockState) DeleteOrphanRootTags(formBlock, toBlock int64) error {
 	m.ctrl.T.Helper()
@@ -811,6 +1061,20 @@ func (mr *MockStateMockRecorder) LoadContractTrees(contractAddress any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadContractTrees", reflect.TypeOf((*MockState)(nil).LoadContractTrees), contractAddress)
 }
 
+// LoadContractTreesAt mocks base method.
+func (m *MockState) LoadContractTreesAt(contractAddress common.Address, data *account.AccountData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadContractTreesAt", contractAddress, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LoadContractTreesAt indicates an expected call of LoadContractTreesAt.
+func (mr *MockStateMockRecorder) LoadContractTreesAt(contractAddress, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadContractTreesAt", reflect.TypeOf((*MockState)(nil).LoadContractTreesAt), contractAddress, data)
+}
+
 // Mint mocks base method.
 func (m *MockState) Mint(contract common.Address, mintEvent *model.MintedWithExternalURI) error {
 	m.ctrl.T.Helper()
@@ -840,6 +1104,20 @@ func (mr *MockStateMockRecorder) OwnerOf(contract, tokenId any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OwnerOf", reflect.TypeOf((*MockState)(nil).OwnerOf), contract, tokenId)
 }
 
+// PatchRootTag mocks base method.
+func (m *MockState) PatchRootTag(blockNumber int64, contract common.Address, data *account.AccountData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchRootTag", blockNumber, contract, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchRootTag indicates an expected call of PatchRootTag.
+func (mr *MockStateMockRecorder) PatchRootTag(blockNumber, contract, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchRootTag", reflect.TypeOf((*MockState)(nil).PatchRootTag), blockNumber, contract, data)
+}
+
 // TagRoot mocks base method.
 func (m *MockState) TagRoot(blockNumber int64) error {
 	m.ctrl.T.Helper()
@@ -869,6 +1147,21 @@ func (mr *MockStateMockRecorder) TokenByIndex(contract, idx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TokenByIndex", reflect.TypeOf((*MockState)(nil).TokenByIndex), contract, idx)
 }
 
+// TokenData mocks base method.
+func (m *MockState) TokenData(contract common.Address, tokenId *big.Int) (*ownership.TokenData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TokenData", contract, tokenId)
+	ret0, _ := ret[0].(*ownership.TokenData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TokenData indicates an expected call of TokenData.
+func (mr *MockStateMockRecorder) TokenData(contract, tokenId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TokenData", reflect.TypeOf((*MockState)(nil).TokenData), contract, tokenId)
+}
+
 // TokenOfOwnerByIndex mocks base method.
 func (m *MockState) TokenOfOwnerByIndex(contract, owner common.Address, idx int) (*big.Int, error) {
 	m.ctrl.T.Helper()
@@ -965,6 +1258,20 @@ func (m *MockOwnershipContractState) EXPECT() *MockOwnershipContractStateMockRec
 	return m.recorder
 }
 
+// DeleteContractBackfill mocks base method.
+func (m *MockOwnershipContractState) DeleteContractBackfill(contract string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteContractBackfill", contract)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteContractBackfill indicates an expected call of DeleteContractBackfill.
+func (mr *MockOwnershipContractStateMockRecorder) DeleteContractBackfill(contract any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteContractBackfill", reflect.TypeOf((*MockOwnershipContractState)(nil).DeleteContractBackfill), contract)
+}
+
 // GetAllERC721UniversalContracts mocks base method.
 func (m *MockOwnershipContractState) GetAllERC721UniversalContracts() []string {
 	m.ctrl.T.Helper()
@@ -994,6 +1301,51 @@ func (mr *MockOwnershipContractStateMockRecorder) GetCollectionAddress(contract
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCollectionAddress", reflect.TypeOf((*MockOwnershipContractState)(nil).GetCollectionAddress), contract)
 }
 
+// GetContractBackfill mocks base method.
+func (m *MockOwnershipContractState) GetContractBackfill(contract string) (*model.ContractBackfill, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContractBackfill", contract)
+	ret0, _ := ret[0].(*model.ContractBackfill)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContractBackfill indicates an expected call of GetContractBackfill.
+func (mr *MockOwnershipContractStateMockRecorder) GetContractBackfill(contract any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContractBackfill", reflect.TypeOf((*MockOwnershipContractState)(nil).GetContractBackfill), contract)
+}
+
+// GetContractBackfills mocks base method.
+func (m *MockOwnershipContractState) GetContractBackfills() ([]model.ContractBackfill, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContractBackfills")
+	ret0, _ := ret[0].([]model.ContractBackfill)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContractBackfills indicates an expected call of GetContractBackfills.
+func (mr *MockOwnershipContractStateMockRecorder) GetContractBackfills() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContractBackfills", reflect.TypeOf((*MockOwnershipContractState)(nil).GetContractBackfills))
+}
+
+// GetERC721UniversalContractBlock mocks base method.
+func (m *MockOwnershipContractState) GetERC721UniversalContractBlock(contract string) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetERC721UniversalContractBlock", contract)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetERC721UniversalContractBlock indicates an expected call of GetERC721UniversalContractBlock.
+func (mr *MockOwnershipContractStateMockRecorder) GetERC721UniversalContractBlock(contract any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetERC721UniversalContractBlock", reflect.TypeOf((*MockOwnershipContractState)(nil).GetERC721UniversalContractBlock), contract)
+}
+
 // GetExistingERC721UniversalContracts mocks base method.
 func (m *MockOwnershipContractState) GetExistingERC721UniversalContracts(contracts []string) ([]string, error) {
 	m.ctrl.T.Helper()
@@ -1024,6 +1376,20 @@ func (mr *MockOwnershipContractStateMockRecorder) HasERC721UniversalContract(con
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasERC721UniversalContract", reflect.TypeOf((*MockOwnershipContractState)(nil).HasERC721UniversalContract), contract)
 }
 
+// StoreContractBackfill mocks base method.
+func (m *MockOwnershipContractState) StoreContractBackfill(backfill model.ContractBackfill) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreContractBackfill", backfill)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreContractBackfill indicates an expected call of StoreContractBackfill.
+func (mr *MockOwnershipContractStateMockRecorder) StoreContractBackfill(backfill any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreContractBackfill", reflect.TypeOf((*MockOwnershipContractState)(nil).StoreContractBackfill), backfill)
+}
+
 // StoreERC721UniversalContracts mocks base method.
 func (m *MockOwnershipContractState) StoreERC721UniversalContracts(universalContracts []model.ERC721UniversalContract) error {
 	m.ctrl.T.Helper()
@@ -1076,6 +1442,21 @@ func (mr *MockEvolutionContractStateMockRecorder) GetMintedWithExternalURIEvents
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMintedWithExternalURIEvents", reflect.TypeOf((*MockEvolutionContractState)(nil).GetMintedWithExternalURIEvents), contract, blockNumber)
 }
 
+// GetTokenMintedWithExternalURIEvent mocks base method.
+func (m *MockEvolutionContractState) GetTokenMintedWithExternalURIEvent(contract string, tokenId *big.Int) (*model.MintedWithExternalURI, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenMintedWithExternalURIEvent", contract, tokenId)
+	ret0, _ := ret[0].(*model.MintedWithExternalURI)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenMintedWithExternalURIEvent indicates an expected call of GetTokenMintedWithExternalURIEvent.
+func (mr *MockEvolutionContractStateMockRecorder) GetTokenMintedWithExternalURIEvent(contract, tokenId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenMintedWithExternalURIEvent", reflect.TypeOf((*MockEvolutionContractState)(nil).GetTokenMintedWithExternalURIEvent), contract, tokenId)
+}
+
 // StoreMintedWithExternalURIEvent mocks base method.
 func (m *MockEvolutionContractState) StoreMintedWithExternalURIEvent(contract string, event *model.MintedWithExternalURI) error {
 	m.ctrl.T.Helper()
@@ -1114,17 +1495,18 @@ func (m *MockOwnershipSyncState) EXPECT() *MockOwnershipSyncStateMockRecorder {
 }
 
 // DeleteOldStoredBlockNumbers mocks base method.
-func (m *MockOwnershipSyncState) DeleteOldStoredBlockNumbers() error {
+func (m *MockOwnershipSyncState) DeleteOldStoredBlockNumbers(retention state.BlockRetention) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteOldStoredBlockNumbers")
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "DeleteOldStoredBlockNumbers", retention)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // DeleteOldStoredBlockNumbers indicates an expected call of DeleteOldStoredBlockNumbers.
-func (mr *MockOwnershipSyncStateMockRecorder) DeleteOldStoredBlockNumbers() *gomock.Call {
+func (mr *MockOwnershipSyncStateMockRecorder) DeleteOldStoredBlockNumbers(retention any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOldStoredBlockNumbers", reflect.TypeOf((*MockOwnershipSyncState)(nil).DeleteOldStoredBlockNumbers))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOldStoredBlockNumbers", reflect.TypeOf((*MockOwnershipSyncState)(nil).DeleteOldStoredBlockNumbers), retention)
 }
 
 // DeleteOrphanBlockData mocks base method.
@@ -1141,6 +1523,20 @@ func (mr *MockOwnershipSyncStateMockRecorder) DeleteOrphanBlockData(blockNumberR
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrphanBlockData", reflect.TypeOf((*MockOwnershipSyncState)(nil).DeleteOrphanBlockData), blockNumberRef)
 }
 
+// DeleteOrphanMappedBlocks mocks base method.
+func (m *MockOwnershipSyncState) DeleteOrphanMappedBlocks(blockNumberRef uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOrphanMappedBlocks", blockNumberRef)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOrphanMappedBlocks indicates an expected call of DeleteOrphanMappedBlocks.
+func (mr *MockOwnershipSyncStateMockRecorder) DeleteOrphanMappedBlocks(blockNumberRef any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrphanMappedBlocks", reflect.TypeOf((*MockOwnershipSyncState)(nil).DeleteOrphanMappedBlocks), blockNumberRef)
+}
+
 // GetAllStoredBlockNumbers mocks base method.
 func (m *MockOwnershipSyncState) GetAllStoredBlockNumbers() ([]uint64, error) {
 	m.ctrl.T.Helper()
@@ -1231,6 +1627,21 @@ func (mr *MockOwnershipSyncStateMockRecorder) GetOwnershipBlock(blockNumber any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnershipBlock", reflect.TypeOf((*MockOwnershipSyncState)(nil).GetOwnershipBlock), blockNumber)
 }
 
+// GetOwnershipBlocksByTimestamp mocks base method.
+func (m *MockOwnershipSyncState) GetOwnershipBlocksByTimestamp(fromTimestamp, toTimestamp uint64) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOwnershipBlocksByTimestamp", fromTimestamp, toTimestamp)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOwnershipBlocksByTimestamp indicates an expected call of GetOwnershipBlocksByTimestamp.
+func (mr *MockOwnershipSyncStateMockRecorder) GetOwnershipBlocksByTimestamp(fromTimestamp, toTimestamp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnershipBlocksByTimestamp", reflect.TypeOf((*MockOwnershipSyncState)(nil).GetOwnershipBlocksByTimestamp), fromTimestamp, toTimestamp)
+}
+
 // SetFirstOwnershipBlock mocks base method.
 func (m *MockOwnershipSyncState) SetFirstOwnershipBlock(block model.Block) error {
 	m.ctrl.T.Helper()
@@ -1287,6 +1698,20 @@ func (mr *MockOwnershipSyncStateMockRecorder) SetOwnershipBlock(blockNumber, blo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOwnershipBlock", reflect.TypeOf((*MockOwnershipSyncState)(nil).SetOwnershipBlock), blockNumber, block)
 }
 
+// SetOwnershipBlockTimestamp mocks base method.
+func (m *MockOwnershipSyncState) SetOwnershipBlockTimestamp(block model.Block) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOwnershipBlockTimestamp", block)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetOwnershipBlockTimestamp indicates an expected call of SetOwnershipBlockTimestamp.
+func (mr *MockOwnershipSyncStateMockRecorder) SetOwnershipBlockTimestamp(block any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOwnershipBlockTimestamp", reflect.TypeOf((*MockOwnershipSyncState)(nil).SetOwnershipBlockTimestamp), block)
+}
+
 // SetOwnershipEvoBlockMapping mocks base method.
 func (m *MockOwnershipSyncState) SetOwnershipEvoBlockMapping(ownershipBlockNumber, evoBlockNumber uint64) error {
 	m.ctrl.T.Helper()
@@ -1324,6 +1749,36 @@ func (m *MockEvolutionSyncState) EXPECT() *MockEvolutionSyncStateMockRecorder {
 	return m.recorder
 }
 
+// GetEvoBlock mocks base method.
+func (m *MockEvolutionSyncState) GetEvoBlock(blockNumber uint64) (model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvoBlock", blockNumber)
+	ret0, _ := ret[0].(model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEvoBlock indicates an expected call of GetEvoBlock.
+func (mr *MockEvolutionSyncStateMockRecorder) GetEvoBlock(blockNumber any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvoBlock", reflect.TypeOf((*MockEvolutionSyncState)(nil).GetEvoBlock), blockNumber)
+}
+
+// GetEvoBlocksByTimestamp mocks base method.
+func (m *MockEvolutionSyncState) GetEvoBlocksByTimestamp(fromTimestamp, toTimestamp uint64) ([]model.Block, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvoBlocksByTimestamp", fromTimestamp, toTimestamp)
+	ret0, _ := ret[0].([]model.Block)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEvoBlocksByTimestamp indicates an expected call of GetEvoBlocksByTimestamp.
+func (mr *MockEvolutionSyncStateMockRecorder) GetEvoBlocksByTimestamp(fromTimestamp, toTimestamp any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvoBlocksByTimestamp", reflect.TypeOf((*MockEvolutionSyncState)(nil).GetEvoBlocksByTimestamp), fromTimestamp, toTimestamp)
+}
+
 // GetFirstEvoBlock mocks base method.
 func (m *MockEvolutionSyncState) GetFirstEvoBlock() (model.Block, error) {
 	m.ctrl.T.Helper()
@@ -1369,6 +1824,20 @@ func (mr *MockEvolutionSyncStateMockRecorder) GetNextEvoEventBlock(contract, blo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNextEvoEventBlock", reflect.TypeOf((*MockEvolutionSyncState)(nil).GetNextEvoEventBlock), contract, blockNumber)
 }
 
+// SetEvoBlock mocks base method.
+func (m *MockEvolutionSyncState) SetEvoBlock(block model.Block) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEvoBlock", block)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEvoBlock indicates an expected call of SetEvoBlock.
+func (mr *MockEvolutionSyncStateMockRecorder) SetEvoBlock(block any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEvoBlock", reflect.TypeOf((*MockEvolutionSyncState)(nil).SetEvoBlock), block)
+}
+
 // SetFirstEvoBlock mocks base method.
 func (m *MockEvolutionSyncState) SetFirstEvoBlock(block model.Block) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,266 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
+	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/tree/account"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/tree/ownership"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/migration"
+)
+
+// binaryCodec rewrites the legacy layout of gob, JSON and decimal strings with the binary codec. The entries are told apart
+// from the migrated ones by their key when it changes, and by the version byte the codec writes first otherwise,
+// which the legacy encodings never start with.
+var binaryCodec = migration.Migration{
+	Version:     1,
+	Description: "binary codec",
+	Steps: []migration.Step{
+		{Prefix: "ownership_first_block", Rewrite: migrateValue(legacyBlock)},
+		{Prefix: "ownership_last_block", Rewrite: migrateValue(legacyBlock)},
+		{Prefix: "evo_first_block", Rewrite: migrateValue(legacyBlock)},
+		{Prefix: "evo_last_block", Rewrite: migrateValue(legacyBlock)},
+		{Prefix: "mapped_ownership_last_block", Rewrite: migrateValue(legacyUint64)},
+		{Prefix: "deployment_block_", Rewrite: migrateValue(legacyUint64)},
+		{Prefix: "last_evo_event_block", Rewrite: migrateValue(legacyUint64)},
+		{Prefix: "accountlasttag/", Rewrite: migrateValue(legacyInt64)},
+		{Prefix: "backfill_", Rewrite: migrateValue(legacyContractBackfill)},
+		{Prefix: "accountdata/", Rewrite: migrateValue(legacyAccountData)},
+		{Prefix: "ownership/data/", Rewrite: migrateValue(legacyTokenData)},
+		{Prefix: "enumerated/tokens/", Rewrite: migrateValue(legacyBigInt)},
+		{Prefix: "enumeratedtotal/tokens/", Rewrite: migrateValue(legacyBigInt)},
+		{Prefix: "ownership_block_", Rewrite: migrateOwnershipBlock},
+		{Prefix: "evo_block_", Rewrite: migrateEvoBlock},
+		{Prefix: "mapped_ownership_block_", Rewrite: migrateMappedOwnershipBlock},
+		{Prefix: "ownership_timestamp_", Rewrite: migrateTimestamp},
+		{Prefix: "evo_timestamp_", Rewrite: migrateTimestamp},
+		{Prefix: "next_evo_event_block_", Rewrite: migrateNextEvoEventBlock},
+		{Prefix: "evo_events_", Rewrite: migrateEvoEvent},
+		{Prefix: "accounttags/", Rewrite: migrateAccountTag},
+	},
+}
+
+// migrateValue migrates the entries whose key is kept, re-encoding their value with decode
+func migrateValue(decode func(value []byte) ([]byte, error)) func(key, value []byte) ([]byte, []byte, error) {
+	return func(key, value []byte) ([]byte, []byte, error) {
+		if codec.IsEncoded(value) {
+			return nil, nil, nil
+		}
+		newValue, err := decode(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, newValue, nil
+	}
+}
+
+func legacyBlock(value []byte) ([]byte, error) {
+	var block model.Block
+	if err := gob.NewDecoder(bytes.NewBuffer(value)).Decode(&block); err != nil {
+		return nil, err
+	}
+	return codec.EncodeBlock(block), nil
+}
+
+func legacyUint64(value []byte) ([]byte, error) {
+	n, err := strconv.ParseUint(string(value), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return codec.EncodeUint64(n), nil
+}
+
+func legacyInt64(value []byte) ([]byte, error) {
+	n, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return codec.EncodeInt64(n), nil
+}
+
+func legacyContractBackfill(value []byte) ([]byte, error) {
+	var backfill model.ContractBackfill
+	if err := gob.NewDecoder(bytes.NewBuffer(value)).Decode(&backfill); err != nil {
+		return nil, err
+	}
+	return codec.EncodeContractBackfill(&backfill), nil
+}
+
+func legacyAccountData(value []byte) ([]byte, error) {
+	var data account.AccountData
+	if err := json.Unmarshal(value, &data); err != nil {
+		return nil, err
+	}
+	return data.MarshalBinary()
+}
+
+func legacyTokenData(value []byte) ([]byte, error) {
+	var data ownership.TokenData
+	if err := json.Unmarshal(value, &data); err != nil {
+		return nil, err
+	}
+	return data.MarshalBinary()
+}
+
+// legacyBigInt decodes the token ids, stored as JSON numbers, and the balances, stored as decimal strings, which are the same
+func legacyBigInt(value []byte) ([]byte, error) {
+	var n big.Int
+	if err := json.Unmarshal(value, &n); err != nil {
+		return nil, err
+	}
+	return codec.EncodeBigInt(&n), nil
+}
+
+// migrateOwnershipBlock migrates ownership_block_<18 digits number>, a gob block, to ownership_block_<number>
+func migrateOwnershipBlock(key, value []byte) ([]byte, []byte, error) {
+	const prefix = "ownership_block_"
+	number, ok := parseDecimal(key[len(prefix):])
+	if !ok {
+		return nil, nil, nil
+	}
+	newValue, err := legacyBlock(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return numberKey(prefix, number), newValue, nil
+}
+
+// migrateEvoBlock migrates evo_block_<18 digits number>, its hash followed by its big endian timestamp, to evo_block_<number>
+func migrateEvoBlock(key, value []byte) ([]byte, []byte, error) {
+	const prefix = "evo_block_"
+	number, ok := parseDecimal(key[len(prefix):])
+	if !ok {
+		return nil, nil, nil
+	}
+	if len(value) != common.HashLength+8 {
+		return nil, nil, fmt.Errorf("got %d bytes, expected %d", len(value), common.HashLength+8)
+	}
+	block := model.Block{
+		Number:    number,
+		Hash:      common.BytesToHash(value[:common.HashLength]),
+		Timestamp: binary.BigEndian.Uint64(value[common.HashLength:]),
+	}
+	return numberKey(prefix, number), codec.EncodeBlock(block), nil
+}
+
+// migrateMappedOwnershipBlock migrates mapped_ownership_block_<decimal number>, a decimal evo block, to mapped_ownership_block_<number>
+func migrateMappedOwnershipBlock(key, value []byte) ([]byte, []byte, error) {
+	const prefix = "mapped_ownership_block_"
+	number, ok := parseDecimal(key[len(prefix):])
+	if !ok {
+		return nil, nil, nil
+	}
+	newValue, err := legacyUint64(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return numberKey(prefix, number), newValue, nil
+}
+
+// migrateTimestamp migrates the timestamp indexes <prefix><20 digits timestamp>_<18 digits number> to <prefix><timestamp><number>
+func migrateTimestamp(key, value []byte) ([]byte, []byte, error) {
+	prefix, rest, ok := bytes.Cut(key, []byte("timestamp_"))
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected timestamp key")
+	}
+	timestamp, number, ok := bytes.Cut(rest, []byte("_"))
+	if !ok {
+		return nil, nil, nil
+	}
+	parsedTimestamp, ok := parseDecimal(timestamp)
+	if !ok {
+		return nil, nil, nil
+	}
+	parsedNumber, ok := parseDecimal(number)
+	if !ok {
+		return nil, nil, nil
+	}
+	newKey := numberKey(string(prefix)+"timestamp_", parsedTimestamp)
+	return append(newKey, codec.Uint64Key(parsedNumber)...), value, nil
+}
+
+// migrateNextEvoEventBlock migrates next_evo_event_block_<contract>_<decimal number>, a decimal block,
+// to next_evo_event_block_<contract>_<number>
+func migrateNextEvoEventBlock(key, value []byte) ([]byte, []byte, error) {
+	const prefix = "next_evo_event_block_"
+	contract, number, ok := bytes.Cut(key[len(prefix):], []byte("_"))
+	if !ok {
+		return nil, nil, fmt.Errorf("missing block number")
+	}
+	parsedNumber, ok := parseDecimal(number)
+	if !ok {
+		return nil, nil, nil
+	}
+	newValue, err := legacyUint64(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return numberKey(prefix+string(contract)+"_", parsedNumber), newValue, nil
+}
+
+// migrateEvoEvent migrates evo_events_<contract>_<18 digits block>_<8 digits tx index>, a gob event,
+// to evo_events_<contract>_<block><tx index>
+func migrateEvoEvent(key, value []byte) ([]byte, []byte, error) {
+	const prefix = "evo_events_"
+	contract, rest, ok := bytes.Cut(key[len(prefix):], []byte("_"))
+	if !ok {
+		return nil, nil, fmt.Errorf("missing block number")
+	}
+	number, txIndex, ok := bytes.Cut(rest, []byte("_"))
+	if !ok {
+		return nil, nil, nil
+	}
+	parsedNumber, ok := parseDecimal(number)
+	if !ok {
+		return nil, nil, nil
+	}
+	parsedTxIndex, ok := parseDecimal(txIndex)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	var event model.MintedWithExternalURI
+	if err := gob.NewDecoder(bytes.NewBuffer(value)).Decode(&event); err != nil {
+		return nil, nil, err
+	}
+	newKey := numberKey(prefix+string(contract)+"_", parsedNumber)
+	return append(newKey, codec.Uint64Key(parsedTxIndex)...), codec.EncodeMintedWithExternalURI(&event), nil
+}
+
+// migrateAccountTag migrates accounttags/<decimal number>, a root, to accounttags/<number>
+func migrateAccountTag(key, value []byte) ([]byte, []byte, error) {
+	const prefix = "accounttags/"
+	number, ok := parseDecimal(key[len(prefix):])
+	if !ok {
+		return nil, nil, nil
+	}
+	return numberKey(prefix, number), value, nil
+}
+
+// parseDecimal parses the decimal numbers of the legacy keys. The migrated keys are fixed-width big endian numbers,
+// which start with a zero byte for any block number or timestamp and are never taken as decimal.
+func parseDecimal(b []byte) (uint64, bool) {
+	if len(b) == 0 {
+		return 0, false
+	}
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.ParseUint(string(b), 10, 64)
+	return n, err == nil
+}
+
+func numberKey(prefix string, number uint64) []byte {
+	return append([]byte(prefix), codec.Uint64Key(number)...)
+}
@@ -0,0 +1,537 @@
+package schema_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
+	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/schema"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/tree/account"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/tree/ownership"
+	v1 "github.com/freeverseio/laos-universal-node/internal/platform/state/v1"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+	badgerStorage "github.com/freeverseio/laos-universal-node/internal/platform/storage/badger"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/migration"
+)
+
+var update = flag.Bool("update", false, "rewrite the fixture databases in testdata")
+
+// latestFixture is the fixture database of the latest version, which every fixture is migrated to
+const latestFixture = "testdata/v2.json"
+
+func TestMigrateFixtures(t *testing.T) {
+	t.Parallel()
+	if *update {
+		writeFixtures(t)
+	}
+	tests := []struct {
+		fixture string
+		version uint64
+	}{
+		{fixture: "testdata/v0.json", version: 0},
+		{fixture: "testdata/v1.json", version: 1},
+		{fixture: latestFixture, version: 2},
+	}
+
+	expected := readFixture(t, latestFixture)
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.fixture, func(t *testing.T) {
+			t.Parallel()
+			service := badgerStorage.NewService(createBadger(t))
+			for key, value := range readFixture(t, tt.fixture) {
+				if err := service.Set([]byte(key), value); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.version != 0 {
+				setVersion(t, service, tt.version)
+			}
+
+			if _, err := migrate(service, migration.Options{BatchSize: 3}); err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			assertMigrated(t, service, expected)
+			assertState(t, service)
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+	t.Run("rewrites a legacy database to the latest layout", func(t *testing.T) {
+		t.Parallel()
+		service := badgerStorage.NewService(createBadger(t))
+		expected := writeFixture(t, service)
+		writeLegacyLayout(t, service)
+
+		if _, err := migrate(service, migration.Options{BatchSize: 3}); err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		assertMigrated(t, service, expected)
+
+		// the migrated state is read through the state service, the tree roots being unchanged
+		assertState(t, service)
+	})
+	t.Run("dry run counts the legacy entries without rewriting them", func(t *testing.T) {
+		t.Parallel()
+		service := badgerStorage.NewService(createBadger(t))
+		writeFixture(t, service)
+		legacyEntries := writeLegacyLayout(t, service)
+		legacy := dump(t, service)
+
+		report, err := migrate(service, migration.Options{BatchSize: 3, DryRun: true})
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if len(report.Migrations) != len(schema.Migrations) || report.Migrations[0].Rewritten != legacyEntries {
+			t.Fatalf("got report %v, expected %d entries to be rewritten", *report, legacyEntries)
+		}
+		if got := dump(t, service); fmt.Sprint(got) != fmt.Sprint(legacy) {
+			t.Fatal("the dry run changed the database")
+		}
+	})
+	t.Run("resumes an interrupted migration", func(t *testing.T) {
+		t.Parallel()
+		service := badgerStorage.NewService(createBadger(t))
+		expected := writeFixture(t, service)
+		writeLegacyLayout(t, service)
+
+		interrupted := &interruptedService{Service: service, commitsLeft: 5}
+		_, err := migrate(interrupted, migration.Options{BatchSize: 3})
+		if !errors.Is(err, errInterrupted) {
+			t.Fatalf(`got error "%v", expected "%v"`, err, errInterrupted)
+		}
+
+		if _, err := migrate(service, migration.Options{BatchSize: 3}); err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		assertMigrated(t, service, expected)
+	})
+	t.Run("does nothing on an up to date database", func(t *testing.T) {
+		t.Parallel()
+		service := badgerStorage.NewService(createBadger(t))
+		if _, err := migrate(service, migration.Options{BatchSize: 3}); err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		expected := writeFixture(t, service)
+
+		report, err := migrate(service, migration.Options{BatchSize: 3})
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if len(report.Migrations) != 0 {
+			t.Fatalf("got report %v, expected no migrations", *report)
+		}
+		assertMigrated(t, service, expected)
+	})
+}
+
+func migrate(service storage.Service, opts migration.Options) (*migration.Report, error) {
+	m, err := schema.NewMigrator(service)
+	if err != nil {
+		return nil, err
+	}
+	return m.Run(opts)
+}
+
+// writeFixtures rewrites the fixture databases with the layout written by the current code
+func writeFixtures(t *testing.T) {
+	t.Helper()
+	service := badgerStorage.NewService(createBadger(t))
+	writeFile(t, latestFixture, writeFixture(t, service))
+	removeEvoTokenEvents(t, service)
+	writeFile(t, "testdata/v1.json", dump(t, service))
+	writeLegacyLayout(t, service)
+	writeFile(t, "testdata/v0.json", dump(t, service))
+}
+
+// readFixture reads a fixture database, stored as a JSON object of hex encoded keys and values
+func readFixture(t *testing.T, path string) map[string][]byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fixture map[string]string
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		t.Fatal(err)
+	}
+	entries := make(map[string][]byte, len(fixture))
+	for key, value := range fixture {
+		entries[string(common.FromHex(key))] = common.FromHex(value)
+	}
+	return entries
+}
+
+func writeFile(t *testing.T, path string, entries map[string][]byte) {
+	t.Helper()
+	fixture := make(map[string]string, len(entries))
+	for key, value := range entries {
+		fixture[hexutil.Encode([]byte(key))] = hexutil.Encode(value)
+	}
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var (
+	contract   = common.HexToAddress("0x500")
+	collection = common.HexToAddress("0x501")
+	owner      = common.HexToAddress("0xB200110583D9d9F5E041FcEe024886bd00996691")
+	receiver   = common.HexToAddress("0x3")
+)
+
+func tokenId(slot int64) *big.Int {
+	id := new(big.Int).Lsh(big.NewInt(slot), 160)
+	return id.Add(id, owner.Big())
+}
+
+// writeFixture fills the database through the state service, so that every family of entries is stored,
+// and returns all its entries but the schema version
+func writeFixture(t *testing.T, service storage.Service) map[string][]byte {
+	t.Helper()
+	tx, err := v1.NewStateService(service).NewTransaction()
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	defer tx.Discard()
+
+	check := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+	}
+	check(tx.StoreERC721UniversalContracts([]model.ERC721UniversalContract{{Address: contract, CollectionAddress: collection, BlockNumber: 100}}))
+	check(tx.LoadContractTrees(contract))
+	for slot := int64(1); slot <= 2; slot++ {
+		event := model.MintedWithExternalURI{
+			Slot: big.NewInt(slot), To: owner, TokenURI: fmt.Sprintf("ipfs://%d", slot), TokenId: tokenId(slot), BlockNumber: 7, Timestamp: 70, TxIndex: uint64(slot),
+		}
+		check(tx.StoreMintedWithExternalURIEvent(contract.String(), &event))
+		check(tx.Mint(contract, &event))
+	}
+	check(tx.Transfer(contract, &model.ERC721Transfer{From: owner, To: receiver, TokenId: tokenId(1), BlockNumber: 101, Contract: contract}))
+	check(tx.UpdateContractState(contract, 7))
+	check(tx.TagRoot(101))
+	check(tx.TagRoot(102))
+
+	for number := uint64(100); number <= 102; number++ {
+		block := model.Block{Number: number, Timestamp: number * 10, Hash: common.BigToHash(new(big.Int).SetUint64(number))}
+		check(tx.SetLastOwnershipBlock(block))
+		check(tx.SetOwnershipBlockTimestamp(block))
+		check(tx.SetOwnershipEvoBlockMapping(number, number-90))
+		check(tx.SetEvoBlock(model.Block{Number: number - 90, Timestamp: number*10 - 1, Hash: common.BigToHash(big.NewInt(int64(number) - 90))}))
+		check(tx.SetNextEvoEventBlock(contract.String(), number-90))
+	}
+	check(tx.SetFirstOwnershipBlock(model.Block{Number: 100, Timestamp: 1000, Hash: common.HexToHash("0x100")}))
+	check(tx.SetLastMappedOwnershipBlockNumber(102))
+	check(tx.SetFirstEvoBlock(model.Block{Number: 10, Timestamp: 99, Hash: common.HexToHash("0x10")}))
+	check(tx.SetLastEvoBlock(model.Block{Number: 12, Timestamp: 119, Hash: common.HexToHash("0x12")}))
+	check(tx.StoreContractBackfill(model.ContractBackfill{
+		Contract:  model.ERC721UniversalContract{Address: receiver, CollectionAddress: collection, BlockNumber: 50},
+		NextBlock: 60, OwnershipRoot: common.HexToHash("0x1"), TotalSupply: 3, LastProcessedEvoBlock: 11,
+	}))
+	check(tx.Commit())
+
+	entries := dump(t, service)
+	delete(entries, "schema_version")
+	return entries
+}
+
+// removeEvoTokenEvents removes the index of the evo events by token, which the nodes of version 1 did not store
+func removeEvoTokenEvents(t *testing.T, service storage.Service) {
+	t.Helper()
+	keys, err := service.GetKeysWithPrefix([]byte("evo_token_events_"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := service.NewTransaction()
+	defer tx.Discard()
+	for _, key := range keys {
+		if err := tx.Delete(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeLegacyLayout rewrites every entry of the database as the nodes that did not record the schema version stored it,
+// and returns how many entries it rewrote
+func writeLegacyLayout(t *testing.T, service storage.Service) int {
+	t.Helper()
+	removeEvoTokenEvents(t, service)
+	tx := service.NewTransaction()
+	defer tx.Discard()
+
+	rewritten := 0
+	for key, value := range dump(t, service) {
+		newKey, newValue, err := legacyEntry([]byte(key), value)
+		if err != nil {
+			t.Fatalf("error writing legacy entry %q: %v", key, err)
+		}
+		if newKey == nil {
+			continue
+		}
+		if err := tx.Delete([]byte(key)); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Set(newKey, newValue); err != nil {
+			t.Fatal(err)
+		}
+		rewritten++
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	return rewritten
+}
+
+// legacyEntry returns the legacy key and value of an entry, or a nil key if its layout did not change
+func legacyEntry(key, value []byte) ([]byte, []byte, error) {
+	hasPrefix := func(prefix string) bool { return bytes.HasPrefix(key, []byte(prefix)) }
+	number := func(b []byte) uint64 { return binary.BigEndian.Uint64(b) }
+	switch {
+	case hasPrefix("ownership_first_block"), hasPrefix("ownership_last_block"), hasPrefix("evo_first_block"), hasPrefix("evo_last_block"):
+		block, err := codec.DecodeBlock(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		legacyValue, err := gobEncode(block)
+		return key, legacyValue, err
+	case hasPrefix("mapped_ownership_last_block"), hasPrefix("deployment_block_"), hasPrefix("last_evo_event_block"):
+		n, err := codec.DecodeUint64(value)
+		return key, []byte(strconv.FormatUint(n, 10)), err
+	case hasPrefix("accountlasttag/"):
+		n, err := codec.DecodeInt64(value)
+		return key, []byte(strconv.FormatInt(n, 10)), err
+	case hasPrefix("backfill_"):
+		backfill, err := codec.DecodeContractBackfill(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		legacyValue, err := gobEncode(backfill)
+		return key, legacyValue, err
+	case hasPrefix("accountdata/"):
+		var data account.AccountData
+		if err := data.UnmarshalBinary(value); err != nil {
+			return nil, nil, err
+		}
+		legacyValue, err := json.Marshal(data)
+		return key, legacyValue, err
+	case hasPrefix("ownership/data/"):
+		var data ownership.TokenData
+		if err := data.UnmarshalBinary(value); err != nil {
+			return nil, nil, err
+		}
+		legacyValue, err := json.Marshal(data)
+		return key, legacyValue, err
+	case hasPrefix("enumerated/tokens/"), hasPrefix("enumeratedtotal/tokens/"):
+		n, err := codec.DecodeBigInt(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, []byte(n.String()), nil
+	case hasPrefix("ownership_block_"):
+		block, err := codec.DecodeBlock(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		legacyValue, err := gobEncode(block)
+		return []byte(fmt.Sprintf("ownership_block_%018d", number(key[len("ownership_block_"):]))), legacyValue, err
+	case hasPrefix("evo_block_"):
+		block, err := codec.DecodeBlock(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		legacyValue := binary.BigEndian.AppendUint64(block.Hash.Bytes(), block.Timestamp)
+		return []byte(fmt.Sprintf("evo_block_%018d", number(key[len("evo_block_"):]))), legacyValue, nil
+	case hasPrefix("mapped_ownership_block_"):
+		n, err := codec.DecodeUint64(value)
+		return []byte(fmt.Sprintf("mapped_ownership_block_%d", number(key[len("mapped_ownership_block_"):]))), []byte(strconv.FormatUint(n, 10)), err
+	case hasPrefix("ownership_timestamp_"), hasPrefix("evo_timestamp_"):
+		prefix, rest, _ := bytes.Cut(key, []byte("timestamp_"))
+		return []byte(fmt.Sprintf("%stimestamp_%020d_%018d", prefix, number(rest[:8]), number(rest[8:]))), value, nil
+	case hasPrefix("next_evo_event_block_"):
+		rest := key[len("next_evo_event_block_"):]
+		n, err := codec.DecodeUint64(value)
+		return []byte(fmt.Sprintf("next_evo_event_block_%s_%d", rest[:42], number(rest[43:]))), []byte(strconv.FormatUint(n, 10)), err
+	case hasPrefix("evo_events_"):
+		rest := key[len("evo_events_"):]
+		event, err := codec.DecodeMintedWithExternalURI(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		legacyValue, err := gobEncode(event)
+		return []byte(fmt.Sprintf("evo_events_%s_%018d_%08d", rest[:42], number(rest[43:51]), number(rest[51:]))), legacyValue, err
+	case hasPrefix("accounttags/"):
+		return []byte(fmt.Sprintf("accounttags/%d", number(key[len("accounttags/"):]))), value, nil
+	}
+	return nil, nil, nil
+}
+
+func gobEncode(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(v)
+	return buf.Bytes(), err
+}
+
+func dump(t *testing.T, service storage.Service) map[string][]byte {
+	t.Helper()
+	keys, err := service.GetKeysWithPrefix(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := service.NewTransaction()
+	defer tx.Discard()
+	entries := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		value, err := tx.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[string(key)] = value
+	}
+	return entries
+}
+
+func assertMigrated(t *testing.T, service storage.Service, expected map[string][]byte) {
+	t.Helper()
+	tx := service.NewTransaction()
+	version, err := migration.GetVersion(tx)
+	tx.Discard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest := uint64(len(schema.Migrations)); version != latest {
+		t.Fatalf("got schema version %d, expected %d", version, latest)
+	}
+
+	got := dump(t, service)
+	delete(got, "schema_version")
+	if len(got) != len(expected) {
+		t.Fatalf("got %d entries, expected %d", len(got), len(expected))
+	}
+	for key, value := range expected {
+		if !bytes.Equal(got[key], value) {
+			t.Fatalf("got value %x for key %q, expected %x", got[key], key, value)
+		}
+	}
+}
+
+func assertState(t *testing.T, service storage.Service) {
+	t.Helper()
+	tx, err := v1.NewStateService(service).NewTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Discard()
+
+	if err := tx.Checkout(101); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if err := tx.LoadContractTrees(contract); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	tokenOwner, err := tx.OwnerOf(contract, tokenId(1))
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if tokenOwner != receiver {
+		t.Fatalf("got owner %s, expected %s", tokenOwner.String(), receiver.String())
+	}
+	balance, err := tx.BalanceOf(contract, owner)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if balance.Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("got balance %s, expected 1", balance.String())
+	}
+	token, err := tx.TokenByIndex(contract, 1)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if token.Cmp(tokenId(2)) != 0 {
+		t.Fatalf("got token %s, expected %s", token.String(), tokenId(2).String())
+	}
+	events, err := tx.GetMintedWithExternalURIEvents(contract.String(), 7)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if len(events) != 2 || events[0].TxIndex != 1 || events[1].TokenURI != "ipfs://2" {
+		t.Fatalf("got events %v, expected the 2 minted events sorted by tx index", events)
+	}
+	minted, err := tx.GetTokenMintedWithExternalURIEvent(contract.String(), tokenId(2))
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if minted == nil || minted.TxIndex != 2 || minted.Slot.Cmp(big.NewInt(2)) != 0 {
+		t.Fatalf("got event %v, expected the event that minted the token", minted)
+	}
+	blocks, err := tx.GetAllStoredBlockNumbers()
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if fmt.Sprint(blocks) != "[102 101 100]" {
+		t.Fatalf("got stored blocks %v, expected [102 101 100]", blocks)
+	}
+	indexed, err := tx.GetEvoBlocksByTimestamp(1009, 1019)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if fmt.Sprint(indexed) != fmt.Sprint([]model.Block{{Number: 11, Timestamp: 1009}, {Number: 12, Timestamp: 1019}}) {
+		t.Fatalf("got evo blocks %v indexed by timestamp", indexed)
+	}
+	next, err := tx.GetNextEvoEventBlock(contract.String(), 11)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if next != 12 {
+		t.Fatalf("got next evo event block %d, expected 12", next)
+	}
+}
+
+var errInterrupted = errors.New("interrupted")
+
+// interruptedService fails the commits once commitsLeft commits have succeeded, as if the migration was killed
+type interruptedService struct {
+	storage.Service
+	commitsLeft int
+}
+
+func (s *interruptedService) NewTransaction() storage.Tx {
+	return &interruptedTx{Tx: s.Service.NewTransaction(), service: s}
+}
+
+type interruptedTx struct {
+	storage.Tx
+	service *interruptedService
+}
+
+func (tx *interruptedTx) Commit() error {
+	if tx.service.commitsLeft == 0 {
+		return errInterrupted
+	}
+	tx.service.commitsLeft--
+	return tx.Tx.Commit()
+}
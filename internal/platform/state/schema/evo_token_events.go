@@ -0,0 +1,38 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/contract/evolution"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/migration"
+)
+
+// evoTokenEvents indexes the stored evo events by the token they minted, keeping them under their block
+var evoTokenEvents = migration.Migration{
+	Version:     2,
+	Description: "evo events by token",
+	Steps: []migration.Step{
+		{Prefix: "evo_events_", Keep: true, Rewrite: indexEvoEvent},
+	},
+}
+
+// indexEvoEvent returns the entry that indexes evo_events_<contract>_<block><tx index> under its token.
+// Rewriting an event again rewrites the same entry.
+func indexEvoEvent(key, value []byte) ([]byte, []byte, error) {
+	const prefix = "evo_events_"
+	contract, rest, ok := bytes.Cut(key[len(prefix):], []byte("_"))
+	if !ok {
+		return nil, nil, fmt.Errorf("missing block number")
+	}
+	// the legacy events are only found by a dry run before the binary codec migration, and indexed once migrated
+	if len(rest) != 16 || !codec.IsEncoded(value) {
+		return nil, nil, nil
+	}
+	event, err := codec.DecodeMintedWithExternalURI(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return evolution.TokenEventKey(string(contract), &event), key, nil
+}
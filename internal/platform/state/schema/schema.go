@@ -0,0 +1,35 @@
+// Package schema holds the migrations of the layout of the stored state, so that a node upgrades the databases
+// written by older nodes and refuses to open the ones written by newer nodes.
+package schema
+
+import (
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/migration"
+)
+
+// Migrations of the stored state, in order. Version 0 is the legacy layout, written before the version was recorded.
+var Migrations = []migration.Migration{
+	binaryCodec,
+	evoTokenEvents,
+}
+
+// legacyKeys are set by the nodes that did not record the schema version as soon as they process a block
+var legacyKeys = []string{"ownership_last_block", "evo_last_block"}
+
+// NewMigrator returns the migrator of the stored state
+func NewMigrator(service storage.Service) (*migration.Migrator, error) {
+	return migration.New(service, Migrations, hasLegacyKeys)
+}
+
+func hasLegacyKeys(tx storage.Tx) (bool, error) {
+	for _, key := range legacyKeys {
+		value, err := tx.Get([]byte(key))
+		if err != nil {
+			return false, err
+		}
+		if value != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
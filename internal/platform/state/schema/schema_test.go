@@ -0,0 +1,125 @@
+package schema_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/schema"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+	badgerStorage "github.com/freeverseio/laos-universal-node/internal/platform/storage/badger"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/migration"
+)
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		entries         map[string][]byte
+		version         uint64
+		expectedErr     error
+		expectedError   string
+		expectedVersion uint64
+	}{
+		{
+			name:            "records the version of a new database",
+			expectedVersion: 2,
+		},
+		{
+			name:            "accepts a database of the latest version",
+			version:         2,
+			expectedVersion: 2,
+		},
+		{
+			name:          "refuses a database of a newer version",
+			version:       3,
+			expectedErr:   migration.ErrNewerVersion,
+			expectedError: "database schema is newer than the supported one: got version 3, this node supports up to version 2",
+		},
+		{
+			name:          "requires migrating a database written before the version was recorded",
+			entries:       map[string][]byte{"ownership_last_block": []byte("legacy")},
+			expectedErr:   migration.ErrMigrationRequired,
+			expectedError: "database schema must be migrated: got version 0, run the migrate command to upgrade it to version 2",
+		},
+		{
+			name:          "requires migrating a database of an older version",
+			version:       1,
+			expectedErr:   migration.ErrMigrationRequired,
+			expectedError: "database schema must be migrated: got version 1, run the migrate command to upgrade it to version 2",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			service := badgerStorage.NewService(createBadger(t))
+			for key, value := range tt.entries {
+				if err := service.Set([]byte(key), value); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.version != 0 {
+				setVersion(t, service, tt.version)
+			}
+
+			m, err := schema.NewMigrator(service)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = m.Check()
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf(`got error "%v", expected error: "%v"`, err, tt.expectedErr)
+				}
+				if err.Error() != tt.expectedError {
+					t.Fatalf(`got error "%v", expected error: "%v"`, err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+
+			tx := service.NewTransaction()
+			defer tx.Discard()
+			version, err := migration.GetVersion(tx)
+			if err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			if version != tt.expectedVersion {
+				t.Fatalf("got version %d, expected %d", version, tt.expectedVersion)
+			}
+		})
+	}
+}
+
+func setVersion(t *testing.T, service storage.Service, version uint64) {
+	t.Helper()
+	tx := service.NewTransaction()
+	defer tx.Discard()
+	if err := migration.SetVersion(tx, version); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func createBadger(t *testing.T) *badger.DB {
+	t.Helper()
+	db, err := badger.Open(
+		badger.DefaultOptions("").
+			WithInMemory(true).
+			WithLoggingLevel(badger.ERROR))
+	if err != nil {
+		t.Fatalf("error initializing storage: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
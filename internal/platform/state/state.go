@@ -2,11 +2,13 @@ package state
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	"github.com/freeverseio/laos-universal-node/internal/platform/state/tree/account"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state/tree/ownership"
 )
 
 // Service interface is used for initializing and terminating state transaction.
@@ -34,12 +36,16 @@ type State interface {
 	TotalSupply(contract common.Address) (int64, error)
 	TokenByIndex(contract common.Address, idx int) (*big.Int, error)
 	TokenURI(contract common.Address, tokenId *big.Int) (string, error)
+	TokenData(contract common.Address, tokenId *big.Int) (*ownership.TokenData, error)
 	Transfer(contract common.Address, eventTransfer *model.ERC721Transfer) error
 	Mint(contract common.Address, mintEvent *model.MintedWithExternalURI) error
 	LoadContractTrees(contractAddress common.Address) error
+	LoadContractTreesAt(contractAddress common.Address, data *account.AccountData) error
 	UpdateContractState(contract common.Address, lastProcessedEvoBlock uint64) error
+	ContractAccountData(contract common.Address, lastProcessedEvoBlock uint64) (*account.AccountData, error)
 	AccountData(contract common.Address) (*account.AccountData, error)
 	TagRoot(blockNumber int64) error
+	PatchRootTag(blockNumber int64, contract common.Address, data *account.AccountData) error
 	DeleteOrphanRootTags(formBlock, toBlock int64) error
 	GetLastTaggedBlock() (int64, error)
 	Checkout(blockNumber int64) error
@@ -51,11 +57,17 @@ type OwnershipContractState interface {
 	GetCollectionAddress(contract string) (common.Address, error)
 	GetAllERC721UniversalContracts() []string
 	HasERC721UniversalContract(contract string) (bool, error)
+	GetERC721UniversalContractBlock(contract string) (uint64, error)
+	StoreContractBackfill(backfill model.ContractBackfill) error
+	GetContractBackfill(contract string) (*model.ContractBackfill, error)
+	GetContractBackfills() ([]model.ContractBackfill, error)
+	DeleteContractBackfill(contract string) error
 }
 
 type EvolutionContractState interface {
 	GetMintedWithExternalURIEvents(contract string, blockNumber uint64) ([]model.MintedWithExternalURI, error)
 	StoreMintedWithExternalURIEvent(contract string, event *model.MintedWithExternalURI) error
+	GetTokenMintedWithExternalURIEvent(contract string, tokenId *big.Int) (*model.MintedWithExternalURI, error)
 }
 
 type OwnershipSyncState interface {
@@ -66,12 +78,26 @@ type OwnershipSyncState interface {
 	GetOwnershipBlock(blockNumber uint64) (model.Block, error)
 	SetOwnershipBlock(blockNumber uint64, block model.Block) error
 	GetAllStoredBlockNumbers() ([]uint64, error)
-	DeleteOldStoredBlockNumbers() error
+	DeleteOldStoredBlockNumbers(retention BlockRetention) (int, error)
 	DeleteOrphanBlockData(blockNumberRef uint64) error
+	SetOwnershipBlockTimestamp(block model.Block) error
+	GetOwnershipBlocksByTimestamp(fromTimestamp, toTimestamp uint64) ([]model.Block, error)
 	SetLastMappedOwnershipBlockNumber(blockNumber uint64) error
 	GetLastMappedOwnershipBlockNumber() (uint64, error)
 	SetOwnershipEvoBlockMapping(ownershipBlockNumber, evoBlockNumber uint64) error
 	GetMappedEvoBlockNumber(ownershipBlockNumber uint64) (uint64, error)
+	DeleteOrphanMappedBlocks(blockNumberRef uint64) error
+}
+
+// BlockRetention defines which of the stored ownership block hashes are kept to detect reorgs
+type BlockRetention struct {
+	// Blocks and Duration are the windows of the newest blocks whose hashes are all kept, by number of blocks and by age.
+	// A block within any of them is kept, and a zero value disables a window.
+	Blocks   uint64
+	Duration time.Duration
+	// CheckpointInterval keeps the oldest stored block of every CheckpointInterval blocks beyond the windows
+	// as a sparse checkpoint, so that deeper reorgs can still be detected. 0 disables the checkpoints.
+	CheckpointInterval uint64
 }
 
 type EvolutionSyncState interface {
@@ -82,4 +108,7 @@ type EvolutionSyncState interface {
 	GetFirstEvoBlock() (model.Block, error)
 	SetLastEvoBlock(block model.Block) error
 	GetLastEvoBlock() (model.Block, error)
+	SetEvoBlock(block model.Block) error
+	GetEvoBlock(blockNumber uint64) (model.Block, error)
+	GetEvoBlocksByTimestamp(fromTimestamp, toTimestamp uint64) ([]model.Block, error)
 }
@@ -2,9 +2,9 @@ package evolution
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
 
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	"github.com/freeverseio/laos-universal-node/internal/platform/state/sync"
 	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
@@ -15,6 +15,8 @@ const (
 	lastBlock               = "evo_last_block"
 	nextEvoEventBlockPrefix = "next_evo_event_block"
 	lastEvoEventBlockPrefix = "last_evo_event_block"
+	evoBlockPrefix          = "evo_block_"
+	evoTimestampPrefix      = "evo_timestamp_"
 )
 
 type service struct {
@@ -52,32 +54,22 @@ func (s *service) SetNextEvoEventBlock(contract string, blockNumber uint64) erro
 
 	uintValue := uint64(0)
 	if len(value) > 0 {
-		uintValue, err = strconv.ParseUint(string(value), 10, 64)
+		uintValue, err = codec.DecodeUint64(value)
 		if err != nil {
-			return err
+			return fmt.Errorf("error decoding the last evo event block of contract %s: %w", contract, err)
 		}
 	}
 
-	nextKey := fmt.Sprintf("%s_%s_%s",
-		nextEvoEventBlockPrefix,
-		strings.ToLower(contract),
-		strconv.FormatUint(uintValue, 10))
-
-	if err := s.tx.Set([]byte(nextKey), []byte(strconv.FormatUint(blockNumber, 10))); err != nil {
+	if err := s.tx.Set(nextEvoEventBlockKey(contract, uintValue), codec.EncodeUint64(blockNumber)); err != nil {
 		return err
 	}
 
-	return s.tx.Set([]byte(lastEvoEventBlockPrefix+strings.ToLower(contract)), []byte(strconv.FormatUint(blockNumber, 10)))
+	return s.tx.Set([]byte(lastEvoEventBlockPrefix+strings.ToLower(contract)), codec.EncodeUint64(blockNumber))
 }
 
 // GetNextEvoEventBlock is used by universal processor for getting the next block that has events
 func (s *service) GetNextEvoEventBlock(contract string, blockNumber uint64) (uint64, error) {
-	key := fmt.Sprintf("%s_%s_%s",
-		nextEvoEventBlockPrefix,
-		strings.ToLower(contract),
-		strconv.FormatUint(blockNumber, 10))
-
-	value, err := s.tx.Get([]byte(key))
+	value, err := s.tx.Get(nextEvoEventBlockKey(contract, blockNumber))
 	if err != nil {
 		return 0, err
 	}
@@ -86,5 +78,38 @@ func (s *service) GetNextEvoEventBlock(contract string, blockNumber uint64) (uin
 		return 0, nil
 	}
 
-	return strconv.ParseUint(string(value), 10, 64)
+	nextBlock, err := codec.DecodeUint64(value)
+	if err != nil {
+		return 0, fmt.Errorf("error decoding the next evo event block of contract %s after block %d: %w", contract, blockNumber, err)
+	}
+	return nextBlock, nil
+}
+
+func nextEvoEventBlockKey(contract string, blockNumber uint64) []byte {
+	return []byte(nextEvoEventBlockPrefix + "_" + strings.ToLower(contract) + "_" + blockKey(blockNumber))
+}
+
+// SetEvoBlock stores the hash and timestamp of a processed evo block, keyed by its number so that blocks are sorted,
+// and indexes it by its timestamp
+func (s *service) SetEvoBlock(block model.Block) error {
+	if err := sync.SetBlock(s.tx, evoBlockPrefix+blockKey(block.Number), block); err != nil {
+		return err
+	}
+	return sync.SetBlockTimestamp(s.tx, evoTimestampPrefix, block)
+}
+
+// GetEvoBlocksByTimestamp returns the stored evo blocks whose timestamp is between fromTimestamp and toTimestamp.
+// Only their number and timestamp are set.
+func (s *service) GetEvoBlocksByTimestamp(fromTimestamp, toTimestamp uint64) ([]model.Block, error) {
+	return sync.GetBlocksByTimestamp(s.tx, evoTimestampPrefix, fromTimestamp, toTimestamp)
+}
+
+// GetEvoBlock returns the stored evo block, or an empty block if it has not been stored
+func (s *service) GetEvoBlock(blockNumber uint64) (model.Block, error) {
+	return sync.GetBlock(s.tx, evoBlockPrefix+blockKey(blockNumber))
+}
+
+// blockKey is the block number as it is stored in keys, so that they are sorted by number
+func blockKey(blockNumber uint64) string {
+	return string(codec.Uint64Key(blockNumber))
 }
@@ -1,12 +1,11 @@
 package evolution_test
 
 import (
-	"bytes"
-	"encoding/gob"
 	"testing"
 
 	"github.com/dgraph-io/badger/v4"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	"github.com/freeverseio/laos-universal-node/internal/platform/state"
 	v1 "github.com/freeverseio/laos-universal-node/internal/platform/state/v1"
@@ -36,17 +35,14 @@ func TestSetGetLastEvoBlock(t *testing.T) {
 		Hash:      common.HexToHash("0x123"),
 	}
 
-	var buf bytes.Buffer
-	encoder := gob.NewEncoder(&buf)
-	_ = encoder.Encode(block) // omit error since block is constant
-
-	mockStorageTransaction.EXPECT().Set([]byte("evo_last_block"), buf.Bytes()).Return(nil)
+	value := codec.EncodeBlock(block)
+	mockStorageTransaction.EXPECT().Set([]byte("evo_last_block"), value).Return(nil)
 
 	err = tx.SetLastEvoBlock(block)
 	if err != nil {
 		t.Fatalf("got error %s, expecting no error", err.Error())
 	}
-	mockStorageTransaction.EXPECT().Get([]byte("evo_last_block")).Return(buf.Bytes(), nil)
+	mockStorageTransaction.EXPECT().Get([]byte("evo_last_block")).Return(value, nil)
 
 	newBlock, err := tx.GetLastEvoBlock()
 	if err != nil {
@@ -106,6 +102,63 @@ func TestSetGetNextNextEvoEventBlock(t *testing.T) {
 	})
 }
 
+func TestSetGetEvoBlock(t *testing.T) {
+	t.Parallel()
+	t.Run("stores evo blocks", func(t *testing.T) {
+		t.Parallel()
+		db := createBadger(t)
+		tx, err := createBadgerTransaction(t, db)
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+
+		blocks := []model.Block{
+			{Number: 9, Timestamp: 90, Hash: common.HexToHash("0x9")},
+			{Number: 10, Timestamp: 102, Hash: common.HexToHash("0x10")},
+		}
+		for _, block := range blocks {
+			if err = tx.SetEvoBlock(block); err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+		}
+
+		for _, block := range blocks {
+			storedBlock, err := tx.GetEvoBlock(block.Number)
+			if err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			if storedBlock != block {
+				t.Fatalf("got block %v, expected %v", storedBlock, block)
+			}
+		}
+
+		indexedBlocks, err := tx.GetEvoBlocksByTimestamp(91, 102)
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if len(indexedBlocks) != 1 || indexedBlocks[0] != (model.Block{Number: 10, Timestamp: 102}) {
+			t.Fatalf("got blocks %v indexed by timestamp, expected block 10", indexedBlocks)
+		}
+
+	})
+	t.Run("returns an empty block when it is not stored", func(t *testing.T) {
+		t.Parallel()
+		db := createBadger(t)
+		tx, err := createBadgerTransaction(t, db)
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+
+		block, err := tx.GetEvoBlock(10)
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if block != (model.Block{}) {
+			t.Fatalf("got block %v, expected an empty block", block)
+		}
+	})
+}
+
 func createBadgerTransaction(t *testing.T, db *badger.DB) (state.Tx, error) {
 	t.Helper()
 	badgerService := badgerStorage.NewService(db)
@@ -2,10 +2,11 @@ package ownership
 
 import (
 	"fmt"
-	"strconv"
-	"strings"
+	"sort"
 
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state"
 	"github.com/freeverseio/laos-universal-node/internal/platform/state/sync"
 	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
 )
@@ -16,8 +17,9 @@ const (
 	ownershipBlockTag        = "ownership_block_"
 	lastMappedOwnershipBlock = "mapped_ownership_last_block"
 	mappedOwnershipBlock     = "mapped_ownership_block_"
-	blockNumberDigits        = 18
-	numberOfBlocksToKeep     = 250
+	ownershipTimestampTag    = "ownership_timestamp_"
+	// prunedOwnershipBlock is the oldest block within the windows of the retention when the block hashes were last pruned
+	prunedOwnershipBlock = "ownership_pruned_block"
 )
 
 type service struct {
@@ -39,11 +41,11 @@ func (s *service) GetFirstOwnershipBlock() (model.Block, error) {
 }
 
 func (s *service) SetLastMappedOwnershipBlockNumber(blockNumber uint64) error {
-	return s.tx.Set([]byte(lastMappedOwnershipBlock), []byte(strconv.FormatUint(blockNumber, 10)))
+	return s.tx.Set([]byte(lastMappedOwnershipBlock), codec.EncodeUint64(blockNumber))
 }
 
 func (s *service) SetOwnershipEvoBlockMapping(ownershipBlock, evoBlock uint64) error {
-	return s.tx.Set([]byte(mappedOwnershipBlock+fmt.Sprint(ownershipBlock)), []byte(strconv.FormatUint(evoBlock, 10)))
+	return s.tx.Set([]byte(mappedOwnershipBlock+blockKey(ownershipBlock)), codec.EncodeUint64(evoBlock))
 }
 
 func (s *service) GetLastMappedOwnershipBlockNumber() (uint64, error) {
@@ -51,7 +53,7 @@ func (s *service) GetLastMappedOwnershipBlockNumber() (uint64, error) {
 }
 
 func (s *service) GetMappedEvoBlockNumber(ownershipBlock uint64) (uint64, error) {
-	return s.getBlockNumber(mappedOwnershipBlock + fmt.Sprint(ownershipBlock))
+	return s.getBlockNumber(mappedOwnershipBlock + blockKey(ownershipBlock))
 }
 
 func (s *service) getBlockNumber(key string) (uint64, error) {
@@ -62,13 +64,16 @@ func (s *service) getBlockNumber(key string) (uint64, error) {
 	if value == nil {
 		return 0, nil
 	}
-	return strconv.ParseUint(string(value), 10, 64)
+	blockNumber, err := codec.DecodeUint64(value)
+	if err != nil {
+		return 0, fmt.Errorf("error decoding block number %x: %w", key, err)
+	}
+	return blockNumber, nil
 }
 
 func (s *service) SetOwnershipBlock(blockNumber uint64, block model.Block) error {
-	formattedOwnershipBlockNumber := formatBlockNumber(blockNumber, blockNumberDigits)
 	// Saving the block with blocknumber as key
-	return sync.SetBlock(s.tx, ownershipBlockTag+formattedOwnershipBlockNumber, block)
+	return sync.SetBlock(s.tx, ownershipBlockTag+blockKey(blockNumber), block)
 }
 
 func (s *service) SetLastOwnershipBlock(block model.Block) error {
@@ -85,55 +90,141 @@ func (s *service) GetLastOwnershipBlock() (model.Block, error) {
 }
 
 func (s *service) GetOwnershipBlock(blockNumber uint64) (model.Block, error) {
-	formattedOwnershipBlockNumber := formatBlockNumber(blockNumber, blockNumberDigits)
-	return sync.GetBlock(s.tx, ownershipBlockTag+formattedOwnershipBlockNumber)
+	return sync.GetBlock(s.tx, ownershipBlockTag+blockKey(blockNumber))
 }
 
 func (s *service) GetAllStoredBlockNumbers() ([]uint64, error) {
 	var blockNumbers []uint64
 	keys := s.tx.GetKeysWithPrefix([]byte(ownershipBlockTag), true)
 	for i := range keys {
-		blockNumberStr := strings.TrimPrefix(string(keys[i]), ownershipBlockTag)
-		blockNumber, err := strconv.ParseInt(blockNumberStr, 10, 64)
+		blockNumber, err := codec.ParseUint64Key(keys[i][len(ownershipBlockTag):])
 		if err != nil {
 			return nil, err
 		}
 
-		blockNumbers = append(blockNumbers, uint64(blockNumber))
+		blockNumbers = append(blockNumbers, blockNumber)
 	}
 	return blockNumbers, nil
 }
 
-func (s *service) DeleteOldStoredBlockNumbers() error {
-	keys := s.tx.GetKeysWithPrefix([]byte(ownershipBlockTag), true)
+// DeleteOldStoredBlockNumbers deletes the stored block hashes out of the windows of the retention and returns how many
+// were deleted. Beyond the windows, the oldest stored block of every checkpoint interval is kept as a sparse checkpoint.
+// The newest block, which is the last ownership block, is always kept.
+// Only the blocks between the windows of the previous pruning and the current ones are read, as the older ones have already been pruned.
+func (s *service) DeleteOldStoredBlockNumbers(retention state.BlockRetention) (int, error) {
+	newest, err := s.GetLastOwnershipBlock()
+	if err != nil {
+		return 0, err
+	}
+	if newest.Number+1 <= max(retention.Blocks, 1) {
+		return 0, nil
+	}
+	// the oldest block within the window of the number of blocks
+	windowStart := newest.Number + 1 - max(retention.Blocks, 1)
+	previousWindowStart, err := s.getBlockNumber(prunedOwnershipBlock)
+	if err != nil {
+		return 0, err
+	}
+	from := min(previousWindowStart, windowStart)
+	if retention.CheckpointInterval > 0 {
+		// the checkpoint of the interval of the previous window might have been kept
+		from -= from % retention.CheckpointInterval
+	}
+	keys := s.tx.FilterKeysWithPrefix([]byte(ownershipBlockTag), blockKey(from), blockKey(windowStart-1))
+	if len(keys) == 0 {
+		return 0, s.tx.Set([]byte(prunedOwnershipBlock), codec.EncodeUint64(windowStart))
+	}
 
-	// Skip the first 250 keys (newest entries)
-	if len(keys) > numberOfBlocksToKeep {
-		keys = keys[numberOfBlocksToKeep:]
-	} else {
-		return nil
+	// keys are sorted from oldest to newest
+	blockNumbers := make([]uint64, len(keys))
+	for i := range keys {
+		blockNumber, err := codec.ParseUint64Key(keys[i][len(ownershipBlockTag):])
+		if err != nil {
+			return 0, err
+		}
+		blockNumbers[i] = blockNumber
 	}
 
-	// Delete all keys beyond the newest 250
-	for _, key := range keys {
-		if err := s.tx.Delete(key); err != nil {
-			return err
+	expired, err := s.expiredBlocks(blockNumbers, newest, retention)
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for i := 0; i < expired; i++ {
+		// keep the block if it is the oldest one stored within its checkpoint interval
+		if retention.CheckpointInterval > 0 &&
+			(i == 0 || blockNumbers[i-1]/retention.CheckpointInterval != blockNumbers[i]/retention.CheckpointInterval) {
+			continue
 		}
+		if err := s.tx.Delete(keys[i]); err != nil {
+			return deleted, err
+		}
+		deleted++
 	}
 
-	return nil
+	if expired < len(blockNumbers) {
+		windowStart = blockNumbers[expired]
+	}
+	return deleted, s.tx.Set([]byte(prunedOwnershipBlock), codec.EncodeUint64(windowStart))
 }
 
+// expiredBlocks returns how many of the oldest blockNumbers, which are sorted from oldest to newest and out of the window
+// of the number of blocks, are out of the window of the duration of the retention
+func (s *service) expiredBlocks(blockNumbers []uint64, newest model.Block, retention state.BlockRetention) (int, error) {
+	if retention.Duration == 0 {
+		return len(blockNumbers), nil
+	}
+	window := uint64(retention.Duration.Seconds())
+	// the age of the blocks is unknown without the timestamp of the newest one
+	if newest.Timestamp == 0 || newest.Timestamp <= window {
+		return 0, nil
+	}
+	oldestTimestamp := newest.Timestamp - window
+	// timestamps grow with block numbers, so the blocks out of the window are the oldest ones
+	var errSearch error
+	expired := sort.Search(len(blockNumbers), func(i int) bool {
+		if errSearch != nil {
+			return true
+		}
+		block, err := s.GetOwnershipBlock(blockNumbers[i])
+		if err != nil {
+			errSearch = err
+			return true
+		}
+		return block.Timestamp >= oldestTimestamp
+	})
+	return expired, errSearch
+}
+
+// SetOwnershipBlockTimestamp indexes the ownership block by its timestamp
+func (s *service) SetOwnershipBlockTimestamp(block model.Block) error {
+	return sync.SetBlockTimestamp(s.tx, ownershipTimestampTag, block)
+}
+
+// GetOwnershipBlocksByTimestamp returns the indexed ownership blocks whose timestamp is between fromTimestamp and toTimestamp
+func (s *service) GetOwnershipBlocksByTimestamp(fromTimestamp, toTimestamp uint64) ([]model.Block, error) {
+	return sync.GetBlocksByTimestamp(s.tx, ownershipTimestampTag, fromTimestamp, toTimestamp)
+}
+
+// DeleteOrphanBlockData deletes the block hashes and the indexed timestamps of the blocks after blockNumberRef
 func (s *service) DeleteOrphanBlockData(blockNumberRef uint64) error {
+	// blocks after blockNumberRef are not older than it, if its timestamp is unknown the whole index is checked
+	blockRef, err := s.GetOwnershipBlock(blockNumberRef)
+	if err != nil {
+		return err
+	}
+	if err := sync.DeleteBlockTimestamps(s.tx, ownershipTimestampTag, blockRef.Timestamp, blockNumberRef); err != nil {
+		return err
+	}
+
 	keys := s.tx.GetKeysWithPrefix([]byte(ownershipBlockTag), true)
 	// Delete all keys
-	for i, key := range keys {
-		blockNumberStr := strings.TrimPrefix(string(keys[i]), ownershipBlockTag)
-		blockNumber, err := strconv.ParseInt(blockNumberStr, 10, 64)
+	for _, key := range keys {
+		blockNumber, err := codec.ParseUint64Key(key[len(ownershipBlockTag):])
 		if err != nil {
 			return err
 		}
-		if blockNumber > int64(blockNumberRef) {
+		if blockNumber > blockNumberRef {
 			if err := s.tx.Delete(key); err != nil {
 				return err
 			}
@@ -143,12 +234,25 @@ func (s *service) DeleteOrphanBlockData(blockNumberRef uint64) error {
 	return nil
 }
 
-func formatBlockNumber(blockNumber uint64, blockNumberDigits uint16) string {
-	// Convert the block number to a string
-	blockNumberString := strconv.FormatUint(blockNumber, 10)
-	// Pad with leading zeros if shorter
-	for len(blockNumberString) < int(blockNumberDigits) {
-		blockNumberString = "0" + blockNumberString
+// DeleteOrphanMappedBlocks deletes all the ownership-evo block mappings after blockNumberRef
+func (s *service) DeleteOrphanMappedBlocks(blockNumberRef uint64) error {
+	keys := s.tx.GetKeysWithPrefix([]byte(mappedOwnershipBlock))
+	for _, key := range keys {
+		blockNumber, err := codec.ParseUint64Key(key[len(mappedOwnershipBlock):])
+		if err != nil {
+			return err
+		}
+		if blockNumber > blockNumberRef {
+			if err := s.tx.Delete(key); err != nil {
+				return err
+			}
+		}
 	}
-	return blockNumberString
+
+	return nil
+}
+
+// blockKey is the block number as it is stored in keys, so that they are sorted by number
+func blockKey(blockNumber uint64) string {
+	return string(codec.Uint64Key(blockNumber))
 }
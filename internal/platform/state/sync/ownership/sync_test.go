@@ -1,14 +1,15 @@
 package ownership_test
 
 import (
-	"bytes"
-	"encoding/gob"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/dgraph-io/badger/v4"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state"
 	"github.com/freeverseio/laos-universal-node/internal/platform/state/sync/ownership"
 	v1 "github.com/freeverseio/laos-universal-node/internal/platform/state/v1"
 	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
@@ -38,18 +39,16 @@ func TestSetGetLastOwnershipBlock(t *testing.T) {
 		Hash:      common.HexToHash("0x123"),
 	}
 
-	var buf bytes.Buffer
-	encoder := gob.NewEncoder(&buf)
-	_ = encoder.Encode(block) // omit error since block is constant
+	value := codec.EncodeBlock(block)
 
-	mockStorageTransaction.EXPECT().Set([]byte("ownership_last_block"), buf.Bytes()).Return(nil)
-	mockStorageTransaction.EXPECT().Set([]byte("ownership_block_000000000000000001"), buf.Bytes())
+	mockStorageTransaction.EXPECT().Set([]byte("ownership_last_block"), value).Return(nil)
+	mockStorageTransaction.EXPECT().Set([]byte(blockKey(1)), value)
 
 	err = tx.SetLastOwnershipBlock(block)
 	if err != nil {
 		t.Fatalf("got error %s, expecting no error", err.Error())
 	}
-	mockStorageTransaction.EXPECT().Get([]byte("ownership_last_block")).Return(buf.Bytes(), nil)
+	mockStorageTransaction.EXPECT().Get([]byte("ownership_last_block")).Return(value, nil)
 
 	newBlock, err := tx.GetLastOwnershipBlock()
 	if err != nil {
@@ -80,12 +79,12 @@ func TestGetAllStoredBlockNumbers(t *testing.T) {
 	}{
 		{
 			name:             "SingleBlockNumber",
-			mockBlockNumbers: []string{"ownership_block_1"},
+			mockBlockNumbers: []string{blockKey(1)},
 			expectedNumbers:  []uint64{1},
 		},
 		{
 			name:             "MultipleBlockNumbers",
-			mockBlockNumbers: []string{"ownership_block_01", "ownership_block_02", "ownership_block_03"},
+			mockBlockNumbers: []string{blockKey(1), blockKey(2), blockKey(3)},
 			expectedNumbers:  []uint64{1, 2, 3},
 		},
 		{
@@ -96,7 +95,7 @@ func TestGetAllStoredBlockNumbers(t *testing.T) {
 
 		{
 			name:             "WithErrorInvalidNumber",
-			mockBlockNumbers: []string{"ownership_block_1", "ownership_block_a"},
+			mockBlockNumbers: []string{blockKey(1), "ownership_block_a"},
 			expectedNumbers:  nil,
 			expectError:      true,
 		},
@@ -143,32 +142,32 @@ func TestSetLastOwnershipBlock(t *testing.T) {
 		{
 			name:                      "Block 1",
 			blockNumber:               1,
-			expectedOwnershipBlockTag: "ownership_block_000000000000000001",
+			expectedOwnershipBlockTag: blockKey(1),
 		},
 		{
 			name:                      "Block 2",
 			blockNumber:               2,
-			expectedOwnershipBlockTag: "ownership_block_000000000000000002",
+			expectedOwnershipBlockTag: blockKey(2),
 		},
 		{
 			name:                      "Block 3",
 			blockNumber:               3,
-			expectedOwnershipBlockTag: "ownership_block_000000000000000003",
+			expectedOwnershipBlockTag: blockKey(3),
 		},
 		{
 			name:                      "Block 1254",
 			blockNumber:               1254,
-			expectedOwnershipBlockTag: "ownership_block_000000000000001254",
+			expectedOwnershipBlockTag: blockKey(1254),
 		},
 		{
 			name:                      "Blocknumer with 18 digits",
 			blockNumber:               123654258965487545,
-			expectedOwnershipBlockTag: "ownership_block_123654258965487545",
+			expectedOwnershipBlockTag: blockKey(123654258965487545),
 		},
 		{
 			name:                      "Blocknumer with more than 18 digits",
 			blockNumber:               8888888754587958787,
-			expectedOwnershipBlockTag: "ownership_block_8888888754587958787",
+			expectedOwnershipBlockTag: blockKey(8888888754587958787),
 		},
 	}
 
@@ -186,12 +185,10 @@ func TestSetLastOwnershipBlock(t *testing.T) {
 				Hash:      common.HexToHash("0x123"),
 			}
 
-			var buf bytes.Buffer
-			encoder := gob.NewEncoder(&buf)
-			_ = encoder.Encode(block) // omit error since block is constant
+			value := codec.EncodeBlock(block)
 
-			mockStorageTransaction.EXPECT().Set([]byte("ownership_last_block"), buf.Bytes()).Return(nil)
-			mockStorageTransaction.EXPECT().Set([]byte(tc.expectedOwnershipBlockTag), buf.Bytes()).Return(nil)
+			mockStorageTransaction.EXPECT().Set([]byte("ownership_last_block"), value).Return(nil)
+			mockStorageTransaction.EXPECT().Set([]byte(tc.expectedOwnershipBlockTag), value).Return(nil)
 
 			service := ownership.NewService(mockStorageTransaction)
 			err := service.SetLastOwnershipBlock(block)
@@ -236,12 +233,10 @@ func TestSetAndGetOwnershipBlock(t *testing.T) {
 
 			mockStorageTransaction := mock.NewMockTx(mockCtrl)
 
-			var buf bytes.Buffer
-			encoder := gob.NewEncoder(&buf)
-			_ = encoder.Encode(tc.block) // omit error since block is constant
+			value := codec.EncodeBlock(tc.block)
 
-			mockStorageTransaction.EXPECT().Set([]byte(fmt.Sprintf("ownership_block_%018d", tc.block.Number)), buf.Bytes()).Return(nil)
-			mockStorageTransaction.EXPECT().Get([]byte(fmt.Sprintf("ownership_block_%018d", tc.block.Number))).Return(buf.Bytes(), nil)
+			mockStorageTransaction.EXPECT().Set([]byte(blockKey(tc.block.Number)), value).Return(nil)
+			mockStorageTransaction.EXPECT().Get([]byte(blockKey(tc.block.Number))).Return(value, nil)
 
 			service := ownership.NewService(mockStorageTransaction)
 
@@ -274,25 +269,69 @@ func TestDeleteOldStoredBlockNumbers(t *testing.T) {
 	t.Parallel()
 
 	testCases := []struct {
-		name              string
-		storedBlockKeys   []string
-		expectedDeletions []string
+		name               string
+		newestBlock        uint64
+		prunedBlock        uint64
+		reorgWindow        uint64
+		checkpointInterval uint64
+		// expectedFrom and expectedTo are the blocks whose keys are read, which are filteredBlockKeys
+		expectedFrom, expectedTo uint64
+		filteredBlockKeys        []string
+		expectedDeletions        []string
+		expectedPrunedBlock      uint64
 	}{
 		{
-			name:              "More than 250 blocks",
-			storedBlockKeys:   generateBlockKeys(300, 1),
-			expectedDeletions: generateBlockKeys(50, 251), // Oldest 50 blocks
+			name:                "More than 250 blocks",
+			newestBlock:         300,
+			reorgWindow:         250,
+			expectedTo:          50,
+			filteredBlockKeys:   generateBlockKeys(50, 1),
+			expectedDeletions:   generateBlockKeys(50, 1), // Oldest 50 blocks
+			expectedPrunedBlock: 51,
 		},
 		{
-			name:              "Exactly 250 blocks",
-			storedBlockKeys:   generateBlockKeys(250, 1),
-			expectedDeletions: nil, // No deletions
+			name:                "Exactly 250 blocks",
+			newestBlock:         250,
+			reorgWindow:         250,
+			expectedDeletions:   nil, // No deletions
+			expectedPrunedBlock: 1,
 		},
 		{
 			name:              "Less than 250 blocks",
-			storedBlockKeys:   generateBlockKeys(200, 1),
+			newestBlock:       200,
+			reorgWindow:       250,
 			expectedDeletions: nil, // No deletions
 		},
+		{
+			name:                "More than 250 blocks keeping checkpoints",
+			newestBlock:         300,
+			reorgWindow:         250,
+			checkpointInterval:  20,
+			expectedTo:          50,
+			filteredBlockKeys:   generateBlockKeys(50, 1),
+			expectedPrunedBlock: 51,
+			// blocks 1, 20 and 40 are kept as checkpoints
+			expectedDeletions: append(append(generateBlockKeys(18, 2), generateBlockKeys(19, 21)...), generateBlockKeys(10, 41)...),
+		},
+		{
+			name:               "Only the blocks since the previous pruning are read",
+			newestBlock:        300,
+			prunedBlock:        45,
+			reorgWindow:        250,
+			checkpointInterval: 20,
+			// the checkpoint of the interval of the previous window is read to be kept
+			expectedFrom:        40,
+			expectedTo:          50,
+			filteredBlockKeys:   append([]string{blockKey(40)}, generateBlockKeys(6, 45)...),
+			expectedDeletions:   generateBlockKeys(6, 45),
+			expectedPrunedBlock: 51,
+		},
+		{
+			name:               "No stored blocks",
+			reorgWindow:        250,
+			checkpointInterval: 20,
+			expectedDeletions:  nil,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -305,17 +344,31 @@ func TestDeleteOldStoredBlockNumbers(t *testing.T) {
 			mockTx := mock.NewMockTx(mockCtrl)
 			service := ownership.NewService(mockTx)
 
-			mockTx.EXPECT().GetKeysWithPrefix([]byte("ownership_block_"), true).
-				Return(convertToByteSliceArray(tc.storedBlockKeys))
+			mockTx.EXPECT().Get([]byte("ownership_last_block")).
+				Return(codec.EncodeBlock(model.Block{Number: tc.newestBlock, Hash: common.HexToHash("0x123")}), nil)
+			if tc.expectedPrunedBlock != 0 {
+				var prunedBlock []byte
+				if tc.prunedBlock != 0 {
+					prunedBlock = codec.EncodeUint64(tc.prunedBlock)
+				}
+				mockTx.EXPECT().Get([]byte("ownership_pruned_block")).Return(prunedBlock, nil)
+				mockTx.EXPECT().FilterKeysWithPrefix([]byte("ownership_block_"),
+					string(codec.Uint64Key(tc.expectedFrom)), string(codec.Uint64Key(tc.expectedTo))).
+					Return(convertToByteSliceArray(tc.filteredBlockKeys))
+				mockTx.EXPECT().Set([]byte("ownership_pruned_block"), codec.EncodeUint64(tc.expectedPrunedBlock)).Return(nil)
+			}
 
 			for _, key := range tc.expectedDeletions {
 				mockTx.EXPECT().Delete([]byte(key)).Return(nil)
 			}
 
-			err := service.DeleteOldStoredBlockNumbers()
+			deleted, err := service.DeleteOldStoredBlockNumbers(state.BlockRetention{Blocks: tc.reorgWindow, CheckpointInterval: tc.checkpointInterval})
 			if err != nil {
 				t.Fatalf("DeleteOldStoredBlockNumbers returned an error: %v", err)
 			}
+			if deleted != len(tc.expectedDeletions) {
+				t.Fatalf("got %d deleted blocks, expected %d", deleted, len(tc.expectedDeletions))
+			}
 		})
 	}
 }
@@ -325,22 +378,34 @@ func TestDeleteOldStoredBlockNumbersWithBadgerInMemory(t *testing.T) {
 	testCases := []struct {
 		name                   string
 		numberOfBlocks         int
+		checkpointInterval     uint64
 		expectedNumberOfBlocks int
+		expectedOldestBlock    uint64
 	}{
 		{
 			name:                   "More than 250 blocks",
 			numberOfBlocks:         300,
 			expectedNumberOfBlocks: 250,
+			expectedOldestBlock:    51,
 		},
 		{
 			name:                   "Exactly 250 blocks",
 			numberOfBlocks:         250,
 			expectedNumberOfBlocks: 250,
+			expectedOldestBlock:    1,
 		},
 		{
 			name:                   "Less than 250 blocks",
 			numberOfBlocks:         200,
 			expectedNumberOfBlocks: 200,
+			expectedOldestBlock:    1,
+		},
+		{
+			name:                   "More than 250 blocks keeping checkpoints",
+			numberOfBlocks:         300,
+			checkpointInterval:     100,
+			expectedNumberOfBlocks: 251,
+			expectedOldestBlock:    1,
 		},
 	}
 
@@ -357,6 +422,9 @@ func TestDeleteOldStoredBlockNumbersWithBadgerInMemory(t *testing.T) {
 			tx := badgerService.NewTransaction()
 			service := ownership.NewService(tx)
 			generationBlockKeysInBadger(t, tx, tc.numberOfBlocks)
+			if err := service.SetLastOwnershipBlock(model.Block{Number: uint64(tc.numberOfBlocks), Timestamp: 1, Hash: common.HexToHash("0x123")}); err != nil {
+				t.Fatalf("error setting the last block: %v", err)
+			}
 			blockNumbers, err := service.GetAllStoredBlockNumbers()
 			if err != nil {
 				t.Fatalf("GetAllStoredBlockNumbers returned an error: %v", err)
@@ -364,7 +432,7 @@ func TestDeleteOldStoredBlockNumbersWithBadgerInMemory(t *testing.T) {
 			if len(blockNumbers) != tc.numberOfBlocks {
 				t.Fatalf("got %d block numbers, expected %d", len(blockNumbers), tc.numberOfBlocks)
 			}
-			err = service.DeleteOldStoredBlockNumbers()
+			_, err = service.DeleteOldStoredBlockNumbers(state.BlockRetention{Blocks: 250, CheckpointInterval: tc.checkpointInterval})
 			if err != nil {
 				t.Fatalf("DeleteOldStoredBlockNumbers returned an error: %v", err)
 			}
@@ -378,6 +446,9 @@ func TestDeleteOldStoredBlockNumbersWithBadgerInMemory(t *testing.T) {
 			if blockNumbers[0] != uint64(tc.numberOfBlocks) {
 				t.Fatalf("got %d as first block number, expected %d", blockNumbers[0], tc.numberOfBlocks)
 			}
+			if blockNumbers[len(blockNumbers)-1] != tc.expectedOldestBlock {
+				t.Fatalf("got %d as oldest block number, expected %d", blockNumbers[len(blockNumbers)-1], tc.expectedOldestBlock)
+			}
 			if err := db.DropAll(); err != nil {
 				t.Fatalf("error closing db: %v", err)
 			}
@@ -388,6 +459,132 @@ func TestDeleteOldStoredBlockNumbersWithBadgerInMemory(t *testing.T) {
 	}
 }
 
+func TestDeleteOldStoredBlockNumbersAfterEveryBlock(t *testing.T) {
+	t.Parallel()
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLoggingLevel(badger.ERROR))
+	if err != nil {
+		t.Fatalf("error initializing storage: %v", err)
+	}
+	defer db.Close()
+	tx := badgerStorage.NewService(db).NewTransaction()
+	defer tx.Discard()
+	service := ownership.NewService(tx)
+
+	// every pruning only reads the blocks since the previous one
+	retention := state.BlockRetention{Blocks: 50, CheckpointInterval: 20}
+	for i := uint64(1); i <= 300; i++ {
+		if err := service.SetLastOwnershipBlock(model.Block{Number: i, Timestamp: 12 * i, Hash: common.HexToHash("0x123")}); err != nil {
+			t.Fatalf("error setting block %d: %v", i, err)
+		}
+		if _, err := service.DeleteOldStoredBlockNumbers(retention); err != nil {
+			t.Fatalf("DeleteOldStoredBlockNumbers returned an error: %v", err)
+		}
+	}
+
+	blockNumbers, err := service.GetAllStoredBlockNumbers()
+	if err != nil {
+		t.Fatalf("GetAllStoredBlockNumbers returned an error: %v", err)
+	}
+	// the newest 50 blocks and the checkpoints 240, 220, ..., 20 and 1
+	expected := make([]uint64, 0, 63)
+	for i := uint64(300); i > 250; i-- {
+		expected = append(expected, i)
+	}
+	for i := uint64(240); i >= 20; i -= 20 {
+		expected = append(expected, i)
+	}
+	expected = append(expected, 1)
+	if !compareSlices(blockNumbers, expected) {
+		t.Fatalf("got block numbers %v, expected %v", blockNumbers, expected)
+	}
+}
+
+func TestDeleteOldStoredBlockNumbersByAge(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name                   string
+		retention              state.BlockRetention
+		noNewestTimestamp      bool
+		expectedNumberOfBlocks int
+		expectedOldestBlock    uint64
+	}{
+		{
+			name:                   "blocks within the duration",
+			retention:              state.BlockRetention{Duration: 10 * time.Minute},
+			expectedNumberOfBlocks: 51,
+			expectedOldestBlock:    250,
+		},
+		{
+			name:                   "blocks within the duration or the number of blocks",
+			retention:              state.BlockRetention{Blocks: 100, Duration: 10 * time.Minute},
+			expectedNumberOfBlocks: 100,
+			expectedOldestBlock:    201,
+		},
+		{
+			name:                   "blocks within the duration keeping checkpoints",
+			retention:              state.BlockRetention{Duration: 10 * time.Minute, CheckpointInterval: 100},
+			expectedNumberOfBlocks: 54,
+			expectedOldestBlock:    1,
+		},
+		{
+			name:                   "duration longer than the chain",
+			retention:              state.BlockRetention{Duration: 24 * time.Hour},
+			expectedNumberOfBlocks: 300,
+			expectedOldestBlock:    1,
+		},
+		{
+			name:                   "unknown timestamp of the newest block",
+			retention:              state.BlockRetention{Duration: 10 * time.Minute},
+			noNewestTimestamp:      true,
+			expectedNumberOfBlocks: 300,
+			expectedOldestBlock:    1,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLoggingLevel(badger.ERROR))
+			if err != nil {
+				t.Fatalf("error initializing storage: %v", err)
+			}
+			defer db.Close()
+			tx := badgerStorage.NewService(db).NewTransaction()
+			defer tx.Discard()
+			service := ownership.NewService(tx)
+			// a block every 12 seconds
+			for i := uint64(1); i <= 300; i++ {
+				block := model.Block{Number: i, Timestamp: 12 * i, Hash: common.HexToHash("0x123")}
+				if i == 300 && tc.noNewestTimestamp {
+					block.Timestamp = 0
+				}
+				if err := service.SetLastOwnershipBlock(block); err != nil {
+					t.Fatalf("error setting block %d: %v", i, err)
+				}
+			}
+
+			deleted, err := service.DeleteOldStoredBlockNumbers(tc.retention)
+			if err != nil {
+				t.Fatalf("DeleteOldStoredBlockNumbers returned an error: %v", err)
+			}
+			if deleted != 300-tc.expectedNumberOfBlocks {
+				t.Fatalf("got %d deleted blocks, expected %d", deleted, 300-tc.expectedNumberOfBlocks)
+			}
+			blockNumbers, err := service.GetAllStoredBlockNumbers()
+			if err != nil {
+				t.Fatalf("GetAllStoredBlockNumbers returned an error: %v", err)
+			}
+			if len(blockNumbers) != tc.expectedNumberOfBlocks {
+				t.Fatalf("got %d block numbers, expected %d", len(blockNumbers), tc.expectedNumberOfBlocks)
+			}
+			if blockNumbers[len(blockNumbers)-1] != tc.expectedOldestBlock {
+				t.Fatalf("got %d as oldest block number, expected %d", blockNumbers[len(blockNumbers)-1], tc.expectedOldestBlock)
+			}
+		})
+	}
+}
+
 func TestDeleteOrphanBlockDataWithBadgerInMemory(t *testing.T) {
 	// Do not run this test in parallel
 	testCases := []struct {
@@ -461,6 +658,105 @@ func TestDeleteOrphanBlockDataWithBadgerInMemory(t *testing.T) {
 	}
 }
 
+func TestOwnershipBlockTimestampsWithBadgerInMemory(t *testing.T) {
+	t.Parallel()
+	db, err := badger.Open(
+		badger.DefaultOptions("").
+			WithInMemory(true).
+			WithLoggingLevel(badger.ERROR))
+	if err != nil {
+		t.Fatalf("error initializing storage: %v", err)
+	}
+	defer db.Close()
+	tx := badgerStorage.NewService(db).NewTransaction()
+	defer tx.Discard()
+	service := ownership.NewService(tx)
+
+	// blocks 10 and 11 share their timestamp
+	timestamps := map[uint64]uint64{9: 98, 10: 100, 11: 100, 12: 1000, 13: 1002}
+	for blockNumber, timestamp := range timestamps {
+		if err := service.SetOwnershipBlockTimestamp(model.Block{Number: blockNumber, Timestamp: timestamp}); err != nil {
+			t.Fatalf("got error %v, expecting no error", err)
+		}
+	}
+	if err := service.SetOwnershipBlock(11, model.Block{Number: 11, Timestamp: 100}); err != nil {
+		t.Fatalf("got error %v, expecting no error", err)
+	}
+
+	blocks, err := service.GetOwnershipBlocksByTimestamp(100, 1000)
+	if err != nil {
+		t.Fatalf("got error %v, expecting no error", err)
+	}
+	expected := []model.Block{{Number: 10, Timestamp: 100}, {Number: 11, Timestamp: 100}, {Number: 12, Timestamp: 1000}}
+	if fmt.Sprint(blocks) != fmt.Sprint(expected) {
+		t.Fatalf("got blocks %v, expected %v", blocks, expected)
+	}
+
+	if err := service.DeleteOrphanBlockData(11); err != nil {
+		t.Fatalf("got error %v, expecting no error", err)
+	}
+	blocks, err = service.GetOwnershipBlocksByTimestamp(0, 2000)
+	if err != nil {
+		t.Fatalf("got error %v, expecting no error", err)
+	}
+	expected = []model.Block{{Number: 9, Timestamp: 98}, {Number: 10, Timestamp: 100}, {Number: 11, Timestamp: 100}}
+	if fmt.Sprint(blocks) != fmt.Sprint(expected) {
+		t.Fatalf("got blocks %v after deleting orphan blocks, expected %v", blocks, expected)
+	}
+}
+
+func TestDeleteOrphanMappedBlocksWithBadgerInMemory(t *testing.T) {
+	// Do not run this test in parallel
+	db, err := badger.Open(
+		badger.DefaultOptions("").
+			WithInMemory(true).
+			WithLoggingLevel(badger.ERROR))
+	if err != nil {
+		t.Fatalf("error initializing storage: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Fatalf("error closing db: %v", err)
+		}
+	}()
+	tx := badgerStorage.NewService(db).NewTransaction()
+	service := ownership.NewService(tx)
+	for i := uint64(1); i <= 20; i++ {
+		if err := service.SetOwnershipEvoBlockMapping(i, i*10); err != nil {
+			t.Fatalf("error setting mapping for block %d: %v", i, err)
+		}
+	}
+	if err := service.SetLastMappedOwnershipBlockNumber(20); err != nil {
+		t.Fatalf("error setting last mapped block: %v", err)
+	}
+
+	if err := service.DeleteOrphanMappedBlocks(9); err != nil {
+		t.Fatalf("DeleteOrphanMappedBlocks returned an error: %v", err)
+	}
+
+	for i := uint64(1); i <= 20; i++ {
+		evoBlock, err := service.GetMappedEvoBlockNumber(i)
+		if err != nil {
+			t.Fatalf("GetMappedEvoBlockNumber returned an error: %v", err)
+		}
+		expected := i * 10
+		if i > 9 {
+			expected = 0
+		}
+		if evoBlock != expected {
+			t.Fatalf("got mapped evo block %d for ownership block %d, expected %d", evoBlock, i, expected)
+		}
+	}
+	// the last mapped block pointer does not share the mapping prefix and must be kept
+	lastMapped, err := service.GetLastMappedOwnershipBlockNumber()
+	if err != nil {
+		t.Fatalf("GetLastMappedOwnershipBlockNumber returned an error: %v", err)
+	}
+	if lastMapped != 20 {
+		t.Fatalf("got last mapped block %d, expected %d", lastMapped, 20)
+	}
+}
+
 func generationBlockKeysInBadger(t *testing.T, tx storage.Tx, num int) {
 	t.Helper()
 	for i := 1; i <= num; i++ {
@@ -470,27 +766,37 @@ func generationBlockKeysInBadger(t *testing.T, tx storage.Tx, num int) {
 			Hash:      common.HexToHash("0x123"),
 		}
 
-		var buf bytes.Buffer
-		encoder := gob.NewEncoder(&buf)
-		_ = encoder.Encode(block) // omit error since block is constant
+		value := codec.EncodeBlock(block)
 
-		err := tx.Set([]byte(fmt.Sprintf("ownership_block_%018d", block.Number)), buf.Bytes())
+		err := tx.Set([]byte(blockKey(block.Number)), value)
 		if err != nil {
 			t.Fatalf("error setting block %d: %v", block.Number, err)
 		}
 	}
 }
 
+func blockKey(blockNumber uint64) string {
+	return "ownership_block_" + string(codec.Uint64Key(blockNumber))
+}
+
 // Helper function to generate mock block keys
 func generateBlockKeys(num, startingBlock int) []string {
 	var keys []string
 	for i := 1; i <= num; i++ {
-		keys = append(keys, fmt.Sprintf("ownership_block_%018d", startingBlock))
+		keys = append(keys, blockKey(uint64(startingBlock)))
 		startingBlock++
 	}
 	return keys
 }
 
+func reverse(strs []string) []string {
+	reversed := make([]string, len(strs))
+	for i, s := range strs {
+		reversed[len(strs)-1-i] = s
+	}
+	return reversed
+}
+
 func convertToByteSliceArray(strs []string) [][]byte {
 	var result [][]byte
 	for _, s := range strs {
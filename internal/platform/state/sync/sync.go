@@ -1,23 +1,17 @@
 package sync
 
 import (
-	"bytes"
-	"encoding/gob"
+	"fmt"
+	"math"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
 	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
 )
 
 func SetBlock(tx storage.Tx, key string, block model.Block) error {
-	var buf bytes.Buffer
-	encoder := gob.NewEncoder(&buf)
-
-	if err := encoder.Encode(block); err != nil {
-		return err
-	}
-
-	return tx.Set([]byte(key), buf.Bytes())
+	return tx.Set([]byte(key), codec.EncodeBlock(block))
 }
 
 func GetBlock(tx storage.Tx, key string) (model.Block, error) {
@@ -35,11 +29,69 @@ func GetBlock(tx storage.Tx, key string) (model.Block, error) {
 		return defaultBlock, nil
 	}
 
-	var block model.Block
-
-	decoder := gob.NewDecoder(bytes.NewBuffer(value))
-	if err := decoder.Decode(&block); err != nil {
-		return defaultBlock, err
+	block, err := codec.DecodeBlock(value)
+	if err != nil {
+		return defaultBlock, fmt.Errorf("error decoding block %s: %w", key, err)
 	}
 	return block, nil
 }
+
+// timestampKeyLength is the length of a timestamp key: the timestamp followed by the block number
+const timestampKeyLength = 16
+
+// SetBlockTimestamp indexes the block number by its timestamp under prefix. Keys are made of the timestamp
+// followed by the block number, so that blocks sharing a timestamp are all indexed and sorted by number.
+func SetBlockTimestamp(tx storage.Tx, prefix string, block model.Block) error {
+	return tx.Set([]byte(prefix+timestampKey(block.Timestamp, block.Number)), []byte{})
+}
+
+// GetBlocksByTimestamp returns the blocks indexed under prefix whose timestamp is between fromTimestamp and toTimestamp,
+// both included, sorted by timestamp and number. Only their number and timestamp are set.
+func GetBlocksByTimestamp(tx storage.Tx, prefix string, fromTimestamp, toTimestamp uint64) ([]model.Block, error) {
+	keys := tx.FilterKeysWithPrefix([]byte(prefix), timestampKey(fromTimestamp, 0), timestampKey(toTimestamp, math.MaxUint64))
+	blocks := make([]model.Block, 0, len(keys))
+	for _, key := range keys {
+		block, err := parseTimestampKey(key[len(prefix):])
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// DeleteBlockTimestamps deletes the blocks indexed under prefix that are newer than blockNumberRef.
+// As timestamps grow with block numbers, only the blocks from fromTimestamp on are checked.
+func DeleteBlockTimestamps(tx storage.Tx, prefix string, fromTimestamp, blockNumberRef uint64) error {
+	blocks, err := GetBlocksByTimestamp(tx, prefix, fromTimestamp, math.MaxUint64)
+	if err != nil {
+		return err
+	}
+	for _, block := range blocks {
+		if block.Number > blockNumberRef {
+			if err := tx.Delete([]byte(prefix + timestampKey(block.Timestamp, block.Number))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func timestampKey(timestamp, blockNumber uint64) string {
+	return string(codec.Uint64Key(timestamp)) + string(codec.Uint64Key(blockNumber))
+}
+
+func parseTimestampKey(key []byte) (model.Block, error) {
+	if len(key) != timestampKeyLength {
+		return model.Block{}, fmt.Errorf("invalid block timestamp key %x", key)
+	}
+	timestamp, err := codec.ParseUint64Key(key[:8])
+	if err != nil {
+		return model.Block{}, err
+	}
+	blockNumber, err := codec.ParseUint64Key(key[8:])
+	if err != nil {
+		return model.Block{}, err
+	}
+	return model.Block{Number: blockNumber, Timestamp: timestamp}, nil
+}
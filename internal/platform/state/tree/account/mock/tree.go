@@ -97,6 +97,20 @@ func (mr *MockTreeMockRecorder) GetLastTaggedBlock() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastTaggedBlock", reflect.TypeOf((*MockTree)(nil).GetLastTaggedBlock))
 }
 
+// PatchRootTag mocks base method.
+func (m *MockTree) PatchRootTag(blockNumber int64, data *account.AccountData, accountAddress common.Address) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchRootTag", blockNumber, data, accountAddress)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchRootTag indicates an expected call of PatchRootTag.
+func (mr *MockTreeMockRecorder) PatchRootTag(blockNumber, data, accountAddress any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchRootTag", reflect.TypeOf((*MockTree)(nil).PatchRootTag), blockNumber, data, accountAddress)
+}
+
 // Root mocks base method.
 func (m *MockTree) Root() common.Hash {
 	m.ctrl.T.Helper()
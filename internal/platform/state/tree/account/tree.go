@@ -3,12 +3,14 @@ package account
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"strconv"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
 	"github.com/freeverseio/laos-universal-node/internal/platform/merkletree"
 	"github.com/freeverseio/laos-universal-node/internal/platform/merkletree/jellyfish"
 	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
@@ -33,12 +35,45 @@ type AccountData struct {
 	LastProcessedEvoBlock uint64
 }
 
+// Hash returns the hash set as leaf of the tree. It is computed over the JSON encoding of the data,
+// which the existing roots commit to, even if the data is stored in binary.
+func (d *AccountData) Hash() (common.Hash, error) {
+	buf, err := json.Marshal(d)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(buf), nil
+}
+
+// MarshalBinary encodes the data as it is stored
+func (d *AccountData) MarshalBinary() ([]byte, error) {
+	return codec.NewEncoder().
+		Hash(d.EnumeratedRoot).
+		Hash(d.EnumeratedTotalRoot).
+		Hash(d.OwnershipRoot).
+		Int64(d.TotalSupply).
+		Uint64(d.LastProcessedEvoBlock).
+		Bytes(), nil
+}
+
+// UnmarshalBinary decodes data encoded by MarshalBinary
+func (d *AccountData) UnmarshalBinary(value []byte) error {
+	decoder := codec.NewDecoder(value)
+	d.EnumeratedRoot = decoder.Hash()
+	d.EnumeratedTotalRoot = decoder.Hash()
+	d.OwnershipRoot = decoder.Hash()
+	d.TotalSupply = decoder.Int64()
+	d.LastProcessedEvoBlock = decoder.Uint64()
+	return decoder.Err()
+}
+
 // Tree defines interface for the account tree
 type Tree interface {
 	Root() common.Hash
 	AccountData(contract common.Address) (*AccountData, error)
 	SetAccountData(data *AccountData, accountAddress common.Address) error
 	TagRoot(blockNumber int64) error
+	PatchRootTag(blockNumber int64, data *AccountData, accountAddress common.Address) error
 	GetLastTaggedBlock() (int64, error)
 	Checkout(blockNumber int64) error
 	DeleteRootTag(blockNumber int64) error
@@ -73,23 +108,30 @@ func NewTree(store storage.Tx) (Tree, error) {
 // SetAccountData updates the MerkleTreeRoots
 func (b *tree) SetAccountData(data *AccountData, address common.Address) error {
 	slog.Debug("SetAccountData", "data", data, "address", address.String())
-	buf, err := json.Marshal(data)
-	if err != nil {
+	if err := setLeaf(b.mt, b.store, data, address); err != nil {
 		return err
 	}
 
-	hash := crypto.Keccak256Hash(buf)
-	if errSet := b.store.Set([]byte(leafDataPrefix+hash.String()), buf); errSet != nil {
-		return errSet
-	}
+	slog.Debug("accountTree", "HEAD", b.Root().String())
+	return setHeadRoot(b.store, b.Root())
+}
 
-	err = b.mt.SetLeaf(address.Big(), hash)
+// setLeaf stores the data and sets its hash as the leaf of address in mt
+func setLeaf(mt merkletree.MerkleTree, store storage.Tx, data *AccountData, address common.Address) error {
+	hash, err := data.Hash()
+	if err != nil {
+		return err
+	}
+	buf, err := data.MarshalBinary()
 	if err != nil {
 		return err
 	}
 
-	slog.Debug("accountTree", "HEAD", b.Root().String())
-	return setHeadRoot(b.store, b.Root())
+	if errSet := store.Set([]byte(leafDataPrefix+hash.String()), buf); errSet != nil {
+		return errSet
+	}
+
+	return mt.SetLeaf(address.Big(), hash)
 }
 
 // AccountData returns the merkle trees roots
@@ -108,8 +150,8 @@ func (b *tree) AccountData(address common.Address) (*AccountData, error) {
 	}
 
 	var roots AccountData
-	if err := json.Unmarshal(buf, &roots); err != nil {
-		return &AccountData{}, err
+	if err := roots.UnmarshalBinary(buf); err != nil {
+		return &AccountData{}, fmt.Errorf("error decoding account data %s: %w", leafHash.String(), err)
 	}
 
 	return &roots, nil
@@ -138,14 +180,36 @@ func setHeadRoot(store storage.Tx, root common.Hash) error {
 
 // TagRoot stores a root value for the block so that it can be checked later
 func (b *tree) TagRoot(blockNumber int64) error {
-	tagKey := tagPrefix + strconv.FormatInt(blockNumber, 10)
+	key := tagKey(blockNumber)
 	root := b.Root()
-	err := b.store.Set([]byte(tagKey), root.Bytes())
+	err := b.store.Set([]byte(key), root.Bytes())
 	if err != nil {
 		return err
 	}
 
-	return b.store.Set([]byte(lastTagPrefix), []byte(strconv.FormatInt(blockNumber, 10)))
+	return b.store.Set([]byte(lastTagPrefix), codec.EncodeInt64(blockNumber))
+}
+
+// PatchRootTag sets the data of address in the root tagged for blockNumber, leaving the current root untouched.
+func (b *tree) PatchRootTag(blockNumber int64, data *AccountData, address common.Address) error {
+	key := tagKey(blockNumber)
+	buf, err := b.store.Get([]byte(key))
+	if err != nil {
+		return err
+	}
+	if len(buf) == 0 {
+		return errors.New("no tag found for this block number " + strconv.FormatInt(blockNumber, 10))
+	}
+
+	mt, err := jellyfish.New(b.store, treePrefix)
+	if err != nil {
+		return err
+	}
+	mt.SetRoot(common.BytesToHash(buf))
+	if err := setLeaf(mt, b.store, data, address); err != nil {
+		return err
+	}
+	return b.store.Set([]byte(key), mt.Root().Bytes())
 }
 
 func (b *tree) GetLastTaggedBlock() (int64, error) {
@@ -157,13 +221,17 @@ func (b *tree) GetLastTaggedBlock() (int64, error) {
 		return 0, nil
 	}
 
-	return strconv.ParseInt(string(buf), 10, 64)
+	blockNumber, err := codec.DecodeInt64(buf)
+	if err != nil {
+		return 0, fmt.Errorf("error decoding the last tagged block: %w", err)
+	}
+	return blockNumber, nil
 }
 
 // Checkout sets the current root to the one that is tagged for a blockNumber.
 func (b *tree) Checkout(blockNumber int64) error {
-	tagKey := tagPrefix + strconv.FormatInt(blockNumber, 10)
-	buf, err := b.store.Get([]byte(tagKey))
+	key := tagKey(blockNumber)
+	buf, err := b.store.Get([]byte(key))
 	if err != nil {
 		return err
 	}
@@ -179,6 +247,11 @@ func (b *tree) Checkout(blockNumber int64) error {
 
 // DeleteRootTag deletes root tag without loading the tree
 func (b *tree) DeleteRootTag(blockNumber int64) error {
-	tagKey := tagPrefix + strconv.FormatInt(blockNumber, 10)
-	return b.store.Delete([]byte(tagKey))
+	key := tagKey(blockNumber)
+	return b.store.Delete([]byte(key))
+}
+
+// tagKey is the key of the root tagged for blockNumber, which is stored as a fixed-width big endian number
+func tagKey(blockNumber int64) string {
+	return tagPrefix + string(codec.Uint64Key(uint64(blockNumber)))
 }
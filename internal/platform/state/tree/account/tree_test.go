@@ -131,6 +131,51 @@ func TestTag(t *testing.T) {
 	})
 }
 
+func TestPatchRootTag(t *testing.T) {
+	t.Parallel()
+	t.Run(`patching a tag adds the account data to the tagged root without changing the current root`, func(t *testing.T) {
+		t.Parallel()
+		service := memory.New()
+		tx := service.NewTransaction()
+
+		tr, err := account.NewTree(tx)
+		assert.NilError(t, err)
+
+		err = tr.SetAccountData(&account.AccountData{TotalSupply: 1}, common.HexToAddress("0x500"))
+		assert.NilError(t, err)
+		err = tr.TagRoot(1)
+		assert.NilError(t, err)
+		head := tr.Root()
+
+		patchedData := account.AccountData{OwnershipRoot: common.HexToHash("0x01"), TotalSupply: 2}
+		err = tr.PatchRootTag(1, &patchedData, common.HexToAddress("0x501"))
+		assert.NilError(t, err)
+		assert.Equal(t, tr.Root(), head)
+
+		err = tr.Checkout(1)
+		assert.NilError(t, err)
+		assert.Assert(t, tr.Root() != head)
+		data, err := tr.AccountData(common.HexToAddress("0x501"))
+		assert.NilError(t, err)
+		assert.Equal(t, *data, patchedData)
+		data, err = tr.AccountData(common.HexToAddress("0x500"))
+		assert.NilError(t, err)
+		assert.Equal(t, data.TotalSupply, int64(1))
+	})
+
+	t.Run(`patching a block without tag should fail`, func(t *testing.T) {
+		t.Parallel()
+		service := memory.New()
+		tx := service.NewTransaction()
+
+		tr, err := account.NewTree(tx)
+		assert.NilError(t, err)
+
+		err = tr.PatchRootTag(1, &account.AccountData{TotalSupply: 2}, common.HexToAddress("0x501"))
+		assert.Error(t, err, "no tag found for this block number 1")
+	})
+}
+
 func TestDeleteRootTag(t *testing.T) {
 	t.Parallel()
 	t.Run(`Tag two roots and then delete the first tag. Checkout at deleted tag returns error`, func(t *testing.T) {
@@ -178,6 +223,9 @@ func TestGetLastTag(t *testing.T) {
 		err = tx.Commit()
 		assert.NilError(t, err)
 
+		tx = service.NewTransaction()
+		tr, err = account.NewTree(tx)
+		assert.NilError(t, err)
 		block, err := tr.GetLastTaggedBlock()
 		assert.NilError(t, err)
 		assert.Equal(t, block, int64(2))
@@ -11,6 +11,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
 	"github.com/freeverseio/laos-universal-node/internal/platform/merkletree"
 	"github.com/freeverseio/laos-universal-node/internal/platform/merkletree/jellyfish"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
@@ -128,13 +129,14 @@ func (b *tree) Transfer(minted bool, eventTransfer *model.ERC721Transfer) error
 
 // SetTokensToOwner sets the tokens of an owner
 func (b *tree) SetTokenToOwnerToIndex(owner common.Address, idx uint64, token *big.Int) error {
+	// the leaf is the hash of the decimal token id, as it was stored before the binary codec
 	buf, err := json.Marshal(token)
 	if err != nil {
 		return err
 	}
 
 	hash := crypto.Keccak256Hash(buf)
-	if err := b.store.Set([]byte(tokensPrefix+b.contract.String()+"/"+hash.String()), buf); err != nil {
+	if err := b.store.Set([]byte(tokensPrefix+b.contract.String()+"/"+hash.String()), codec.EncodeBigInt(token)); err != nil {
 		return err
 	}
 
@@ -173,20 +175,20 @@ func (b *tree) TokenOfOwnerByIndex(owner common.Address, idx uint64) (*big.Int,
 		return big.NewInt(0), err
 	}
 
-	var token big.Int
-	if err := json.Unmarshal(buf, &token); err != nil {
+	token, err := codec.DecodeBigInt(buf)
+	if err != nil {
 		return big.NewInt(0), err
 	}
 
-	return &token, nil
+	return token, nil
 }
 
 // SetTokensToOwner sets the tokens of an owner
 func (b *tree) SetBalanceToOwner(owner common.Address, balance uint64) error {
-	buf := []byte(strconv.FormatUint(balance, 10))
-
-	hash := crypto.Keccak256Hash(buf)
-	if err := b.store.Set([]byte(tokensPrefix+b.contract.String()+"/"+hash.String()), buf); err != nil {
+	// the leaf is the hash of the decimal balance, which is also the hash of a token id of the same value,
+	// so balances are stored as big ints like token ids
+	hash := crypto.Keccak256Hash([]byte(strconv.FormatUint(balance, 10)))
+	if err := b.store.Set([]byte(tokensPrefix+b.contract.String()+"/"+hash.String()), codec.EncodeBigInt(new(big.Int).SetUint64(balance))); err != nil {
 		return err
 	}
 
@@ -212,7 +214,14 @@ func (b *tree) BalanceOfOwner(owner common.Address) (uint64, error) {
 		return 0, err
 	}
 
-	return strconv.ParseUint(string(buf), 10, 64)
+	balance, err := codec.DecodeBigInt(buf)
+	if err != nil {
+		return 0, err
+	}
+	if !balance.IsUint64() {
+		return 0, fmt.Errorf("invalid balance %s of owner %s", balance.String(), owner.String())
+	}
+	return balance.Uint64(), nil
 }
 
 // Root returns the root of the tree
@@ -9,6 +9,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
 	"github.com/freeverseio/laos-universal-node/internal/platform/merkletree"
 	"github.com/freeverseio/laos-universal-node/internal/platform/merkletree/jellyfish"
 	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
@@ -109,13 +110,14 @@ func (b *tree) TotalSupply() int64 {
 
 // SetTokenIndex sets the token index
 func (b *tree) SetTokenToIndex(idx int, token *big.Int) error {
+	// the leaf is the hash of the decimal token id, as it was stored before the binary codec
 	buf, err := json.Marshal(token)
 	if err != nil {
 		return err
 	}
 
 	hash := crypto.Keccak256Hash(buf)
-	if err := b.store.Set([]byte(tokensPrefix+b.contract.String()+"/"+hash.String()), buf); err != nil {
+	if err := b.store.Set([]byte(tokensPrefix+b.contract.String()+"/"+hash.String()), codec.EncodeBigInt(token)); err != nil {
 		return err
 	}
 
@@ -142,12 +144,12 @@ func (b *tree) TokenByIndex(idx int) (*big.Int, error) {
 		return nil, err
 	}
 
-	var token big.Int
-	if err := json.Unmarshal(buf, &token); err != nil {
+	token, err := codec.DecodeBigInt(buf)
+	if err != nil {
 		return nil, err
 	}
 
-	return &token, nil
+	return token, nil
 }
 
 // Root returns the root of the tree
@@ -3,12 +3,14 @@ package ownership
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
 	"github.com/freeverseio/laos-universal-node/internal/platform/merkletree"
 	"github.com/freeverseio/laos-universal-node/internal/platform/merkletree/jellyfish"
 	"github.com/freeverseio/laos-universal-node/internal/platform/model"
@@ -31,6 +33,36 @@ type TokenData struct {
 	Idx       int
 }
 
+// Hash returns the hash set as leaf of the tree. It is computed over the JSON encoding of the data,
+// which the existing roots commit to, even if the data is stored in binary.
+func (d *TokenData) Hash() (common.Hash, error) {
+	buf, err := json.Marshal(d)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(buf), nil
+}
+
+// MarshalBinary encodes the data as it is stored
+func (d *TokenData) MarshalBinary() ([]byte, error) {
+	return codec.NewEncoder().
+		Address(d.SlotOwner).
+		String(d.TokenURI).
+		Bool(d.Minted).
+		Int64(int64(d.Idx)).
+		Bytes(), nil
+}
+
+// UnmarshalBinary decodes data encoded by MarshalBinary
+func (d *TokenData) UnmarshalBinary(value []byte) error {
+	decoder := codec.NewDecoder(value)
+	d.SlotOwner = decoder.Address()
+	d.TokenURI = decoder.String()
+	d.Minted = decoder.Bool()
+	d.Idx = int(decoder.Int64())
+	return decoder.Err()
+}
+
 // Tree defines interface for ownership tree
 type Tree interface {
 	Root() common.Hash
@@ -101,12 +133,15 @@ func (b *tree) Mint(mintEvent *model.MintedWithExternalURI, idx int) error {
 
 // SetTokenData updates the tokenData
 func (b *tree) SetTokenData(tokenData *TokenData, tokenId *big.Int) error {
-	buf, err := json.Marshal(tokenData)
+	hash, err := tokenData.Hash()
+	if err != nil {
+		return err
+	}
+	buf, err := tokenData.MarshalBinary()
 	if err != nil {
 		return err
 	}
 
-	hash := crypto.Keccak256Hash(buf)
 	if err := b.store.Set([]byte(tokenDataPrefix+b.contract.String()+"/"+hash.String()), buf); err != nil {
 		return err
 	}
@@ -131,8 +166,8 @@ func (b *tree) TokenData(tokenId *big.Int) (*TokenData, error) {
 	}
 
 	var tokenData TokenData
-	if err := json.Unmarshal(buf, &tokenData); err != nil {
-		return &TokenData{common.Address{}, "", false, 0}, err
+	if err := tokenData.UnmarshalBinary(buf); err != nil {
+		return &TokenData{common.Address{}, "", false, 0}, fmt.Errorf("error decoding token data %s: %w", leaf.String(), err)
 	}
 
 	return &tokenData, nil
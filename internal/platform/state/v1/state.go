@@ -161,6 +161,29 @@ func (t *tx) LoadContractTrees(contractAddress common.Address) error {
 	return t.loadContractStateFromAccountTree(contractAddress)
 }
 
+// LoadContractTreesAt loads the merkle trees in memory for contractAddress with the roots of data
+// instead of those in the account tree
+func (t *tx) LoadContractTreesAt(contractAddress common.Address, data *account.AccountData) error {
+	slog.Debug("LoadContractTreesAt", "contract", contractAddress.String(), "data", data)
+	ownershipTree, err := ownership.NewTree(contractAddress, data.OwnershipRoot, t.tx)
+	if err != nil {
+		return err
+	}
+
+	enumeratedTree, err := enumerated.NewTree(contractAddress, data.EnumeratedRoot, t.tx)
+	if err != nil {
+		return err
+	}
+
+	enumeratedTotalTree, err := enumeratedtotal.NewTree(contractAddress, data.EnumeratedTotalRoot, data.TotalSupply, t.tx)
+	if err != nil {
+		return err
+	}
+
+	t.setTreesForContract(contractAddress, ownershipTree, enumeratedTree, enumeratedTotalTree)
+	return nil
+}
+
 // OwnerOf returns the owner of the token
 func (t *tx) OwnerOf(contract common.Address, tokenId *big.Int) (common.Address, error) {
 	slog.Debug("OwnerOf", "contract", contract.String(), "tokenId", tokenId.String())
@@ -338,12 +361,29 @@ func (t *tx) TokenURI(contract common.Address, tokenId *big.Int) (string, error)
 	return tokenData.TokenURI, nil
 }
 
+// TokenData returns the data stored in the ownership tree for tokenId
+func (t *tx) TokenData(contract common.Address, tokenId *big.Int) (*ownership.TokenData, error) {
+	slog.Debug("TokenData", "contract", contract.String(), "tokenId", tokenId.String())
+	ownershipTree, ok := t.ownershipTrees[contract]
+	if !ok {
+		return nil, fmt.Errorf("contract %s does not exist", contract.String())
+	}
+
+	return ownershipTree.TokenData(tokenId)
+}
+
 // TagRoot tags roots for all 3 merkle trees at the same block
 func (t *tx) TagRoot(blockNumber int64) error {
 	slog.Info("TagRoot", "blockNumber", strconv.FormatInt(blockNumber, 10))
 	return t.accountTree.TagRoot(blockNumber)
 }
 
+// PatchRootTag sets the contract data in the root tagged at the block, so that checking it out includes the contract
+func (t *tx) PatchRootTag(blockNumber int64, contract common.Address, data *account.AccountData) error {
+	slog.Debug("PatchRootTag", "blockNumber", strconv.FormatInt(blockNumber, 10), "contract", contract.String())
+	return t.accountTree.PatchRootTag(blockNumber, data, contract)
+}
+
 func (t *tx) GetLastTaggedBlock() (int64, error) {
 	slog.Debug("GetLastTaggedBlock")
 	return t.accountTree.GetLastTaggedBlock()
@@ -377,31 +417,39 @@ func (t *tx) Checkout(blockNumber int64) error {
 
 // UpdateContractState updates the contract state in the account tree
 func (t *tx) UpdateContractState(contract common.Address, lastProcessedEvoBlock uint64) error {
+	accountData, err := t.ContractAccountData(contract, lastProcessedEvoBlock)
+	if err != nil {
+		return err
+	}
+
+	slog.Debug("UpdatingContractState in the account tree", "accountData", accountData)
+	return t.accountTree.SetAccountData(accountData, contract)
+}
+
+// ContractAccountData returns the roots of the contract trees loaded in memory, without updating the account tree
+func (t *tx) ContractAccountData(contract common.Address, lastProcessedEvoBlock uint64) (*account.AccountData, error) {
 	enumeratedTree, ok := t.enumeratedTrees[contract]
 	if !ok {
-		return fmt.Errorf("contract %s does not exist", contract.String())
+		return nil, fmt.Errorf("contract %s does not exist", contract.String())
 	}
 
 	enumeratedTotalTree, ok := t.enumeratedTotalTrees[contract]
 	if !ok {
-		return fmt.Errorf("contract %s does not exist", contract.String())
+		return nil, fmt.Errorf("contract %s does not exist", contract.String())
 	}
 
 	ownershipTree, ok := t.ownershipTrees[contract]
 	if !ok {
-		return fmt.Errorf("contract %s does not exist", contract.String())
+		return nil, fmt.Errorf("contract %s does not exist", contract.String())
 	}
 
-	accountData := account.AccountData{
+	return &account.AccountData{
 		EnumeratedRoot:        enumeratedTree.Root(),
 		EnumeratedTotalRoot:   enumeratedTotalTree.Root(),
 		OwnershipRoot:         ownershipTree.Root(),
 		TotalSupply:           enumeratedTotalTree.TotalSupply(),
 		LastProcessedEvoBlock: lastProcessedEvoBlock,
-	}
-
-	slog.Debug("UpdatingContractState in the account tree", "accountData", accountData)
-	return t.accountTree.SetAccountData(&accountData, contract)
+	}, nil
 }
 
 func (t *tx) AccountData(contract common.Address) (*account.AccountData, error) {
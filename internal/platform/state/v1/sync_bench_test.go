@@ -0,0 +1,329 @@
+package v1_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/model"
+	"github.com/freeverseio/laos-universal-node/internal/platform/state"
+	v1 "github.com/freeverseio/laos-universal-node/internal/platform/state/v1"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+	badgerStorage "github.com/freeverseio/laos-universal-node/internal/platform/storage/badger"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/memory"
+	pebbleStorage "github.com/freeverseio/laos-universal-node/internal/platform/storage/pebble"
+)
+
+// syncEventsEnv is the environment variable with the path of the JSON file of recorded events replayed by BenchmarkSync
+const syncEventsEnv = "SYNC_EVENTS"
+
+// syncEvents are the events of a universal contract recorded from a chain, grouped by the ownership block that applies them
+type syncEvents struct {
+	Contract model.ERC721UniversalContract
+	Blocks   []syncBlock
+}
+
+// syncBlock is an ownership block with the evo mints of the collection of the contract and the transfers of the contract
+// that the universal processor applies at it
+type syncBlock struct {
+	Number    uint64
+	Timestamp uint64
+	EvoMints  []model.MintedWithExternalURI
+	Transfers []model.ERC721Transfer
+}
+
+func (e *syncEvents) count() int {
+	n := 0
+	for _, block := range e.Blocks {
+		n += len(block.EvoMints) + len(block.Transfers)
+	}
+	return n
+}
+
+func loadSyncEvents(path string) (*syncEvents, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var events syncEvents
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("error decoding the events of %s: %w", path, err)
+	}
+	return &events, nil
+}
+
+// TestSyncEvents makes sure that recorded events load and replay
+func TestSyncEvents(t *testing.T) {
+	t.Parallel()
+	contract := model.ERC721UniversalContract{
+		Address:           common.HexToAddress("0x26cb70039fe1bd36b4659858d4c4d0cbcafd743a"),
+		CollectionAddress: common.HexToAddress("0xfffffffffffffffffffffffe0000000000000001"),
+		BlockNumber:       1,
+	}
+	alice := common.HexToAddress("0x1000")
+	bob := common.HexToAddress("0x2000")
+	tokenId := func(slot int64, owner common.Address) *big.Int {
+		return new(big.Int).Or(new(big.Int).Lsh(big.NewInt(slot), 160), owner.Big())
+	}
+	recorded := syncEvents{
+		Contract: contract,
+		Blocks: []syncBlock{
+			{
+				Number:    1,
+				Timestamp: 1700000012,
+				EvoMints: []model.MintedWithExternalURI{
+					{Slot: big.NewInt(0), To: alice, TokenURI: "ipfs://0", TokenId: tokenId(0, alice), BlockNumber: 10, Timestamp: 1700000006},
+					{Slot: big.NewInt(1), To: alice, TokenURI: "ipfs://1", TokenId: tokenId(1, alice), BlockNumber: 10, Timestamp: 1700000006, TxIndex: 1},
+				},
+			},
+			{
+				Number:    2,
+				Timestamp: 1700000024,
+				EvoMints: []model.MintedWithExternalURI{
+					{Slot: big.NewInt(0), To: bob, TokenURI: "ipfs://2", TokenId: tokenId(0, bob), BlockNumber: 11, Timestamp: 1700000018},
+				},
+				Transfers: []model.ERC721Transfer{
+					{From: alice, To: bob, TokenId: tokenId(0, alice), BlockNumber: 2, Timestamp: 1700000024, Contract: contract.Address},
+				},
+			},
+		},
+	}
+	data, err := json.Marshal(recorded)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	path := filepath.Join(t.TempDir(), "events.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+
+	events, err := loadSyncEvents(path)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if events.count() != 4 {
+		t.Fatalf("got %d events, expected 4", events.count())
+	}
+	stateService := v1.NewStateService(memory.New())
+	if err := replay(stateService, events); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+
+	tx, err := stateService.NewTransaction()
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	defer tx.Discard()
+	if err := tx.LoadContractTrees(contract.Address); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	totalSupply, err := tx.TotalSupply(contract.Address)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if totalSupply != 3 {
+		t.Fatalf("got total supply %d, expected 3", totalSupply)
+	}
+	owner, err := tx.OwnerOf(contract.Address, tokenId(0, alice))
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if owner != bob {
+		t.Fatalf("got owner %s, expected %s", owner.String(), bob.String())
+	}
+	mints, err := tx.GetMintedWithExternalURIEvents(contract.CollectionAddress.String(), 10)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if len(mints) != 2 {
+		t.Fatalf("got %d evo mints stored at block 10, expected 2", len(mints))
+	}
+}
+
+// BenchmarkSync compares the storage engines replaying the events recorded in the file of SYNC_EVENTS on a database on disk,
+// storing the evo mints like the evolution processor and applying every block in a transaction like the universal processor.
+// It reports the events applied per second and the disk usage of the database, and it is skipped when SYNC_EVENTS is not set.
+func BenchmarkSync(b *testing.B) {
+	path := os.Getenv(syncEventsEnv)
+	if path == "" {
+		b.Skipf("%s is not set to the file of recorded events", syncEventsEnv)
+	}
+	events, err := loadSyncEvents(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	engines := []struct {
+		name string
+		open func(b *testing.B, path string) (storage.Service, func() error)
+	}{
+		{
+			name: "badger",
+			open: func(b *testing.B, path string) (storage.Service, func() error) {
+				db, err := badger.Open(badger.DefaultOptions(path).WithLoggingLevel(badger.ERROR))
+				if err != nil {
+					b.Fatal(err)
+				}
+				return badgerStorage.NewService(db), db.Close
+			},
+		},
+		{
+			name: "pebble",
+			open: func(b *testing.B, path string) (storage.Service, func() error) {
+				db, err := pebbleStorage.Open(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				return pebbleStorage.NewService(db), db.Close
+			},
+		},
+	}
+
+	for _, engine := range engines {
+		engine := engine
+		b.Run(engine.name, func(b *testing.B) {
+			var elapsed time.Duration
+			var diskUsage int64
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				path := b.TempDir()
+				service, closeDB := engine.open(b, path)
+				b.StartTimer()
+
+				start := time.Now()
+				if err := replay(v1.NewStateService(service), events); err != nil {
+					b.Fatal(err)
+				}
+				elapsed += time.Since(start)
+
+				b.StopTimer()
+				if err := closeDB(); err != nil {
+					b.Fatal(err)
+				}
+				size, err := dirSize(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				diskUsage += size
+				b.StartTimer()
+			}
+			b.ReportMetric(float64(events.count()*b.N)/elapsed.Seconds(), "events/s")
+			b.ReportMetric(float64(diskUsage)/float64(b.N), "disk-bytes")
+		})
+	}
+}
+
+// replay applies the events block by block: the evo mints of a block are stored in a transaction as the evolution processor
+// does, and then they are read back and applied together with the transfers in another transaction as the universal processor does
+func replay(stateService state.Service, events *syncEvents) error {
+	for i, block := range events.Blocks {
+		if err := storeEvoMints(stateService, events.Contract.CollectionAddress.String(), block.EvoMints); err != nil {
+			return err
+		}
+		tx, err := stateService.NewTransaction()
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			if err := tx.StoreERC721UniversalContracts([]model.ERC721UniversalContract{events.Contract}); err != nil {
+				tx.Discard()
+				return err
+			}
+		}
+		if err := applyBlock(tx, events.Contract, block); err != nil {
+			tx.Discard()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func storeEvoMints(stateService state.Service, collection string, mints []model.MintedWithExternalURI) error {
+	if len(mints) == 0 {
+		return nil
+	}
+	tx, err := stateService.NewTransaction()
+	if err != nil {
+		return err
+	}
+	defer tx.Discard()
+	for i := range mints {
+		if err := tx.StoreMintedWithExternalURIEvent(collection, &mints[i]); err != nil {
+			return err
+		}
+		if i > 0 && mints[i-1].BlockNumber == mints[i].BlockNumber {
+			continue
+		}
+		if err := tx.SetNextEvoEventBlock(collection, mints[i].BlockNumber); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func applyBlock(tx state.Tx, contract model.ERC721UniversalContract, block syncBlock) error {
+	if err := tx.LoadContractTrees(contract.Address); err != nil {
+		return err
+	}
+	var lastEvoBlock uint64
+	for i := range block.EvoMints {
+		evoBlock := block.EvoMints[i].BlockNumber
+		if i > 0 && block.EvoMints[i-1].BlockNumber == evoBlock {
+			continue
+		}
+		mints, err := tx.GetMintedWithExternalURIEvents(contract.CollectionAddress.String(), evoBlock)
+		if err != nil {
+			return err
+		}
+		for j := range mints {
+			if err := tx.Mint(contract.Address, &mints[j]); err != nil {
+				return err
+			}
+		}
+		lastEvoBlock = evoBlock
+	}
+	for i := range block.Transfers {
+		if err := tx.Transfer(contract.Address, &block.Transfers[i]); err != nil {
+			return err
+		}
+	}
+	if lastEvoBlock > 0 {
+		if err := tx.UpdateContractState(contract.Address, lastEvoBlock); err != nil {
+			return err
+		}
+	}
+	if err := tx.TagRoot(int64(block.Number)); err != nil {
+		return err
+	}
+	ownershipBlock := model.Block{Number: block.Number, Timestamp: block.Timestamp, Hash: common.BigToHash(new(big.Int).SetUint64(block.Number))}
+	if err := tx.SetOwnershipBlock(block.Number, ownershipBlock); err != nil {
+		return err
+	}
+	return tx.SetLastOwnershipBlock(ownershipBlock)
+}
+
+func dirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,245 @@
+package backup
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+)
+
+// ManifestFile is the file of a backup directory that lists its backups
+const ManifestFile = "manifest.json"
+
+var (
+	// ErrNoBackups is returned when restoring a directory without backups
+	ErrNoBackups = errors.New("no backups found")
+	// ErrStateMismatch is returned when a restored state is not the one recorded in the manifest
+	ErrStateMismatch = errors.New("restored state does not match the backup manifest")
+)
+
+// Database is a database that can be backed up incrementally
+type Database interface {
+	// Snapshot opens a snapshot of the database at its last commit, blocking the commits only while it is opened
+	Snapshot() Snapshot
+	// Load writes the entries of a backup written by Snapshot.Backup
+	Load(r io.Reader) error
+}
+
+// Snapshot is a read-only transaction on a version of a database, which must be discarded
+type Snapshot interface {
+	storage.Tx
+	// Backup writes the entries of the snapshot committed after the version since to w and returns the last version written,
+	// or 0 if there are none
+	Backup(w io.Writer, since uint64) (uint64, error)
+}
+
+// State identifies the state of the node saved by a backup, to validate it once restored
+type State struct {
+	LastOwnershipBlock uint64      `json:"lastOwnershipBlock"`
+	AccountHeadRoot    common.Hash `json:"accountHeadRoot"`
+}
+
+// Entry is a backup file of the manifest
+type Entry struct {
+	File string `json:"file"`
+	// Since is the version the entries of the file were committed after, 0 for a full backup
+	Since uint64 `json:"since"`
+	// Version is the last version of the entries of the database once the file is restored
+	Version   uint64    `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	State
+}
+
+// Validate returns ErrStateMismatch if state is not the state saved by the backup
+func (e Entry) Validate(state State) error {
+	if state != e.State {
+		return fmt.Errorf("%w: got last ownership block %d and account head root %s, expected %d and %s", ErrStateMismatch,
+			state.LastOwnershipBlock, state.AccountHeadRoot.Hex(), e.LastOwnershipBlock, e.AccountHeadRoot.Hex())
+	}
+	return nil
+}
+
+// Manifest lists the backups of a directory: a full backup followed by the incremental backups on top of it
+type Manifest struct {
+	Backups []Entry `json:"backups"`
+}
+
+// ReadManifest returns the manifest of dir, which is empty if there are no backups yet
+func ReadManifest(dir string) (*Manifest, error) {
+	buf, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Manifest{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(buf, &manifest); err != nil {
+		return nil, fmt.Errorf("error decoding manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// Backuper backs up a database to a directory
+type Backuper struct {
+	dir   string
+	db    Database
+	state func(tx storage.Tx) (State, error)
+	// mu serializes the backups, which append to the same manifest
+	mu sync.Mutex
+}
+
+// New returns a Backuper of db to dir. state returns the state saved by a backup, read from the snapshot it backs up.
+func New(dir string, db Database, state func(tx storage.Tx) (State, error)) *Backuper {
+	return &Backuper{
+		dir:   dir,
+		db:    db,
+		state: state,
+	}
+}
+
+// Backup writes the entries committed since the last backup to a new file of the directory, or all of them
+// if full is true or the directory has no backups yet. A full backup replaces the previous backups of the directory.
+// If nothing was committed since the last backup, no file is written and the last backup is returned.
+func (b *Backuper) Backup(full bool) (Entry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := os.MkdirAll(b.dir, 0o755); err != nil {
+		return Entry{}, fmt.Errorf("error creating backup directory: %w", err)
+	}
+	manifest, err := ReadManifest(b.dir)
+	if err != nil {
+		return Entry{}, err
+	}
+	previous := manifest.Backups
+	if full {
+		manifest.Backups = nil
+	}
+	var since uint64
+	if n := len(manifest.Backups); n > 0 {
+		since = manifest.Backups[n-1].Version
+	}
+
+	entry := Entry{
+		File:      fmt.Sprintf("backup-%s.bak", time.Now().UTC().Format("20060102T150405.000000000")),
+		Since:     since,
+		CreatedAt: time.Now().UTC(),
+	}
+	path := filepath.Join(b.dir, entry.File)
+	written, err := b.write(path, &entry)
+	if err != nil {
+		_ = os.Remove(path)
+		return Entry{}, err
+	}
+	if !written {
+		_ = os.Remove(path)
+		last := manifest.Backups[len(manifest.Backups)-1]
+		slog.Info("nothing committed since the last backup", "file", last.File, "version", last.Version)
+		return last, nil
+	}
+
+	manifest.Backups = append(manifest.Backups, entry)
+	if err := writeManifest(b.dir, manifest); err != nil {
+		return Entry{}, err
+	}
+	if full {
+		for _, replaced := range previous {
+			if err := os.Remove(filepath.Join(b.dir, replaced.File)); err != nil {
+				slog.Error("error removing a backup replaced by a full backup", "file", replaced.File, "err", err)
+			}
+		}
+	}
+	slog.Info("backup written", "file", entry.File, "since", entry.Since, "version", entry.Version,
+		"lastOwnershipBlock", entry.LastOwnershipBlock, "accountHeadRoot", entry.AccountHeadRoot.Hex())
+	return entry, nil
+}
+
+// write writes the backup of entry to path, setting its version and state. It returns false if there was
+// nothing to back up.
+func (b *Backuper) write(path string, entry *Entry) (bool, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return false, fmt.Errorf("error creating backup file: %w", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	// the commits go on while the snapshot is backed up
+	snapshot := b.db.Snapshot()
+	defer snapshot.Discard()
+	entry.State, err = b.state(snapshot)
+	if err != nil {
+		return false, fmt.Errorf("error reading the state to back up: %w", err)
+	}
+	version, err := snapshot.Backup(w, entry.Since)
+	if err != nil {
+		return false, fmt.Errorf("error backing up database: %w", err)
+	}
+	if version <= entry.Since && entry.Since > 0 {
+		return false, nil
+	}
+	entry.Version = version
+
+	if err := w.Flush(); err != nil {
+		return false, fmt.Errorf("error writing backup file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return false, fmt.Errorf("error writing backup file: %w", err)
+	}
+	return true, nil
+}
+
+// writeManifest replaces the manifest of dir, so that an interrupted write leaves the previous one
+func writeManifest(dir string, manifest *Manifest) error {
+	buf, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, ManifestFile+".tmp")
+	if err := os.WriteFile(tmp, buf, 0o644); err != nil {
+		return fmt.Errorf("error writing manifest: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, ManifestFile)); err != nil {
+		return fmt.Errorf("error writing manifest: %w", err)
+	}
+	return nil
+}
+
+// Restore loads the backups of dir into db, which must be empty, and returns the last one, whose state
+// the restored database must have
+func Restore(dir string, db Database) (Entry, error) {
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(manifest.Backups) == 0 {
+		return Entry{}, fmt.Errorf("%w in %s", ErrNoBackups, dir)
+	}
+	for _, entry := range manifest.Backups {
+		slog.Info("restoring backup", "file", entry.File, "since", entry.Since, "version", entry.Version)
+		if err := load(filepath.Join(dir, entry.File), db); err != nil {
+			return Entry{}, fmt.Errorf("error restoring backup %s: %w", entry.File, err)
+		}
+	}
+	return manifest.Backups[len(manifest.Backups)-1], nil
+}
+
+func load(path string, db Database) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return db.Load(f)
+}
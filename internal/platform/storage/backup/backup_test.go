@@ -0,0 +1,228 @@
+package backup_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/backup"
+	badgerStorage "github.com/freeverseio/laos-universal-node/internal/platform/storage/badger"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	service := newBadger(t)
+	block := uint64(1)
+	backuper := backup.New(dir, service, func(storage.Tx) (backup.State, error) {
+		return backup.State{LastOwnershipBlock: block, AccountHeadRoot: common.HexToHash("0x01")}, nil
+	})
+
+	write(t, service, map[string]string{"a": "1", "b": "2"}, nil)
+	full, err := backuper.Backup(false)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if full.Since != 0 {
+		t.Fatalf("got since %d for the first backup, expected a full backup", full.Since)
+	}
+
+	write(t, service, map[string]string{"a": "10", "c": "3"}, []string{"b"})
+	block = 2
+	incremental, err := backuper.Backup(false)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if incremental.Since != full.Version {
+		t.Fatalf("got since %d, expected %d", incremental.Since, full.Version)
+	}
+
+	// nothing was committed since the last backup
+	block = 3
+	last, err := backuper.Backup(false)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if last != incremental {
+		t.Fatalf("got backup %v, expected the last backup %v", last, incremental)
+	}
+	assertManifest(t, dir, full.File, incremental.File)
+
+	restored := newBadger(t)
+	entry, err := backup.Restore(dir, restored)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if entry != incremental {
+		t.Fatalf("got backup %v, expected the last backup %v", entry, incremental)
+	}
+	assertEntries(t, restored, map[string]string{"a": "10", "c": "3"})
+}
+
+func TestFullBackupReplacesPreviousBackups(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	service := newBadger(t)
+	backuper := backup.New(dir, service, func(storage.Tx) (backup.State, error) { return backup.State{}, nil })
+
+	write(t, service, map[string]string{"a": "1"}, nil)
+	first, err := backuper.Backup(false)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	write(t, service, map[string]string{"b": "2"}, nil)
+	full, err := backuper.Backup(true)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if full.Since != 0 {
+		t.Fatalf("got since %d, expected a full backup", full.Since)
+	}
+	assertManifest(t, dir, full.File)
+	if _, err := os.Stat(filepath.Join(dir, first.File)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("got error %v for the replaced backup, expected it to be removed", err)
+	}
+
+	restored := newBadger(t)
+	if _, err := backup.Restore(dir, restored); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	assertEntries(t, restored, map[string]string{"a": "1", "b": "2"})
+}
+
+func TestBackupDoesNotBlockCommits(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	service := newBadger(t)
+	committed := false
+	backuper := backup.New(dir, service, func(tx storage.Tx) (backup.State, error) {
+		// a commit while the snapshot is backed up is neither blocked nor backed up
+		if !committed {
+			committed = true
+			write(t, service, map[string]string{"b": "2"}, nil)
+		}
+		value, err := tx.Get([]byte("b"))
+		if err != nil || value != nil {
+			return backup.State{}, fmt.Errorf("got value %q and error %v for a key committed after the snapshot", value, err)
+		}
+		return backup.State{LastOwnershipBlock: 1}, nil
+	})
+
+	write(t, service, map[string]string{"a": "1"}, nil)
+	if _, err := backuper.Backup(false); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	restored := newBadger(t)
+	if _, err := backup.Restore(dir, restored); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	assertEntries(t, restored, map[string]string{"a": "1"})
+}
+
+func TestRestoreWithoutBackups(t *testing.T) {
+	t.Parallel()
+	if _, err := backup.Restore(t.TempDir(), newBadger(t)); !errors.Is(err, backup.ErrNoBackups) {
+		t.Fatalf(`got error "%v", expected "%v"`, err, backup.ErrNoBackups)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	entry := backup.Entry{State: backup.State{LastOwnershipBlock: 10, AccountHeadRoot: common.HexToHash("0x01")}}
+	tests := []struct {
+		name        string
+		state       backup.State
+		expectedErr error
+	}{
+		{
+			name:  "same state",
+			state: backup.State{LastOwnershipBlock: 10, AccountHeadRoot: common.HexToHash("0x01")},
+		},
+		{
+			name:        "different last ownership block",
+			state:       backup.State{LastOwnershipBlock: 9, AccountHeadRoot: common.HexToHash("0x01")},
+			expectedErr: backup.ErrStateMismatch,
+		},
+		{
+			name:        "different account head root",
+			state:       backup.State{LastOwnershipBlock: 10, AccountHeadRoot: common.HexToHash("0x02")},
+			expectedErr: backup.ErrStateMismatch,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if err := entry.Validate(tt.state); !errors.Is(err, tt.expectedErr) {
+				t.Fatalf(`got error "%v", expected "%v"`, err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func newBadger(t *testing.T) badgerStorage.Badger {
+	t.Helper()
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLoggingLevel(badger.ERROR))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return badgerStorage.NewService(db)
+}
+
+func write(t *testing.T, service storage.Service, entries map[string]string, deleted []string) {
+	t.Helper()
+	tx := service.NewTransaction()
+	defer tx.Discard()
+	for key, value := range entries {
+		if err := tx.Set([]byte(key), []byte(value)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, key := range deleted {
+		if err := tx.Delete([]byte(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertEntries(t *testing.T, service storage.Service, expected map[string]string) {
+	t.Helper()
+	tx := service.NewTransaction()
+	defer tx.Discard()
+	got := make(map[string]string)
+	for _, key := range tx.GetKeysWithPrefix(nil) {
+		value, err := tx.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[string(key)] = string(value)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("got entries %v, expected %v", got, expected)
+	}
+}
+
+func assertManifest(t *testing.T, dir string, files ...string) {
+	t.Helper()
+	manifest, err := backup.ReadManifest(dir)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	got := make([]string, 0, len(manifest.Backups))
+	for _, entry := range manifest.Backups {
+		got = append(got, entry.File)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(files) {
+		t.Fatalf("got backups %v in the manifest, expected %v", got, files)
+	}
+}
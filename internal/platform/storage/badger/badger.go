@@ -2,22 +2,144 @@ package badger
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
 
 	"github.com/dgraph-io/badger/v4"
+	"github.com/dgraph-io/badger/v4/pb"
 	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/backup"
+)
+
+const (
+	// maxPendingWrites is the amount of entries of a backup buffered while it is loaded
+	maxPendingWrites = 256
+	// backupBatchSize is the size of the lists of entries a backup is written in
+	backupBatchSize = 4 << 20
+	// the meta bits of the backed up entries read by Load, which are part of the backup format of Badger
+	bitDelete                 byte = 1 << 0
+	bitDiscardEarlierVersions byte = 1 << 2
 )
 
 type Badger struct {
 	db *badger.DB
+	// commits is locked to pause the commits while a snapshot of the database is opened
+	commits *sync.RWMutex
 }
 
-func NewService(db *badger.DB) storage.Service {
+func NewService(db *badger.DB) Badger {
 	return Badger{
-		db: db,
+		db:      db,
+		commits: &sync.RWMutex{},
+	}
+}
+
+// Snapshot opens a read-only transaction to back up the database at the version of its last commit. The commits are only
+// paused while the transaction is opened, so that they are not blocked while the snapshot is backed up. It must be discarded.
+func (b Badger) Snapshot() backup.Snapshot {
+	b.commits.Lock()
+	defer b.commits.Unlock()
+	return snapshot{Tx: Tx{tx: b.db.NewTransaction(false), commits: b.commits}}
+}
+
+type snapshot struct {
+	Tx
+}
+
+// Backup writes the entries of the snapshot committed after the version since to w, in the format of the backups of Badger,
+// and returns the last version written, or 0 if there are none
+func (s snapshot) Backup(w io.Writer, since uint64) (uint64, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.AllVersions = true
+	opts.PrefetchValues = false
+	opts.SinceTs = since
+	iterator := s.tx.NewIterator(opts)
+	defer iterator.Close()
+
+	var version uint64
+	list := &pb.KVList{}
+	size := 0
+	var key []byte
+	// the versions older than a deleted version or one that discards them are not restored
+	skip := false
+	for iterator.Rewind(); iterator.Valid(); iterator.Next() {
+		item := iterator.Item()
+		if !bytes.Equal(item.Key(), key) {
+			key = item.KeyCopy(nil)
+			skip = false
+		}
+		if skip {
+			continue
+		}
+		kv := &pb.KV{Key: key, Version: item.Version(), ExpiresAt: item.ExpiresAt(), UserMeta: []byte{item.UserMeta()}}
+		var meta byte
+		switch {
+		case item.IsDeletedOrExpired():
+			meta = bitDelete
+			skip = true
+		default:
+			value, err := item.ValueCopy(nil)
+			if err != nil {
+				return 0, fmt.Errorf("error reading the value of key %x at version %d: %w", key, item.Version(), err)
+			}
+			kv.Value = value
+		}
+		if item.DiscardEarlierVersions() {
+			meta |= bitDiscardEarlierVersions
+			skip = true
+		}
+		kv.Meta = []byte{meta}
+		list.Kv = append(list.Kv, kv)
+		size += kv.Size()
+		if item.DiscardEarlierVersions() && item.Version() > 1 {
+			marker := &pb.KV{Key: key, Version: item.Version() - 1, Meta: []byte{bitDelete}}
+			list.Kv = append(list.Kv, marker)
+			size += marker.Size()
+		}
+		version = max(version, item.Version())
+
+		if size >= backupBatchSize {
+			if err := writeKVList(w, list); err != nil {
+				return 0, err
+			}
+			list.Kv = list.Kv[:0]
+			size = 0
+		}
 	}
+	if len(list.Kv) > 0 {
+		if err := writeKVList(w, list); err != nil {
+			return 0, err
+		}
+	}
+	return version, nil
+}
+
+// writeKVList writes list to w prefixed with its size, like the backups of Badger
+func writeKVList(w io.Writer, list *pb.KVList) error {
+	buf, err := list.Marshal()
+	if err != nil {
+		return fmt.Errorf("error encoding backup entries: %w", err)
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint64(len(buf))); err != nil {
+		return fmt.Errorf("error writing backup entries: %w", err)
+	}
+	if _, err := w.Write(buf); err != nil {
+		return fmt.Errorf("error writing backup entries: %w", err)
+	}
+	return nil
+}
+
+// Load writes the entries of a backup written by Backup
+func (b Badger) Load(r io.Reader) error {
+	return b.db.Load(r, maxPendingWrites)
 }
 
 func (b Badger) Set(key, value []byte) error {
+	b.commits.RLock()
+	defer b.commits.RUnlock()
 	return b.db.Update(func(txn *badger.Txn) error {
 		err := txn.Set(key, value)
 		if err != nil {
@@ -32,7 +154,7 @@ func (b Badger) Get(key []byte) ([]byte, error) {
 	err := b.db.View(func(txn *badger.Txn) error {
 		item, err := txn.Get(key)
 		if err != nil {
-			return err
+			return storageError(err)
 		}
 		returnValue, err = item.ValueCopy(returnValue)
 		if err != nil {
@@ -83,17 +205,21 @@ func (b Badger) GetKeysWithPrefix(prefix []byte, reverse ...bool) ([][]byte, err
 }
 
 type Tx struct {
-	tx *badger.Txn
+	tx      *badger.Txn
+	commits *sync.RWMutex
 }
 
 func (b Badger) NewTransaction() storage.Tx {
 	return Tx{
-		b.db.NewTransaction(true),
+		tx:      b.db.NewTransaction(true),
+		commits: b.commits,
 	}
 }
 
 func (t Tx) Commit() error {
-	return t.tx.Commit()
+	t.commits.RLock()
+	defer t.commits.RUnlock()
+	return storageError(t.tx.Commit())
 }
 
 func (t Tx) Discard() {
@@ -102,7 +228,7 @@ func (t Tx) Discard() {
 
 // Set sets []byte value for []byte key
 func (t Tx) Set(key, value []byte) error {
-	return t.tx.Set(key, value)
+	return storageError(t.tx.Set(key, value))
 }
 
 // Get returns byte for the key
@@ -114,14 +240,29 @@ func (t Tx) Get(key []byte) ([]byte, error) {
 		if err == badger.ErrKeyNotFound {
 			return nil, nil
 		}
-		return nil, err
+		return nil, storageError(err)
 	}
 	return item.ValueCopy(nil)
 }
 
 // Delete deletes a key.
 func (t Tx) Delete(key []byte) error {
-	return t.tx.Delete(key)
+	return storageError(t.tx.Delete(key))
+}
+
+// storageError wraps the badger errors that callers handle with the errors of the storage package
+func storageError(err error) error {
+	switch {
+	case errors.Is(err, badger.ErrTxnTooBig):
+		return fmt.Errorf("%w: %w", storage.ErrTxnTooBig, err)
+	case errors.Is(err, badger.ErrConflict):
+		return fmt.Errorf("%w: %w", storage.ErrConflict, err)
+	case errors.Is(err, badger.ErrKeyNotFound):
+		return fmt.Errorf("%w: %w", storage.ErrKeyNotFound, err)
+	case errors.Is(err, badger.ErrDiscardedTxn):
+		return fmt.Errorf("%w: %w", storage.ErrDiscardedTxn, err)
+	}
+	return err
 }
 
 // GetKeysWithPrefix looks for all the keys with the specified prefix and returns them. It doesn't return values
@@ -196,6 +337,28 @@ func (t Tx) GetValuesWithPrefix(prefix []byte, reverse ...bool) [][]byte {
 	return values
 }
 
+// Iterate calls fn with the keys with the specified prefix from the key from on and their values in ascending order,
+// stopping at the first error
+func (t Tx) Iterate(prefix, from []byte, fn func(key, value []byte) error) error {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+	opts.PrefetchSize = 100
+	iterator := t.tx.NewIterator(opts)
+	defer iterator.Close()
+
+	start := prefix
+	if bytes.Compare(from, prefix) > 0 {
+		start = from
+	}
+	for iterator.Seek(start); iterator.ValidForPrefix(prefix); iterator.Next() {
+		item := iterator.Item()
+		if err := item.Value(func(value []byte) error { return fn(item.Key(), value) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (t Tx) FilterKeysWithPrefix(prefix []byte, from, to string) [][]byte {
 	var keys [][]byte
 
@@ -2,6 +2,7 @@ package badger_test
 
 import (
 	"bytes"
+	"errors"
 	"log"
 	"os"
 	"strconv"
@@ -10,6 +11,7 @@ import (
 	"github.com/dgraph-io/badger/v4"
 	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
 	badgerStorage "github.com/freeverseio/laos-universal-node/internal/platform/storage/badger"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/storagetest"
 )
 
 const prefix = "prefix_"
@@ -48,7 +50,7 @@ func TestStorageGetNoData(t *testing.T) {
 	if err == nil {
 		t.Fatal("got no error, expecting badger.ErrKeyNotFound")
 	}
-	if err != badger.ErrKeyNotFound {
+	if !errors.Is(err, badger.ErrKeyNotFound) {
 		t.Fatalf("got error %s, expecting badger.ErrKeyNotFound", err.Error())
 	}
 	if got != nil {
@@ -267,6 +269,43 @@ func TestGetValuesWithPrefixWithValues(t *testing.T) {
 	}
 }
 
+func TestTxnTooBig(t *testing.T) {
+	t.Parallel()
+	// the transactions of an 8MB memtable are limited to about 1.2MB
+	smallDB, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithMemTableSize(8 << 20).WithLoggingLevel(badger.ERROR))
+	if err != nil {
+		t.Fatalf("error initializing storage: %v", err)
+	}
+	defer smallDB.Close()
+
+	tx := badgerStorage.NewService(smallDB).NewTransaction()
+	defer tx.Discard()
+	value := bytes.Repeat([]byte{1}, 1024)
+	for i := 0; i < 10000; i++ {
+		err = tx.Set([]byte(prefix+strconv.Itoa(i)), value)
+		if err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, storage.ErrTxnTooBig) {
+		t.Fatalf("got error %v, expecting storage.ErrTxnTooBig", err)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+	storagetest.Run(t, func(t *testing.T) storage.Service {
+		db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLoggingLevel(badger.ERROR))
+		if err != nil {
+			t.Fatalf("error initializing storage: %v", err)
+		}
+		t.Cleanup(func() {
+			_ = db.Close()
+		})
+		return badgerStorage.NewService(db)
+	})
+}
+
 func performTransaction(t *testing.T, key, val []byte, service storage.Service) {
 	t.Helper()
 	tx := service.NewTransaction()
@@ -1,112 +1,418 @@
 package memory
 
 import (
+	"bytes"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
 	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
 )
 
-type data struct {
-	data map[string][]byte
+// version of a key written by a commit
+type version struct {
+	commit  uint64
+	value   []byte
+	deleted bool
 }
 
 type service struct {
-	committed data
+	mu sync.Mutex
+	// commit is incremented by every commit, transactions read the versions of the keys written up to the commit they started at
+	commit uint64
+	// versions of every key, oldest first. The versions no open transaction reads are dropped on commit.
+	versions map[string][]version
+	// keys are the keys of versions in ascending order
+	keys []string
+	// active counts the open transactions by the commit they started at
+	active map[uint64]int
+	// stale are the keys with versions that might no longer be read, which are pruned once no open transaction reads them
+	stale map[string]struct{}
 }
 
-// New creates a new in-memory storage service.
+// New creates a new in-memory storage service. Like Badger, its transactions are isolated on a snapshot
+// and fail to commit with storage.ErrConflict when another transaction wrote a key they read since they started.
 func New() *service {
-	s := service{}
-	s.committed.data = make(map[string][]byte)
-	return &s
+	return &service{
+		versions: make(map[string][]version),
+		active:   make(map[uint64]int),
+		stale:    make(map[string]struct{}),
+	}
 }
 
 // NewTransaction creates a new storage transaction.
 func (b *service) NewTransaction() storage.Tx {
-	temp := b.committed
-	temp.data = make(map[string][]byte)
-	for k, v := range b.committed.data {
-		temp.data[k] = v
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.active[b.commit]++
+	return &tx{
+		s:      b,
+		commit: b.commit,
+		writes: make(map[string]write),
+		reads:  make(map[string]struct{}),
 	}
-	return &tx{temp, b}
 }
 
+// Get returns the committed value of a key, or storage.ErrKeyNotFound if it does not exist.
 func (b *service) Get(key []byte) ([]byte, error) {
-	value, has := b.committed.data[string(key)]
+	b.mu.Lock()
+	value, has := b.get(string(key), b.commit)
+	b.mu.Unlock()
 	if !has {
-		return nil, nil
+		return nil, storage.ErrKeyNotFound
 	}
-
-	return value, nil
+	return bytes.Clone(value), nil
 }
 
-// GetKeysWithPrefix returns nil. it is just put here for interface compatibility
+// GetKeysWithPrefix returns the committed keys with the given prefix in ascending order, or descending if reverse is true.
 func (b *service) GetKeysWithPrefix(prefix []byte, reverse ...bool) ([][]byte, error) {
-	return nil, nil
+	b.mu.Lock()
+	keys := b.keysWithPrefix(string(prefix), b.commit)
+	b.mu.Unlock()
+	return toBytes(orderKeys(keys, reverse...)), nil
 }
 
 // Set updates a key/value pair in the storage service.
 func (b *service) Set(key, value []byte) error {
-	b.committed.data[string(key)] = value
-	return nil
+	t := b.NewTransaction()
+	defer t.Discard()
+	if err := t.Set(key, value); err != nil {
+		return err
+	}
+	return t.Commit()
 }
 
 // Delete deletes a key
 func (b *service) Delete(key []byte) error {
-	delete(b.committed.data, string(key))
-	return nil
+	t := b.NewTransaction()
+	defer t.Discard()
+	if err := t.Delete(key); err != nil {
+		return err
+	}
+	return t.Commit()
 }
 
 // Close closes the storage service.
-func (b service) Close() {}
+func (b *service) Close() {}
+
+// get returns the value of a key at commit. The caller must hold the lock.
+func (b *service) get(key string, commit uint64) ([]byte, bool) {
+	versions := b.versions[key]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i].commit <= commit {
+			return versions[i].value, !versions[i].deleted
+		}
+	}
+	return nil, false
+}
+
+// keysWithPrefix returns the keys with the given prefix at commit in ascending order. The caller must hold the lock.
+func (b *service) keysWithPrefix(prefix string, commit uint64) []string {
+	var keys []string
+	for i := sort.SearchStrings(b.keys, prefix); i < len(b.keys) && strings.HasPrefix(b.keys[i], prefix); i++ {
+		if _, has := b.get(b.keys[i], commit); has {
+			keys = append(keys, b.keys[i])
+		}
+	}
+	return keys
+}
+
+// apply commits the writes of t, unless a key read by t was written after it started
+func (b *service) apply(t *tx) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	defer b.release(t.commit)
+
+	for key := range t.reads {
+		if versions := b.versions[key]; len(versions) > 0 && versions[len(versions)-1].commit > t.commit {
+			return storage.ErrConflict
+		}
+	}
+
+	b.commit++
+	var added []string
+	for key, w := range t.writes {
+		versions, existed := b.versions[key]
+		if !existed {
+			added = append(added, key)
+		}
+		b.versions[key] = append(versions, version{commit: b.commit, value: w.value, deleted: w.deleted})
+		if existed || w.deleted {
+			b.stale[key] = struct{}{}
+		}
+	}
+	b.addKeys(added)
+	// the stale versions are pruned once the transaction is released
+	return nil
+}
+
+// addKeys merges the new keys into the sorted keys, without copying them
+func (b *service) addKeys(added []string) {
+	if len(added) == 0 {
+		return
+	}
+	sort.Strings(added)
+	n := len(b.keys)
+	b.keys = slices.Grow(b.keys, len(added))[:n+len(added)]
+	// merge from the end so that the keys are moved at most once
+	i, j := n-1, len(added)-1
+	for k := len(b.keys) - 1; j >= 0; k-- {
+		if i >= 0 && b.keys[i] > added[j] {
+			b.keys[k] = b.keys[i]
+			i--
+		} else {
+			b.keys[k] = added[j]
+			j--
+		}
+	}
+}
+
+// prune drops the versions of the stale keys that no open transaction reads, and the keys that no longer exist for any of them.
+// Versions kept for a long transaction are dropped once it is closed. The caller must hold the lock.
+func (b *service) prune() {
+	oldest := b.oldestActive()
+	removed := false
+	for key := range b.stale {
+		versions := b.versions[key]
+		// the latest version up to oldest is read by the transactions started at oldest, the versions before it by none
+		first := 0
+		for i := len(versions) - 1; i >= 0; i-- {
+			if versions[i].commit <= oldest {
+				first = i
+				break
+			}
+		}
+		if first > 0 {
+			// the versions are copied so that the dropped values are not referenced by the underlying array
+			versions = slices.Clone(versions[first:])
+		}
+		switch {
+		case len(versions) == 1 && versions[0].deleted:
+			delete(b.versions, key)
+			delete(b.stale, key)
+			removed = true
+		case len(versions) == 1:
+			b.versions[key] = versions
+			delete(b.stale, key)
+		default:
+			b.versions[key] = versions
+		}
+	}
+	if removed {
+		b.keys = slices.DeleteFunc(b.keys, func(key string) bool {
+			_, ok := b.versions[key]
+			return !ok
+		})
+	}
+}
+
+// oldestActive returns the commit the oldest open transaction started at, or the last commit if there is none
+func (b *service) oldestActive() uint64 {
+	oldest := b.commit
+	for commit := range b.active {
+		oldest = min(oldest, commit)
+	}
+	return oldest
+}
+
+// release closes the transaction started at commit and prunes the versions no other transaction reads. The caller must hold the lock.
+func (b *service) release(commit uint64) {
+	b.active[commit]--
+	if b.active[commit] == 0 {
+		delete(b.active, commit)
+	}
+	b.prune()
+}
+
+type write struct {
+	value   []byte
+	deleted bool
+}
 
 type tx struct {
-	temp data
-	s    *service
+	s *service
+	// commit is the last commit when the transaction started, whose versions it reads
+	commit uint64
+	writes map[string]write
+	// reads are the keys read by the transaction, which conflict with the writes committed after it started
+	reads     map[string]struct{}
+	discarded bool
 }
 
 // Set updates a key/value pair in the storage service.
-func (b tx) Set(key, value []byte) error {
-	b.temp.data[string(key)] = value
+func (b *tx) Set(key, value []byte) error {
+	if b.discarded {
+		return storage.ErrDiscardedTxn
+	}
+	b.writes[string(key)] = write{value: bytes.Clone(value)}
 	return nil
 }
 
-// Get stores a key/value pair in the storage service.
-func (b tx) Get(key []byte) ([]byte, error) {
-	value, has := b.temp.data[string(key)]
+// Get returns the value of a key, or nil if it does not exist. The writes of the transaction are visible.
+func (b *tx) Get(key []byte) ([]byte, error) {
+	if b.discarded {
+		return nil, storage.ErrDiscardedTxn
+	}
+	b.s.mu.Lock()
+	value, has := b.get(string(key))
+	b.s.mu.Unlock()
 	if !has {
 		return nil, nil
 	}
+	return bytes.Clone(value), nil
+}
 
-	return value, nil
+// get returns the value of a key. The caller must hold the lock of the service.
+func (b *tx) get(key string) ([]byte, bool) {
+	b.reads[key] = struct{}{}
+	if w, ok := b.writes[key]; ok {
+		return w.value, !w.deleted
+	}
+	return b.s.get(key, b.commit)
 }
 
-// GetKeysWithPrefix added for interface compatibility
-func (b tx) GetKeysWithPrefix(prefix []byte, reverse ...bool) [][]byte {
-	// TODO implement this if we have to use it for testing purposes
-	return nil
+// GetKeysWithPrefix looks for all the keys with the specified prefix and returns them in ascending order,
+// or descending if reverse is true. It doesn't return values
+func (b *tx) GetKeysWithPrefix(prefix []byte, reverse ...bool) [][]byte {
+	b.s.mu.Lock()
+	defer b.s.mu.Unlock()
+	return toBytes(orderKeys(b.keysWithPrefix(string(prefix)), reverse...))
 }
 
-func (b tx) FilterKeysWithPrefix(prefix []byte, from, to string) [][]byte {
-	// TODO implement this if we have to use it for testing purposes
-	return nil
+// FilterKeysWithPrefix returns the keys with the specified prefix between prefix+from and prefix+to, both included, in ascending order
+func (b *tx) FilterKeysWithPrefix(prefix []byte, from, to string) [][]byte {
+	b.s.mu.Lock()
+	defer b.s.mu.Unlock()
+	start := string(prefix) + from
+	end := string(prefix) + to
+	var keys [][]byte
+	for _, key := range b.keysWithPrefix(string(prefix)) {
+		if key < start {
+			continue
+		}
+		if key > end {
+			break
+		}
+		keys = append(keys, []byte(key))
+	}
+	return keys
+}
+
+// GetValuesWithPrefix returns the values of the keys with the specified prefix in the order of their keys,
+// ascending or descending if reverse is true
+func (b *tx) GetValuesWithPrefix(prefix []byte, reverse ...bool) [][]byte {
+	b.s.mu.Lock()
+	defer b.s.mu.Unlock()
+	var values [][]byte
+	for _, key := range orderKeys(b.keysWithPrefix(string(prefix)), reverse...) {
+		value, _ := b.get(key)
+		values = append(values, bytes.Clone(value))
+	}
+	return values
 }
 
-func (b tx) GetValuesWithPrefix(prefix []byte, reverse ...bool) [][]byte {
-	// TODO implement this if we have to use it for testing purposes
+// Iterate calls fn with the keys with the specified prefix from the key from on and their values in ascending order,
+// stopping at the first error. The lock of the service is not held while fn is called.
+func (b *tx) Iterate(prefix, from []byte, fn func(key, value []byte) error) error {
+	if b.discarded {
+		return storage.ErrDiscardedTxn
+	}
+	b.s.mu.Lock()
+	keys := b.keysWithPrefix(string(prefix))
+	b.s.mu.Unlock()
+	keys = keys[sort.SearchStrings(keys, string(from)):]
+	for _, key := range keys {
+		b.s.mu.Lock()
+		value, _ := b.get(key)
+		b.s.mu.Unlock()
+		if err := fn([]byte(key), value); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// keysWithPrefix merges the writes of the transaction with the keys it reads from the service, in ascending order.
+// The keys are read by the transaction, so that they conflict with the writes committed after it started.
+// The caller must hold the lock of the service.
+func (b *tx) keysWithPrefix(prefix string) []string {
+	if b.discarded {
+		return nil
+	}
+	keys := b.s.keysWithPrefix(prefix, b.commit)
+	written := false
+	for key, w := range b.writes {
+		if strings.HasPrefix(key, prefix) {
+			written = true
+			if !w.deleted {
+				keys = append(keys, key)
+			}
+		}
+	}
+	if written {
+		slices.Sort(keys)
+		keys = slices.Compact(keys)
+		keys = slices.DeleteFunc(keys, func(key string) bool {
+			w, ok := b.writes[key]
+			return ok && w.deleted
+		})
+	}
+	for _, key := range keys {
+		b.reads[key] = struct{}{}
+	}
+	return keys
+}
+
 // Delete deletes a key.
-func (b tx) Delete(key []byte) error {
-	delete(b.temp.data, string(key))
+func (b *tx) Delete(key []byte) error {
+	if b.discarded {
+		return storage.ErrDiscardedTxn
+	}
+	b.writes[string(key)] = write{deleted: true}
 	return nil
 }
 
-// Discard implemented just to make it compatible with storage interface
+// Discard drops the writes of the transaction. It does nothing on a committed transaction.
 func (b *tx) Discard() {
+	if b.discarded {
+		return
+	}
+	b.discarded = true
+	b.s.mu.Lock()
+	b.s.release(b.commit)
+	b.s.mu.Unlock()
 }
 
-// Commit commits the storage transaction.
+// Commit commits the storage transaction. A transaction without writes always commits.
 func (b *tx) Commit() error {
-	b.s.committed = b.temp
-	return nil
+	if b.discarded {
+		return storage.ErrDiscardedTxn
+	}
+	b.discarded = true
+	if len(b.writes) == 0 {
+		b.s.mu.Lock()
+		b.s.release(b.commit)
+		b.s.mu.Unlock()
+		return nil
+	}
+	return b.s.apply(b)
+}
+
+func orderKeys(keys []string, reverse ...bool) []string {
+	if len(reverse) > 0 && reverse[0] {
+		slices.Reverse(keys)
+	}
+	return keys
+}
+
+func toBytes(keys []string) [][]byte {
+	if len(keys) == 0 {
+		return nil
+	}
+	result := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, []byte(key))
+	}
+	return result
 }
@@ -0,0 +1,54 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/memory"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+	storagetest.Run(t, func(t *testing.T) storage.Service {
+		return memory.New()
+	})
+}
+
+func TestSnapshotAcrossCommits(t *testing.T) {
+	t.Parallel()
+	service := memory.New()
+	if err := service.Set([]byte("key"), []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	old := service.NewTransaction()
+	defer old.Discard()
+
+	// the versions read by the open transaction survive the commits that rewrite and delete the key
+	for _, value := range []string{"2", "3"} {
+		if err := service.Set([]byte("key"), []byte(value)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := service.Delete([]byte("key")); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := old.Get([]byte("key"))
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if string(value) != "1" {
+		t.Fatalf("got value %q, expected %q", value, "1")
+	}
+	keys, err := service.GetKeysWithPrefix([]byte("k"))
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("got keys %q, expected none", keys)
+	}
+	if keys := old.GetKeysWithPrefix([]byte("k")); len(keys) != 1 {
+		t.Fatalf("got keys %q, expected the key read by the open transaction", keys)
+	}
+}
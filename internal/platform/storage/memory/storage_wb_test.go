@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"testing"
+)
+
+func TestPruneAfterLongTransaction(t *testing.T) {
+	t.Parallel()
+	service := New()
+	for _, key := range []string{"rewritten", "deleted"} {
+		if err := service.Set([]byte(key), []byte("1")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	long := service.NewTransaction()
+
+	// the versions read by the long transaction are kept while it is open, and the commits do not write them again
+	for _, value := range []string{"2", "3"} {
+		if err := service.Set([]byte("rewritten"), []byte(value)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := service.Delete([]byte("deleted")); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Set([]byte("other"), []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := long.Get([]byte("rewritten")); err != nil || string(value) != "1" {
+		t.Fatalf(`got value %q and error "%v", expected the version read by the open transaction`, value, err)
+	}
+
+	// the versions of the keys not written by the last commit are dropped once the long transaction is closed
+	long.Discard()
+	if len(service.versions["rewritten"]) != 1 || string(service.versions["rewritten"][0].value) != "3" {
+		t.Fatalf("got versions %v of rewritten, expected only the last one", service.versions["rewritten"])
+	}
+	if _, ok := service.versions["deleted"]; ok {
+		t.Fatalf("got versions %v of deleted, expected none", service.versions["deleted"])
+	}
+	if len(service.keys) != 2 || len(service.stale) != 0 {
+		t.Fatalf("got keys %v and stale keys %v, expected 2 keys and no stale ones", service.keys, service.stale)
+	}
+}
@@ -0,0 +1,367 @@
+// Package migration records the schema version of a database and upgrades it with ordered migrations.
+//
+// A migration is made of steps that rewrite the entries under a key prefix, paging through them in batches. The progress
+// is recorded with every batch and the version after every migration, so an interrupted run is resumed by running it again
+// from the batch it was interrupted at.
+package migration
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+)
+
+const (
+	versionKey = "schema_version"
+	// progressKey holds the version of the migration being run, the amount of its steps that are done and the last key
+	// rewritten by the step being run
+	progressKey = "schema_migration_progress"
+)
+
+// errBatchFull stops the iteration over the keys of a step once a batch is read
+var errBatchFull = errors.New("batch full")
+
+var (
+	ErrNewerVersion      = errors.New("database schema is newer than the supported one")
+	ErrMigrationRequired = errors.New("database schema must be migrated")
+)
+
+// Step rewrites the entries under Prefix
+type Step struct {
+	Prefix string
+	// Rewrite returns the new key and value of an entry, or a nil key if the entry does not need to be rewritten.
+	// It must skip the entries it already rewrote, as the entries rewritten to a key under Prefix that sorts after
+	// the entry are read again.
+	Rewrite func(key, value []byte) ([]byte, []byte, error)
+	// Keep keeps the entries rewritten to a new key, for the steps that derive new entries from the existing ones, like indexes
+	Keep bool
+}
+
+// Migration upgrades the database from the previous version to Version
+type Migration struct {
+	Version     uint64
+	Description string
+	Steps       []Step
+}
+
+// Options of Run
+type Options struct {
+	// BatchSize is the amount of entries rewritten per transaction
+	BatchSize int
+	// DryRun counts the entries that would be rewritten without writing anything
+	DryRun bool
+}
+
+// Report tells which migrations Run applied, or would apply in dry-run mode, and how many entries they rewrote
+type Report struct {
+	FromVersion uint64
+	ToVersion   uint64
+	Migrations  []MigrationReport
+}
+
+type MigrationReport struct {
+	Version     uint64
+	Description string
+	Scanned     int
+	Rewritten   int
+}
+
+type Migrator struct {
+	service    storage.Service
+	migrations []Migration
+	hasData    func(tx storage.Tx) (bool, error)
+}
+
+// New returns a Migrator of the migrations, whose versions must go from 1 up one by one. hasData tells whether
+// a database without a recorded version holds data, which was written before the version was recorded,
+// or is a new database, which is set to the latest version without migrating it.
+func New(service storage.Service, migrations []Migration, hasData func(tx storage.Tx) (bool, error)) (*Migrator, error) {
+	for i, m := range migrations {
+		if m.Version != uint64(i+1) {
+			return nil, fmt.Errorf("invalid migration %q: got version %d, expected %d", m.Description, m.Version, i+1)
+		}
+	}
+	return &Migrator{service: service, migrations: migrations, hasData: hasData}, nil
+}
+
+// LatestVersion is the version of the database once all the migrations are applied
+func (m *Migrator) LatestVersion() uint64 {
+	return uint64(len(m.migrations))
+}
+
+// Check makes sure that the database is at the latest version without migrating it. A new database is set to the latest version.
+func (m *Migrator) Check() error {
+	tx := m.service.NewTransaction()
+	defer tx.Discard()
+
+	version, isNew, err := m.version(tx)
+	if err != nil {
+		return err
+	}
+	if isNew {
+		return m.initialize(tx)
+	}
+	if version < m.LatestVersion() {
+		return fmt.Errorf("%w: got version %d, run the migrate command to upgrade it to version %d", ErrMigrationRequired, version, m.LatestVersion())
+	}
+	return nil
+}
+
+// Run applies the migrations the database is missing, in order. A new database is set to the latest version.
+func (m *Migrator) Run(opts Options) (*Report, error) {
+	if opts.BatchSize <= 0 {
+		return nil, fmt.Errorf("invalid batch size %d", opts.BatchSize)
+	}
+	tx := m.service.NewTransaction()
+	version, isNew, err := m.version(tx)
+	if err == nil && isNew && !opts.DryRun {
+		err = m.initialize(tx)
+	}
+	tx.Discard()
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{FromVersion: version, ToVersion: version}
+	if isNew {
+		report.ToVersion = m.LatestVersion()
+		return report, nil
+	}
+
+	for _, migration := range m.migrations[version:] {
+		slog.Info("running migration", "version", migration.Version, "description", migration.Description, "dryRun", opts.DryRun)
+		migrationReport, err := m.run(migration, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error running migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+		report.Migrations = append(report.Migrations, *migrationReport)
+		report.ToVersion = migration.Version
+		slog.Info("migration done", "version", migration.Version, "scanned", migrationReport.Scanned,
+			"rewritten", migrationReport.Rewritten, "dryRun", opts.DryRun)
+	}
+	return report, nil
+}
+
+// version returns the version of the database, and whether it is a new database without version
+func (m *Migrator) version(tx storage.Tx) (uint64, bool, error) {
+	version, err := GetVersion(tx)
+	if err != nil {
+		return 0, false, err
+	}
+	if version > m.LatestVersion() {
+		return 0, false, fmt.Errorf("%w: got version %d, this node supports up to version %d", ErrNewerVersion, version, m.LatestVersion())
+	}
+	if version > 0 {
+		return version, false, nil
+	}
+	hasData, err := m.hasData(tx)
+	if err != nil {
+		return 0, false, err
+	}
+	return 0, !hasData, nil
+}
+
+func (m *Migrator) initialize(tx storage.Tx) error {
+	slog.Debug("recording the schema version of a new database", "version", m.LatestVersion())
+	if err := SetVersion(tx, m.LatestVersion()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) run(migration Migration, opts Options) (*MigrationReport, error) {
+	report := &MigrationReport{Version: migration.Version, Description: migration.Description}
+	doneSteps, lastKey, err := m.progress(migration.Version)
+	if err != nil {
+		return nil, err
+	}
+	if doneSteps > 0 || lastKey != nil {
+		slog.Info("resuming migration", "version", migration.Version, "doneSteps", doneSteps, "steps", len(migration.Steps),
+			"lastKey", string(lastKey))
+	}
+
+	for i := int(doneSteps); i < len(migration.Steps); i++ {
+		step := migration.Steps[i]
+		scanned, rewritten, err := m.runStep(migration.Version, uint64(i), step, lastKey, opts)
+		lastKey = nil
+		if err != nil {
+			return nil, fmt.Errorf("error rewriting %s: %w", step.Prefix, err)
+		}
+		report.Scanned += scanned
+		report.Rewritten += rewritten
+		slog.Info("migration step done", "version", migration.Version, "step", i+1, "of", len(migration.Steps),
+			"prefix", step.Prefix, "scanned", scanned, "rewritten", rewritten)
+		if opts.DryRun {
+			continue
+		}
+		if err := m.setProgress(migration.Version, uint64(i+1), nil); err != nil {
+			return nil, err
+		}
+	}
+	if opts.DryRun {
+		return report, nil
+	}
+
+	tx := m.service.NewTransaction()
+	defer tx.Discard()
+	if err := SetVersion(tx, migration.Version); err != nil {
+		return nil, err
+	}
+	if err := tx.Delete([]byte(progressKey)); err != nil {
+		return nil, err
+	}
+	return report, tx.Commit()
+}
+
+// runStep rewrites the entries of the step after lastKey, BatchSize of them per transaction, so that the keys of a prefix
+// are never loaded at once. The last key of every batch is recorded with it as the progress of step number doneSteps.
+func (m *Migrator) runStep(version, doneSteps uint64, step Step, lastKey []byte, opts Options) (int, int, error) {
+	scanned, rewritten := 0, 0
+	for {
+		keys, err := m.nextKeys([]byte(step.Prefix), lastKey, opts.BatchSize)
+		if err != nil {
+			return 0, 0, err
+		}
+		if len(keys) == 0 {
+			return scanned, rewritten, nil
+		}
+		lastKey = keys[len(keys)-1]
+		n, err := m.runBatch(step, keys, opts.DryRun, func(tx storage.Tx) error {
+			return setProgress(tx, version, doneSteps, lastKey)
+		})
+		if err != nil {
+			if errors.Is(err, storage.ErrTxnTooBig) {
+				return 0, 0, fmt.Errorf("batch of %d entries does not fit in a transaction, use a smaller batch size: %w", len(keys), err)
+			}
+			return 0, 0, err
+		}
+		scanned += len(keys)
+		rewritten += n
+		slog.Debug("migration batch done", "prefix", step.Prefix, "scanned", scanned, "lastKey", string(lastKey))
+		if len(keys) < opts.BatchSize {
+			return scanned, rewritten, nil
+		}
+	}
+}
+
+// nextKeys returns up to limit keys with prefix after lastKey, or from the first one if lastKey is nil
+func (m *Migrator) nextKeys(prefix, lastKey []byte, limit int) ([][]byte, error) {
+	var from []byte
+	if lastKey != nil {
+		// the smallest key after lastKey
+		from = append(bytes.Clone(lastKey), 0)
+	}
+	tx := m.service.NewTransaction()
+	defer tx.Discard()
+	var keys [][]byte
+	err := tx.Iterate(prefix, from, func(key, _ []byte) error {
+		keys = append(keys, bytes.Clone(key))
+		if len(keys) == limit {
+			return errBatchFull
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBatchFull) {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// runBatch rewrites the entries of keys in a single transaction, in which it records the progress, and returns how many it rewrote
+func (m *Migrator) runBatch(step Step, keys [][]byte, dryRun bool, progress func(tx storage.Tx) error) (int, error) {
+	tx := m.service.NewTransaction()
+	defer tx.Discard()
+
+	rewritten := 0
+	for _, key := range keys {
+		value, err := tx.Get(key)
+		if err != nil {
+			return 0, err
+		}
+		newKey, newValue, err := step.Rewrite(key, value)
+		if err != nil {
+			return 0, fmt.Errorf("error rewriting key %q: %w", key, err)
+		}
+		if newKey == nil {
+			continue
+		}
+		rewritten++
+		if dryRun {
+			continue
+		}
+		if !step.Keep && !bytes.Equal(newKey, key) {
+			if err := tx.Delete(key); err != nil {
+				return 0, err
+			}
+		}
+		if err := tx.Set(newKey, newValue); err != nil {
+			return 0, err
+		}
+	}
+	if dryRun {
+		return rewritten, nil
+	}
+	if err := progress(tx); err != nil {
+		return 0, err
+	}
+	return rewritten, tx.Commit()
+}
+
+// progress returns the amount of steps of the migration to version that are done, and the last key rewritten by the next one,
+// which is nil if it has not started
+func (m *Migrator) progress(version uint64) (uint64, []byte, error) {
+	tx := m.service.NewTransaction()
+	defer tx.Discard()
+	value, err := tx.Get([]byte(progressKey))
+	if err != nil || value == nil {
+		return 0, nil, err
+	}
+	d := codec.NewDecoder(value)
+	progressVersion, doneSteps, lastKey := d.Uint64(), d.Uint64(), d.ByteString()
+	if err := d.Err(); err != nil {
+		return 0, nil, fmt.Errorf("error decoding the migration progress: %w", err)
+	}
+	if progressVersion != version {
+		return 0, nil, nil
+	}
+	if len(lastKey) == 0 {
+		lastKey = nil
+	}
+	return doneSteps, lastKey, nil
+}
+
+func (m *Migrator) setProgress(version, doneSteps uint64, lastKey []byte) error {
+	tx := m.service.NewTransaction()
+	defer tx.Discard()
+	if err := setProgress(tx, version, doneSteps, lastKey); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func setProgress(tx storage.Tx, version, doneSteps uint64, lastKey []byte) error {
+	return tx.Set([]byte(progressKey), codec.NewEncoder().Uint64(version).Uint64(doneSteps).ByteString(lastKey).Bytes())
+}
+
+// GetVersion returns the schema version of the database, 0 if it has not been recorded
+func GetVersion(tx storage.Tx) (uint64, error) {
+	value, err := tx.Get([]byte(versionKey))
+	if err != nil {
+		return 0, err
+	}
+	if value == nil {
+		return 0, nil
+	}
+	version, err := codec.DecodeUint64(value)
+	if err != nil {
+		return 0, fmt.Errorf("error decoding the schema version: %w", err)
+	}
+	return version, nil
+}
+
+func SetVersion(tx storage.Tx, version uint64) error {
+	return tx.Set([]byte(versionKey), codec.EncodeUint64(version))
+}
@@ -0,0 +1,494 @@
+package migration_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+	badgerStorage "github.com/freeverseio/laos-universal-node/internal/platform/storage/badger"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/migration"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		versions      []uint64
+		expectedError string
+	}{
+		{
+			name:     "no migrations",
+			versions: nil,
+		},
+		{
+			name:     "consecutive versions",
+			versions: []uint64{1, 2, 3},
+		},
+		{
+			name:          "first version is not 1",
+			versions:      []uint64{2},
+			expectedError: `invalid migration "migration 0": got version 2, expected 1`,
+		},
+		{
+			name:          "versions out of order",
+			versions:      []uint64{1, 3, 2},
+			expectedError: `invalid migration "migration 1": got version 3, expected 2`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			migrations := make([]migration.Migration, 0, len(tt.versions))
+			for i, version := range tt.versions {
+				migrations = append(migrations, migration.Migration{Version: version, Description: fmt.Sprintf("migration %d", i)})
+			}
+			m, err := migration.New(badgerStorage.NewService(createBadger(t)), migrations, hasData)
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Fatalf(`got error "%v", expected error: "%v"`, err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			if m.LatestVersion() != uint64(len(tt.versions)) {
+				t.Fatalf("got latest version %d, expected %d", m.LatestVersion(), len(tt.versions))
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name            string
+		entries         map[string]string
+		version         uint64
+		expectedErr     error
+		expectedError   string
+		expectedVersion uint64
+	}{
+		{
+			name:            "records the latest version of a new database",
+			expectedVersion: 2,
+		},
+		{
+			name:            "accepts a database of the latest version",
+			version:         2,
+			expectedVersion: 2,
+		},
+		{
+			name:          "refuses a database of a newer version",
+			version:       3,
+			expectedErr:   migration.ErrNewerVersion,
+			expectedError: "database schema is newer than the supported one: got version 3, this node supports up to version 2",
+		},
+		{
+			name:          "requires migrating a database of an older version",
+			version:       1,
+			expectedErr:   migration.ErrMigrationRequired,
+			expectedError: "database schema must be migrated: got version 1, run the migrate command to upgrade it to version 2",
+		},
+		{
+			name:          "requires migrating a database written before the version was recorded",
+			entries:       map[string]string{"a/1": "value"},
+			expectedErr:   migration.ErrMigrationRequired,
+			expectedError: "database schema must be migrated: got version 0, run the migrate command to upgrade it to version 2",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			service := badgerStorage.NewService(createBadger(t))
+			writeEntries(t, service, tt.entries)
+			if tt.version != 0 {
+				setVersion(t, service, tt.version)
+			}
+			m, err := migration.New(service, testMigrations(nil), hasData)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = m.Check()
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf(`got error "%v", expected error: "%v"`, err, tt.expectedErr)
+				}
+				if err.Error() != tt.expectedError {
+					t.Fatalf(`got error "%v", expected error: "%v"`, err, tt.expectedError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			assertVersion(t, service, tt.expectedVersion)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	legacy := map[string]string{"a/1": "one", "a/2": "two", "a/3": "three", "c/1": "other"}
+	migrated := map[string]string{"b/1": "ONE", "b/2": "TWO", "b/3": "THREE", "c/1": "other"}
+
+	t.Run("applies the missing migrations in order", func(t *testing.T) {
+		t.Parallel()
+		service := badgerStorage.NewService(createBadger(t))
+		writeEntries(t, service, legacy)
+		m, err := migration.New(service, testMigrations(nil), hasData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := m.Run(migration.Options{BatchSize: 2})
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		expected := "{0 2 [{1 upper case values 3 3} {2 move a to b 4 3}]}"
+		if fmt.Sprint(*report) != expected {
+			t.Fatalf("got report %v, expected %s", *report, expected)
+		}
+		assertVersion(t, service, 2)
+		assertEntries(t, service, migrated)
+	})
+	t.Run("applies only the migrations newer than the version", func(t *testing.T) {
+		t.Parallel()
+		service := badgerStorage.NewService(createBadger(t))
+		writeEntries(t, service, map[string]string{"a/1": "ONE"})
+		setVersion(t, service, 1)
+		m, err := migration.New(service, testMigrations(nil), hasData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := m.Run(migration.Options{BatchSize: 2})
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if len(report.Migrations) != 1 || report.Migrations[0].Version != 2 {
+			t.Fatalf("got report %v, expected only migration 2", *report)
+		}
+		assertEntries(t, service, map[string]string{"b/1": "ONE"})
+	})
+	t.Run("keeps the entries of the steps that derive new ones", func(t *testing.T) {
+		t.Parallel()
+		service := badgerStorage.NewService(createBadger(t))
+		writeEntries(t, service, map[string]string{"a/1": "one", "a/2": "two"})
+		m, err := migration.New(service, []migration.Migration{{
+			Version:     1,
+			Description: "index a by value",
+			Steps: []migration.Step{{Prefix: "a/", Keep: true, Rewrite: func(key, value []byte) ([]byte, []byte, error) {
+				return append([]byte("i/"), value...), key, nil
+			}}},
+		}}, hasData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := m.Run(migration.Options{BatchSize: 1}); err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		assertEntries(t, service, map[string]string{"a/1": "one", "a/2": "two", "i/one": "a/1", "i/two": "a/2"})
+	})
+	t.Run("records the latest version of a new database", func(t *testing.T) {
+		t.Parallel()
+		service := badgerStorage.NewService(createBadger(t))
+		m, err := migration.New(service, testMigrations(nil), hasData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := m.Run(migration.Options{BatchSize: 2})
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if report.FromVersion != 0 || report.ToVersion != 2 || len(report.Migrations) != 0 {
+			t.Fatalf("got report %v, expected no migrations", *report)
+		}
+		assertVersion(t, service, 2)
+	})
+	t.Run("resumes an interrupted run after the last step done", func(t *testing.T) {
+		t.Parallel()
+		service := badgerStorage.NewService(createBadger(t))
+		writeEntries(t, service, legacy)
+		rewrites := map[string]int{}
+		m, err := migration.New(service, testMigrations(rewrites), hasData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the first migration commits 2 batches, its progress and its version, and the second one its first step
+		// and its progress before being interrupted in the first batch of its second step
+		interrupted, err := migration.New(&interruptedService{Service: service, commitsLeft: 6}, testMigrations(rewrites), hasData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := interrupted.Run(migration.Options{BatchSize: 2}); !errors.Is(err, errInterrupted) {
+			t.Fatalf(`got error "%v", expected "%v"`, err, errInterrupted)
+		}
+		assertVersion(t, service, 1)
+		if err := m.Check(); !errors.Is(err, migration.ErrMigrationRequired) {
+			t.Fatalf(`got error "%v", expected "%v"`, err, migration.ErrMigrationRequired)
+		}
+
+		report, err := m.Run(migration.Options{BatchSize: 2})
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if len(report.Migrations) != 1 || report.Migrations[0].Version != 2 || report.Migrations[0].Rewritten != 3 {
+			t.Fatalf("got report %v, expected migration 2 to rewrite 3 entries", *report)
+		}
+		// the first step of the second migration is skipped when resuming
+		if rewrites["a/"] != 3+2+3 || rewrites["c/"] != 1 {
+			t.Fatalf("got rewrites %v", rewrites)
+		}
+		assertVersion(t, service, 2)
+		assertEntries(t, service, migrated)
+	})
+	t.Run("resumes an interrupted step after the last batch done", func(t *testing.T) {
+		t.Parallel()
+		service := badgerStorage.NewService(createBadger(t))
+		writeEntries(t, service, legacy)
+		rewrites := map[string]int{}
+		m, err := migration.New(service, testMigrations(rewrites), hasData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the second migration commits the first batch of its second step before being interrupted in the second one
+		interrupted, err := migration.New(&interruptedService{Service: service, commitsLeft: 7}, testMigrations(rewrites), hasData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := interrupted.Run(migration.Options{BatchSize: 2}); !errors.Is(err, errInterrupted) {
+			t.Fatalf(`got error "%v", expected "%v"`, err, errInterrupted)
+		}
+		report, err := m.Run(migration.Options{BatchSize: 2})
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if len(report.Migrations) != 1 || report.Migrations[0].Scanned != 1 || report.Migrations[0].Rewritten != 1 {
+			t.Fatalf("got report %v, expected migration 2 to rewrite the last entry only", *report)
+		}
+		// the first batch of the step is not rewritten again when resuming
+		if rewrites["a/"] != 3+2+1+1 {
+			t.Fatalf("got rewrites %v", rewrites)
+		}
+		assertVersion(t, service, 2)
+		assertEntries(t, service, migrated)
+	})
+	t.Run("dry run writes nothing", func(t *testing.T) {
+		t.Parallel()
+		service := badgerStorage.NewService(createBadger(t))
+		writeEntries(t, service, legacy)
+		m, err := migration.New(service, testMigrations(nil), hasData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := m.Run(migration.Options{BatchSize: 2, DryRun: true})
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if report.ToVersion != 2 || len(report.Migrations) != 2 || report.Migrations[0].Rewritten != 3 {
+			t.Fatalf("got report %v", *report)
+		}
+		assertVersion(t, service, 0)
+		assertEntries(t, service, legacy)
+	})
+	t.Run("fails on an entry that cannot be rewritten", func(t *testing.T) {
+		t.Parallel()
+		service := badgerStorage.NewService(createBadger(t))
+		writeEntries(t, service, map[string]string{"a/1": "1nvalid"})
+		m, err := migration.New(service, testMigrations(nil), hasData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = m.Run(migration.Options{BatchSize: 2})
+		expectedError := `error running migration 1 (upper case values): error rewriting a/: error rewriting key "a/1": invalid value`
+		if err == nil || err.Error() != expectedError {
+			t.Fatalf(`got error "%v", expected error: "%v"`, err, expectedError)
+		}
+		assertVersion(t, service, 0)
+	})
+	t.Run("refuses a database of a newer version", func(t *testing.T) {
+		t.Parallel()
+		service := badgerStorage.NewService(createBadger(t))
+		setVersion(t, service, 3)
+		m, err := migration.New(service, testMigrations(nil), hasData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := m.Run(migration.Options{BatchSize: 2}); !errors.Is(err, migration.ErrNewerVersion) {
+			t.Fatalf(`got error "%v", expected "%v"`, err, migration.ErrNewerVersion)
+		}
+	})
+	t.Run("invalid batch size", func(t *testing.T) {
+		t.Parallel()
+		m, err := migration.New(badgerStorage.NewService(createBadger(t)), testMigrations(nil), hasData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := m.Run(migration.Options{}); err == nil || err.Error() != "invalid batch size 0" {
+			t.Fatalf(`got error "%v", expected error: "invalid batch size 0"`, err)
+		}
+	})
+}
+
+// testMigrations upper cases the values under a/ and then moves them to b/, counting the rewrites per prefix
+// when rewrites is not nil. The second migration first goes over c/ without rewriting anything, so that the steps
+// done before an interruption are told apart from the ones done after it.
+func testMigrations(rewrites map[string]int) []migration.Migration {
+	count := func(prefix string, rewrite func(key, value []byte) ([]byte, []byte, error)) migration.Step {
+		return migration.Step{Prefix: prefix, Rewrite: func(key, value []byte) ([]byte, []byte, error) {
+			if rewrites != nil {
+				rewrites[prefix]++
+			}
+			return rewrite(key, value)
+		}}
+	}
+	return []migration.Migration{
+		{
+			Version:     1,
+			Description: "upper case values",
+			Steps: []migration.Step{
+				count("a/", func(key, value []byte) ([]byte, []byte, error) {
+					if bytes.ContainsAny(value, "0123456789") {
+						return nil, nil, errors.New("invalid value")
+					}
+					upper := bytes.ToUpper(value)
+					if bytes.Equal(upper, value) {
+						return nil, nil, nil
+					}
+					return key, upper, nil
+				}),
+			},
+		},
+		{
+			Version:     2,
+			Description: "move a to b",
+			Steps: []migration.Step{
+				count("c/", func(key, value []byte) ([]byte, []byte, error) {
+					return nil, nil, nil
+				}),
+				count("a/", func(key, value []byte) ([]byte, []byte, error) {
+					return append([]byte("b/"), key[len("a/"):]...), value, nil
+				}),
+			},
+		},
+	}
+}
+
+func hasData(tx storage.Tx) (bool, error) {
+	return len(tx.GetKeysWithPrefix([]byte("a/"))) > 0, nil
+}
+
+func writeEntries(t *testing.T, service storage.Service, entries map[string]string) {
+	t.Helper()
+	for key, value := range entries {
+		if err := service.Set([]byte(key), []byte(value)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func setVersion(t *testing.T, service storage.Service, version uint64) {
+	t.Helper()
+	tx := service.NewTransaction()
+	defer tx.Discard()
+	if err := migration.SetVersion(tx, version); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertVersion(t *testing.T, service storage.Service, expected uint64) {
+	t.Helper()
+	tx := service.NewTransaction()
+	defer tx.Discard()
+	version, err := migration.GetVersion(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != expected {
+		t.Fatalf("got version %d, expected %d", version, expected)
+	}
+}
+
+// assertEntries checks the entries of the database, leaving out the ones of the migrations
+func assertEntries(t *testing.T, service storage.Service, expected map[string]string) {
+	t.Helper()
+	keys, err := service.GetKeysWithPrefix(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if bytes.HasPrefix(key, []byte("schema_")) {
+			continue
+		}
+		value, err := service.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[string(key)] = string(value)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("got entries %v, expected %v", got, expected)
+	}
+}
+
+var errInterrupted = errors.New("interrupted")
+
+// interruptedService fails the commits once commitsLeft commits have succeeded, as if the node was killed
+type interruptedService struct {
+	storage.Service
+	commitsLeft int
+}
+
+func (s *interruptedService) NewTransaction() storage.Tx {
+	return &interruptedTx{Tx: s.Service.NewTransaction(), service: s}
+}
+
+type interruptedTx struct {
+	storage.Tx
+	service *interruptedService
+}
+
+func (tx *interruptedTx) Commit() error {
+	if tx.service.commitsLeft == 0 {
+		return errInterrupted
+	}
+	tx.service.commitsLeft--
+	return tx.Tx.Commit()
+}
+
+func createBadger(t *testing.T) *badger.DB {
+	t.Helper()
+	db, err := badger.Open(
+		badger.DefaultOptions("").
+			WithInMemory(true).
+			WithLoggingLevel(badger.ERROR))
+	if err != nil {
+		t.Fatalf("error initializing storage: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
@@ -145,6 +145,20 @@ func (mr *MockTxMockRecorder) GetValuesWithPrefix(prefix any, reverse ...any) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetValuesWithPrefix", reflect.TypeOf((*MockTx)(nil).GetValuesWithPrefix), varargs...)
 }
 
+// Iterate mocks base method.
+func (m *MockTx) Iterate(prefix, from []byte, fn func([]byte, []byte) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Iterate", prefix, from, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Iterate indicates an expected call of Iterate.
+func (mr *MockTxMockRecorder) Iterate(prefix, from, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Iterate", reflect.TypeOf((*MockTx)(nil).Iterate), prefix, from, fn)
+}
+
 // Set mocks base method.
 func (m *MockTx) Set(key, value []byte) error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,426 @@
+package pebble
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/pebble"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+)
+
+// Pebble implements the storage service on a Pebble database. Pebble has no transactions of its own:
+// transactions read a Pebble snapshot, buffer their writes and apply them in a single batch on commit,
+// failing with storage.ErrConflict when a transaction committed since they started wrote a key they read, like Badger.
+type Pebble struct {
+	db *pebble.DB
+
+	mu sync.Mutex
+	// version is incremented by every commit
+	version uint64
+	// committed are the keys written by the commits that transactions started before them may conflict with
+	committed []commit
+	// active counts the open transactions by the version they started at
+	active map[uint64]int
+}
+
+type commit struct {
+	version uint64
+	keys    map[string]struct{}
+}
+
+// Open opens the Pebble database at path, creating it if it does not exist
+func Open(path string) (*pebble.DB, error) {
+	return pebble.Open(path, &pebble.Options{Logger: quietLogger{}})
+}
+
+func NewService(db *pebble.DB) *Pebble {
+	return &Pebble{
+		db:     db,
+		active: make(map[uint64]int),
+	}
+}
+
+func (p *Pebble) Set(key, value []byte) error {
+	t := p.NewTransaction()
+	defer t.Discard()
+	if err := t.Set(key, value); err != nil {
+		return err
+	}
+	return t.Commit()
+}
+
+func (p *Pebble) Get(key []byte) ([]byte, error) {
+	value, closer, err := p.db.Get(key)
+	if err != nil {
+		return nil, storageError(err)
+	}
+	defer closer.Close()
+	return bytes.Clone(value), nil
+}
+
+// GetKeysWithPrefix fetches keys with a given prefix.
+// The reverse parameter is optional and defaults to false (non-reverse iterator).
+// Pass true as the second argument to enable reverse iteration.
+func (p *Pebble) GetKeysWithPrefix(prefix []byte, reverse ...bool) ([][]byte, error) {
+	keys, err := iterate(p.db, prefix, func(iter *pebble.Iterator) []byte {
+		return bytes.Clone(iter.Key())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orderKeys(keys, reverse...), nil
+}
+
+func (p *Pebble) NewTransaction() storage.Tx {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active[p.version]++
+	return &Tx{
+		p:        p,
+		snapshot: p.db.NewSnapshot(),
+		version:  p.version,
+		writes:   make(map[string]write),
+		reads:    make(map[string]struct{}),
+	}
+}
+
+// commit applies the writes of t in a batch, unless a key read by t was written since it started
+func (p *Pebble) commit(t *Tx) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	defer p.release(t.version)
+
+	for _, c := range p.committed {
+		if c.version <= t.version {
+			continue
+		}
+		for key := range t.reads {
+			if _, ok := c.keys[key]; ok {
+				return storage.ErrConflict
+			}
+		}
+	}
+
+	batch := p.db.NewBatch()
+	defer batch.Close()
+	keys := make(map[string]struct{}, len(t.writes))
+	for key, w := range t.writes {
+		keys[key] = struct{}{}
+		var err error
+		if w.deleted {
+			err = batch.Delete([]byte(key), nil)
+		} else {
+			err = batch.Set([]byte(key), w.value, nil)
+		}
+		if err != nil {
+			return storageError(err)
+		}
+	}
+	// like Badger, whose writes are not synced by default, the commits survive process crashes but not machine ones
+	if err := batch.Commit(pebble.NoSync); err != nil {
+		return storageError(err)
+	}
+	p.version++
+	if len(p.active) > 0 {
+		p.committed = append(p.committed, commit{version: p.version, keys: keys})
+	}
+	return nil
+}
+
+// release closes the transaction started at version and forgets the commits no open transaction can conflict with
+func (p *Pebble) release(version uint64) {
+	p.active[version]--
+	if p.active[version] == 0 {
+		delete(p.active, version)
+	}
+	oldest := p.version
+	for v := range p.active {
+		oldest = min(oldest, v)
+	}
+	p.committed = slices.DeleteFunc(p.committed, func(c commit) bool {
+		return c.version <= oldest
+	})
+}
+
+type write struct {
+	value   []byte
+	deleted bool
+}
+
+type Tx struct {
+	p        *Pebble
+	snapshot *pebble.Snapshot
+	version  uint64
+	writes   map[string]write
+	// reads are the keys read by the transaction, which conflict with the writes committed after it started
+	reads map[string]struct{}
+	done  bool
+}
+
+func (t *Tx) Commit() error {
+	if t.done {
+		return storage.ErrDiscardedTxn
+	}
+	t.close()
+	if len(t.writes) == 0 {
+		t.p.mu.Lock()
+		t.p.release(t.version)
+		t.p.mu.Unlock()
+		return nil
+	}
+	return t.p.commit(t)
+}
+
+// Discard drops the writes of the transaction. It does nothing on a committed transaction.
+func (t *Tx) Discard() {
+	if t.done {
+		return
+	}
+	t.close()
+	t.p.mu.Lock()
+	t.p.release(t.version)
+	t.p.mu.Unlock()
+}
+
+func (t *Tx) close() {
+	t.done = true
+	_ = t.snapshot.Close()
+}
+
+// Set sets []byte value for []byte key
+func (t *Tx) Set(key, value []byte) error {
+	if t.done {
+		return storage.ErrDiscardedTxn
+	}
+	t.writes[string(key)] = write{value: bytes.Clone(value)}
+	return nil
+}
+
+// Get returns the value of a key, or nil if it does not exist. The writes of the transaction are visible.
+func (t *Tx) Get(key []byte) ([]byte, error) {
+	if t.done {
+		return nil, storage.ErrDiscardedTxn
+	}
+	t.reads[string(key)] = struct{}{}
+	if w, ok := t.writes[string(key)]; ok {
+		if w.deleted {
+			return nil, nil
+		}
+		return bytes.Clone(w.value), nil
+	}
+	value, closer, err := t.snapshot.Get(key)
+	if err != nil {
+		if errors.Is(err, pebble.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, storageError(err)
+	}
+	defer closer.Close()
+	return bytes.Clone(value), nil
+}
+
+// Delete deletes a key.
+func (t *Tx) Delete(key []byte) error {
+	if t.done {
+		return storage.ErrDiscardedTxn
+	}
+	t.writes[string(key)] = write{deleted: true}
+	return nil
+}
+
+// GetKeysWithPrefix looks for all the keys with the specified prefix and returns them. It doesn't return values
+func (t *Tx) GetKeysWithPrefix(prefix []byte, reverse ...bool) [][]byte {
+	entries := t.entriesWithPrefix(prefix)
+	keys := make([][]byte, 0, len(entries))
+	for _, e := range entries {
+		keys = append(keys, []byte(e.key))
+	}
+	return orderKeys(keys, reverse...)
+}
+
+func (t *Tx) GetValuesWithPrefix(prefix []byte, reverse ...bool) [][]byte {
+	entries := t.entriesWithPrefix(prefix)
+	values := make([][]byte, 0, len(entries))
+	for _, e := range entries {
+		values = append(values, e.value)
+	}
+	return orderKeys(values, reverse...)
+}
+
+func (t *Tx) FilterKeysWithPrefix(prefix []byte, from, to string) [][]byte {
+	start := string(prefix) + from
+	end := string(prefix) + to
+	var keys [][]byte
+	for _, e := range t.entriesWithPrefix(prefix) {
+		if e.key < start {
+			continue
+		}
+		if e.key > end {
+			break
+		}
+		keys = append(keys, []byte(e.key))
+	}
+	return keys
+}
+
+// Iterate calls fn with the keys with the specified prefix from the key from on and their values in ascending order,
+// stopping at the first error. The entries of the snapshot are streamed and merged with the writes of the transaction.
+func (t *Tx) Iterate(prefix, from []byte, fn func(key, value []byte) error) error {
+	if t.done {
+		return storage.ErrDiscardedTxn
+	}
+	lowerBound := prefix
+	if bytes.Compare(from, prefix) > 0 {
+		lowerBound = from
+	}
+	var written []string
+	for key := range t.writes {
+		if bytes.HasPrefix([]byte(key), prefix) && key >= string(lowerBound) {
+			written = append(written, key)
+		}
+	}
+	slices.Sort(written)
+	// emitWrites calls fn with the writes up to key, or with all of them if key is empty
+	emitWrites := func(key string) error {
+		for len(written) > 0 && (key == "" || written[0] <= key) {
+			if w := t.writes[written[0]]; !w.deleted {
+				if err := fn([]byte(written[0]), w.value); err != nil {
+					return err
+				}
+			}
+			written = written[1:]
+		}
+		return nil
+	}
+
+	iter := t.snapshot.NewIter(&pebble.IterOptions{LowerBound: lowerBound, UpperBound: upperBound(prefix)})
+	for iter.First(); iter.Valid(); iter.Next() {
+		key := string(iter.Key())
+		// the entries written by the transaction replace the ones of the snapshot
+		_, replaced := t.writes[key]
+		if err := emitWrites(key); err != nil {
+			_ = iter.Close()
+			return err
+		}
+		if replaced {
+			continue
+		}
+		if err := fn(iter.Key(), iter.Value()); err != nil {
+			_ = iter.Close()
+			return err
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return storageError(err)
+	}
+	return emitWrites("")
+}
+
+type entry struct {
+	key   string
+	value []byte
+}
+
+// entriesWithPrefix merges the writes of the transaction with the entries of its snapshot, in ascending order of their keys.
+// The keys are read by the transaction, so that they conflict with the writes committed after it started.
+func (t *Tx) entriesWithPrefix(prefix []byte) []entry {
+	if t.done {
+		return nil
+	}
+	entries, err := iterate(t.snapshot, prefix, func(iter *pebble.Iterator) entry {
+		return entry{key: string(iter.Key()), value: bytes.Clone(iter.Value())}
+	})
+	if err != nil {
+		// like the Badger iterations, the iterations of the transactions do not fail
+		return nil
+	}
+
+	written := false
+	for key, w := range t.writes {
+		if bytes.HasPrefix([]byte(key), prefix) {
+			written = true
+			entries = append(entries, entry{key: key, value: bytes.Clone(w.value)})
+		}
+	}
+	if written {
+		// the writes are appended after the snapshot entries, so a stable sort keeps them after the entries they replace
+		slices.SortStableFunc(entries, func(a, b entry) int { return strings.Compare(a.key, b.key) })
+		merged := entries[:0]
+		for _, e := range entries {
+			if len(merged) > 0 && merged[len(merged)-1].key == e.key {
+				merged[len(merged)-1] = e
+				continue
+			}
+			merged = append(merged, e)
+		}
+		entries = slices.DeleteFunc(merged, func(e entry) bool {
+			w, ok := t.writes[e.key]
+			return ok && w.deleted
+		})
+	}
+	for _, e := range entries {
+		t.reads[e.key] = struct{}{}
+	}
+	return entries
+}
+
+type reader interface {
+	NewIter(o *pebble.IterOptions) *pebble.Iterator
+}
+
+// iterate returns the result of read on the entries of r with the given prefix, in ascending order of their keys
+func iterate[T any](r reader, prefix []byte, read func(iter *pebble.Iterator) T) ([]T, error) {
+	iter := r.NewIter(&pebble.IterOptions{LowerBound: prefix, UpperBound: upperBound(prefix)})
+	var result []T
+	for iter.First(); iter.Valid(); iter.Next() {
+		result = append(result, read(iter))
+	}
+	if err := iter.Close(); err != nil {
+		return nil, storageError(err)
+	}
+	return result, nil
+}
+
+// upperBound returns the first key after all the keys with the given prefix, or nil if there is none
+func upperBound(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+func orderKeys[T any](keys []T, reverse ...bool) []T {
+	if len(keys) == 0 {
+		return nil
+	}
+	if len(reverse) > 0 && reverse[0] {
+		slices.Reverse(keys)
+	}
+	return keys
+}
+
+// storageError wraps the pebble errors that callers handle with the errors of the storage package
+func storageError(err error) error {
+	if errors.Is(err, pebble.ErrNotFound) {
+		return fmt.Errorf("%w: %w", storage.ErrKeyNotFound, err)
+	}
+	return err
+}
+
+// quietLogger drops the informational messages Pebble logs on every flush and compaction
+type quietLogger struct{}
+
+func (quietLogger) Infof(string, ...interface{}) {}
+
+func (quietLogger) Fatalf(format string, args ...interface{}) {
+	panic(fmt.Sprintf(format, args...))
+}
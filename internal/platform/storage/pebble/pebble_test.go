@@ -0,0 +1,59 @@
+package pebble_test
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/vfs"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+	pebbleStorage "github.com/freeverseio/laos-universal-node/internal/platform/storage/pebble"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+	storagetest.Run(t, func(t *testing.T) storage.Service {
+		db, err := pebble.Open("", &pebble.Options{FS: vfs.NewMem()})
+		if err != nil {
+			t.Fatalf("error initializing storage: %v", err)
+		}
+		t.Cleanup(func() {
+			_ = db.Close()
+		})
+		return pebbleStorage.NewService(db)
+	})
+}
+
+func TestReopen(t *testing.T) {
+	t.Parallel()
+	path := t.TempDir()
+	db, err := pebbleStorage.Open(path)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	service := pebbleStorage.NewService(db)
+	tx := service.NewTransaction()
+	if err := tx.Set([]byte("key"), []byte("value")); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+
+	db, err = pebbleStorage.Open(path)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	defer db.Close()
+	value, err := pebbleStorage.NewService(db).Get([]byte("key"))
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if string(value) != "value" {
+		t.Fatalf("got value %q, expected %q", value, "value")
+	}
+}
@@ -0,0 +1,114 @@
+package replication
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// pollTimeout is how long a request for write-sets waits for a commit when the replica is up to date
+const pollTimeout = 30 * time.Second
+
+// NewHandler serves the snapshots and the write-sets of log to the replicas. lastBlock returns the last ownership
+// block processed by the writer, which the replicas compare with theirs to report their lag.
+func NewHandler(log *Log, lastBlock func() (uint64, error)) http.Handler {
+	h := &handler{log: log, lastBlock: lastBlock}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/snapshot", h.snapshot)
+	mux.HandleFunc("/writesets", h.writeSets)
+	return mux
+}
+
+type handler struct {
+	log       *Log
+	lastBlock func() (uint64, error)
+}
+
+func (h *handler) snapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	tx, sequence := h.log.Snapshot()
+	defer tx.Discard()
+	slog.Info("streaming snapshot to replica", "replica", r.RemoteAddr, "sequence", sequence)
+
+	w.Header().Set(EpochHeader, h.log.Epoch())
+	w.Header().Set(SequenceHeader, strconv.FormatUint(sequence, 10))
+	h.setLastBlock(w)
+	bw := bufio.NewWriter(w)
+	// the entries are streamed from the transaction, as the state does not fit in memory
+	err := tx.Iterate(nil, nil, func(key, value []byte) error {
+		return writeWrite(bw, Write{Key: key, Value: value})
+	})
+	if err != nil {
+		// the replica detects the stream is interrupted as it does not end
+		slog.Error("error streaming snapshot", "replica", r.RemoteAddr, "err", err)
+		return
+	}
+	if err := end(bw); err != nil {
+		slog.Error("error streaming snapshot", "replica", r.RemoteAddr, "err", err)
+	}
+}
+
+func (h *handler) writeSets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	after, err := strconv.ParseUint(r.URL.Query().Get("after"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid after sequence", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set(EpochHeader, h.log.Epoch())
+	if r.URL.Query().Get("epoch") != h.log.Epoch() {
+		http.Error(w, "the writer restarted", http.StatusConflict)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), pollTimeout)
+	defer cancel()
+	writeSets, err := h.log.WriteSetsAfter(ctx, after)
+	if err != nil {
+		if errors.Is(err, ErrTruncated) {
+			http.Error(w, err.Error(), http.StatusGone)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.setLastBlock(w)
+	bw := bufio.NewWriter(w)
+	for _, ws := range writeSets {
+		if err := writeWriteSet(bw, ws); err != nil {
+			slog.Error("error streaming write-sets", "replica", r.RemoteAddr, "err", err)
+			return
+		}
+	}
+	if err := end(bw); err != nil {
+		slog.Error("error streaming write-sets", "replica", r.RemoteAddr, "err", err)
+	}
+}
+
+// end ends the stream and sends the buffered frames
+func end(bw *bufio.Writer) error {
+	if err := writeEnd(bw); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (h *handler) setLastBlock(w http.ResponseWriter) {
+	lastBlock, err := h.lastBlock()
+	if err != nil {
+		slog.Error("error getting the last ownership block for the replicas", "err", err)
+		return
+	}
+	w.Header().Set(LastBlockHeader, strconv.FormatUint(lastBlock, 10))
+}
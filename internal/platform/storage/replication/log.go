@@ -0,0 +1,200 @@
+// Package replication streams the writes committed on the storage of the node that runs the workers to read-only replicas.
+//
+// The writer wraps its storage with a Log, which numbers the committed write-sets and retains the latest ones.
+// A replica loads a snapshot of the writer storage and then applies the write-sets committed after it, in order.
+package replication
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+)
+
+// ErrTruncated is returned when the write-sets a replica asks for are no longer retained, so it must load a new snapshot
+var ErrTruncated = errors.New("write-sets are no longer retained")
+
+// Write is a key set or deleted by a commit
+type Write struct {
+	Key     []byte
+	Value   []byte
+	Deleted bool
+}
+
+// WriteSet holds the writes of a commit, numbered by Sequence from 1 in the order of the commits
+type WriteSet struct {
+	Sequence uint64
+	Writes   []Write
+}
+
+func (ws *WriteSet) size() int {
+	size := 0
+	for _, w := range ws.Writes {
+		size += len(w.Key) + len(w.Value)
+	}
+	return size
+}
+
+// Log is a storage service that records the write-sets committed through it. The sequences start again from 1
+// on every start of the node, so each Log has a random epoch that tells the replicas when the sequences restarted.
+type Log struct {
+	service storage.Service
+	// maxSize is the size of the keys and values of the retained write-sets, the oldest ones are dropped beyond it
+	maxSize int
+	epoch   string
+
+	mu        sync.Mutex
+	sequence  uint64
+	writeSets []WriteSet
+	size      int
+	// appended is closed when a write-set is appended
+	appended chan struct{}
+}
+
+func NewLog(service storage.Service, maxSize int) (*Log, error) {
+	epoch := make([]byte, 8)
+	if _, err := rand.Read(epoch); err != nil {
+		return nil, fmt.Errorf("error generating the replication epoch: %w", err)
+	}
+	return &Log{
+		service:  service,
+		maxSize:  maxSize,
+		epoch:    hex.EncodeToString(epoch),
+		appended: make(chan struct{}),
+	}, nil
+}
+
+// Epoch identifies the sequences of the log
+func (l *Log) Epoch() string {
+	return l.epoch
+}
+
+func (l *Log) NewTransaction() storage.Tx {
+	return &tx{
+		Tx:    l.service.NewTransaction(),
+		log:   l,
+		index: make(map[string]int),
+	}
+}
+
+func (l *Log) Get(key []byte) ([]byte, error) {
+	return l.service.Get(key)
+}
+
+func (l *Log) GetKeysWithPrefix(prefix []byte, reverse ...bool) ([][]byte, error) {
+	return l.service.GetKeysWithPrefix(prefix, reverse...)
+}
+
+func (l *Log) Set(key, value []byte) error {
+	t := l.NewTransaction()
+	defer t.Discard()
+	if err := t.Set(key, value); err != nil {
+		return err
+	}
+	return t.Commit()
+}
+
+// Snapshot returns a transaction that reads the storage after the write-set with the returned sequence was committed.
+// The caller must discard it.
+func (l *Log) Snapshot() (storage.Tx, uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.service.NewTransaction(), l.sequence
+}
+
+// WriteSetsAfter returns the write-sets committed after the one with the given sequence. It waits for the next
+// write-set to be committed while there is none, and returns no write-sets if ctx is done before.
+func (l *Log) WriteSetsAfter(ctx context.Context, sequence uint64) ([]WriteSet, error) {
+	for {
+		l.mu.Lock()
+		if sequence > l.sequence {
+			l.mu.Unlock()
+			return nil, fmt.Errorf("%w: sequence %d is ahead of the last one %d", ErrTruncated, sequence, l.sequence)
+		}
+		if sequence < l.sequence {
+			first := l.sequence - uint64(len(l.writeSets)) + 1
+			if sequence+1 < first {
+				l.mu.Unlock()
+				return nil, fmt.Errorf("%w: the oldest retained sequence is %d, got %d", ErrTruncated, first, sequence)
+			}
+			writeSets := append([]WriteSet(nil), l.writeSets[sequence+1-first:]...)
+			l.mu.Unlock()
+			return writeSets, nil
+		}
+		appended := l.appended
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-appended:
+		}
+	}
+}
+
+// commit commits t and records its writes. The commits are serialized so that the sequences follow their order.
+func (l *Log) commit(t *tx) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := t.Tx.Commit(); err != nil {
+		return err
+	}
+	if len(t.writes) == 0 {
+		return nil
+	}
+
+	l.sequence++
+	ws := WriteSet{Sequence: l.sequence, Writes: t.writes}
+	l.writeSets = append(l.writeSets, ws)
+	l.size += ws.size()
+	for l.size > l.maxSize && len(l.writeSets) > 1 {
+		l.size -= l.writeSets[0].size()
+		l.writeSets[0] = WriteSet{}
+		l.writeSets = l.writeSets[1:]
+	}
+	close(l.appended)
+	l.appended = make(chan struct{})
+	return nil
+}
+
+// tx records the writes of a transaction, the last write of a key replacing the previous ones
+type tx struct {
+	storage.Tx
+	log    *Log
+	writes []Write
+	index  map[string]int
+}
+
+func (t *tx) Set(key, value []byte) error {
+	if err := t.Tx.Set(key, value); err != nil {
+		return err
+	}
+	t.record(Write{Key: bytes.Clone(key), Value: bytes.Clone(value)})
+	return nil
+}
+
+func (t *tx) Delete(key []byte) error {
+	if err := t.Tx.Delete(key); err != nil {
+		return err
+	}
+	t.record(Write{Key: bytes.Clone(key), Deleted: true})
+	return nil
+}
+
+func (t *tx) Commit() error {
+	return t.log.commit(t)
+}
+
+func (t *tx) record(w Write) {
+	if i, ok := t.index[string(w.Key)]; ok {
+		t.writes[i] = w
+		return
+	}
+	t.index[string(w.Key)] = len(t.writes)
+	t.writes = append(t.writes, w)
+}
@@ -0,0 +1,117 @@
+package replication
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/codec"
+)
+
+// The responses of the writer are streams of frames, each one prefixed with its length. A frame of length 0 ends the stream,
+// so that a replica tells a complete stream from an interrupted one.
+//
+// A snapshot is a stream of write frames. The write-sets are streams of a write-set frame, with the sequence and the number
+// of writes, followed by the write frames.
+
+const (
+	// EpochHeader holds the epoch of the writer log
+	EpochHeader = "X-Replication-Epoch"
+	// SequenceHeader holds the sequence of the last write-set included in a snapshot
+	SequenceHeader = "X-Replication-Sequence"
+	// LastBlockHeader holds the last ownership block processed by the writer
+	LastBlockHeader = "X-Replication-Last-Ownership-Block"
+
+	maxFrameSize = 64 << 20
+)
+
+var errEndOfStream = errors.New("end of stream")
+
+func writeFrame(w *bufio.Writer, frame []byte) error {
+	if _, err := w.Write(binary.AppendUvarint(nil, uint64(len(frame)))); err != nil {
+		return err
+	}
+	_, err := w.Write(frame)
+	return err
+}
+
+func writeEnd(w *bufio.Writer) error {
+	return writeFrame(w, nil)
+}
+
+// readFrame returns the next frame of r, or errEndOfStream if the stream is over
+func readFrame(r *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("stream interrupted: %w", io.ErrUnexpectedEOF)
+		}
+		return nil, err
+	}
+	if n == 0 {
+		return nil, errEndOfStream
+	}
+	if n > maxFrameSize {
+		return nil, fmt.Errorf("frame of %d bytes exceeds the maximum of %d", n, maxFrameSize)
+	}
+	frame := make([]byte, n)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		return nil, fmt.Errorf("stream interrupted: %w", err)
+	}
+	return frame, nil
+}
+
+func writeWrite(w *bufio.Writer, write Write) error {
+	return writeFrame(w, codec.NewEncoder().ByteString(write.Key).ByteString(write.Value).Bool(write.Deleted).Bytes())
+}
+
+func readWrite(r *bufio.Reader) (Write, error) {
+	frame, err := readFrame(r)
+	if err != nil {
+		return Write{}, err
+	}
+	d := codec.NewDecoder(frame)
+	write := Write{Key: d.ByteString(), Value: d.ByteString(), Deleted: d.Bool()}
+	if err := d.Err(); err != nil {
+		return Write{}, fmt.Errorf("error decoding write: %w", err)
+	}
+	return write, nil
+}
+
+func writeWriteSet(w *bufio.Writer, ws WriteSet) error {
+	if err := writeFrame(w, codec.NewEncoder().Uint64(ws.Sequence).Uint64(uint64(len(ws.Writes))).Bytes()); err != nil {
+		return err
+	}
+	for _, write := range ws.Writes {
+		if err := writeWrite(w, write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readWriteSet(r *bufio.Reader) (WriteSet, error) {
+	frame, err := readFrame(r)
+	if err != nil {
+		return WriteSet{}, err
+	}
+	d := codec.NewDecoder(frame)
+	sequence, n := d.Uint64(), d.Uint64()
+	if err := d.Err(); err != nil {
+		return WriteSet{}, fmt.Errorf("error decoding write-set: %w", err)
+	}
+	ws := WriteSet{Sequence: sequence}
+	for i := uint64(0); i < n; i++ {
+		write, err := readWrite(r)
+		if err != nil {
+			if errors.Is(err, errEndOfStream) {
+				return WriteSet{}, fmt.Errorf("write-set %d ends after %d of %d writes", sequence, i, n)
+			}
+			return WriteSet{}, err
+		}
+		ws.Writes = append(ws.Writes, write)
+	}
+	return ws, nil
+}
@@ -0,0 +1,229 @@
+package replication
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+)
+
+// ErrResyncRequired is returned by Replica.Run when the write-sets the replica misses are no longer available,
+// because the writer restarted or dropped them, so the replica must load a new snapshot
+var ErrResyncRequired = errors.New("replica must load a new snapshot")
+
+const (
+	initialRetryDelay = time.Second
+	maxRetryDelay     = time.Minute
+)
+
+var (
+	// replicationLag is the number of ownership blocks the replica is behind the writer
+	replicationLag = expvar.NewInt("replication_lag_blocks")
+	// replicationSequence is the sequence of the last write-set applied by the replica
+	replicationSequence = expvar.NewInt("replication_sequence")
+)
+
+// Replica copies the storage of a writer into its own storage, which it must be the only one to write
+type Replica struct {
+	url     string
+	client  *http.Client
+	service storage.Service
+	// batchSize is the amount of entries of the snapshot written per transaction
+	batchSize int
+	// lastBlock returns the last ownership block in the storage of the replica
+	lastBlock func() (uint64, error)
+
+	epoch    string
+	sequence uint64
+}
+
+// NewReplica returns a Replica of the writer serving the replication endpoints at writerURL
+func NewReplica(writerURL string, service storage.Service, batchSize int, lastBlock func() (uint64, error)) *Replica {
+	return &Replica{
+		url:       writerURL,
+		client:    &http.Client{},
+		service:   service,
+		batchSize: batchSize,
+		lastBlock: lastBlock,
+	}
+}
+
+// LoadSnapshot copies a snapshot of the writer storage into the storage of the replica, which must be empty
+func (r *Replica) LoadSnapshot(ctx context.Context) error {
+	resp, err := r.get(ctx, "/snapshot", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	sequence, err := strconv.ParseUint(resp.Header.Get(SequenceHeader), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot sequence %q: %w", resp.Header.Get(SequenceHeader), err)
+	}
+	slog.Info("loading snapshot from the writer", "url", r.url, "sequence", sequence)
+
+	reader := bufio.NewReader(resp.Body)
+	batch := make([]Write, 0, r.batchSize)
+	loaded := 0
+	for {
+		write, err := readWrite(reader)
+		if errors.Is(err, errEndOfStream) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading snapshot: %w", err)
+		}
+		batch = append(batch, write)
+		if len(batch) == r.batchSize {
+			if err := r.apply(batch); err != nil {
+				return err
+			}
+			loaded += len(batch)
+			batch = batch[:0]
+			slog.Debug("snapshot batch loaded", "entries", loaded)
+		}
+	}
+	if err := r.apply(batch); err != nil {
+		return err
+	}
+	loaded += len(batch)
+
+	r.epoch = resp.Header.Get(EpochHeader)
+	r.sequence = sequence
+	replicationSequence.Set(int64(sequence))
+	r.reportLag(resp.Header)
+	slog.Info("snapshot loaded", "entries", loaded, "sequence", sequence)
+	return nil
+}
+
+// Run applies the write-sets committed by the writer after the loaded snapshot until ctx is done.
+// It returns ErrResyncRequired when they are not available anymore.
+func (r *Replica) Run(ctx context.Context) error {
+	retryDelay := initialRetryDelay
+	for {
+		err := r.sync(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err == nil {
+			retryDelay = initialRetryDelay
+			continue
+		}
+		if errors.Is(err, ErrResyncRequired) {
+			return err
+		}
+		slog.Error("error replicating the writer, retrying", "err", err, "retryDelay", retryDelay)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(retryDelay):
+		}
+		retryDelay = min(2*retryDelay, maxRetryDelay)
+	}
+}
+
+// sync applies the write-sets committed after the last applied one, waiting for them if there are none
+func (r *Replica) sync(ctx context.Context) error {
+	query := url.Values{
+		"after": {strconv.FormatUint(r.sequence, 10)},
+		"epoch": {r.epoch},
+	}
+	// the writer answers within its poll timeout, unless it is unreachable
+	ctx, cancel := context.WithTimeout(ctx, 2*pollTimeout)
+	defer cancel()
+	resp, err := r.get(ctx, "/writesets", query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	for {
+		ws, err := readWriteSet(reader)
+		if errors.Is(err, errEndOfStream) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading write-sets: %w", err)
+		}
+		if ws.Sequence != r.sequence+1 {
+			return fmt.Errorf("%w: got write-set %d after %d", ErrResyncRequired, ws.Sequence, r.sequence)
+		}
+		if err := r.apply(ws.Writes); err != nil {
+			return fmt.Errorf("error applying write-set %d: %w", ws.Sequence, err)
+		}
+		r.sequence = ws.Sequence
+		replicationSequence.Set(int64(ws.Sequence))
+	}
+	r.reportLag(resp.Header)
+	return nil
+}
+
+// apply writes the writes in a single transaction
+func (r *Replica) apply(writes []Write) error {
+	if len(writes) == 0 {
+		return nil
+	}
+	tx := r.service.NewTransaction()
+	defer tx.Discard()
+	for _, w := range writes {
+		var err error
+		if w.Deleted {
+			err = tx.Delete(w.Key)
+		} else {
+			err = tx.Set(w.Key, w.Value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *Replica) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	u := r.url + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode == http.StatusConflict || resp.StatusCode == http.StatusGone {
+		return nil, fmt.Errorf("%w: %s", ErrResyncRequired, body)
+	}
+	return nil, fmt.Errorf("unexpected status %d from the writer: %s", resp.StatusCode, body)
+}
+
+// reportLag compares the last ownership block of the writer with the one of the replica
+func (r *Replica) reportLag(header http.Header) {
+	writerBlock, err := strconv.ParseUint(header.Get(LastBlockHeader), 10, 64)
+	if err != nil {
+		return
+	}
+	replicaBlock, err := r.lastBlock()
+	if err != nil {
+		slog.Error("error getting the last ownership block of the replica", "err", err)
+		return
+	}
+	lag := int64(writerBlock) - int64(replicaBlock)
+	replicationLag.Set(lag)
+	slog.Debug("replication lag", "writerBlock", writerBlock, "replicaBlock", replicaBlock, "lagBlocks", lag, "sequence", r.sequence)
+}
@@ -0,0 +1,206 @@
+package replication_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/memory"
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage/replication"
+)
+
+func TestReplica(t *testing.T) {
+	t.Parallel()
+	log := newLog(t, 1<<20)
+	write(t, log, map[string]string{"a": "1", "b": "2", "c": "3"})
+	writer := httptest.NewServer(replication.NewHandler(log, func() (uint64, error) { return 10, nil }))
+	defer writer.Close()
+
+	local := memory.New()
+	replica := replication.NewReplica(writer.URL, local, 2, func() (uint64, error) { return 8, nil })
+	if err := replica.LoadSnapshot(context.Background()); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	assertEntries(t, local, map[string]string{"a": "1", "b": "2", "c": "3"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- replica.Run(ctx)
+	}()
+	write(t, log, map[string]string{"a": "10", "d": "4"})
+	tx := log.NewTransaction()
+	if err := tx.Delete([]byte("b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	// a discarded transaction is not replicated
+	tx = log.NewTransaction()
+	if err := tx.Set([]byte("e"), []byte("5")); err != nil {
+		t.Fatal(err)
+	}
+	tx.Discard()
+
+	waitForEntries(t, local, map[string]string{"a": "10", "c": "3", "d": "4"})
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+}
+
+func TestReplicaResync(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		// change makes the write-sets the replica misses unavailable
+		change func(t *testing.T, log *replication.Log) *replication.Log
+	}{
+		{
+			name: "the writer restarts",
+			change: func(t *testing.T, log *replication.Log) *replication.Log {
+				return newLog(t, 1<<20)
+			},
+		},
+		{
+			name: "the writer drops the write-sets",
+			change: func(t *testing.T, log *replication.Log) *replication.Log {
+				write(t, log, map[string]string{"b": "2"})
+				write(t, log, map[string]string{"c": "3"})
+				return log
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			// the log only retains the last write-set
+			log := newLog(t, 1)
+			write(t, log, map[string]string{"a": "1"})
+			var handler atomic.Value
+			handler.Store(replication.NewHandler(log, func() (uint64, error) { return 0, nil }))
+			writer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handler.Load().(http.Handler).ServeHTTP(w, r)
+			}))
+			defer writer.Close()
+
+			replica := replication.NewReplica(writer.URL, memory.New(), 10, func() (uint64, error) { return 0, nil })
+			if err := replica.LoadSnapshot(context.Background()); err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			handler.Store(replication.NewHandler(tt.change(t, log), func() (uint64, error) { return 0, nil }))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := replica.Run(ctx); !errors.Is(err, replication.ErrResyncRequired) {
+				t.Fatalf(`got error "%v", expected "%v"`, err, replication.ErrResyncRequired)
+			}
+		})
+	}
+}
+
+func TestWriteSetsAfter(t *testing.T) {
+	t.Parallel()
+	log := newLog(t, 1<<20)
+	write(t, log, map[string]string{"a": "1"})
+	write(t, log, map[string]string{"b": "2"})
+
+	writeSets, err := log.WriteSetsAfter(context.Background(), 1)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if len(writeSets) != 1 || writeSets[0].Sequence != 2 || string(writeSets[0].Writes[0].Key) != "b" {
+		t.Fatalf("got write-sets %v, expected the write-set 2 writing b", writeSets)
+	}
+
+	// an up to date replica waits for the next commit
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	writeSets, err = log.WriteSetsAfter(ctx, 2)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if len(writeSets) != 0 {
+		t.Fatalf("got write-sets %v, expected none", writeSets)
+	}
+
+	if _, err := log.WriteSetsAfter(context.Background(), 3); !errors.Is(err, replication.ErrTruncated) {
+		t.Fatalf(`got error "%v", expected "%v"`, err, replication.ErrTruncated)
+	}
+}
+
+func newLog(t *testing.T, maxSize int) *replication.Log {
+	t.Helper()
+	log, err := replication.NewLog(memory.New(), maxSize)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	return log
+}
+
+func write(t *testing.T, service storage.Service, entries map[string]string) {
+	t.Helper()
+	tx := service.NewTransaction()
+	defer tx.Discard()
+	for key, value := range entries {
+		if err := tx.Set([]byte(key), []byte(value)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func entries(service storage.Service) (map[string]string, error) {
+	keys, err := service.GetKeysWithPrefix(nil)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(keys))
+	for _, key := range keys {
+		value, err := service.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		result[string(key)] = string(value)
+	}
+	return result, nil
+}
+
+func assertEntries(t *testing.T, service storage.Service, expected map[string]string) {
+	t.Helper()
+	got, err := entries(service)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("got entries %v, expected %v", got, expected)
+	}
+}
+
+func waitForEntries(t *testing.T, service storage.Service, expected map[string]string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, err := entries(service)
+		if err != nil {
+			t.Fatalf(`got error "%v" when no error was expected`, err)
+		}
+		if fmt.Sprint(got) == fmt.Sprint(expected) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got entries %v, expected %v", got, expected)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -1,5 +1,20 @@
 package storage
 
+import "errors"
+
+var (
+	// ErrTxnTooBig is returned by the writes and the commit of a transaction that exceeds the size the storage can commit at once.
+	// The transaction must be discarded and its writes split into smaller transactions.
+	ErrTxnTooBig = errors.New("transaction too big")
+	// ErrConflict is returned by the commit of a transaction that read a key written by another transaction committed
+	// since it started. The transaction must be discarded and retried.
+	ErrConflict = errors.New("transaction conflict")
+	// ErrKeyNotFound is returned by Service.Get when the key does not exist. Tx.Get returns a nil value instead.
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrDiscardedTxn is returned by the operations on a transaction that has already been committed or discarded
+	ErrDiscardedTxn = errors.New("transaction has been discarded")
+)
+
 type Tx interface {
 	Commit() error
 	Discard()
@@ -9,6 +24,11 @@ type Tx interface {
 	GetKeysWithPrefix(prefix []byte, reverse ...bool) [][]byte
 	FilterKeysWithPrefix(prefix []byte, from, to string) [][]byte
 	GetValuesWithPrefix(prefix []byte, reverse ...bool) [][]byte
+	// Iterate calls fn with the keys with the specified prefix and their values in ascending order, streaming them instead of
+	// loading them at once, and returns the first error returned by fn. The iteration starts at the key from, or at the first
+	// key with the prefix if from is nil. The key and value are only valid during the call.
+	// The iterated keys might not be tracked for conflicts, so it is meant for transactions that are not committed.
+	Iterate(prefix, from []byte, fn func(key, value []byte) error) error
 }
 
 type Service interface {
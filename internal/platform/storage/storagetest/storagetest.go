@@ -0,0 +1,368 @@
+// Package storagetest is the conformance test suite of the storage backends, so that the node behaves the same
+// on every backend and the in-memory one can stand in for Badger in tests.
+package storagetest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/storage"
+)
+
+// Run runs the conformance tests against the services returned by newService, which must be empty
+func Run(t *testing.T, newService func(t *testing.T) storage.Service) {
+	t.Helper()
+	tests := []struct {
+		name string
+		test func(t *testing.T, service storage.Service)
+	}{
+		{name: "service get and set", test: testServiceGetSet},
+		{name: "service keys with prefix", test: testServiceKeysWithPrefix},
+		{name: "transaction get, set and delete", test: testTxGetSetDelete},
+		{name: "transaction keys with prefix", test: testTxKeysWithPrefix},
+		{name: "transaction values with prefix", test: testTxValuesWithPrefix},
+		{name: "transaction filter keys with prefix", test: testTxFilterKeysWithPrefix},
+		{name: "transaction iterate", test: testTxIterate},
+		{name: "transaction isolation", test: testTxIsolation},
+		{name: "transaction discard", test: testTxDiscard},
+		{name: "transaction conflicts", test: testTxConflicts},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tt.test(t, newService(t))
+		})
+	}
+}
+
+func testServiceGetSet(t *testing.T, service storage.Service) {
+	if err := service.Set([]byte("key"), []byte("value")); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	value, err := service.Get([]byte("key"))
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	assertValue(t, value, "value")
+
+	// values are copied from and to the storage
+	value[0] = 'V'
+	value, err = service.Get([]byte("key"))
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	assertValue(t, value, "value")
+
+	if _, err := service.Get([]byte("missing")); !errors.Is(err, storage.ErrKeyNotFound) {
+		t.Fatalf(`got error "%v", expected "%v"`, err, storage.ErrKeyNotFound)
+	}
+}
+
+func testServiceKeysWithPrefix(t *testing.T, service storage.Service) {
+	write(t, service, "a/2", "a/1", "a/10", "a0", "b/1", "a")
+
+	keys, err := service.GetKeysWithPrefix([]byte("a/"))
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	assertKeys(t, keys, "a/1", "a/10", "a/2")
+
+	keys, err = service.GetKeysWithPrefix([]byte("a/"), true)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	assertKeys(t, keys, "a/2", "a/10", "a/1")
+
+	keys, err = service.GetKeysWithPrefix([]byte("c/"))
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	assertKeys(t, keys)
+}
+
+func testTxGetSetDelete(t *testing.T, service storage.Service) {
+	write(t, service, "committed")
+	tx := service.NewTransaction()
+	defer tx.Discard()
+
+	value, err := tx.Get([]byte("missing"))
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if value != nil {
+		t.Fatalf("got value %q for a missing key, expected nil", value)
+	}
+
+	// the transaction reads its own writes
+	if err := tx.Set([]byte("key"), []byte("value")); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	assertTxValue(t, tx, "key", "value")
+	if err := tx.Delete([]byte("committed")); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	assertTxValue(t, tx, "committed", "")
+	if err := tx.Commit(); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+
+	if err := tx.Set([]byte("key"), []byte("other")); !errors.Is(err, storage.ErrDiscardedTxn) {
+		t.Fatalf(`got error "%v" writing on a committed transaction, expected "%v"`, err, storage.ErrDiscardedTxn)
+	}
+	if _, err := tx.Get([]byte("key")); !errors.Is(err, storage.ErrDiscardedTxn) {
+		t.Fatalf(`got error "%v" reading on a committed transaction, expected "%v"`, err, storage.ErrDiscardedTxn)
+	}
+
+	tx = service.NewTransaction()
+	defer tx.Discard()
+	assertTxValue(t, tx, "key", "value")
+	assertTxValue(t, tx, "committed", "")
+}
+
+func testTxKeysWithPrefix(t *testing.T, service storage.Service) {
+	write(t, service, "a/2", "a/1", "a/3", "a0", "b/1", "a")
+	tx := service.NewTransaction()
+	defer tx.Discard()
+
+	assertKeys(t, tx.GetKeysWithPrefix([]byte("a/")), "a/1", "a/2", "a/3")
+	assertKeys(t, tx.GetKeysWithPrefix([]byte("a/"), true), "a/3", "a/2", "a/1")
+	assertKeys(t, tx.GetKeysWithPrefix([]byte("c/")))
+	assertKeys(t, tx.GetKeysWithPrefix([]byte("c/"), true))
+
+	// the iterations merge the writes of the transaction with the committed keys
+	set(t, tx, "a/0", "a/25")
+	if err := tx.Delete([]byte("a/3")); err != nil {
+		t.Fatal(err)
+	}
+	assertKeys(t, tx.GetKeysWithPrefix([]byte("a/")), "a/0", "a/1", "a/2", "a/25")
+	assertKeys(t, tx.GetKeysWithPrefix([]byte("a/"), true), "a/25", "a/2", "a/1", "a/0")
+}
+
+func testTxValuesWithPrefix(t *testing.T, service storage.Service) {
+	write(t, service, "a/2", "a/1", "b/1")
+	tx := service.NewTransaction()
+	defer tx.Discard()
+	set(t, tx, "a/3")
+
+	assertKeys(t, tx.GetValuesWithPrefix([]byte("a/")), "a/1", "a/2", "a/3")
+	assertKeys(t, tx.GetValuesWithPrefix([]byte("a/"), true), "a/3", "a/2", "a/1")
+	assertKeys(t, tx.GetValuesWithPrefix([]byte("c/")))
+}
+
+func testTxFilterKeysWithPrefix(t *testing.T, service storage.Service) {
+	write(t, service, "a/01", "a/02", "a/03", "a/04", "a0", "b/02")
+	tx := service.NewTransaction()
+	defer tx.Discard()
+	set(t, tx, "a/025")
+
+	// both bounds are included
+	assertKeys(t, tx.FilterKeysWithPrefix([]byte("a/"), "02", "03"), "a/02", "a/025", "a/03")
+	assertKeys(t, tx.FilterKeysWithPrefix([]byte("a/"), "", "02"), "a/01", "a/02")
+	assertKeys(t, tx.FilterKeysWithPrefix([]byte("a/"), "035", "zz"), "a/04")
+	assertKeys(t, tx.FilterKeysWithPrefix([]byte("a/"), "05", "06"))
+}
+
+func testTxIterate(t *testing.T, service storage.Service) {
+	write(t, service, "a/2", "a/1", "a/4", "a/5", "b/1")
+	tx := service.NewTransaction()
+	defer tx.Discard()
+	// the writes of the transaction replace and delete the entries of the storage
+	set(t, tx, "a/3", "a/0")
+	if err := tx.Set([]byte("a/2"), []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Delete([]byte("a/4")); err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []string
+	err := tx.Iterate([]byte("a/"), nil, func(key, value []byte) error {
+		entries = append(entries, string(key)+"="+string(value))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if fmt.Sprint(entries) != "[a/0=a/0 a/1=a/1 a/2=new a/3=a/3 a/5=a/5]" {
+		t.Fatalf("got entries %v", entries)
+	}
+
+	// the iteration starts at the key from, which does not need to exist
+	entries = nil
+	err = tx.Iterate([]byte("a/"), []byte("a/1x"), func(key, value []byte) error {
+		entries = append(entries, string(key)+"="+string(value))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if fmt.Sprint(entries) != "[a/2=new a/3=a/3 a/5=a/5]" {
+		t.Fatalf("got entries %v from a/1x", entries)
+	}
+
+	// the iteration stops at the first error
+	stop := errors.New("stop")
+	iterated := 0
+	err = tx.Iterate([]byte("a/"), nil, func(key, value []byte) error {
+		iterated++
+		return stop
+	})
+	if !errors.Is(err, stop) || iterated != 1 {
+		t.Fatalf(`got error "%v" after %d entries, expected "%v" after 1`, err, iterated, stop)
+	}
+}
+
+func testTxIsolation(t *testing.T, service storage.Service) {
+	write(t, service, "a/1")
+	before := service.NewTransaction()
+	defer before.Discard()
+
+	tx := service.NewTransaction()
+	defer tx.Discard()
+	set(t, tx, "a/2")
+	// the writes are not visible before they are committed
+	assertKeys(t, before.GetKeysWithPrefix([]byte("a/")), "a/1")
+	if _, err := service.Get([]byte("a/2")); !errors.Is(err, storage.ErrKeyNotFound) {
+		t.Fatalf(`got error "%v", expected "%v"`, err, storage.ErrKeyNotFound)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+
+	// the transactions read the snapshot committed when they started
+	assertTxValue(t, before, "a/2", "")
+	assertKeys(t, before.GetKeysWithPrefix([]byte("a/")), "a/1")
+	after := service.NewTransaction()
+	defer after.Discard()
+	assertKeys(t, after.GetKeysWithPrefix([]byte("a/")), "a/1", "a/2")
+}
+
+func testTxDiscard(t *testing.T, service storage.Service) {
+	tx := service.NewTransaction()
+	set(t, tx, "key")
+	tx.Discard()
+	// Badger fails with an error of its own
+	if err := tx.Commit(); err == nil {
+		t.Fatal("got no error committing a discarded transaction")
+	}
+
+	keys, err := service.GetKeysWithPrefix(nil)
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	assertKeys(t, keys)
+}
+
+func testTxConflicts(t *testing.T, service storage.Service) {
+	write(t, service, "read", "iterated/1", "blind")
+	tests := []struct {
+		name        string
+		access      func(tx storage.Tx)
+		written     string
+		expectedErr error
+	}{
+		{
+			name:        "a key read by Get",
+			access:      func(tx storage.Tx) { _, _ = tx.Get([]byte("read")) },
+			written:     "read",
+			expectedErr: storage.ErrConflict,
+		},
+		{
+			name:        "a key read by an iteration",
+			access:      func(tx storage.Tx) { tx.GetKeysWithPrefix([]byte("iterated/")) },
+			written:     "iterated/1",
+			expectedErr: storage.ErrConflict,
+		},
+		{
+			name:    "a key that was not read",
+			access:  func(tx storage.Tx) { _, _ = tx.Get([]byte("read")) },
+			written: "blind",
+		},
+	}
+
+	for _, tt := range tests {
+		tx := service.NewTransaction()
+		tt.access(tx)
+		set(t, tx, "result/"+tt.name)
+
+		other := service.NewTransaction()
+		if err := other.Set([]byte(tt.written), []byte("other")); err != nil {
+			t.Fatal(err)
+		}
+		if err := other.Commit(); err != nil {
+			t.Fatalf("%s: got error %v committing the other transaction", tt.name, err)
+		}
+
+		err := tx.Commit()
+		tx.Discard()
+		if !errors.Is(err, tt.expectedErr) {
+			t.Fatalf(`%s: got error "%v", expected "%v"`, tt.name, err, tt.expectedErr)
+		}
+	}
+
+	// a transaction without writes always commits
+	tx := service.NewTransaction()
+	_, _ = tx.Get([]byte("read"))
+	write(t, service, "read")
+	if err := tx.Commit(); err != nil {
+		t.Fatalf(`got error "%v" committing a read-only transaction`, err)
+	}
+}
+
+// write commits entries whose values are their keys
+func write(t *testing.T, service storage.Service, keys ...string) {
+	t.Helper()
+	tx := service.NewTransaction()
+	defer tx.Discard()
+	set(t, tx, keys...)
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// set writes entries whose values are their keys
+func set(t *testing.T, tx storage.Tx, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		if err := tx.Set([]byte(key), []byte(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// assertTxValue checks the value of a key, an empty expected value meaning that the key does not exist
+func assertTxValue(t *testing.T, tx storage.Tx, key, expected string) {
+	t.Helper()
+	value, err := tx.Get([]byte(key))
+	if err != nil {
+		t.Fatalf(`got error "%v" when no error was expected`, err)
+	}
+	if expected == "" {
+		if value != nil {
+			t.Fatalf("got value %q for key %q, expected it not to exist", value, key)
+		}
+		return
+	}
+	assertValue(t, value, expected)
+}
+
+func assertValue(t *testing.T, value []byte, expected string) {
+	t.Helper()
+	if !bytes.Equal(value, []byte(expected)) {
+		t.Fatalf("got value %q, expected %q", value, expected)
+	}
+}
+
+func assertKeys(t *testing.T, keys [][]byte, expected ...string) {
+	t.Helper()
+	got := make([]string, 0, len(keys))
+	for _, key := range keys {
+		got = append(got, string(key))
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Fatalf("got %v, expected %v", got, expected)
+	}
+}
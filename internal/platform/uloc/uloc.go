@@ -0,0 +1,263 @@
+// Package uloc parses the LAOS universal location URIs that ERC721 universal contracts use as base URI
+// to point to their collection in the evolution chain, such as
+//
+//	uloc://GlobalConsensus(3)/Parachain(2900)/PalletInstance(51)/AccountKey20(0xfFfffFFFffFFFffffffFfFfe0000000000000001)/
+//
+// The location is made of the GlobalConsensus, Parachain, PalletInstance (optional) and AccountKey20 junctions,
+// in this order, and may be followed by a path.
+package uloc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	GlobalConsensus = "GlobalConsensus"
+	Parachain       = "Parachain"
+	PalletInstance  = "PalletInstance"
+	AccountKey20    = "AccountKey20"
+)
+
+var (
+	ErrInvalidPrefix      = errors.New("invalid universal location prefix")
+	ErrMalformedJunction  = errors.New("malformed junction")
+	ErrMissingJunction    = errors.New("missing junction")
+	ErrDuplicatedJunction = errors.New("duplicated junction")
+	ErrInvalidValue       = errors.New("invalid junction value")
+)
+
+// prefixes are the accepted prefixes of the URI and the scheme they stand for
+var prefixes = []struct {
+	prefix string
+	scheme string
+}{
+	{prefix: "uloc://", scheme: "uloc"},
+	{prefix: "https://uloc.io/", scheme: "https"},
+}
+
+// Location is a parsed universal location
+type Location struct {
+	Scheme          string
+	GlobalConsensus string
+	Parachain       uint64
+	// PalletInstance is nil when the junction is omitted
+	PalletInstance *uint8
+	AccountKey20   common.Address
+	// Path is what follows the AccountKey20 junction, either empty or starting with a slash, e.g. "/GeneralKey(666)"
+	Path string
+}
+
+// String returns the location in its canonical form, with a lowercase AccountKey20
+func (l Location) String() string {
+	var b strings.Builder
+	for _, p := range prefixes {
+		if p.scheme == l.Scheme {
+			b.WriteString(p.prefix)
+			break
+		}
+	}
+	fmt.Fprintf(&b, "%s(%s)/%s(%d)/", GlobalConsensus, l.GlobalConsensus, Parachain, l.Parachain)
+	if l.PalletInstance != nil {
+		fmt.Fprintf(&b, "%s(%d)/", PalletInstance, *l.PalletInstance)
+	}
+	fmt.Fprintf(&b, "%s(%s)%s", AccountKey20, strings.ToLower(l.AccountKey20.Hex()), l.Path)
+	return b.String()
+}
+
+// junction is a Name(value) segment of the URI
+type junction struct {
+	name  string
+	value string
+	// offset is where the junction starts in the URI
+	offset int
+}
+
+type parser struct {
+	uri    string
+	offset int
+	seen   map[string]bool
+}
+
+// Parse parses a universal location URI. The errors wrap one of the Err* errors of the package
+// and tell the offset in uri where parsing failed.
+func Parse(uri string) (*Location, error) {
+	location := &Location{}
+	p := &parser{uri: uri, offset: -1, seen: make(map[string]bool)}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(uri, prefix.prefix) {
+			location.Scheme = prefix.scheme
+			p.offset = len(prefix.prefix)
+			break
+		}
+	}
+	if p.offset < 0 {
+		return nil, fmt.Errorf("%w: expected uloc:// or https://uloc.io/", ErrInvalidPrefix)
+	}
+
+	j, err := p.expect(GlobalConsensus)
+	if err != nil {
+		return nil, err
+	}
+	if location.GlobalConsensus, err = parseGlobalConsensus(j); err != nil {
+		return nil, err
+	}
+
+	if j, err = p.expect(Parachain); err != nil {
+		return nil, err
+	}
+	if location.Parachain, err = strconv.ParseUint(j.value, 10, 64); err != nil {
+		return nil, invalidValue(j, err)
+	}
+
+	if j, err = p.expect(PalletInstance, AccountKey20); err != nil {
+		return nil, err
+	}
+	if j.name == PalletInstance {
+		palletInstance, err := strconv.ParseUint(j.value, 10, 8)
+		if err != nil {
+			return nil, invalidValue(j, err)
+		}
+		location.PalletInstance = new(uint8)
+		*location.PalletInstance = uint8(palletInstance)
+		if j, err = p.expect(AccountKey20); err != nil {
+			return nil, err
+		}
+	}
+	if location.AccountKey20, err = parseAccountKey20(j); err != nil {
+		return nil, err
+	}
+
+	if location.Path, err = p.path(); err != nil {
+		return nil, err
+	}
+	return location, nil
+}
+
+// expect parses the next junction, which must be one of names
+func (p *parser) expect(names ...string) (junction, error) {
+	expected := strings.Join(names, " or ")
+	if p.offset == len(p.uri) || (p.uri[p.offset] == '/' && p.offset == len(p.uri)-1) {
+		return junction{}, fmt.Errorf("%w: expected %s at offset %d", ErrMissingJunction, expected, p.offset)
+	}
+	// the junctions after the first one are preceded by a slash
+	if len(p.seen) > 0 {
+		if p.uri[p.offset] != '/' {
+			return junction{}, fmt.Errorf("%w: expected / at offset %d", ErrMalformedJunction, p.offset)
+		}
+		p.offset++
+	}
+	j, err := p.junction()
+	if err != nil {
+		return junction{}, err
+	}
+	if p.seen[j.name] {
+		return junction{}, fmt.Errorf("%w: %s at offset %d", ErrDuplicatedJunction, j.name, j.offset)
+	}
+	for _, name := range names {
+		if j.name == name {
+			p.seen[j.name] = true
+			return j, nil
+		}
+	}
+	return junction{}, fmt.Errorf("%w: expected %s at offset %d, found %s", ErrMissingJunction, expected, j.offset, j.name)
+}
+
+// junction parses the Name(value) junction at the offset of the parser and moves the offset past its closing parenthesis
+func (p *parser) junction() (junction, error) {
+	j := junction{offset: p.offset}
+	rest := p.uri[p.offset:]
+	open := strings.IndexByte(rest, '(')
+	if open < 0 {
+		return j, fmt.Errorf("%w: expected Name(value) at offset %d", ErrMalformedJunction, p.offset)
+	}
+	j.name = rest[:open]
+	if !isName(j.name) {
+		return j, fmt.Errorf("%w: invalid junction name %q at offset %d", ErrMalformedJunction, j.name, p.offset)
+	}
+	closing := strings.IndexAny(rest[open+1:], "()/")
+	if closing < 0 {
+		return j, fmt.Errorf("%w: unterminated %s at offset %d", ErrMalformedJunction, j.name, p.offset)
+	}
+	closing += open + 1
+	if rest[closing] != ')' {
+		return j, fmt.Errorf("%w: unexpected %q in %s at offset %d", ErrMalformedJunction, rest[closing], j.name, p.offset+closing)
+	}
+	j.value = rest[open+1 : closing]
+	p.offset += closing + 1
+	return j, nil
+}
+
+// path returns what follows the AccountKey20 junction. Its segments are either well-formed junctions that are not
+// part of the location or plain segments without parentheses.
+func (p *parser) path() (string, error) {
+	start := p.offset
+	for p.offset < len(p.uri) {
+		if p.uri[p.offset] != '/' {
+			return "", fmt.Errorf("%w: expected / at offset %d", ErrMalformedJunction, p.offset)
+		}
+		p.offset++
+		segment, _, _ := strings.Cut(p.uri[p.offset:], "/")
+		if !strings.ContainsAny(segment, "()") {
+			p.offset += len(segment)
+			continue
+		}
+		j, err := p.junction()
+		if err != nil {
+			return "", err
+		}
+		if isLocationJunction(j.name) {
+			return "", fmt.Errorf("%w: %s at offset %d", ErrDuplicatedJunction, j.name, j.offset)
+		}
+	}
+	return p.uri[start:], nil
+}
+
+// parseGlobalConsensus accepts the network ids, such as 3 or polkadot, and the ids made of several parts, such as 0:0x4756c4
+func parseGlobalConsensus(j junction) (string, error) {
+	if j.value == "" {
+		return "", invalidValue(j, errors.New("empty value"))
+	}
+	for _, r := range j.value {
+		if !isAlphanumeric(r) && r != ':' && r != '-' && r != '_' {
+			return "", invalidValue(j, fmt.Errorf("unexpected %q", r))
+		}
+	}
+	return j.value, nil
+}
+
+// parseAccountKey20 accepts 0x prefixed addresses of 40 hex digits, regardless of their case
+func parseAccountKey20(j junction) (common.Address, error) {
+	if len(j.value) != 2+2*common.AddressLength || !strings.HasPrefix(j.value, "0x") || !common.IsHexAddress(j.value) {
+		return common.Address{}, invalidValue(j, errors.New("expected a 0x prefixed address of 40 hex digits"))
+	}
+	return common.HexToAddress(j.value), nil
+}
+
+func invalidValue(j junction, err error) error {
+	return fmt.Errorf("%w: %s(%s) at offset %d: %w", ErrInvalidValue, j.name, j.value, j.offset, err)
+}
+
+func isLocationJunction(name string) bool {
+	return name == GlobalConsensus || name == Parachain || name == PalletInstance || name == AccountKey20
+}
+
+func isName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !isAlphanumeric(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphanumeric(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
@@ -0,0 +1,234 @@
+package uloc_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/freeverseio/laos-universal-node/internal/platform/uloc"
+)
+
+const collection = "0xfFfffFFFffFFFffffffFfFfe0000000000000001"
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	palletInstance := uint8(51)
+	tests := []struct {
+		name             string
+		uri              string
+		expectedLocation uloc.Location
+	}{
+		{
+			name: "https prefix with trailing slash",
+			uri:  "https://uloc.io/GlobalConsensus(3)/Parachain(9999)/AccountKey20(" + collection + ")/",
+			expectedLocation: uloc.Location{
+				Scheme: "https", GlobalConsensus: "3", Parachain: 9999, AccountKey20: common.HexToAddress(collection), Path: "/",
+			},
+		},
+		{
+			name: "uloc prefix without trailing slash",
+			uri:  "uloc://GlobalConsensus(0:0x4756c4042a431ad2bbe61d8c4b966c1328e7a8daa0110e9bbd3d4013138a0bd4)/Parachain(2001)/AccountKey20(0xfffffffffffffffffffffffe0000000000000001)",
+			expectedLocation: uloc.Location{
+				Scheme:          "uloc",
+				GlobalConsensus: "0:0x4756c4042a431ad2bbe61d8c4b966c1328e7a8daa0110e9bbd3d4013138a0bd4",
+				Parachain:       2001,
+				AccountKey20:    common.HexToAddress(collection),
+			},
+		},
+		{
+			name: "pallet instance and path",
+			uri:  "uloc://GlobalConsensus(polkadot)/Parachain(3336)/PalletInstance(51)/AccountKey20(0xFFFFFFFFFFFFFFFFFFFFFFFE0000000000000001)/GeneralKey(666)/metadata",
+			expectedLocation: uloc.Location{
+				Scheme:          "uloc",
+				GlobalConsensus: "polkadot",
+				Parachain:       3336,
+				PalletInstance:  &palletInstance,
+				AccountKey20:    common.HexToAddress(collection),
+				Path:            "/GeneralKey(666)/metadata",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			location, err := uloc.Parse(tt.uri)
+			if err != nil {
+				t.Fatalf(`got error "%v" when no error was expected`, err)
+			}
+			assertLocation(t, tt.expectedLocation, *location)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		uri           string
+		expectedErr   error
+		expectedError string
+	}{
+		{
+			name:          "unknown prefix",
+			uri:           "ipfs://GlobalConsensus(3)/Parachain(9999)/AccountKey20(" + collection + ")",
+			expectedErr:   uloc.ErrInvalidPrefix,
+			expectedError: "invalid universal location prefix: expected uloc:// or https://uloc.io/",
+		},
+		{
+			name:          "missing global consensus",
+			uri:           "uloc://Parachain(9999)/AccountKey20(" + collection + ")",
+			expectedErr:   uloc.ErrMissingJunction,
+			expectedError: "missing junction: expected GlobalConsensus at offset 7, found Parachain",
+		},
+		{
+			name:          "missing parachain",
+			uri:           "uloc://GlobalConsensus(3)/AccountKey20(" + collection + ")",
+			expectedErr:   uloc.ErrMissingJunction,
+			expectedError: "missing junction: expected Parachain at offset 26, found AccountKey20",
+		},
+		{
+			name:          "missing account key",
+			uri:           "https://uloc.io/GlobalConsensus(3)/Parachain(9999)/",
+			expectedErr:   uloc.ErrMissingJunction,
+			expectedError: "missing junction: expected PalletInstance or AccountKey20 at offset 50",
+		},
+		{
+			name:          "other junction instead of the account key",
+			uri:           "uloc://GlobalConsensus(3)/Parachain(9999)/GeneralKey(666)",
+			expectedErr:   uloc.ErrMissingJunction,
+			expectedError: "missing junction: expected PalletInstance or AccountKey20 at offset 42, found GeneralKey",
+		},
+		{
+			name:          "junctions out of order",
+			uri:           "uloc://GlobalConsensus(3)/AccountKey20(" + collection + ")/Parachain(9999)",
+			expectedErr:   uloc.ErrMissingJunction,
+			expectedError: "missing junction: expected Parachain at offset 26, found AccountKey20",
+		},
+		{
+			name:          "duplicated junction",
+			uri:           "uloc://GlobalConsensus(3)/Parachain(9999)/Parachain(9999)/AccountKey20(" + collection + ")",
+			expectedErr:   uloc.ErrDuplicatedJunction,
+			expectedError: "duplicated junction: Parachain at offset 42",
+		},
+		{
+			name:          "duplicated junction in the path",
+			uri:           "uloc://GlobalConsensus(3)/Parachain(9999)/AccountKey20(" + collection + ")/AccountKey20(" + collection + ")",
+			expectedErr:   uloc.ErrDuplicatedJunction,
+			expectedError: "duplicated junction: AccountKey20 at offset 99",
+		},
+		{
+			name:          "nested parentheses",
+			uri:           "uloc://GlobalConsensus(GlobalConsensus(3))/Parachain(9999)/AccountKey20(" + collection + ")",
+			expectedErr:   uloc.ErrMalformedJunction,
+			expectedError: `malformed junction: unexpected '(' in GlobalConsensus at offset 38`,
+		},
+		{
+			name:          "nested parentheses in the path",
+			uri:           "uloc://GlobalConsensus(3)/Parachain(9999)/AccountKey20(" + collection + ")/GeneralKey((1))",
+			expectedErr:   uloc.ErrMalformedJunction,
+			expectedError: `malformed junction: unexpected '(' in GeneralKey at offset 110`,
+		},
+		{
+			name:          "unterminated junction",
+			uri:           "uloc://GlobalConsensus(3",
+			expectedErr:   uloc.ErrMalformedJunction,
+			expectedError: "malformed junction: unterminated GlobalConsensus at offset 7",
+		},
+		{
+			name:          "missing slash between junctions",
+			uri:           "uloc://GlobalConsensus(3)Parachain(9999)/AccountKey20(" + collection + ")",
+			expectedErr:   uloc.ErrMalformedJunction,
+			expectedError: "malformed junction: expected / at offset 25",
+		},
+		{
+			name:          "text after the account key",
+			uri:           "uloc://GlobalConsensus(3)/Parachain(9999)/AccountKey20(" + collection + ")x",
+			expectedErr:   uloc.ErrMalformedJunction,
+			expectedError: "malformed junction: expected / at offset 98",
+		},
+		{
+			name:          "empty global consensus",
+			uri:           "uloc://GlobalConsensus()/Parachain(9999)/AccountKey20(" + collection + ")",
+			expectedErr:   uloc.ErrInvalidValue,
+			expectedError: "invalid junction value: GlobalConsensus() at offset 7: empty value",
+		},
+		{
+			name:          "malformed parachain",
+			uri:           "uloc://GlobalConsensus(3)/Parachain(kkk)/AccountKey20(" + collection + ")",
+			expectedErr:   uloc.ErrInvalidValue,
+			expectedError: `invalid junction value: Parachain(kkk) at offset 26: strconv.ParseUint: parsing "kkk": invalid syntax`,
+		},
+		{
+			name:          "pallet instance out of range",
+			uri:           "uloc://GlobalConsensus(3)/Parachain(9999)/PalletInstance(256)/AccountKey20(" + collection + ")",
+			expectedErr:   uloc.ErrInvalidValue,
+			expectedError: `invalid junction value: PalletInstance(256) at offset 42: strconv.ParseUint: parsing "256": value out of range`,
+		},
+		{
+			name:          "short account key",
+			uri:           "uloc://GlobalConsensus(3)/Parachain(9999)/AccountKey20(0x0)",
+			expectedErr:   uloc.ErrInvalidValue,
+			expectedError: "invalid junction value: AccountKey20(0x0) at offset 42: expected a 0x prefixed address of 40 hex digits",
+		},
+		{
+			name:        "account key of 32 bytes",
+			uri:         "uloc://GlobalConsensus(3)/Parachain(9999)/AccountKey20(0x00000000000000000000000010fc4aa0135af7bc5d48fe75da32dbb52bd9631b)",
+			expectedErr: uloc.ErrInvalidValue,
+			expectedError: "invalid junction value: AccountKey20(0x00000000000000000000000010fc4aa0135af7bc5d48fe75da32dbb52bd9631b) at offset 42: " +
+				"expected a 0x prefixed address of 40 hex digits",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := uloc.Parse(tt.uri)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf(`got error "%v", expected error: "%v"`, err, tt.expectedErr)
+			}
+			if err.Error() != tt.expectedError {
+				t.Fatalf(`got error "%v", expected error: "%v"`, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+// FuzzParse checks that Parse does not panic and that the parsed locations are parsed again from their canonical form
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"https://uloc.io/GlobalConsensus(3)/Parachain(9999)/AccountKey20(" + collection + ")/",
+		"uloc://GlobalConsensus(0:0x4756c4)/Parachain(2001)/PalletInstance(51)/AccountKey20(" + collection + ")/GeneralKey(666)",
+		"uloc://GlobalConsensus(GlobalConsensus(3))/Parachain(9999)/AccountKey20(" + collection + ")",
+		"uloc://GlobalConsensus(3)/Parachain(9999)/Parachain(9999)/AccountKey20(" + collection + ")",
+		"uloc://GlobalConsensus(3)/Parachain(kkk)/AccountKey20(0x0)",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, uri string) {
+		location, err := uloc.Parse(uri)
+		if err != nil {
+			return
+		}
+		reparsed, err := uloc.Parse(location.String())
+		if err != nil {
+			t.Fatalf(`got error "%v" parsing %q, the canonical form of %q`, err, location.String(), uri)
+		}
+		assertLocation(t, *location, *reparsed)
+	})
+}
+
+func assertLocation(t *testing.T, expected, got uloc.Location) {
+	t.Helper()
+	if got.Scheme != expected.Scheme || got.GlobalConsensus != expected.GlobalConsensus || got.Parachain != expected.Parachain ||
+		got.AccountKey20 != expected.AccountKey20 || got.Path != expected.Path {
+		t.Fatalf("got location %+v, expected %+v", got, expected)
+	}
+	if (got.PalletInstance == nil) != (expected.PalletInstance == nil) ||
+		(got.PalletInstance != nil && *got.PalletInstance != *expected.PalletInstance) {
+		t.Fatalf("got pallet instance %v, expected %v", got.PalletInstance, expected.PalletInstance)
+	}
+}